@@ -2,8 +2,11 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"os"
+	"os/signal"
+	"syscall"
 
 	"github.com/jmreicha/cfgctl/internal/cli"
 )
@@ -19,7 +22,19 @@ func main() {
 	versionStr := fmt.Sprintf("%s (commit: %s, built: %s)", version, commit, date)
 	rootCmd := cli.NewRootCmd(versionStr)
 
-	if err := rootCmd.Execute(); err != nil {
-		os.Exit(1)
+	// Cancel in-flight provider work on Ctrl-C or termination.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	err := rootCmd.ExecuteContext(ctx)
+	stop()
+
+	if closeErr := cli.CloseLogs(); closeErr != nil {
+		fmt.Fprintf(os.Stderr, "Error: failed to close log file: %v\n", closeErr)
+		if err == nil {
+			os.Exit(cli.ExitError)
+		}
+	}
+
+	if err != nil {
+		os.Exit(cli.ExitCode(err))
 	}
 }
@@ -0,0 +1,507 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"os/signal"
+	"strings"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"github.com/jmreicha/cfgctl/internal/core"
+	"github.com/jmreicha/cfgctl/internal/providers/aws"
+	"github.com/spf13/cobra"
+)
+
+func newAWSCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "aws",
+		Short: "Work with AWS SSO profiles",
+		Long:  "Fetch short-lived credentials for AWS SSO profiles generated by cfgctl.",
+	}
+
+	cmd.AddCommand(newAWSEnvCmd())
+	cmd.AddCommand(newAWSExecCmd())
+	cmd.AddCommand(newAWSCredentialProcessCmd())
+	cmd.AddCommand(newAWSConsoleCmd())
+	cmd.AddCommand(newAWSExportCmd())
+	cmd.AddCommand(newAWSLintCmd())
+	cmd.AddCommand(newAWSSessionsCmd())
+
+	return cmd
+}
+
+func newAWSEnvCmd() *cobra.Command {
+	var (
+		profile string
+		format  string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "env",
+		Short: "Print credentials for a profile as shell exports",
+		Long: `Print short-lived credentials for an AWS SSO profile.
+
+Examples:
+  eval "$(cfgctl aws env --profile prod/admin)"
+  cfgctl aws env --profile prod/admin --format fish | source
+  cfgctl aws env --profile prod/admin --format json`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			creds, err := awsProfileCredentials(cmd, profile)
+			if err != nil {
+				return err
+			}
+
+			output, err := aws.FormatCredentials(creds, format)
+			if err != nil {
+				return err
+			}
+			fmt.Print(output)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&profile, "profile", "", "AWS profile to fetch credentials for")
+	cmd.Flags().StringVar(&format, "format", aws.CredentialsFormatBash, "output format: bash, zsh, fish, or json")
+	_ = cmd.MarkFlagRequired("profile")
+
+	return cmd
+}
+
+func newAWSExecCmd() *cobra.Command {
+	var profile string
+
+	cmd := &cobra.Command{
+		Use:   "exec --profile PROFILE -- COMMAND [ARGS...]",
+		Short: "Run a command with credentials for a profile",
+		Long: `Run a command with short-lived credentials for an AWS SSO profile in its environment.
+
+Examples:
+  cfgctl aws exec --profile prod/admin -- aws s3 ls
+  cfgctl aws exec --profile prod/admin -- terraform plan`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			creds, err := awsProfileCredentials(cmd, profile)
+			if err != nil {
+				return err
+			}
+
+			// The command is not tied to cmd.Context(): an interrupt is
+			// passed on to it instead of killing it.
+			// #nosec G204 -- the command is what the user asked to run
+			child := exec.Command(args[0], args[1:]...)
+			child.Env = append(os.Environ(), creds.Environ()...)
+			child.Stdin = os.Stdin
+			child.Stdout = os.Stdout
+			child.Stderr = os.Stderr
+			if err := runForwardingSignals(child); err != nil {
+				// The command reported its own failure; ExitCode passes
+				// its status through without repeating it.
+				var exitErr *exec.ExitError
+				if errors.As(err, &exitErr) {
+					cmd.SilenceErrors = true
+				}
+				return err
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&profile, "profile", "", "AWS profile to fetch credentials for")
+	_ = cmd.MarkFlagRequired("profile")
+
+	return cmd
+}
+
+// runForwardingSignals runs child, passing SIGINT and SIGTERM on to it so the
+// command decides how to stop.
+func runForwardingSignals(child *exec.Cmd) error {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(signals)
+	return runWithSignals(child, signals)
+}
+
+func runWithSignals(child *exec.Cmd, signals <-chan os.Signal) error {
+	if err := child.Start(); err != nil {
+		return err
+	}
+	done := make(chan error, 1)
+	go func() { done <- child.Wait() }()
+	for {
+		select {
+		case err := <-done:
+			return err
+		case sig := <-signals:
+			_ = child.Process.Signal(sig)
+		}
+	}
+}
+
+func newAWSCredentialProcessCmd() *cobra.Command {
+	var profile string
+
+	cmd := &cobra.Command{
+		Use:   "credential-process",
+		Short: "Print credentials for a profile in credential_process format",
+		Long: `Print short-lived credentials for an AWS SSO profile as the JSON document
+credential_process expects. Set credential_process_helper: cfgctl in the aws
+provider config to generate profiles that use it.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			creds, err := awsProfileCredentials(cmd, profile)
+			if err != nil {
+				return err
+			}
+
+			output, err := aws.FormatCredentials(creds, aws.CredentialsFormatJSON)
+			if err != nil {
+				return err
+			}
+			fmt.Print(output)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&profile, "profile", "", "AWS profile to fetch credentials for")
+	_ = cmd.MarkFlagRequired("profile")
+
+	return cmd
+}
+
+func newAWSConsoleCmd() *cobra.Command {
+	var (
+		service   string
+		region    string
+		printOnly bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "console PROFILE",
+		Short: "Open the AWS console for a profile",
+		Long: `Sign in to the AWS console with short-lived credentials for an AWS SSO profile.
+
+Examples:
+  cfgctl aws console prod/admin
+  cfgctl aws console prod/admin --service ec2 --region us-west-2
+  cfgctl aws console prod/admin --print`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			awsConfig, cacheDir, err := awsCommandConfig()
+			if err != nil {
+				return err
+			}
+
+			signinURL, err := aws.ConsoleURL(cmd.Context(), awsConfig, args[0], cacheDir, aws.ConsoleOptions{Service: service, Region: region})
+			if err != nil {
+				return fmt.Errorf("failed to create console url for %s: %w", args[0], err)
+			}
+
+			if printOnly {
+				fmt.Println(signinURL)
+				return nil
+			}
+			if err := aws.OpenBrowser(signinURL); err != nil {
+				fmt.Fprintln(os.Stderr, "Could not open a browser; open this URL manually:")
+				fmt.Println(signinURL)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&service, "service", "", "console service to open, e.g. ec2 or s3")
+	cmd.Flags().StringVar(&region, "region", "", "console region (default: the profile's region)")
+	cmd.Flags().BoolVar(&printOnly, "print", false, "print the sign-in URL instead of opening it")
+
+	return cmd
+}
+
+func newAWSExportCmd() *cobra.Command {
+	var (
+		format  string
+		output  string
+		refresh bool
+		offline bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "export",
+		Short: "Export discovered profiles for other tools",
+		Long: `Render the profiles cfgctl generates, after filtering, in formats other tools read.
+
+Formats:
+  json, yaml, csv       profile, account, role, and SSO session of each profile
+  aws-vault             profiles with per-profile sso keys for aws-vault
+  leapp                 leapp integration create commands, one per SSO session
+  granted-registry      an AWS config file for a Granted profile registry
+  terraform-providers   an aliased provider "aws" block per profile
+
+Examples:
+  cfgctl aws export --format csv
+  cfgctl aws export --format terraform-providers --output providers.tf`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			provider, err := awsProvider()
+			if err != nil {
+				return err
+			}
+
+			cache, err := newDiscoveryCache(refresh, offline)
+			if err != nil {
+				return err
+			}
+			providerCache := cache.ForProvider(aws.ProviderName, config.ProviderCacheTTL(aws.ProviderName))
+
+			content, warnings, err := provider.Export(cmd.Context(), format, providerCache, nil)
+			if err != nil {
+				return fmt.Errorf("failed to export aws profiles: %w", err)
+			}
+			for _, warning := range warnings {
+				fmt.Fprintf(os.Stderr, "Warning: %s\n", warning)
+			}
+
+			if output == "" {
+				fmt.Print(content)
+				return nil
+			}
+			if err := os.WriteFile(output, []byte(content), 0o600); err != nil {
+				return fmt.Errorf("failed to write %s: %w", output, err)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&format, "format", aws.ExportFormatJSON, "output format: "+strings.Join(aws.ExportFormats, ", "))
+	cmd.Flags().StringVar(&output, "output", "", "write the export to this file instead of stdout")
+	cmd.Flags().BoolVar(&refresh, "refresh", false, "ignore cached discovery results and rediscover")
+	cmd.Flags().BoolVar(&offline, "offline", false, "use only cached discovery results and make no network calls")
+	cmd.MarkFlagsMutuallyExclusive("refresh", "offline")
+
+	return cmd
+}
+
+func newAWSLintCmd() *cobra.Command {
+	var (
+		fix         bool
+		noDiscovery bool
+		offline     bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "lint",
+		Short: "Check the AWS config and credentials files for problems",
+		Long: `Check the AWS config and credentials files for duplicate sections, missing
+sso_session and source_profile references, source_profile cycles, legacy
+sso_start_url profiles, long-lived access keys, generated profiles that no
+longer match discovery, and permissions that let other users read the files.
+
+--fix removes stale generated profiles, moves legacy profiles to a matching
+sso-session, and sets file modes to 0600. Rewritten files are backed up first
+unless --no-backup is set, and --dry-run reports findings without fixing them.
+The command fails while findings remain.
+
+Examples:
+  cfgctl aws lint
+  cfgctl aws lint --fix
+  cfgctl aws lint --no-discovery`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			provider, err := awsProvider()
+			if err != nil {
+				return err
+			}
+
+			var providerCache *core.ProviderCache
+			if !noDiscovery {
+				cache, err := newDiscoveryCache(false, offline)
+				if err != nil {
+					return err
+				}
+				providerCache = cache.ForProvider(aws.ProviderName, config.ProviderCacheTTL(aws.ProviderName))
+			}
+
+			opts := aws.LintOptions{Fix: fix && !dryRun}
+			if !noBackup {
+				opts.Backups = backupManager
+			}
+			findings, err := provider.Lint(cmd.Context(), !noDiscovery, opts, providerCache)
+			if err != nil {
+				return fmt.Errorf("failed to lint aws config: %w", err)
+			}
+
+			remaining := 0
+			for _, finding := range findings {
+				fmt.Println(finding.String())
+				if !finding.Fixed {
+					remaining++
+				}
+			}
+			if remaining > 0 {
+				return fmt.Errorf("%w: %d", aws.ErrLintFindings, remaining)
+			}
+			if len(findings) == 0 {
+				fmt.Println("No problems found")
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&fix, "fix", false, "apply safe fixes")
+	cmd.Flags().BoolVar(&noDiscovery, "no-discovery", false, "skip discovery and the stale profile check")
+	cmd.Flags().BoolVar(&offline, "offline", false, "use only cached discovery results and make no network calls")
+	cmd.MarkFlagsMutuallyExclusive("no-discovery", "offline")
+
+	return cmd
+}
+
+func newAWSSessionsCmd() *cobra.Command {
+	var warnWithin time.Duration
+
+	cmd := &cobra.Command{
+		Use:   "sessions",
+		Short: "List cached SSO tokens and credentials",
+		Long: `List the SSO tokens in the token cache paths, the role credentials cfgctl
+cached for profiles in the AWS config file, and the aws-vault sessions when
+aws-vault is installed, with the time left before each expires.
+
+--warn-within fails the command when a session expires within the given
+duration, for shell prompt integration. Tokens with a refresh token are not
+counted, since they are renewed when used.
+
+Examples:
+  cfgctl aws sessions
+  cfgctl aws sessions --warn-within 2h`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			awsConfig, cacheDir, err := awsCommandConfig()
+			if err != nil {
+				return err
+			}
+
+			now := time.Now().UTC()
+			sessions, warnings, err := aws.ListSessions(cmd.Context(), awsConfig, cacheDir, now)
+			if err != nil {
+				return fmt.Errorf("failed to list aws sessions: %w", err)
+			}
+			for _, warning := range warnings {
+				fmt.Fprintf(os.Stderr, "Warning: %s\n", warning)
+			}
+			if err := printAWSSessions(cmd.OutOrStdout(), sessions, now); err != nil {
+				return err
+			}
+
+			if warnWithin > 0 {
+				if expiring := sessions.ExpiringWithin(warnWithin, now); expiring > 0 {
+					return fmt.Errorf("%w: %d within %s", aws.ErrSessionsExpiring, expiring, warnWithin)
+				}
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().DurationVar(&warnWithin, "warn-within", 0, "exit non-zero when a session expires within this duration, such as 2h")
+
+	return cmd
+}
+
+// printAWSSessions writes one table per kind of session, skipping kinds
+// with no sessions.
+func printAWSSessions(out io.Writer, sessions aws.Sessions, now time.Time) error {
+	if len(sessions.Tokens) == 0 && len(sessions.Credentials) == 0 && len(sessions.Vault) == 0 {
+		_, err := fmt.Fprintln(out, "No cached sessions found")
+		return err
+	}
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	started := false
+	section := func(title, header string) {
+		if started {
+			fmt.Fprintln(w)
+		}
+		started = true
+		fmt.Fprintln(w, title)
+		fmt.Fprintln(w, header)
+	}
+
+	if len(sessions.Tokens) > 0 {
+		section("SSO tokens", "  START URL\tREGION\tEXPIRES\tREFRESH TOKEN\tPATH")
+		for _, token := range sessions.Tokens {
+			refresh := "no"
+			if token.Refreshable {
+				refresh = "yes"
+			}
+			fmt.Fprintf(w, "  %s\t%s\t%s\t%s\t%s\n", token.StartURL, token.Region,
+				aws.FormatCountdown(token.ExpiresAt.Sub(now)), refresh, token.Path)
+		}
+	}
+	if len(sessions.Credentials) > 0 {
+		section("Role credentials", "  PROFILE\tACCOUNT\tROLE\tEXPIRES")
+		for _, creds := range sessions.Credentials {
+			fmt.Fprintf(w, "  %s\t%s\t%s\t%s\n", creds.Profile, creds.AccountID, creds.RoleName,
+				aws.FormatCountdown(creds.Expiration.Sub(now)))
+		}
+	}
+	if len(sessions.Vault) > 0 {
+		section("aws-vault sessions", "  PROFILE\tTYPE\tEXPIRES")
+		for _, session := range sessions.Vault {
+			fmt.Fprintf(w, "  %s\t%s\t%s\n", session.Profile, session.Type,
+				aws.FormatCountdown(session.ExpiresAt.Sub(now)))
+		}
+	}
+	return w.Flush()
+}
+
+// awsProvider returns the registered aws provider after validating its
+// configuration.
+func awsProvider() (*aws.Provider, error) {
+	if _, _, err := awsCommandConfig(); err != nil {
+		return nil, err
+	}
+	registered, err := registry.Get(aws.ProviderName)
+	if err != nil {
+		return nil, err
+	}
+	provider, ok := registered.(*aws.Provider)
+	if !ok {
+		return nil, errors.New("aws provider is not registered")
+	}
+	return provider, nil
+}
+
+// awsCommandConfig returns the validated aws provider configuration and the
+// cfgctl cache directory.
+func awsCommandConfig() (*aws.Config, string, error) {
+	awsConfig, ok := config.GetProviderConfig(aws.ProviderName).(*aws.Config)
+	if !ok {
+		return nil, "", errors.New("aws provider is not configured")
+	}
+	if err := awsConfig.Validate(); err != nil {
+		return nil, "", fmt.Errorf("invalid aws config: %w", err)
+	}
+
+	cache, err := core.NewCache(config.Cache.Dir, core.CacheDefault)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to open cache: %w", err)
+	}
+	return awsConfig, cache.Dir(), nil
+}
+
+// awsProfileCredentials fetches credentials for profile using the aws
+// provider configuration and the cfgctl cache directory.
+func awsProfileCredentials(cmd *cobra.Command, profile string) (aws.RoleCredentials, error) {
+	awsConfig, cacheDir, err := awsCommandConfig()
+	if err != nil {
+		return aws.RoleCredentials{}, err
+	}
+
+	creds, err := aws.ProfileCredentials(cmd.Context(), awsConfig, profile, cacheDir)
+	if err != nil {
+		return aws.RoleCredentials{}, fmt.Errorf("failed to get credentials for %s: %w", profile, err)
+	}
+	return creds, nil
+}
@@ -2,7 +2,6 @@
 package cli
 
 import (
-	"context"
 	"errors"
 	"fmt"
 
@@ -18,12 +17,12 @@ func newCleanCmd() *cobra.Command {
 Examples:
   cfgctl clean aws
   cfgctl clean aws kubernetes`,
-		RunE: func(_ *cobra.Command, args []string) error {
+		RunE: func(cmd *cobra.Command, args []string) error {
 			if len(args) == 0 {
 				return errors.New("must specify at least one provider to clean")
 			}
 
-			ctx := context.Background()
+			ctx := cmd.Context()
 
 			for _, providerName := range args {
 				if err := engine.CleanProvider(ctx, providerName); err != nil {
@@ -1,12 +1,20 @@
 package cli
 
 import (
+	"bufio"
 	"context"
 	"errors"
+	"fmt"
 	"io"
 	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
 	"reflect"
+	"strings"
+	"syscall"
 	"testing"
+	"time"
 
 	"github.com/jmreicha/cfgctl/internal/core"
 	"github.com/jmreicha/cfgctl/internal/providers/aws"
@@ -88,17 +96,77 @@ func TestInitializeComponents(t *testing.T) {
 	}
 }
 
+func TestCloseLogsAfterFailedCommand(t *testing.T) {
+	prevLogFile := logFile
+	t.Cleanup(func() { logFile = prevLogFile })
+
+	path := filepath.Join(t.TempDir(), "cfgctl.log")
+	cmd := NewRootCmd("1.0.0")
+	cmd.SetArgs([]string{"--log-file", path, "clean"})
+	cmd.SetOut(io.Discard)
+	cmd.SetErr(io.Discard)
+	if err := cmd.Execute(); err == nil {
+		t.Fatal("expected clean without arguments to fail")
+	}
+	if logFactory == nil {
+		t.Fatal("expected the log file to be open after the failed command")
+	}
+
+	if err := CloseLogs(); err != nil {
+		t.Fatalf("CloseLogs failed: %v", err)
+	}
+	if logFactory != nil {
+		t.Fatal("expected CloseLogs to release the log factory")
+	}
+	if err := CloseLogs(); err != nil {
+		t.Fatalf("second CloseLogs failed: %v", err)
+	}
+}
+
 func TestNewRootCmdFlags(t *testing.T) {
 	cmd := NewRootCmd("1.0.0")
 	flags := cmd.PersistentFlags()
 
-	for _, name := range []string{"config", "debug", "dry-run", "no-backup", "ssh-config-path", "verbose"} {
+	for _, name := range []string{"config", "debug", "dry-run", "log-file", "log-format", "log-levels", "no-backup", "ssh-config-path", "timeout", "verbose"} {
 		if flags.Lookup(name) == nil {
 			t.Fatalf("expected %s flag", name)
 		}
 	}
 }
 
+func TestApplyLoggingCLIOverrides(t *testing.T) {
+	prevDebug, prevLogFormat, prevLogFile, prevLogLevels := debug, logFormat, logFile, logLevels
+	defer func() {
+		debug, logFormat, logFile, logLevels = prevDebug, prevLogFormat, prevLogFile, prevLogLevels
+	}()
+
+	debug = true
+	logFormat = "json"
+	logFile = "/tmp/cfgctl.log"
+	logLevels = "kubernetes=debug, aws=info"
+
+	cfg, err := applyLoggingCLIOverrides(core.LoggingConfig{
+		Level:     "warn",
+		Providers: map[string]string{"aws": "error", "ssh": "warn"},
+	})
+	if err != nil {
+		t.Fatalf("applyLoggingCLIOverrides failed: %v", err)
+	}
+
+	if cfg.Level != "debug" || cfg.Format != "json" || cfg.File != "/tmp/cfgctl.log" {
+		t.Fatalf("cfg = %+v", cfg)
+	}
+	want := map[string]string{"aws": "info", "kubernetes": "debug", "ssh": "warn"}
+	if !reflect.DeepEqual(cfg.Providers, want) {
+		t.Fatalf("providers = %v, want %v", cfg.Providers, want)
+	}
+
+	logLevels = "kubernetes"
+	if _, err := applyLoggingCLIOverrides(core.LoggingConfig{}); err == nil {
+		t.Fatal("expected error for entry without level")
+	}
+}
+
 func TestApplyKubernetesCLIOverrides(t *testing.T) {
 	prevKubeMerge := kubeMerge
 	prevKubeMergeOnly := kubeMergeOnly
@@ -158,6 +226,7 @@ func TestInitializeComponentsAWSCLIOverrides(t *testing.T) {
 	awsCredentialProcess = true
 	awsCredentials = true
 	awsDemo = true
+	awsExplainFilters = true
 	awsPrefix = "team-"
 	awsPrune = true
 	awsRoleFilters = "AdminAccess,ReadOnly"
@@ -182,6 +251,9 @@ func TestInitializeComponentsAWSCLIOverrides(t *testing.T) {
 	if !awsConfig.Demo {
 		t.Fatal("expected demo enabled")
 	}
+	if !awsConfig.ExplainFilters {
+		t.Fatal("expected filter explanations enabled")
+	}
 	if awsConfig.ProfilePrefix != "team-" {
 		t.Fatalf("profile prefix = %q", awsConfig.ProfilePrefix)
 	}
@@ -196,6 +268,123 @@ func TestInitializeComponentsAWSCLIOverrides(t *testing.T) {
 	}
 }
 
+func TestInitializeComponentsDemoFixtures(t *testing.T) {
+	cfgFile = ""
+	path := filepath.Join(t.TempDir(), "fixtures.yaml")
+	content := "accounts:\n  - id: \"111111111111\"\n    roles: [AdminAccess]\nhistory:\n  - ssh bastion\n"
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("write fixtures: %v", err)
+	}
+	demoFixtures = path
+	t.Cleanup(func() {
+		demoFixtures = ""
+		dryRun = false
+	})
+
+	if err := initializeComponents(); err != nil {
+		t.Fatalf("initializeComponents failed: %v", err)
+	}
+	if !dryRun || !config.DryRun {
+		t.Fatal("expected fixtures with default output paths to imply dry-run")
+	}
+
+	awsConfig, ok := config.GetProviderConfig(aws.ProviderName).(*aws.Config)
+	if !ok {
+		t.Fatalf("expected aws config, got %T", config.GetProviderConfig(aws.ProviderName))
+	}
+	if !awsConfig.Demo || awsConfig.Fixtures == nil || len(awsConfig.Fixtures.Accounts) != 1 {
+		t.Fatalf("aws demo = %v, fixtures = %+v", awsConfig.Demo, awsConfig.Fixtures)
+	}
+	kubernetesConfig, ok := config.GetProviderConfig(kubernetes.ProviderName).(*kubernetes.Config)
+	if !ok || kubernetesConfig.Fixtures != awsConfig.Fixtures {
+		t.Fatal("expected kubernetes config to share the fixtures")
+	}
+
+	demoFixtures = filepath.Join(t.TempDir(), "missing.yaml")
+	if err := initializeComponents(); err == nil {
+		t.Fatal("expected error for missing fixtures file")
+	}
+}
+
+func TestInitializeComponentsDemoFixturesRedirectedOutputs(t *testing.T) {
+	dir := t.TempDir()
+	fixturesPath := filepath.Join(dir, "fixtures.yaml")
+	if err := os.WriteFile(fixturesPath, []byte("history:\n  - ssh bastion\n"), 0o600); err != nil {
+		t.Fatalf("write fixtures: %v", err)
+	}
+	configContent := fmt.Sprintf(`providers:
+  ssh:
+    config_path: %[1]s/ssh/config
+  granted:
+    config_path: %[1]s/granted/config
+  aws:
+    config_path: %[1]s/aws/config
+    credentials_path: %[1]s/aws/credentials
+  kubernetes:
+    config_path: %[1]s/kube/config
+  steampipe:
+    config_path: %[1]s/steampipe/aws.spc
+`, dir)
+	cfgFile = filepath.Join(dir, "cfgctl.yaml")
+	if err := os.WriteFile(cfgFile, []byte(configContent), 0o600); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	demoFixtures = fixturesPath
+	dryRun = false
+	t.Cleanup(func() {
+		cfgFile = ""
+		demoFixtures = ""
+		dryRun = false
+	})
+
+	if err := initializeComponents(); err != nil {
+		t.Fatalf("initializeComponents failed: %v", err)
+	}
+	if dryRun {
+		t.Fatal("expected redirected outputs to allow writes")
+	}
+}
+
+func TestOutputsRedirected(t *testing.T) {
+	tests := []struct {
+		name  string
+		paths [][2]string
+		want  bool
+	}{
+		{name: "all redirected", paths: [][2]string{{"/tmp/demo/config", "/home/user/.ssh/config"}}, want: true},
+		{name: "default path", paths: [][2]string{{"/tmp/demo/config", "/a"}, {"/home/user/.ssh/config", "/home/user/.ssh/config"}}, want: false},
+		{name: "unclean default", paths: [][2]string{{"/home/user/.ssh//config", "/home/user/.ssh/config"}}, want: false},
+		{name: "empty path", paths: [][2]string{{" ", "/home/user/.ssh/config"}}, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := outputsRedirected(tt.paths); got != tt.want {
+				t.Fatalf("outputsRedirected() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClusterRegions(t *testing.T) {
+	kubernetesConfig := kubernetes.DefaultConfig()
+	kubernetesConfig.ConfigPath = filepath.Join(t.TempDir(), "kubeconfig")
+	kubernetesConfig.AWS.Regions = []string{"all"}
+	kubernetesConfig.Fixtures = &core.DemoFixtures{Clusters: []core.ClusterFixture{
+		{Profile: "prod/AdminAccess", Region: "eu-west-1", Name: "web", Endpoint: "https://web.example.com"},
+		{Profile: "prod/AdminAccess", Region: "us-west-2", Name: "batch", Endpoint: "https://batch.example.com"},
+	}}
+
+	discover := clusterRegions(kubernetesConfig, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	regions, err := discover(context.Background(), filepath.Join(t.TempDir(), "config"), nil, nil)
+	if err != nil {
+		t.Fatalf("discover cluster regions: %v", err)
+	}
+	want := map[string][]string{"prod/AdminAccess": {"eu-west-1", "us-west-2"}}
+	if !reflect.DeepEqual(regions, want) {
+		t.Fatalf("regions = %v, want %v", regions, want)
+	}
+}
+
 func TestNewVersionCmd(t *testing.T) {
 	cmd := newVersionCmd("1.0.0")
 	if err := cmd.Execute(); err != nil {
@@ -253,6 +442,53 @@ func TestCleanCmd_NoArgs(t *testing.T) {
 	}
 }
 
+func TestAWSEnvCmd_RequiresProfile(t *testing.T) {
+	setupCommandEngine(t)
+
+	cmd := newAWSEnvCmd()
+	cmd.SetArgs([]string{})
+	if err := cmd.Execute(); err == nil || !strings.Contains(err.Error(), "profile") {
+		t.Fatalf("expected missing profile error, got %v", err)
+	}
+}
+
+func TestAWSSessionsCmd_WarnWithin(t *testing.T) {
+	setupCommandEngine(t)
+
+	dir := t.TempDir()
+	awsConfig := aws.DefaultConfig()
+	awsConfig.SSO.StartURL = "https://example.awsapps.com/start"
+	awsConfig.SSO.Region = "us-east-1"
+	awsConfig.ConfigPath = filepath.Join(dir, "config")
+	awsConfig.TokenCachePaths = []string{dir}
+	config.SetProviderConfig(aws.ProviderName, awsConfig)
+	config.Cache.Dir = filepath.Join(dir, "cache")
+
+	expiresAt := time.Now().UTC().Add(30 * time.Minute).Format(time.RFC3339)
+	token := `{"accessToken":"token","expiresAt":"` + expiresAt + `","region":"us-east-1","startUrl":"https://example.awsapps.com/start"}`
+	if err := os.WriteFile(filepath.Join(dir, "token.json"), []byte(token), 0o600); err != nil {
+		t.Fatalf("write token: %v", err)
+	}
+
+	var out strings.Builder
+	cmd := newAWSSessionsCmd()
+	cmd.SetOut(&out)
+	cmd.SetArgs([]string{"--warn-within", "10m"})
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("sessions command failed: %v", err)
+	}
+	if !strings.Contains(out.String(), "https://example.awsapps.com/start") {
+		t.Fatalf("expected the token in the output, got:\n%s", out.String())
+	}
+
+	cmd = newAWSSessionsCmd()
+	cmd.SetOut(io.Discard)
+	cmd.SetArgs([]string{"--warn-within", "2h"})
+	if err := cmd.Execute(); !errors.Is(err, aws.ErrSessionsExpiring) {
+		t.Fatalf("expected ErrSessionsExpiring, got %v", err)
+	}
+}
+
 func TestCleanCmd_WithArgs(t *testing.T) {
 	provider := &commandProvider{name: "alpha"}
 	setupCommandEngine(t, provider)
@@ -266,3 +502,73 @@ func TestCleanCmd_WithArgs(t *testing.T) {
 		t.Fatal("expected clean to be called")
 	}
 }
+
+func TestExitCode(t *testing.T) {
+	partial := &core.RunError{Attempted: 2, Errors: []*core.ProviderError{{Provider: "a", Phase: core.PhaseGeneration, Err: errors.New("no")}}}
+	total := &core.RunError{Attempted: 1, Errors: partial.Errors}
+	exited := exec.Command("sh", "-c", "exit 7").Run()
+	killed := exec.Command("sh", "-c", "kill -TERM $$").Run()
+
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{name: "nil", err: nil, want: ExitOK},
+		{name: "generic", err: errors.New("no"), want: ExitError},
+		{name: "partial", err: partial, want: ExitPartialFailure},
+		{name: "total", err: total, want: ExitTotalFailure},
+		{name: "interrupted", err: fmt.Errorf("generation stopped: %w", context.Canceled), want: ExitInterrupted},
+		{name: "exec exit status", err: exited, want: 7},
+		{name: "exec killed by signal", err: killed, want: 143},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ExitCode(tt.err); got != tt.want {
+				t.Fatalf("ExitCode() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRunWithSignalsForwards(t *testing.T) {
+	child := exec.Command("sh", "-c", `trap 'exit 7' TERM; echo ready; while :; do sleep 0.1; done`)
+	stdout, err := child.StdoutPipe()
+	if err != nil {
+		t.Fatalf("stdout pipe: %v", err)
+	}
+
+	signals := make(chan os.Signal, 1)
+	done := make(chan error, 1)
+	go func() { done <- runWithSignals(child, signals) }()
+
+	if _, err := bufio.NewReader(stdout).ReadString('\n'); err != nil {
+		t.Fatalf("wait for child: %v", err)
+	}
+	signals <- syscall.SIGTERM
+
+	select {
+	case err := <-done:
+		if got := ExitCode(err); got != 7 {
+			t.Fatalf("ExitCode() = %d, want the child's own 7 (err %v)", got, err)
+		}
+	case <-time.After(5 * time.Second):
+		_ = child.Process.Kill()
+		t.Fatal("child did not exit after the forwarded signal")
+	}
+}
+
+func TestWatchCmd_NothingToWatch(t *testing.T) {
+	setupCommandEngine(t, &commandProvider{name: "alpha"})
+	pidFile := filepath.Join(t.TempDir(), "watch.pid")
+
+	cmd := newWatchCmd()
+	cmd.SetArgs([]string{"--pid-file", pidFile})
+	if err := cmd.Execute(); !errors.Is(err, core.ErrNothingToWatch) {
+		t.Fatalf("expected ErrNothingToWatch, got %v", err)
+	}
+	if _, err := os.Stat(pidFile); !os.IsNotExist(err) {
+		t.Fatalf("expected pid file to be released, stat: %v", err)
+	}
+}
@@ -1,12 +1,16 @@
 package cli
 
 import (
-	"context"
+	"errors"
 	"fmt"
 	"os"
+	"sort"
 	"strings"
+	"text/tabwriter"
+	"time"
 
 	"github.com/jmreicha/cfgctl/internal/core"
+	"github.com/jmreicha/cfgctl/internal/providers/aws"
 	"github.com/spf13/cobra"
 )
 
@@ -66,6 +70,104 @@ func printMetadataSummary(colorEnabled bool, metadata map[string]interface{}) {
 			fmt.Printf("  %s %d files\n", formatLabel(colorEnabled, "Merged:"), len(files))
 		}
 	}
+	if hits, ok := metadata[core.MetadataCacheHits]; ok {
+		fmt.Printf("  %s %v hits, %v misses\n", formatLabel(colorEnabled, "Cache:"), hits, metadata[core.MetadataCacheMisses])
+	}
+	if decisions, ok := metadata[aws.MetadataFilterDecisions].([]string); ok && len(decisions) > 0 {
+		fmt.Printf("  %s\n", formatLabel(colorEnabled, "Filters:"))
+		for _, decision := range decisions {
+			fmt.Printf("    - %s\n", decision)
+		}
+	}
+}
+
+// newDiscoveryCache opens the discovery cache in the mode selected by --refresh or --offline.
+func newDiscoveryCache(refresh, offline bool) (*core.Cache, error) {
+	mode := core.CacheDefault
+	switch {
+	case refresh:
+		mode = core.CacheRefresh
+	case offline:
+		mode = core.CacheOffline
+	}
+
+	cache, err := core.NewCache(config.Cache.Dir, mode)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open discovery cache: %w", err)
+	}
+	return cache, nil
+}
+
+// printTimings outputs per-phase durations for each provider.
+func printTimings(results map[string]*core.Result) {
+	colorEnabled := supportsColor()
+
+	names := make([]string, 0, len(results))
+	for name, result := range results {
+		if result != nil && len(result.Timings) > 0 {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		return
+	}
+	sort.Strings(names)
+
+	fmt.Printf("\n%s\n", formatSection(colorEnabled, "Timings:"))
+	writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	for _, name := range names {
+		for _, timing := range results[name].Timings {
+			fmt.Fprintf(writer, "  %s\t%s\t%s\n", name, timing.Phase, formatDuration(timing.Duration))
+		}
+	}
+	_ = writer.Flush()
+}
+
+func formatDuration(d time.Duration) string {
+	if d < time.Millisecond {
+		return d.Round(time.Microsecond).String()
+	}
+	return d.Round(time.Millisecond).String()
+}
+
+// printRunSummary outputs a per-provider status table for a keep-going run.
+func printRunSummary(results map[string]*core.Result, runErr *core.RunError) {
+	colorEnabled := supportsColor()
+
+	names := make([]string, 0, len(results))
+	for name := range results {
+		names = append(names, name)
+	}
+	if runErr != nil {
+		for _, providerErr := range runErr.Errors {
+			names = append(names, providerErr.Provider)
+		}
+	}
+	sort.Strings(names)
+
+	fmt.Printf("\n%s\n", formatSection(colorEnabled, "Summary:"))
+	writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(writer, "  PROVIDER\tSTATUS\tDETAIL")
+	for _, name := range names {
+		status, detail := providerStatus(name, results[name], runErr)
+		fmt.Fprintf(writer, "  %s\t%s\t%s\n", name, status, detail)
+	}
+	_ = writer.Flush()
+}
+
+func providerStatus(name string, result *core.Result, runErr *core.RunError) (string, string) {
+	if runErr != nil {
+		if providerErr := runErr.Failed(name); providerErr != nil {
+			return "failed", fmt.Sprintf("%s: %v", providerErr.Phase, providerErr.Err)
+		}
+	}
+	if result == nil {
+		return "unknown", ""
+	}
+	if len(result.FilesCreated) == 0 && len(result.Warnings) > 0 {
+		return "skipped", result.Warnings[0]
+	}
+	return "ok", fmt.Sprintf("%d files created", len(result.FilesCreated))
 }
 
 func formatLabel(colorEnabled bool, value string) string {
@@ -114,7 +216,13 @@ func isTerminal(file *os.File) bool {
 }
 
 func newGenerateCmd() *cobra.Command {
-	var force bool
+	var (
+		force      bool
+		keepGoing  bool
+		noProgress bool
+		offline    bool
+		refresh    bool
+	)
 
 	cmd := &cobra.Command{
 		Use:   "generate [provider...]",
@@ -129,9 +237,14 @@ Examples:
   cfgctl generate aws ssh
   cfgctl generate all
   cfgctl generate --dry-run
-  cfgctl generate --force`,
-		RunE: func(_ *cobra.Command, args []string) error {
-			ctx := context.Background()
+  cfgctl generate --force
+  cfgctl generate --keep-going
+  cfgctl generate kubernetes --refresh
+  cfgctl generate --offline
+  cfgctl generate --demo-fixtures demo.yaml
+  cfgctl generate --timeout 2m --verbose`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
 
 			// Handle "all" as an explicit provider name
 			providers := args
@@ -139,34 +252,71 @@ Examples:
 				providers = nil // Empty list means "all providers"
 			}
 
+			cache, err := newDiscoveryCache(refresh, offline)
+			if err != nil {
+				return err
+			}
+
 			opts := &core.ExecuteOptions{
 				Providers: providers,
 				DryRun:    dryRun,
 				Force:     force,
 				NoBackup:  noBackup,
 				Verbose:   verbose,
+				KeepGoing: keepGoing,
+				Cache:     cache,
+			}
+
+			var progress progressRenderer
+			if !noProgress {
+				progress = newProgressRenderer(os.Stderr)
+				if debug {
+					// Debug logs share stderr, so avoid redrawing over them.
+					progress = &plainProgress{out: os.Stderr}
+				}
+				opts.Progress = progress
 			}
 
 			results, err := engine.Execute(ctx, opts)
-			if err != nil {
+			if progress != nil {
+				progress.Finish()
+			}
+			var runErr *core.RunError
+			if err != nil && !errors.As(err, &runErr) {
 				return err
 			}
 
 			printGenerateResults(results)
+			if verbose {
+				printTimings(results)
+			}
+			if keepGoing {
+				printRunSummary(results, runErr)
+			}
+			if runErr != nil {
+				return runErr
+			}
 			return nil
 		},
 	}
 
 	cmd.Flags().BoolVar(&force, "force", false, "overwrite existing files")
+	cmd.Flags().BoolVar(&keepGoing, "keep-going", false, "run all providers even if some fail")
+	cmd.Flags().BoolVar(&noProgress, "no-progress", false, "disable progress output on stderr")
+	cmd.Flags().BoolVar(&refresh, "refresh", false, "ignore cached discovery results and rediscover")
+	cmd.Flags().BoolVar(&offline, "offline", false, "use only cached discovery results and make no network calls")
+	cmd.MarkFlagsMutuallyExclusive("refresh", "offline")
 	cmd.Flags().BoolVar(&awsCredentialProcess, "aws-credential-process", false, "use credential_process for AWS profiles")
 	cmd.Flags().BoolVar(&awsCredentials, "aws-credentials", false, "generate AWS credentials output")
 	cmd.Flags().BoolVar(&awsDemo, "aws-demo", false, "use fake AWS discovery data")
+	cmd.Flags().BoolVar(&awsExplainFilters, "aws-explain-filters", false, "show why each discovered AWS account/role was kept or dropped")
 	cmd.Flags().StringVar(&awsPrefix, "aws-prefix", "", "prefix for generated AWS profile names")
 	cmd.Flags().BoolVar(&awsPrune, "aws-prune", false, "remove stale AWS profiles with marker key")
 	cmd.Flags().StringVar(&awsRoleFilters, "aws-roles", "", "comma-separated AWS role names")
 	cmd.Flags().StringVar(&awsSSOStartURL, "aws-sso-url", "", "AWS SSO start URL")
 	cmd.Flags().StringVar(&awsSSORegion, "aws-sso-region", "", "AWS SSO region")
 	cmd.Flags().StringVar(&awsTemplate, "aws-template", "", "template for AWS profile names")
+	cmd.Flags().StringVar(&demoFixtures, "demo-fixtures", "", "discover from a YAML file of fake accounts, clusters, and shell history (implies --dry-run unless every output path is redirected)")
 	cmd.Flags().BoolVar(&kubeMerge, "kube-merge", false, "merge existing kubeconfig files")
 	cmd.Flags().BoolVar(&kubeMergeOnly, "kube-merge-only", false, "merge existing kubeconfig files without AWS discovery")
 	cmd.Flags().StringVar(&kubeRegions, "kube-regions", "", "comma-separated AWS regions")
@@ -2,10 +2,12 @@ package cli
 
 import (
 	"context"
+	"errors"
 	"log/slog"
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/jmreicha/cfgctl/internal/core"
 )
@@ -146,6 +148,80 @@ func TestGenerateCmdAllKeyword(t *testing.T) {
 	}
 }
 
+type failingProvider struct {
+	mockProvider
+}
+
+func (f *failingProvider) Generate(_ context.Context, _ *core.GenerateOptions) (*core.Result, error) {
+	return nil, errors.New("generate failed")
+}
+
+func TestGenerateCmdKeepGoing(t *testing.T) {
+	setupCommandEngine(t, &failingProvider{mockProvider{name: "broken"}}, &mockProvider{name: "healthy"})
+
+	cmd := newGenerateCmd()
+	cmd.SetArgs([]string{"--keep-going"})
+
+	err := cmd.Execute()
+	if !errors.Is(err, core.ErrPartialFailure) {
+		t.Fatalf("expected partial failure, got %v", err)
+	}
+	if ExitCode(err) != ExitPartialFailure {
+		t.Fatalf("exit code = %d", ExitCode(err))
+	}
+}
+
+func TestGenerateCmdRefreshOfflineExclusive(t *testing.T) {
+	setupCommandEngine(t, &mockProvider{name: "healthy"})
+
+	cmd := newGenerateCmd()
+	cmd.SetArgs([]string{"--refresh", "--offline"})
+	if err := cmd.Execute(); err == nil {
+		t.Fatal("expected error when --refresh and --offline are combined")
+	}
+}
+
+func TestNewDiscoveryCache(t *testing.T) {
+	setupCommandEngine(t)
+	config.Cache.Dir = t.TempDir()
+
+	cache, err := newDiscoveryCache(false, true)
+	if err != nil {
+		t.Fatalf("newDiscoveryCache failed: %v", err)
+	}
+	if cache.Dir() != config.Cache.Dir {
+		t.Fatalf("cache dir = %q, want %q", cache.Dir(), config.Cache.Dir)
+	}
+	if !cache.ForProvider("aws", 0).Offline() {
+		t.Fatal("expected offline cache")
+	}
+}
+
+func TestProviderStatus(t *testing.T) {
+	runErr := &core.RunError{Attempted: 3, Errors: []*core.ProviderError{{Provider: "broken", Phase: core.PhaseValidation, Err: errors.New("bad input")}}}
+
+	tests := []struct {
+		name       string
+		provider   string
+		result     *core.Result
+		wantStatus string
+		wantDetail string
+	}{
+		{name: "failed", provider: "broken", wantStatus: "failed", wantDetail: "validation: bad input"},
+		{name: "ok", provider: "ok", result: &core.Result{FilesCreated: []string{"a"}}, wantStatus: "ok", wantDetail: "1 files created"},
+		{name: "skipped", provider: "skip", result: &core.Result{Warnings: []string{"disabled"}}, wantStatus: "skipped", wantDetail: "disabled"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, detail := providerStatus(tt.provider, tt.result, runErr)
+			if status != tt.wantStatus || detail != tt.wantDetail {
+				t.Fatalf("providerStatus() = %q, %q", status, detail)
+			}
+		})
+	}
+}
+
 func TestPrintGenerateResults(t *testing.T) {
 	results := map[string]*core.Result{
 		"test": {
@@ -176,6 +252,20 @@ func TestPrintGenerateResults(t *testing.T) {
 	printGenerateResults(results)
 }
 
+func TestFormatDuration(t *testing.T) {
+	tests := map[time.Duration]string{
+		1500 * time.Microsecond:                 "2ms",
+		250 * time.Microsecond:                  "250µs",
+		2*time.Second + 123456*time.Microsecond: "2.123s",
+	}
+
+	for duration, want := range tests {
+		if got := formatDuration(duration); got != want {
+			t.Errorf("formatDuration(%s) = %q, want %q", duration, got, want)
+		}
+	}
+}
+
 func TestColorize(t *testing.T) {
 	if got := colorize(false, "value", "1"); got != "value" {
 		t.Fatalf("expected uncolored output, got %q", got)
@@ -0,0 +1,120 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/jmreicha/cfgctl/internal/core"
+)
+
+// progressRenderer displays provider progress events while generation runs.
+type progressRenderer interface {
+	core.ProgressReporter
+
+	// Finish clears any live output once generation is complete.
+	Finish()
+}
+
+// newProgressRenderer returns a live multi-line display when out is a
+// terminal and a plain line-per-event renderer otherwise.
+func newProgressRenderer(out *os.File) progressRenderer {
+	if isTerminal(out) && os.Getenv("TERM") != "dumb" {
+		return &liveProgress{out: out, colorEnabled: supportsColor(), lines: make(map[string]string)}
+	}
+	return &plainProgress{out: out}
+}
+
+// formatProgress returns the status text for an event, without the provider name.
+func formatProgress(event core.ProgressEvent) string {
+	switch event.Kind {
+	case core.ProgressCount:
+		return fmt.Sprintf("%s %d/%d", event.Message, event.Current, event.Total)
+	case core.ProgressWarning:
+		return "warning: " + event.Message
+	case core.ProgressDone:
+		return "done"
+	default:
+		return event.Message
+	}
+}
+
+// plainProgress writes one line per event, suitable for logs and pipes.
+type plainProgress struct {
+	mu  sync.Mutex
+	out io.Writer
+}
+
+func (p *plainProgress) Report(event core.ProgressEvent) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	fmt.Fprintf(p.out, "%s: %s\n", event.Provider, formatProgress(event))
+}
+
+func (p *plainProgress) Finish() {}
+
+// liveProgress keeps one status line per running provider and redraws them in
+// place. Warnings are printed above the live lines so they stay visible.
+type liveProgress struct {
+	mu           sync.Mutex
+	out          io.Writer
+	colorEnabled bool
+	order        []string
+	lines        map[string]string
+	drawn        int
+}
+
+func (p *liveProgress) Report(event core.ProgressEvent) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.clear()
+
+	switch event.Kind {
+	case core.ProgressWarning:
+		fmt.Fprintf(p.out, "%s: %s\n", event.Provider, formatWarning(p.colorEnabled, formatProgress(event)))
+	case core.ProgressDone:
+		p.remove(event.Provider)
+	default:
+		if _, ok := p.lines[event.Provider]; !ok {
+			p.order = append(p.order, event.Provider)
+		}
+		p.lines[event.Provider] = formatProgress(event)
+	}
+
+	p.draw()
+}
+
+func (p *liveProgress) Finish() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.clear()
+	p.order = nil
+	p.lines = make(map[string]string)
+}
+
+func (p *liveProgress) remove(provider string) {
+	delete(p.lines, provider)
+	for i, name := range p.order {
+		if name == provider {
+			p.order = append(p.order[:i], p.order[i+1:]...)
+			return
+		}
+	}
+}
+
+// clear erases the previously drawn lines and leaves the cursor where they began.
+func (p *liveProgress) clear() {
+	for range p.drawn {
+		fmt.Fprint(p.out, "\x1b[1A\x1b[2K")
+	}
+	p.drawn = 0
+}
+
+func (p *liveProgress) draw() {
+	for _, provider := range p.order {
+		fmt.Fprintf(p.out, "%s %s\n", formatLabel(p.colorEnabled, provider+":"), p.lines[provider])
+	}
+	p.drawn = len(p.order)
+}
@@ -0,0 +1,67 @@
+package cli
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/jmreicha/cfgctl/internal/core"
+)
+
+func TestFormatProgress(t *testing.T) {
+	tests := []struct {
+		name  string
+		event core.ProgressEvent
+		want  string
+	}{
+		{name: "step", event: core.ProgressEvent{Kind: core.ProgressStep, Message: "scanning prod"}, want: "scanning prod"},
+		{name: "count", event: core.ProgressEvent{Kind: core.ProgressCount, Message: "accounts", Current: 3, Total: 10}, want: "accounts 3/10"},
+		{name: "warning", event: core.ProgressEvent{Kind: core.ProgressWarning, Message: "access denied"}, want: "warning: access denied"},
+		{name: "done", event: core.ProgressEvent{Kind: core.ProgressDone}, want: "done"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := formatProgress(tt.event); got != tt.want {
+				t.Fatalf("formatProgress() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPlainProgress(t *testing.T) {
+	var out bytes.Buffer
+	progress := &plainProgress{out: &out}
+
+	progress.Report(core.ProgressEvent{Provider: "aws", Kind: core.ProgressCount, Message: "accounts", Current: 1, Total: 2})
+	progress.Finish()
+
+	if got := out.String(); got != "aws: accounts 1/2\n" {
+		t.Fatalf("output = %q", got)
+	}
+}
+
+func TestLiveProgress(t *testing.T) {
+	var out bytes.Buffer
+	progress := &liveProgress{out: &out, lines: make(map[string]string)}
+
+	progress.Report(core.ProgressEvent{Provider: "aws", Kind: core.ProgressStep, Message: "listing sso accounts"})
+	progress.Report(core.ProgressEvent{Provider: "kubernetes", Kind: core.ProgressStep, Message: "resolving regions"})
+	if progress.drawn != 2 {
+		t.Fatalf("drawn = %d, want 2", progress.drawn)
+	}
+
+	progress.Report(core.ProgressEvent{Provider: "kubernetes", Kind: core.ProgressWarning, Message: "access denied"})
+	progress.Report(core.ProgressEvent{Provider: "aws", Kind: core.ProgressDone})
+	if progress.drawn != 1 || progress.order[0] != "kubernetes" {
+		t.Fatalf("drawn = %d, order = %v", progress.drawn, progress.order)
+	}
+
+	progress.Finish()
+	if progress.drawn != 0 {
+		t.Fatalf("drawn = %d after finish", progress.drawn)
+	}
+	if !strings.Contains(out.String(), "kubernetes: warning: access denied\n") {
+		t.Fatalf("expected warning line in output, got %q", out.String())
+	}
+}
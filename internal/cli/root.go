@@ -2,11 +2,17 @@
 package cli
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log/slog"
 	"os"
+	"os/exec"
+	"path/filepath"
 	"sort"
 	"strings"
+	"syscall"
+	"time"
 
 	"github.com/jmreicha/cfgctl/internal/core"
 	"github.com/jmreicha/cfgctl/internal/providers/aws"
@@ -25,6 +31,15 @@ var (
 	sshConfigPath string
 	debug         bool
 	verbose       bool
+	timeout       time.Duration
+
+	// Logging flags.
+	logFile   string
+	logFormat string
+	logLevels string
+
+	// Demo generate flags.
+	demoFixtures string
 
 	// Kubernetes generate flags.
 	kubeMerge     bool
@@ -36,6 +51,7 @@ var (
 	awsCredentialProcess bool
 	awsCredentials       bool
 	awsDemo              bool
+	awsExplainFilters    bool
 	awsPrefix            string
 	awsPrune             bool
 	awsRoleFilters       string
@@ -53,8 +69,45 @@ var (
 	config        *core.Config
 	engine        *core.Engine
 	logger        *slog.Logger
+	logFactory    *core.LogFactory
+)
+
+// Exit codes returned by ExitCode.
+const (
+	ExitOK             = 0
+	ExitError          = 1
+	ExitPartialFailure = 2
+	ExitTotalFailure   = 3
+	ExitInterrupted    = 130
 )
 
+// ExitCode maps a command error to a process exit code. Keep-going runs use
+// distinct codes for partial and total provider failure, and interrupted runs
+// exit like a shell command killed by SIGINT. Commands run by `aws exec`
+// pass their own exit code through, or 128 plus the signal that killed them.
+func ExitCode(err error) int {
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		if status, ok := exitErr.Sys().(syscall.WaitStatus); ok && status.Signaled() {
+			return 128 + int(status.Signal())
+		}
+	}
+	switch {
+	case err == nil:
+		return ExitOK
+	case errors.Is(err, context.Canceled):
+		return ExitInterrupted
+	case errors.Is(err, core.ErrPartialFailure):
+		return ExitPartialFailure
+	case errors.Is(err, core.ErrTotalFailure):
+		return ExitTotalFailure
+	case errors.As(err, &exitErr) && exitErr.ExitCode() > 0:
+		return exitErr.ExitCode()
+	default:
+		return ExitError
+	}
+}
+
 // NewRootCmd creates the root command for cfgctl.
 func NewRootCmd(version string) *cobra.Command {
 	rootCmd := &cobra.Command{
@@ -80,28 +133,33 @@ func NewRootCmd(version string) *cobra.Command {
 	rootCmd.PersistentFlags().BoolVarP(&verbose, "verbose", "v", false, "verbose provider output")
 	rootCmd.PersistentFlags().BoolVar(&noBackup, "no-backup", false, "skip backup creation before generation")
 	rootCmd.PersistentFlags().StringVar(&sshConfigPath, "ssh-config-path", "", "ssh config directory (default: ~/.ssh)")
+	rootCmd.PersistentFlags().DurationVar(&timeout, "timeout", 0, "maximum duration for the whole run, e.g. 2m (default: no limit)")
+	rootCmd.PersistentFlags().StringVar(&logFormat, "log-format", "", "log format: text or json (default: text)")
+	rootCmd.PersistentFlags().StringVar(&logFile, "log-file", "", "write logs to a rotated file instead of stderr")
+	rootCmd.PersistentFlags().StringVar(&logLevels, "log-levels", "", "comma-separated provider log levels, e.g. kubernetes=debug,aws=info")
 
 	// Add subcommands
+	rootCmd.AddCommand(newAWSCmd())
 	rootCmd.AddCommand(newGenerateCmd())
 	rootCmd.AddCommand(newCleanCmd())
 	rootCmd.AddCommand(newListCmd())
 	rootCmd.AddCommand(newValidateCmd())
 	rootCmd.AddCommand(newVersionCmd(version))
+	rootCmd.AddCommand(newWatchCmd())
 
 	return rootCmd
 }
 
+// CloseLogs closes the log file opened for the last command. Callers run it
+// after Execute returns, since cobra skips post-run hooks when a command fails.
+func CloseLogs() error {
+	err := logFactory.Close()
+	logFactory = nil
+	return err
+}
+
 // initializeComponents sets up the core components needed by all commands.
 func initializeComponents() error {
-	// Set up logger
-	logLevel := slog.LevelError
-	if debug {
-		logLevel = slog.LevelDebug
-	}
-	logger = slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{
-		Level: logLevel,
-	}))
-
 	// Load configuration
 	var err error
 	config, err = core.LoadConfig(cfgFile)
@@ -112,6 +170,18 @@ func initializeComponents() error {
 		config = core.NewConfig()
 	}
 
+	// Set up loggers
+	loggingConfig, err := applyLoggingCLIOverrides(config.Logging)
+	if err != nil {
+		return err
+	}
+	_ = logFactory.Close()
+	logFactory, err = core.NewLogFactory(loggingConfig, os.Stderr)
+	if err != nil {
+		return fmt.Errorf("failed to set up logging: %w", err)
+	}
+	logger = logFactory.Logger()
+
 	// Override config with CLI flags
 	if verbose {
 		config.Verbose = true
@@ -122,12 +192,23 @@ func initializeComponents() error {
 	if noBackup {
 		config.NoBackup = true
 	}
+	if timeout > 0 {
+		config.Timeout = timeout
+	}
 
 	// Initialize core components
 	registry = core.NewRegistry()
 	backupManager = core.NewBackupManager("")
 	engine = core.NewEngine(registry, backupManager, config, logger)
 
+	var fixtures *core.DemoFixtures
+	if strings.TrimSpace(demoFixtures) != "" {
+		fixtures, err = core.LoadDemoFixtures(strings.TrimSpace(demoFixtures))
+		if err != nil {
+			return err
+		}
+	}
+
 	// Register providers
 	var sshConfig *ssh.Config
 	providerConfig := config.GetProviderConfig(ssh.ProviderName)
@@ -143,7 +224,8 @@ func initializeComponents() error {
 	if sshConfigPath != "" {
 		sshConfig.ConfigPath = sshConfigPath
 	}
-	if err := registry.Register(ssh.NewProvider(sshConfig)); err != nil {
+	sshConfig.Fixtures = fixtures
+	if err := registry.Register(ssh.NewProvider(sshConfig, ssh.WithLogger(logFactory.ProviderLogger(ssh.ProviderName)))); err != nil {
 		return fmt.Errorf("failed to register ssh provider: %w", err)
 	}
 
@@ -158,7 +240,7 @@ func initializeComponents() error {
 		}
 		grantedConfig = typedConfig
 	}
-	if err := registry.Register(granted.NewProvider(grantedConfig)); err != nil {
+	if err := registry.Register(granted.NewProvider(grantedConfig, granted.WithLogger(logFactory.ProviderLogger(granted.ProviderName)))); err != nil {
 		return fmt.Errorf("failed to register granted provider: %w", err)
 	}
 
@@ -175,7 +257,11 @@ func initializeComponents() error {
 		awsConfig = typedConfig
 	}
 	applyAWSCLIOverrides(awsConfig)
-	if err := registry.Register(aws.NewProvider(awsConfig)); err != nil {
+	if fixtures != nil {
+		awsConfig.Demo = true
+		awsConfig.Fixtures = fixtures
+	}
+	if err := registry.Register(aws.NewProvider(awsConfig, aws.WithLogger(logFactory.ProviderLogger(aws.ProviderName)))); err != nil {
 		return fmt.Errorf("failed to register aws provider: %w", err)
 	}
 
@@ -192,7 +278,11 @@ func initializeComponents() error {
 		kubernetesConfig = typedConfig
 	}
 	applyKubernetesCLIOverrides(kubernetesConfig)
-	if err := registry.Register(kubernetes.NewProvider(kubernetesConfig, kubernetes.WithLogger(logger))); err != nil {
+	kubernetesConfig.Fixtures = fixtures
+	if awsConfig.UsesEKSRegions() {
+		awsConfig.DiscoverClusterRegions = clusterRegions(kubernetesConfig, logFactory.ProviderLogger(kubernetes.ProviderName))
+	}
+	if err := registry.Register(kubernetes.NewProvider(kubernetesConfig, kubernetes.WithLogger(logFactory.ProviderLogger(kubernetes.ProviderName)))); err != nil {
 		return fmt.Errorf("failed to register kubernetes provider: %w", err)
 	}
 
@@ -209,13 +299,81 @@ func initializeComponents() error {
 		steampipeConfig = typedConfig
 	}
 	applySteampipeCLIOverrides(steampipeConfig)
-	if err := registry.Register(steampipe.NewProvider(steampipeConfig)); err != nil {
+	steampipeConfig.Fixtures = fixtures
+	if err := registry.Register(steampipe.NewProvider(steampipeConfig, steampipe.WithLogger(logFactory.ProviderLogger(steampipe.ProviderName)))); err != nil {
 		return fmt.Errorf("failed to register steampipe provider: %w", err)
 	}
 
+	// Fake data must not replace real files, so fixture runs only write when
+	// every output has been pointed away from its default path.
+	if fixtures != nil && !dryRun && !outputsRedirected([][2]string{
+		{sshConfig.ConfigPath, ssh.DefaultConfig().ConfigPath},
+		{grantedConfig.ConfigPath, granted.DefaultConfig().ConfigPath},
+		{awsConfig.ConfigPath, aws.DefaultConfig().ConfigPath},
+		{awsConfig.CredentialsPath, aws.DefaultConfig().CredentialsPath},
+		{kubernetesConfig.ConfigPath, kubernetes.DefaultConfig().ConfigPath},
+		{steampipeConfig.ConfigPath, steampipe.DefaultConfig().ConfigPath},
+	}) {
+		fmt.Fprintln(os.Stderr, "Warning: --demo-fixtures implies --dry-run while outputs use their default paths")
+		dryRun = true
+		config.DryRun = true
+	}
+
 	return nil
 }
 
+// outputsRedirected reports whether each configured path, paired with its
+// default, resolves to a different file than the default.
+func outputsRedirected(paths [][2]string) bool {
+	for _, pair := range paths {
+		configured, err := core.ExpandPath(strings.TrimSpace(pair[0]))
+		if err != nil || configured == "" {
+			return false
+		}
+		defaultPath, err := core.ExpandPath(pair[1])
+		if err != nil || filepath.Clean(configured) == filepath.Clean(defaultPath) {
+			return false
+		}
+	}
+	return true
+}
+
+// applyLoggingCLIOverrides returns cfg with logging flags applied on top.
+// --debug raises the default level to debug.
+func applyLoggingCLIOverrides(cfg core.LoggingConfig) (core.LoggingConfig, error) {
+	if debug {
+		cfg.Level = "debug"
+	}
+
+	if strings.TrimSpace(logFormat) != "" {
+		cfg.Format = strings.TrimSpace(logFormat)
+	}
+
+	if strings.TrimSpace(logFile) != "" {
+		cfg.File = strings.TrimSpace(logFile)
+	}
+
+	overrides := parseCSVFlag(logLevels)
+	if len(overrides) == 0 {
+		return cfg, nil
+	}
+
+	providers := make(map[string]string, len(cfg.Providers)+len(overrides))
+	for name, level := range cfg.Providers {
+		providers[name] = level
+	}
+	for _, override := range overrides {
+		name, level, ok := strings.Cut(override, "=")
+		if !ok || strings.TrimSpace(name) == "" || strings.TrimSpace(level) == "" {
+			return cfg, fmt.Errorf("invalid --log-levels entry %q, want provider=level", override)
+		}
+		providers[strings.TrimSpace(name)] = strings.TrimSpace(level)
+	}
+	cfg.Providers = providers
+
+	return cfg, nil
+}
+
 func applyKubernetesCLIOverrides(cfg *kubernetes.Config) {
 	if cfg == nil {
 		return
@@ -254,6 +412,10 @@ func applyAWSCLIOverrides(cfg *aws.Config) {
 		cfg.Demo = true
 	}
 
+	if awsExplainFilters {
+		cfg.ExplainFilters = true
+	}
+
 	if strings.TrimSpace(awsPrefix) != "" {
 		cfg.ProfilePrefix = strings.TrimSpace(awsPrefix)
 	}
@@ -279,6 +441,16 @@ func applyAWSCLIOverrides(cfg *aws.Config) {
 	}
 }
 
+// clusterRegions returns the EKS scan behind aws eks_regions region variants.
+// It scans with the kubernetes provider's settings, whether or not that
+// provider runs, and shares its cache so the provider reuses the scans.
+func clusterRegions(cfg *kubernetes.Config, logger *slog.Logger) aws.ClusterRegionsFunc {
+	return func(ctx context.Context, path string, profiles []core.AWSProfile, cache *core.ProviderCache) (map[string][]string, error) {
+		cache = cache.ForProvider(kubernetes.ProviderName, config.ProviderCacheTTL(kubernetes.ProviderName))
+		return kubernetes.ProfileClusterRegions(ctx, cfg, path, profiles, cache, logger)
+	}
+}
+
 func applySteampipeCLIOverrides(cfg *steampipe.Config) {
 	if cfg == nil {
 		return
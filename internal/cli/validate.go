@@ -1,9 +1,11 @@
 package cli
 
 import (
-	"context"
 	"fmt"
+	"os"
+	"time"
 
+	"github.com/jmreicha/cfgctl/internal/providers/aws"
 	"github.com/spf13/cobra"
 )
 
@@ -11,13 +13,16 @@ func newValidateCmd() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "validate",
 		Short: "Validate provider prerequisites",
-		Long:  "Check if all prerequisites are met for registered providers.",
-		RunE: func(_ *cobra.Command, _ []string) error {
-			ctx := context.Background()
+		Long:  "Check if all prerequisites are met for registered providers, and warn about SSO tokens that expire soon.",
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			ctx := cmd.Context()
 
 			if err := engine.ValidateAll(ctx); err != nil {
 				return fmt.Errorf("validation failed: %w", err)
 			}
+			for _, warning := range awsExpiryWarnings() {
+				fmt.Fprintf(os.Stderr, "Warning: %s\n", warning)
+			}
 
 			fmt.Println("All providers validated successfully")
 			return nil
@@ -26,3 +31,17 @@ func newValidateCmd() *cobra.Command {
 
 	return cmd
 }
+
+// awsExpiryWarnings returns the SSO expiry warnings of the aws provider, if
+// it is registered.
+func awsExpiryWarnings() []string {
+	registered, err := registry.Get(aws.ProviderName)
+	if err != nil {
+		return nil
+	}
+	provider, ok := registered.(*aws.Provider)
+	if !ok {
+		return nil
+	}
+	return provider.ExpiryWarnings(time.Now().UTC())
+}
@@ -0,0 +1,88 @@
+package cli
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jmreicha/cfgctl/internal/core"
+	"github.com/spf13/cobra"
+)
+
+// defaultWatchPIDFile is where the watch command records its PID.
+const defaultWatchPIDFile = "~/.cfgctl/watch.pid"
+
+func newWatchCmd() *cobra.Command {
+	var (
+		debounce time.Duration
+		pidFile  string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "watch [provider...]",
+		Short: "Regenerate configuration files when their inputs change",
+		Long: `Watch provider input files and regenerate the providers that read them.
+The AWS config file is watched for steampipe and kubernetes, the kubeconfig
+merge directory for kubernetes, and shell history files for ssh when history
+parsing is enabled. Changes are debounced, and generated files are overwritten.
+
+A PID file prevents more than one watcher from running, so the command can be
+run as a user service. It stops on SIGINT or SIGTERM.
+
+Examples:
+  cfgctl watch
+  cfgctl watch kubernetes steampipe
+  cfgctl watch --debounce 5s --pid-file ~/.cache/cfgctl/watch.pid`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			providers := args
+			if len(args) == 1 && args[0] == "all" {
+				providers = nil
+			}
+
+			cache, err := newDiscoveryCache(false, false)
+			if err != nil {
+				return err
+			}
+
+			lock, err := core.AcquirePIDFile(pidFile)
+			if err != nil {
+				return err
+			}
+			defer func() {
+				_ = lock.Release()
+			}()
+
+			return engine.Watch(cmd.Context(), &core.WatchOptions{
+				Execute: core.ExecuteOptions{
+					Providers: providers,
+					DryRun:    dryRun,
+					Force:     true,
+					NoBackup:  noBackup,
+					Verbose:   verbose,
+					KeepGoing: true,
+					Cache:     cache,
+				},
+				Debounce: debounce,
+				OnRun:    printWatchRun,
+			})
+		},
+	}
+
+	cmd.Flags().DurationVar(&debounce, "debounce", core.DefaultWatchDebounce, "wait this long after the last change before regenerating")
+	cmd.Flags().StringVar(&pidFile, "pid-file", defaultWatchPIDFile, "PID file used to prevent multiple watchers")
+
+	return cmd
+}
+
+// printWatchRun reports the outcome of a regeneration triggered by a change.
+func printWatchRun(providers []string, results map[string]*core.Result, err error) {
+	colorEnabled := supportsColor()
+	sort.Strings(providers)
+	fmt.Printf("\n%s regenerated %s\n", time.Now().Format(time.TimeOnly), strings.Join(providers, ", "))
+
+	printGenerateResults(results)
+	if err != nil {
+		fmt.Printf("%s %v\n", formatLabel(colorEnabled, "Error:"), formatWarning(colorEnabled, err.Error()))
+	}
+}
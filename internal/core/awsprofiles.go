@@ -0,0 +1,280 @@
+package core
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// DefaultAWSMarkerKey is the key that tags profiles generated by the aws provider.
+const DefaultAWSMarkerKey = "sso_auto_populated"
+
+// AWSVariantOfKey names the profile a generated region variant was copied
+// from.
+const AWSVariantOfKey = "cfgctl_variant_of"
+
+// AWSProfile describes one profile in an AWS config file. Fields that the
+// profile does not set are left empty.
+type AWSProfile struct {
+	Name        string `json:"name"`
+	AccountID   string `json:"account_id,omitempty"`
+	AccountName string `json:"account_name,omitempty"`
+	RoleName    string `json:"role_name,omitempty"`
+	SSOSession  string `json:"sso_session,omitempty"`
+	Region      string `json:"region,omitempty"`
+
+	// Managed reports whether the profile carries the marker key, meaning it
+	// was generated rather than maintained by hand.
+	Managed bool `json:"managed,omitempty"`
+
+	// VariantOf is the profile this one is a region variant of. Variants
+	// reach the same account and role as that profile, so providers that
+	// scan accounts skip them.
+	VariantOf string `json:"variant_of,omitempty"`
+}
+
+// Account returns the key used to group profiles by account: the account ID
+// when known, otherwise the lowercased part of the name before the first "/".
+func (p AWSProfile) Account() string {
+	if p.AccountID != "" {
+		return p.AccountID
+	}
+	account, _, _ := strings.Cut(p.Name, "/")
+	return strings.ToLower(account)
+}
+
+// Role returns the role name, falling back to the part of the profile name
+// after the last "/", or the whole name if it has none.
+func (p AWSProfile) Role() string {
+	if p.RoleName != "" {
+		return p.RoleName
+	}
+	if idx := strings.LastIndex(p.Name, "/"); idx >= 0 {
+		return p.Name[idx+1:]
+	}
+	return p.Name
+}
+
+// ParseAWSProfiles reads the profiles from the AWS config file at path.
+// Profiles containing markerKey = true are reported as managed; an empty
+// markerKey uses DefaultAWSMarkerKey. Errors from opening the file wrap the
+// underlying error, so callers can test for os.ErrNotExist.
+func ParseAWSProfiles(path, markerKey string) ([]AWSProfile, error) {
+	// #nosec G304 -- AWS config path is user configured
+	data, err := os.ReadFile(filepath.Clean(path))
+	if err != nil {
+		return nil, fmt.Errorf("read aws config: %w", err)
+	}
+	return ParseAWSProfilesContent(string(data), markerKey), nil
+}
+
+// ParseAWSProfilesContent parses AWS config file content. Named profiles use
+// "[profile name]" headers and the default profile uses "[default]"; other
+// sections, such as "[sso-session name]", are skipped.
+func ParseAWSProfilesContent(content, markerKey string) []AWSProfile {
+	if strings.TrimSpace(markerKey) == "" {
+		markerKey = DefaultAWSMarkerKey
+	}
+
+	var (
+		profiles []AWSProfile
+		current  *AWSProfile
+	)
+	flush := func() {
+		if current != nil {
+			profiles = append(profiles, *current)
+		}
+		current = nil
+	}
+
+	scanner := bufio.NewScanner(strings.NewReader(content))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";") {
+			continue
+		}
+
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			flush()
+			if name := awsProfileSectionName(line[1 : len(line)-1]); name != "" {
+				current = &AWSProfile{Name: name}
+			}
+			continue
+		}
+
+		if current == nil {
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+		current.set(strings.ToLower(strings.TrimSpace(key)), strings.TrimSpace(value), markerKey)
+	}
+	flush()
+
+	return profiles
+}
+
+// awsProfileSectionName returns the profile name for a section header, or ""
+// if the section is not a profile.
+func awsProfileSectionName(header string) string {
+	header = strings.TrimSpace(header)
+	if strings.EqualFold(header, "default") {
+		return "default"
+	}
+	if len(header) > len("profile ") && strings.EqualFold(header[:len("profile ")], "profile ") {
+		return strings.TrimSpace(header[len("profile "):])
+	}
+	return ""
+}
+
+func (p *AWSProfile) set(key, value, markerKey string) {
+	switch key {
+	case "sso_account_id":
+		p.AccountID = value
+	case "sso_account_name":
+		p.AccountName = value
+	case "sso_role_name":
+		p.RoleName = value
+	case "sso_session":
+		p.SSOSession = value
+	case "region":
+		p.Region = value
+	case AWSVariantOfKey:
+		p.VariantOf = value
+	case "role_arn":
+		// arn:aws:iam::<account>:role/<path/>name
+		parts := strings.SplitN(value, ":", 6)
+		if len(parts) == 6 && strings.HasPrefix(parts[5], "role/") {
+			if p.AccountID == "" {
+				p.AccountID = parts[4]
+			}
+			if p.RoleName == "" {
+				p.RoleName = parts[5][strings.LastIndex(parts[5], "/")+1:]
+			}
+		}
+	case strings.ToLower(markerKey):
+		p.Managed = strings.EqualFold(value, "true")
+	}
+}
+
+// AWSProfileNames returns the names of profiles, in order.
+func AWSProfileNames(profiles []AWSProfile) []string {
+	names := make([]string, 0, len(profiles))
+	for _, profile := range profiles {
+		names = append(names, profile.Name)
+	}
+	return names
+}
+
+// MatchesAWSRole reports whether role is one of roles, ignoring case and
+// surrounding whitespace. Blank entries in roles are ignored.
+func MatchesAWSRole(role string, roles []string) bool {
+	for _, candidate := range roles {
+		candidate = strings.TrimSpace(candidate)
+		if candidate != "" && strings.EqualFold(candidate, strings.TrimSpace(role)) {
+			return true
+		}
+	}
+	return false
+}
+
+// FilterAWSProfilesByRole returns the profiles whose role is in roles. It
+// returns nil if nothing matches.
+func FilterAWSProfilesByRole(profiles []AWSProfile, roles []string) []AWSProfile {
+	var filtered []AWSProfile
+	for _, profile := range profiles {
+		if MatchesAWSRole(profile.Role(), roles) {
+			filtered = append(filtered, profile)
+		}
+	}
+	return filtered
+}
+
+// DedupeAWSProfilesByAccount keeps one profile per account, in order of first
+// appearance. For each account it picks the first profile whose role matches
+// preferredRoles, checked in order, and otherwise the account's first profile.
+func DedupeAWSProfilesByAccount(profiles []AWSProfile, preferredRoles []string) []AWSProfile {
+	groups := make(map[string][]AWSProfile)
+	var order []string
+	for _, profile := range profiles {
+		account := profile.Account()
+		if _, ok := groups[account]; !ok {
+			order = append(order, account)
+		}
+		groups[account] = append(groups[account], profile)
+	}
+
+	deduped := make([]AWSProfile, 0, len(order))
+	for _, account := range order {
+		deduped = append(deduped, preferredAWSProfile(groups[account], preferredRoles))
+	}
+	return deduped
+}
+
+func preferredAWSProfile(profiles []AWSProfile, preferredRoles []string) AWSProfile {
+	for _, role := range preferredRoles {
+		for _, profile := range profiles {
+			if MatchesAWSRole(profile.Role(), []string{role}) {
+				return profile
+			}
+		}
+	}
+	return profiles[0]
+}
+
+// AWSProfileInventory shares the profiles the aws provider wrote with the
+// providers that run after it, so they do not have to reparse the file. A nil
+// inventory is valid and never has profiles. It is safe for concurrent use.
+type AWSProfileInventory struct {
+	mu       sync.RWMutex
+	path     string
+	profiles []AWSProfile
+}
+
+// Publish records profiles as the content of the AWS config file at path,
+// replacing anything published earlier.
+func (i *AWSProfileInventory) Publish(path string, profiles []AWSProfile) {
+	if i == nil {
+		return
+	}
+
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.path = cleanInventoryPath(path)
+	i.profiles = append([]AWSProfile(nil), profiles...)
+}
+
+// Profiles returns a copy of the profiles published for the AWS config file at
+// path. It reports false if nothing was published for that file, in which
+// case callers should parse the file themselves.
+func (i *AWSProfileInventory) Profiles(path string) ([]AWSProfile, bool) {
+	if i == nil {
+		return nil, false
+	}
+
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+	if i.path == "" || i.path != cleanInventoryPath(path) {
+		return nil, false
+	}
+	return append([]AWSProfile(nil), i.profiles...), true
+}
+
+func cleanInventoryPath(path string) string {
+	if strings.TrimSpace(path) == "" {
+		return ""
+	}
+	expanded, err := ExpandPath(path)
+	if err != nil {
+		return filepath.Clean(path)
+	}
+	if abs, err := filepath.Abs(expanded); err == nil {
+		return abs
+	}
+	return filepath.Clean(expanded)
+}
@@ -0,0 +1,269 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+const testAWSConfig = `# generated
+[default]
+region = us-east-1
+
+[sso-session cfgctl]
+sso_start_url = https://example.awsapps.com/start
+
+[profile prod/AdminAccess]
+sso_session = cfgctl
+sso_account_id = 111111111111
+sso_account_name = prod
+sso_role_name = AdminAccess
+sso_auto_populated = true
+
+[profile prod/AdminAccess@eu-west-1]
+sso_session = cfgctl
+sso_account_id = 111111111111
+sso_account_name = prod
+sso_role_name = AdminAccess
+region = eu-west-1
+cfgctl_variant_of = prod/AdminAccess
+sso_auto_populated = true
+
+; hand-written
+[profile chained]
+source_profile = prod/AdminAccess
+role_arn = arn:aws:iam::222222222222:role/team/Deploy
+region = eu-west-1
+`
+
+func TestParseAWSProfilesContent(t *testing.T) {
+	profiles := ParseAWSProfilesContent(testAWSConfig, "")
+
+	want := []AWSProfile{
+		{Name: "default", Region: "us-east-1"},
+		{
+			Name:        "prod/AdminAccess",
+			AccountID:   "111111111111",
+			AccountName: "prod",
+			RoleName:    "AdminAccess",
+			SSOSession:  "cfgctl",
+			Managed:     true,
+		},
+		{
+			Name:        "prod/AdminAccess@eu-west-1",
+			AccountID:   "111111111111",
+			AccountName: "prod",
+			RoleName:    "AdminAccess",
+			SSOSession:  "cfgctl",
+			Region:      "eu-west-1",
+			Managed:     true,
+			VariantOf:   "prod/AdminAccess",
+		},
+		{Name: "chained", AccountID: "222222222222", RoleName: "Deploy", Region: "eu-west-1"},
+	}
+	if !reflect.DeepEqual(profiles, want) {
+		t.Fatalf("profiles = %+v, want %+v", profiles, want)
+	}
+
+	custom := ParseAWSProfilesContent("[profile a]\ncfgctl_managed = true\n", "cfgctl_managed")
+	if len(custom) != 1 || !custom[0].Managed {
+		t.Fatalf("custom marker profiles = %+v, want one managed profile", custom)
+	}
+}
+
+func TestParseAWSProfiles_MissingFile(t *testing.T) {
+	_, err := ParseAWSProfiles(filepath.Join(t.TempDir(), "config"), "")
+	if !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("err = %v, want os.ErrNotExist", err)
+	}
+}
+
+func TestFilterAWSProfilesByRole(t *testing.T) {
+	profiles := []AWSProfile{
+		{Name: "prod/adminaccess"},
+		{Name: "prod/readonly"},
+		{Name: "staging/adminaccess"},
+		{Name: "staging/poweruser"},
+		{Name: "simple-profile"},
+		{Name: "custom-name", RoleName: "ReadOnly"},
+	}
+
+	tests := []struct {
+		name     string
+		roles    []string
+		expected []string
+	}{
+		{
+			name:     "single role",
+			roles:    []string{"adminaccess"},
+			expected: []string{"prod/adminaccess", "staging/adminaccess"},
+		},
+		{
+			name:     "multiple roles",
+			roles:    []string{"adminaccess", "readonly"},
+			expected: []string{"prod/adminaccess", "prod/readonly", "staging/adminaccess", "custom-name"},
+		},
+		{
+			name:     "case insensitive",
+			roles:    []string{"AdminAccess"},
+			expected: []string{"prod/adminaccess", "staging/adminaccess"},
+		},
+		{
+			name:     "no slash in profile",
+			roles:    []string{"simple-profile"},
+			expected: []string{"simple-profile"},
+		},
+		{
+			name:     "no match",
+			roles:    []string{"nonexistent"},
+			expected: []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := AWSProfileNames(FilterAWSProfilesByRole(profiles, tt.roles))
+			if !reflect.DeepEqual(result, tt.expected) {
+				t.Errorf("FilterAWSProfilesByRole() = %v, want %v", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestDedupeAWSProfilesByAccount(t *testing.T) {
+	named := func(names ...string) []AWSProfile {
+		profiles := make([]AWSProfile, 0, len(names))
+		for _, name := range names {
+			profiles = append(profiles, AWSProfile{Name: name})
+		}
+		return profiles
+	}
+
+	tests := []struct {
+		name      string
+		profiles  []AWSProfile
+		preferred []string
+		expected  []string
+	}{
+		{
+			name:     "single role per account",
+			profiles: named("default", "bedrock-test"),
+			expected: []string{"default", "bedrock-test"},
+		},
+		{
+			name:     "first profile per account",
+			profiles: named("deviceplatform/cloudinfra", "deviceplatform/lytxread", "aft-management/cloudinfra", "aft-management/lytxread"),
+			expected: []string{"deviceplatform/cloudinfra", "aft-management/cloudinfra"},
+		},
+		{
+			name:      "preferred role",
+			profiles:  named("deviceplatform/cloudinfra", "deviceplatform/lytxread"),
+			preferred: []string{"LytxRead"},
+			expected:  []string{"deviceplatform/lytxread"},
+		},
+		{
+			name:     "preserves order",
+			profiles: named("aft-management/cloudinfra", "deviceplatform/cloudinfra", "aft-management/lytxread"),
+			expected: []string{"aft-management/cloudinfra", "deviceplatform/cloudinfra"},
+		},
+		{
+			name: "groups by account id",
+			profiles: []AWSProfile{
+				{Name: "prod-admin", AccountID: "111111111111", RoleName: "Admin"},
+				{Name: "prod-read", AccountID: "111111111111", RoleName: "ReadOnly"},
+				{Name: "dev-read", AccountID: "222222222222", RoleName: "ReadOnly"},
+			},
+			preferred: []string{"readonly"},
+			expected:  []string{"prod-read", "dev-read"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := AWSProfileNames(DedupeAWSProfilesByAccount(tt.profiles, tt.preferred))
+			if !reflect.DeepEqual(result, tt.expected) {
+				t.Errorf("DedupeAWSProfilesByAccount() = %v, want %v", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestAWSProfileInventory(t *testing.T) {
+	var nilInventory *AWSProfileInventory
+	nilInventory.Publish("config", []AWSProfile{{Name: "a"}})
+	if _, ok := nilInventory.Profiles("config"); ok {
+		t.Fatal("nil inventory should have no profiles")
+	}
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config")
+	inventory := &AWSProfileInventory{}
+	if _, ok := inventory.Profiles(path); ok {
+		t.Fatal("empty inventory should have no profiles")
+	}
+
+	inventory.Publish(path, []AWSProfile{{Name: "a"}})
+	profiles, ok := inventory.Profiles(filepath.Join(dir, ".", "config"))
+	if !ok || len(profiles) != 1 || profiles[0].Name != "a" {
+		t.Fatalf("Profiles = %+v, %v; want profile a", profiles, ok)
+	}
+	profiles[0].Name = "changed"
+	if again, _ := inventory.Profiles(path); again[0].Name != "a" {
+		t.Fatal("Profiles should return a copy")
+	}
+
+	if _, ok := inventory.Profiles(filepath.Join(dir, "other")); ok {
+		t.Fatal("inventory should not match a different file")
+	}
+}
+
+type inventoryTestProvider struct {
+	engineTestProvider
+	publish   []AWSProfile
+	published []AWSProfile
+}
+
+func (p *inventoryTestProvider) Generate(ctx context.Context, opts *GenerateOptions) (*Result, error) {
+	if p.publish != nil {
+		opts.AWSProfiles.Publish("config", p.publish)
+	} else {
+		p.published, _ = opts.AWSProfiles.Profiles("config")
+	}
+	return p.engineTestProvider.Generate(ctx, opts)
+}
+
+func TestEngineExecute_SharesAWSProfiles(t *testing.T) {
+	publisher := &inventoryTestProvider{
+		engineTestProvider: engineTestProvider{name: "publisher"},
+		publish:            []AWSProfile{{Name: "prod/AdminAccess"}},
+	}
+	consumer := &inventoryTestProvider{engineTestProvider: engineTestProvider{name: "consumer"}}
+
+	registry := NewRegistry()
+	for _, provider := range []Provider{publisher, consumer} {
+		if err := registry.Register(provider); err != nil {
+			t.Fatalf("register: %v", err)
+		}
+	}
+	engine := NewEngine(registry, NewBackupManager(""), NewConfig(), newTestLogger())
+
+	run := &ExecuteOptions{NoBackup: true, Providers: []string{"publisher", "consumer"}}
+	if _, err := engine.Execute(context.Background(), run); err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	if !reflect.DeepEqual(consumer.published, publisher.publish) {
+		t.Fatalf("consumer saw %+v, want %+v", consumer.published, publisher.publish)
+	}
+
+	// Each run starts with an empty inventory.
+	consumer.published = nil
+	if _, err := engine.Execute(context.Background(), &ExecuteOptions{NoBackup: true, Providers: []string{"consumer"}}); err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	if consumer.published != nil {
+		t.Fatalf("consumer saw %+v from an earlier run", consumer.published)
+	}
+}
@@ -0,0 +1,253 @@
+package core
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// DefaultCacheDir is where discovery results are cached, relative to the home directory.
+	DefaultCacheDir = ".cfgctl/cache"
+
+	// DefaultCacheTTL is how long cached discovery results are used before rediscovering.
+	DefaultCacheTTL = time.Hour
+)
+
+// Result metadata keys recorded for providers that use the cache.
+const (
+	MetadataCacheHits   = "cache_hits"
+	MetadataCacheMisses = "cache_misses"
+)
+
+// CacheMode controls how providers use cached discovery results.
+type CacheMode int
+
+const (
+	// CacheDefault uses fresh entries and rediscovers missing or expired ones.
+	CacheDefault CacheMode = iota
+
+	// CacheRefresh ignores cached entries and rediscovers, updating the cache.
+	CacheRefresh
+
+	// CacheOffline uses cached entries regardless of age and never rediscovers.
+	CacheOffline
+)
+
+// ErrCacheMiss is returned by ProviderCache.Load in offline mode when nothing is cached.
+var ErrCacheMiss = errors.New("no cached discovery data and offline mode forbids network calls")
+
+// CacheConfig configures the discovery cache.
+type CacheConfig struct {
+	// Dir is the cache directory. Defaults to ~/.cfgctl/cache.
+	Dir string `yaml:"dir"`
+
+	// TTL is how long entries stay fresh. Defaults to one hour; providers may
+	// override it with their own cache_ttl setting.
+	TTL time.Duration `yaml:"ttl"`
+}
+
+// CacheKey identifies a cached discovery result within a provider. Fields that
+// do not apply to a provider are left empty.
+type CacheKey struct {
+	StartURL string `json:"start_url,omitempty"`
+	Profile  string `json:"profile,omitempty"`
+	Region   string `json:"region,omitempty"`
+
+	// Filter fingerprints filters applied during discovery, so results
+	// discovered with different filters are cached apart.
+	Filter string `json:"filter,omitempty"`
+}
+
+// cacheEntry is the on-disk form of a cached value.
+type cacheEntry struct {
+	Key      CacheKey        `json:"key"`
+	StoredAt time.Time       `json:"stored_at"`
+	Data     json.RawMessage `json:"data"`
+}
+
+// Cache stores provider discovery results as JSON files, one directory per provider.
+type Cache struct {
+	dir  string
+	mode CacheMode
+	now  func() time.Time
+}
+
+// NewCache returns a cache rooted at dir, or ~/.cfgctl/cache if dir is empty.
+func NewCache(dir string, mode CacheMode) (*Cache, error) {
+	if dir == "" {
+		dir = filepath.Join("~", DefaultCacheDir)
+	}
+
+	expanded, err := ExpandPath(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Cache{dir: expanded, mode: mode, now: time.Now}, nil
+}
+
+// Dir returns the cache directory.
+func (c *Cache) Dir() string {
+	return c.dir
+}
+
+// ForProvider returns a view of the cache for one provider whose entries
+// expire after ttl. It returns nil, which disables caching, for a nil cache.
+func (c *Cache) ForProvider(provider string, ttl time.Duration) *ProviderCache {
+	if c == nil {
+		return nil
+	}
+	if ttl <= 0 {
+		ttl = DefaultCacheTTL
+	}
+	return &ProviderCache{cache: c, provider: provider, ttl: ttl}
+}
+
+// ProviderCache is a Cache scoped to a single provider. A nil ProviderCache is
+// valid and never hits. It is safe for concurrent use.
+type ProviderCache struct {
+	cache    *Cache
+	provider string
+	ttl      time.Duration
+	hits     atomic.Int64
+	misses   atomic.Int64
+}
+
+// ForProvider returns a view of the same cache for another provider, so one
+// provider can store discovery results the other reuses later in the run.
+func (p *ProviderCache) ForProvider(provider string, ttl time.Duration) *ProviderCache {
+	if p == nil {
+		return nil
+	}
+	return p.cache.ForProvider(provider, ttl)
+}
+
+// Offline reports whether network discovery is forbidden.
+func (p *ProviderCache) Offline() bool {
+	return p != nil && p.cache.mode == CacheOffline
+}
+
+// Load decodes the entry for key into value and reports whether it was found.
+// Expired entries are ignored unless the cache is offline, and every entry is
+// ignored in refresh mode. In offline mode a missing entry returns ErrCacheMiss.
+func (p *ProviderCache) Load(key CacheKey, value any) (bool, error) {
+	if p == nil {
+		return false, nil
+	}
+
+	entry, ok := p.read(key)
+	if ok && p.cache.mode != CacheRefresh && (p.cache.mode == CacheOffline || p.cache.now().Sub(entry.StoredAt) < p.ttl) {
+		if err := json.Unmarshal(entry.Data, value); err == nil {
+			p.hits.Add(1)
+			return true, nil
+		}
+	}
+
+	p.misses.Add(1)
+	if p.Offline() {
+		return false, fmt.Errorf("%w: %s %s", ErrCacheMiss, p.provider, describeCacheKey(key))
+	}
+	return false, nil
+}
+
+// Store saves value as the entry for key.
+func (p *ProviderCache) Store(key CacheKey, value any) error {
+	if p == nil {
+		return nil
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("encode cache entry: %w", err)
+	}
+	entry, err := json.Marshal(cacheEntry{Key: key, StoredAt: p.cache.now().UTC(), Data: data})
+	if err != nil {
+		return fmt.Errorf("encode cache entry: %w", err)
+	}
+
+	path := p.path(key)
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return fmt.Errorf("create cache directory: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".entry-*")
+	if err != nil {
+		return fmt.Errorf("create cache entry: %w", err)
+	}
+	_, writeErr := tmp.Write(entry)
+	if err := errors.Join(writeErr, tmp.Close()); err != nil {
+		_ = os.Remove(tmp.Name())
+		return fmt.Errorf("write cache entry: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		_ = os.Remove(tmp.Name())
+		return fmt.Errorf("write cache entry: %w", err)
+	}
+	return nil
+}
+
+// recordMetadata adds hit and miss counts to result when the cache was used.
+func (p *ProviderCache) recordMetadata(result *Result) {
+	if p == nil || result == nil {
+		return
+	}
+
+	hits, misses := p.hits.Load(), p.misses.Load()
+	if hits == 0 && misses == 0 {
+		return
+	}
+	if result.Metadata == nil {
+		result.Metadata = make(map[string]interface{})
+	}
+	result.Metadata[MetadataCacheHits] = int(hits)
+	result.Metadata[MetadataCacheMisses] = int(misses)
+}
+
+func (p *ProviderCache) read(key CacheKey) (cacheEntry, bool) {
+	var entry cacheEntry
+
+	// #nosec G304 -- cache path is derived from the configured cache directory
+	data, err := os.ReadFile(p.path(key))
+	if err != nil {
+		return entry, false
+	}
+	if err := json.Unmarshal(data, &entry); err != nil || entry.Key != key {
+		return entry, false
+	}
+	return entry, true
+}
+
+func (p *ProviderCache) path(key CacheKey) string {
+	id := key.StartURL + "\x00" + key.Profile + "\x00" + key.Region
+	if key.Filter != "" {
+		id += "\x00" + key.Filter
+	}
+	sum := sha256.Sum256([]byte(id))
+	return filepath.Join(p.cache.dir, p.provider, hex.EncodeToString(sum[:16])+".json")
+}
+
+func describeCacheKey(key CacheKey) string {
+	desc := ""
+	for _, part := range []struct{ name, value string }{
+		{"start url", key.StartURL},
+		{"profile", key.Profile},
+		{"region", key.Region},
+		{"filter", key.Filter},
+	} {
+		if part.value == "" {
+			continue
+		}
+		if desc != "" {
+			desc += ", "
+		}
+		desc += fmt.Sprintf("%s %q", part.name, part.value)
+	}
+	return desc
+}
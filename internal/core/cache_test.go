@@ -0,0 +1,151 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func newTestCache(t *testing.T, mode CacheMode, now *time.Time) *Cache {
+	t.Helper()
+
+	cache, err := NewCache(t.TempDir(), mode)
+	if err != nil {
+		t.Fatalf("NewCache failed: %v", err)
+	}
+	cache.now = func() time.Time { return *now }
+	return cache
+}
+
+func TestProviderCache_LoadStore(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	cache := newTestCache(t, CacheDefault, &now)
+	providerCache := cache.ForProvider("aws", time.Hour)
+	key := CacheKey{StartURL: "https://example.awsapps.com/start", Region: "us-east-1"}
+
+	var value []string
+	if found, err := providerCache.Load(key, &value); err != nil || found {
+		t.Fatalf("Load on empty cache = %v, %v", found, err)
+	}
+
+	if err := providerCache.Store(key, []string{"a", "b"}); err != nil {
+		t.Fatalf("Store failed: %v", err)
+	}
+	if found, err := providerCache.Load(key, &value); err != nil || !found || len(value) != 2 {
+		t.Fatalf("Load after store = %v, %v, %v", found, value, err)
+	}
+
+	// Other keys and providers do not share entries.
+	if found, _ := providerCache.Load(CacheKey{StartURL: key.StartURL, Region: "us-west-2"}, &value); found {
+		t.Fatal("expected miss for a different region")
+	}
+	if found, _ := providerCache.Load(CacheKey{StartURL: key.StartURL, Region: key.Region, Filter: "abc"}, &value); found {
+		t.Fatal("expected miss for a different filter")
+	}
+	if found, _ := cache.ForProvider("kubernetes", time.Hour).Load(key, &value); found {
+		t.Fatal("expected miss for a different provider")
+	}
+
+	now = now.Add(2 * time.Hour)
+	if found, _ := providerCache.Load(key, &value); found {
+		t.Fatal("expected expired entry to miss")
+	}
+
+	result := &Result{}
+	providerCache.recordMetadata(result)
+	if result.Metadata[MetadataCacheHits] != 1 || result.Metadata[MetadataCacheMisses] != 4 {
+		t.Fatalf("metadata = %v", result.Metadata)
+	}
+}
+
+func TestProviderCache_Modes(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	key := CacheKey{Profile: "prod", Region: "us-east-1"}
+
+	open := func(mode CacheMode) *ProviderCache {
+		cache, err := NewCache(dir, mode)
+		if err != nil {
+			t.Fatalf("NewCache failed: %v", err)
+		}
+		cache.now = func() time.Time { return now }
+		return cache.ForProvider("kubernetes", time.Minute)
+	}
+
+	var value []string
+	if _, err := open(CacheOffline).Load(key, &value); !errors.Is(err, ErrCacheMiss) {
+		t.Fatalf("expected ErrCacheMiss offline, got %v", err)
+	}
+
+	if err := open(CacheDefault).Store(key, []string{"cluster"}); err != nil {
+		t.Fatalf("Store failed: %v", err)
+	}
+	if found, _ := open(CacheRefresh).Load(key, &value); found {
+		t.Fatal("expected refresh mode to ignore cached entry")
+	}
+
+	now = now.Add(time.Hour)
+	if found, err := open(CacheOffline).Load(key, &value); err != nil || !found {
+		t.Fatalf("expected offline mode to use expired entry, got %v, %v", found, err)
+	}
+}
+
+func TestProviderCache_Nil(t *testing.T) {
+	var cache *Cache
+	providerCache := cache.ForProvider("aws", 0)
+
+	var value []string
+	if found, err := providerCache.Load(CacheKey{}, &value); found || err != nil {
+		t.Fatalf("nil cache Load = %v, %v", found, err)
+	}
+	if err := providerCache.Store(CacheKey{}, value); err != nil {
+		t.Fatalf("nil cache Store failed: %v", err)
+	}
+	if providerCache.Offline() {
+		t.Fatal("nil cache should not be offline")
+	}
+}
+
+type cacheTestProvider struct {
+	engineTestProvider
+	cache *ProviderCache
+}
+
+func (p *cacheTestProvider) Generate(ctx context.Context, opts *GenerateOptions) (*Result, error) {
+	p.cache = opts.Cache
+	var value string
+	if found, _ := opts.Cache.Load(CacheKey{Region: "us-east-1"}, &value); !found {
+		_ = opts.Cache.Store(CacheKey{Region: "us-east-1"}, "discovered")
+	}
+	return p.engineTestProvider.Generate(ctx, opts)
+}
+
+func TestEngineExecute_Cache(t *testing.T) {
+	provider := &cacheTestProvider{engineTestProvider: engineTestProvider{name: "cached"}}
+	registry := NewRegistry()
+	if err := registry.Register(provider); err != nil {
+		t.Fatalf("register: %v", err)
+	}
+	config := NewConfig()
+	config.ProviderSettings["cached"] = ProviderSettings{CacheTTL: 5 * time.Minute}
+	engine := NewEngine(registry, NewBackupManager(""), config, newTestLogger())
+
+	cache, err := NewCache(t.TempDir(), CacheDefault)
+	if err != nil {
+		t.Fatalf("NewCache failed: %v", err)
+	}
+
+	for i, wantHits := range []int{0, 1} {
+		results, err := engine.Execute(context.Background(), &ExecuteOptions{NoBackup: true, Cache: cache})
+		if err != nil {
+			t.Fatalf("Execute %d failed: %v", i, err)
+		}
+		if got := results["cached"].Metadata[MetadataCacheHits]; got != wantHits {
+			t.Fatalf("run %d cache hits = %v, want %d", i, got, wantHits)
+		}
+	}
+	if provider.cache.ttl != 5*time.Minute {
+		t.Fatalf("provider cache ttl = %s, want 5m", provider.cache.ttl)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"time"
 
 	"gopkg.in/yaml.v3"
 )
@@ -16,6 +17,22 @@ type Config struct {
 	DryRun   bool `yaml:"dry_run"`
 	NoBackup bool `yaml:"no_backup"`
 
+	// Timeout bounds the whole generation run. Zero means no limit.
+	Timeout time.Duration `yaml:"timeout"`
+
+	// Logging configures log format, destination, and levels.
+	Logging LoggingConfig `yaml:"logging"`
+
+	// Hooks run for every provider, before the provider's own hooks.
+	Hooks HooksConfig `yaml:"hooks"`
+
+	// Cache configures where discovery results are cached and for how long.
+	Cache CacheConfig `yaml:"cache"`
+
+	// ProviderSettings contains engine-level settings for each provider,
+	// decoded from the provider sections alongside the provider config.
+	ProviderSettings map[string]ProviderSettings `yaml:"-"`
+
 	// Providers contains provider-specific configuration.
 	// The map key is the provider name.
 	Providers map[string]ProviderConfig `yaml:"-"`
@@ -27,14 +44,28 @@ type Config struct {
 // NewConfig creates a new configuration with default values.
 func NewConfig() *Config {
 	return &Config{
-		Verbose:      false,
-		DryRun:       false,
-		NoBackup:     false,
-		Providers:    make(map[string]ProviderConfig),
-		RawProviders: make(map[string]map[string]interface{}),
+		Verbose:          false,
+		DryRun:           false,
+		NoBackup:         false,
+		Providers:        make(map[string]ProviderConfig),
+		ProviderSettings: make(map[string]ProviderSettings),
+		RawProviders:     make(map[string]map[string]interface{}),
 	}
 }
 
+// ProviderSettings holds settings the engine applies to a provider regardless
+// of its type.
+type ProviderSettings struct {
+	// Timeout bounds a single run of the provider. Zero means no limit.
+	Timeout time.Duration
+
+	// Hooks run around the provider's phases, after the global hooks.
+	Hooks HooksConfig
+
+	// CacheTTL overrides the global cache TTL for the provider. Zero uses the global TTL.
+	CacheTTL time.Duration
+}
+
 // LoadConfig loads configuration from a YAML file.
 // If the file doesn't exist, returns a default configuration.
 // The precedence order is: CLI flags > YAML config > defaults.
@@ -62,6 +93,14 @@ func LoadConfig(path string) (*Config, error) {
 		return nil, fmt.Errorf("failed to parse config file: %w", err)
 	}
 
+	if err := cfg.Hooks.validate(); err != nil {
+		return nil, fmt.Errorf("invalid hooks: %w", err)
+	}
+
+	if cfg.Cache.TTL < 0 {
+		return nil, fmt.Errorf("invalid cache ttl %s: must not be negative", cfg.Cache.TTL)
+	}
+
 	if err := cfg.decodeProviders(); err != nil {
 		return nil, err
 	}
@@ -99,6 +138,38 @@ func (c *Config) GetProviderConfig(providerName string) ProviderConfig {
 	return c.Providers[providerName]
 }
 
+// ProviderTimeout returns the configured timeout for a provider, or zero if
+// the provider has no timeout.
+func (c *Config) ProviderTimeout(providerName string) time.Duration {
+	if c == nil || c.ProviderSettings == nil {
+		return 0
+	}
+	return c.ProviderSettings[providerName].Timeout
+}
+
+// ProviderHooks returns the hooks configured for a provider.
+func (c *Config) ProviderHooks(providerName string) HooksConfig {
+	if c == nil || c.ProviderSettings == nil {
+		return HooksConfig{}
+	}
+	return c.ProviderSettings[providerName].Hooks
+}
+
+// ProviderCacheTTL returns how long a provider's cached discovery results stay
+// fresh: its own cache_ttl, else the global cache TTL, else DefaultCacheTTL.
+func (c *Config) ProviderCacheTTL(providerName string) time.Duration {
+	if c == nil {
+		return DefaultCacheTTL
+	}
+	if ttl := c.ProviderSettings[providerName].CacheTTL; ttl > 0 {
+		return ttl
+	}
+	if c.Cache.TTL > 0 {
+		return c.Cache.TTL
+	}
+	return DefaultCacheTTL
+}
+
 // SetProviderConfig sets the configuration for a specific provider.
 func (c *Config) SetProviderConfig(providerName string, config ProviderConfig) {
 	if c.Providers == nil {
@@ -119,6 +190,9 @@ func (c *Config) Merge(other *Config) {
 	if other.NoBackup {
 		c.NoBackup = true
 	}
+	if other.Timeout > 0 {
+		c.Timeout = other.Timeout
+	}
 
 	if other.Providers != nil {
 		if c.Providers == nil {
@@ -139,7 +213,17 @@ func (c *Config) decodeProviders() error {
 		c.Providers = make(map[string]ProviderConfig)
 	}
 
+	if c.ProviderSettings == nil {
+		c.ProviderSettings = make(map[string]ProviderSettings)
+	}
+
 	for name, providerCfg := range c.RawProviders {
+		settings, err := decodeProviderSettings(providerCfg)
+		if err != nil {
+			return fmt.Errorf("invalid settings for provider %q: %w", name, err)
+		}
+		c.ProviderSettings[name] = settings
+
 		cfg, err := ProviderConfigFromMap(name, providerCfg)
 		if err != nil {
 			return err
@@ -151,3 +235,60 @@ func (c *Config) decodeProviders() error {
 
 	return nil
 }
+
+func decodeProviderSettings(raw map[string]interface{}) (ProviderSettings, error) {
+	settings := ProviderSettings{}
+
+	timeout, err := parseDuration(raw["timeout"])
+	if err != nil {
+		return settings, fmt.Errorf("timeout: %w", err)
+	}
+	settings.Timeout = timeout
+
+	cacheTTL, err := parseDuration(raw["cache_ttl"])
+	if err != nil {
+		return settings, fmt.Errorf("cache_ttl: %w", err)
+	}
+	settings.CacheTTL = cacheTTL
+
+	if rawHooks, ok := raw["hooks"]; ok && rawHooks != nil {
+		data, err := yaml.Marshal(rawHooks)
+		if err != nil {
+			return settings, fmt.Errorf("hooks: %w", err)
+		}
+		if err := yaml.Unmarshal(data, &settings.Hooks); err != nil {
+			return settings, fmt.Errorf("hooks: %w", err)
+		}
+		if err := settings.Hooks.validate(); err != nil {
+			return settings, fmt.Errorf("hooks: %w", err)
+		}
+	}
+
+	return settings, nil
+}
+
+func parseDuration(value interface{}) (time.Duration, error) {
+	switch v := value.(type) {
+	case nil:
+		return 0, nil
+	case string:
+		if v == "" {
+			return 0, nil
+		}
+		duration, err := time.ParseDuration(v)
+		if err != nil {
+			return 0, err
+		}
+		if duration < 0 {
+			return 0, fmt.Errorf("duration %q must not be negative", v)
+		}
+		return duration, nil
+	case int:
+		if v < 0 {
+			return 0, fmt.Errorf("duration %d must not be negative", v)
+		}
+		return time.Duration(v) * time.Second, nil
+	default:
+		return 0, fmt.Errorf("unsupported duration value %v", value)
+	}
+}
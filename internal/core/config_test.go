@@ -4,6 +4,7 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 )
 
 // testProviderConfig is a simple implementation of ProviderConfig for testing.
@@ -361,3 +362,77 @@ func TestConfig_Merge(t *testing.T) {
 		t.Error("expected Kubernetes config to be added")
 	}
 }
+
+func TestLoadConfig_Timeouts(t *testing.T) {
+	tmpDir := t.TempDir()
+	cfgPath := filepath.Join(tmpDir, "config.yaml")
+
+	yamlContent := `timeout: 5m
+providers:
+  kubernetes:
+    timeout: 90s
+`
+
+	if err := os.WriteFile(cfgPath, []byte(yamlContent), 0o600); err != nil {
+		t.Fatalf("failed to write test config: %v", err)
+	}
+
+	cfg, err := LoadConfig(cfgPath)
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+
+	if cfg.Timeout != 5*time.Minute {
+		t.Errorf("Timeout = %s", cfg.Timeout)
+	}
+	if got := cfg.ProviderTimeout("kubernetes"); got != 90*time.Second {
+		t.Errorf("ProviderTimeout(kubernetes) = %s", got)
+	}
+	if got := cfg.ProviderTimeout("aws"); got != 0 {
+		t.Errorf("ProviderTimeout(aws) = %s", got)
+	}
+}
+
+func TestDecodeProviders_InvalidTimeout(t *testing.T) {
+	cfg := &Config{
+		RawProviders: map[string]map[string]interface{}{
+			"test": {"timeout": "soon"},
+		},
+	}
+
+	if err := cfg.decodeProviders(); err == nil {
+		t.Fatal("expected error for invalid timeout, got nil")
+	}
+}
+
+func TestLoadConfig_CacheTTL(t *testing.T) {
+	cfgPath := filepath.Join(t.TempDir(), "config.yaml")
+	yamlContent := `cache:
+  dir: /tmp/cfgctl-cache
+  ttl: 2h
+providers:
+  kubernetes:
+    cache_ttl: 15m
+`
+	if err := os.WriteFile(cfgPath, []byte(yamlContent), 0o600); err != nil {
+		t.Fatalf("failed to write test config: %v", err)
+	}
+
+	cfg, err := LoadConfig(cfgPath)
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+
+	if cfg.Cache.Dir != "/tmp/cfgctl-cache" {
+		t.Errorf("cache dir = %q", cfg.Cache.Dir)
+	}
+	if got := cfg.ProviderCacheTTL("kubernetes"); got != 15*time.Minute {
+		t.Errorf("kubernetes cache ttl = %s, want 15m", got)
+	}
+	if got := cfg.ProviderCacheTTL("aws"); got != 2*time.Hour {
+		t.Errorf("aws cache ttl = %s, want 2h", got)
+	}
+	if got := NewConfig().ProviderCacheTTL("aws"); got != DefaultCacheTTL {
+		t.Errorf("default cache ttl = %s, want %s", got, DefaultCacheTTL)
+	}
+}
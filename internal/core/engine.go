@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"log/slog"
 	"strings"
+	"time"
 )
 
 // Engine coordinates provider lifecycle: validation, backup, generation, error handling, and rollback.
@@ -47,16 +48,39 @@ type ExecuteOptions struct {
 
 	// Verbose enables detailed logging.
 	Verbose bool
+
+	// KeepGoing runs every provider even when one fails, returning the
+	// collected failures as a *RunError.
+	KeepGoing bool
+
+	// Progress receives progress events from providers. It may be nil.
+	Progress ProgressReporter
+
+	// Cache holds discovery results between runs. It may be nil, which disables caching.
+	Cache *Cache
+
+	// awsProfiles is shared by the providers of a single Execute call.
+	awsProfiles *AWSProfileInventory
 }
 
 // Execute runs the generation process for the specified providers.
 // Returns a map of provider names to their results, or an error if the process fails.
-//
-//nolint:gocognit // Execution flow coordinates multiple phases and providers.
+// When opts.KeepGoing is set, every provider is run and failures are returned as a *RunError.
+// Cancelling ctx, or exceeding the configured run timeout, stops the run even in keep-going mode;
+// the RunError then also carries the interruption.
 func (e *Engine) Execute(ctx context.Context, opts *ExecuteOptions) (map[string]*Result, error) {
 	if opts == nil {
 		opts = &ExecuteOptions{}
 	}
+	runOpts := *opts
+	runOpts.awsProfiles = &AWSProfileInventory{}
+	opts = &runOpts
+
+	if e.config.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, e.config.Timeout)
+		defer cancel()
+	}
 
 	// Determine which providers to run
 	providers, err := e.resolveProviders(opts.Providers)
@@ -71,91 +95,223 @@ func (e *Engine) Execute(ctx context.Context, opts *ExecuteOptions) (map[string]
 	e.logger.Info("starting generation", "provider_count", len(providers))
 
 	results := make(map[string]*Result)
-	backups := make(map[string]string)
+	runErr := &RunError{}
+
+	// interrupted keeps the failures collected so far when the run stops early.
+	interrupted := func(err error) error {
+		if len(runErr.Errors) == 0 {
+			return err
+		}
+		runErr.Interrupted = err
+		return runErr
+	}
 
 	// Execute each provider
 	for _, provider := range providers {
 		providerName := provider.Name()
+		if err := ctx.Err(); err != nil {
+			return results, interrupted(fmt.Errorf("generation stopped before provider %q: %w", providerName, err))
+		}
 		e.logger.Info("processing provider", "provider", providerName)
 
-		missingTools := e.providerMissingTools(providerName)
-		if len(missingTools) > 0 {
-			if !e.providerEnabled(providerName) {
-				result := &Result{
-					Provider: providerName,
-					Warnings: []string{providerName + " provider is disabled"},
-				}
-				results[providerName] = result
-				continue
-			}
-			result := &Result{
-				Provider: providerName,
-				Warnings: []string{fmt.Sprintf("%s provider disabled: missing tools %s", providerName, strings.Join(missingTools, ", "))},
-			}
+		if result := e.skipResult(providerName); result != nil {
 			results[providerName] = result
 			continue
 		}
 
-		// Phase 1: Validate
-		if err := e.validateProvider(ctx, provider); err != nil {
-			return results, fmt.Errorf("validation failed for provider %q: %w", providerName, err)
-		}
-
-		// Phase 2: Backup (unless disabled or dry-run)
-		var backupPath string
-		shouldBackup := !opts.NoBackup && !opts.DryRun
-		if shouldBackup {
-			if decider, ok := provider.(BackupDecider); ok {
-				backupNeeded, err := decider.NeedsBackup(&GenerateOptions{
-					DryRun:  opts.DryRun,
-					Force:   opts.Force,
-					Verbose: opts.Verbose,
-					Config:  e.config.GetProviderConfig(providerName),
-				})
-				if err != nil {
-					return results, fmt.Errorf("backup check failed for provider %q: %w", providerName, err)
-				}
-				shouldBackup = backupNeeded
-			}
-		}
-		if shouldBackup {
-			backupPath, err = e.backupProvider(ctx, provider)
-			if err != nil {
-				e.logger.Warn("backup failed", "provider", providerName, "error", err)
-			} else if backupPath != "" {
-				backups[providerName] = backupPath
-				e.logger.Info("backup created", "provider", providerName, "path", backupPath)
-			}
-		}
-
-		// Phase 3: Generate
-		result, err := e.generateProvider(ctx, provider, opts)
+		runErr.Attempted++
+		result, err := e.runProvider(ctx, provider, opts)
+		ReportProgress(opts.Progress, ProgressEvent{Provider: providerName, Kind: ProgressDone})
 		if err != nil {
-			e.logger.Error("generation failed", "provider", providerName, "error", err)
-
-			// Attempt rollback if we have a backup
-			if backupPath != "" {
-				e.logger.Info("attempting rollback", "provider", providerName)
-				if rollbackErr := e.backupManager.Restore(backupPath); rollbackErr != nil {
-					e.logger.Error("rollback failed", "provider", providerName, "error", rollbackErr)
-				} else {
-					e.logger.Info("rollback successful", "provider", providerName)
+			if !opts.KeepGoing {
+				return results, err
+			}
+			if ctxErr := ctx.Err(); ctxErr != nil {
+				if len(runErr.Errors) == 0 {
+					return results, err
 				}
+				runErr.Errors = append(runErr.Errors, err)
+				return results, interrupted(fmt.Errorf("generation stopped after provider %q: %w", providerName, ctxErr))
 			}
-
-			return results, fmt.Errorf("generation failed for provider %q: %w", providerName, err)
+			e.logger.Error("provider failed, continuing", "provider", providerName, "error", err)
+			runErr.Errors = append(runErr.Errors, err)
+			continue
 		}
 
-		result.BackupPath = backupPath
 		results[providerName] = result
-
 		e.logger.Info("provider completed", "provider", providerName, "files_created", len(result.FilesCreated))
 	}
 
 	e.logger.Info("generation complete", "provider_count", len(results))
+	if len(runErr.Errors) > 0 {
+		return results, runErr
+	}
 	return results, nil
 }
 
+// skipResult returns a warning result when a provider cannot run because its
+// required tools are missing, or nil when the provider should run.
+func (e *Engine) skipResult(providerName string) *Result {
+	missingTools := e.providerMissingTools(providerName)
+	if len(missingTools) == 0 {
+		return nil
+	}
+
+	if !e.providerEnabled(providerName) {
+		return &Result{
+			Provider: providerName,
+			Warnings: []string{providerName + " provider is disabled"},
+		}
+	}
+
+	return &Result{
+		Provider: providerName,
+		Warnings: []string{fmt.Sprintf("%s provider disabled: missing tools %s", providerName, strings.Join(missingTools, ", "))},
+	}
+}
+
+// runProvider executes the validate, backup, and generate phases for a single provider,
+// bounded by the provider's configured timeout. Configured hooks run before validation,
+// before generation, and after successful generation.
+func (e *Engine) runProvider(ctx context.Context, provider Provider, opts *ExecuteOptions) (*Result, *ProviderError) {
+	providerName := provider.Name()
+
+	timeout := e.config.ProviderTimeout(providerName)
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	newError := func(phase string, err error) *ProviderError {
+		if timeout > 0 && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			err = fmt.Errorf("timed out after %s: %w", timeout, err)
+		}
+		return &ProviderError{Provider: providerName, Phase: phase, Err: err}
+	}
+
+	timePhase := func(phase string, start time.Time) PhaseTiming {
+		duration := time.Since(start)
+		e.logger.Debug("phase complete", "provider", providerName, "phase", phase, "duration", duration)
+		return PhaseTiming{Phase: phase, Duration: duration}
+	}
+
+	hooks := hookRun{provider: providerName, dryRun: opts.DryRun}
+	runHooks := func(phase string, result *Result) ([]string, *ProviderError) {
+		hooks.phase, hooks.result = phase, result
+		warnings, err := e.runHooks(ctx, hooks)
+		if err != nil {
+			return nil, newError(phase+" hook", err)
+		}
+		return warnings, nil
+	}
+
+	hookWarnings, hookErr := runHooks(HookPreValidate, nil)
+	if hookErr != nil {
+		return nil, hookErr
+	}
+
+	// Phase 1: Validate
+	start := time.Now()
+	if err := e.validateProvider(ctx, provider); err != nil {
+		return nil, newError(PhaseValidation, err)
+	}
+	timings := []PhaseTiming{timePhase(TimingValidate, start)}
+
+	// Phase 2: Backup (unless disabled or dry-run)
+	start = time.Now()
+	backupPath, err := e.backupPhase(ctx, provider, opts)
+	if err != nil {
+		return nil, newError(PhaseBackupCheck, err)
+	}
+	timings = append(timings, timePhase(TimingBackup, start))
+
+	if err := ctx.Err(); err != nil {
+		return nil, newError(PhaseGeneration, err)
+	}
+
+	warnings, hookErr := runHooks(HookPreGenerate, nil)
+	if hookErr != nil {
+		return nil, hookErr
+	}
+	hookWarnings = append(hookWarnings, warnings...)
+
+	// Phase 3: Generate
+	start = time.Now()
+	result, err := e.generateProvider(ctx, provider, opts)
+	if err != nil {
+		e.logger.Error("generation failed", "provider", providerName, "error", err)
+		e.rollback(providerName, backupPath)
+		return nil, newError(PhaseGeneration, err)
+	}
+	generateTiming := timePhase(TimingGenerate, start)
+
+	// Provider-recorded phases are nested inside the generate phase.
+	result.BackupPath = backupPath
+	result.Timings = append(append(timings, result.Timings...), generateTiming)
+	result.Warnings = append(result.Warnings, hookWarnings...)
+
+	warnings, hookErr = runHooks(HookPostGenerate, result)
+	if hookErr != nil {
+		e.rollback(providerName, backupPath)
+		return nil, hookErr
+	}
+	result.Warnings = append(result.Warnings, warnings...)
+
+	return result, nil
+}
+
+// rollback restores a provider's backup after a failed generation or
+// post_generate hook, if one was made.
+func (e *Engine) rollback(providerName, backupPath string) {
+	if backupPath == "" {
+		return
+	}
+
+	e.logger.Info("attempting rollback", "provider", providerName)
+	if err := e.backupManager.Restore(backupPath); err != nil {
+		e.logger.Error("rollback failed", "provider", providerName, "error", err)
+		return
+	}
+	e.logger.Info("rollback successful", "provider", providerName)
+}
+
+// backupPhase creates a backup before generation unless backups are disabled,
+// the run is a dry run, or the provider reports that no backup is needed.
+// Backup failures are logged and do not stop generation.
+func (e *Engine) backupPhase(ctx context.Context, provider Provider, opts *ExecuteOptions) (string, error) {
+	providerName := provider.Name()
+
+	if opts.NoBackup || opts.DryRun {
+		return "", nil
+	}
+
+	if decider, ok := provider.(BackupDecider); ok {
+		backupNeeded, err := decider.NeedsBackup(&GenerateOptions{
+			DryRun:  opts.DryRun,
+			Force:   opts.Force,
+			Verbose: opts.Verbose,
+			Config:  e.config.GetProviderConfig(providerName),
+		})
+		if err != nil {
+			return "", err
+		}
+		if !backupNeeded {
+			return "", nil
+		}
+	}
+
+	backupPath, err := e.backupProvider(ctx, provider)
+	if err != nil {
+		e.logger.Warn("backup failed", "provider", providerName, "error", err)
+		return "", nil
+	}
+	if backupPath != "" {
+		e.logger.Info("backup created", "provider", providerName, "path", backupPath)
+	}
+	return backupPath, nil
+}
+
 // ValidateAll validates all providers without generating anything.
 func (e *Engine) ValidateAll(ctx context.Context) error {
 	providers := e.registry.GetAll()
@@ -286,6 +442,11 @@ func (e *Engine) generateProvider(ctx context.Context, provider Provider, opts *
 		Verbose: opts.Verbose,
 		Config:  providerCfg,
 	}
+	if opts.Progress != nil {
+		genOpts.Progress = providerProgress{provider: provider.Name(), reporter: opts.Progress}
+	}
+	genOpts.Cache = opts.Cache.ForProvider(provider.Name(), e.config.ProviderCacheTTL(provider.Name()))
+	genOpts.AWSProfiles = opts.awsProfiles
 
 	result, err := provider.Generate(ctx, genOpts)
 	if err != nil {
@@ -293,10 +454,15 @@ func (e *Engine) generateProvider(ctx context.Context, provider Provider, opts *
 	}
 
 	result.Provider = provider.Name()
+	genOpts.Cache.recordMetadata(result)
 	return result, nil
 }
 
 func (e *Engine) providerMissingTools(providerName string) []string {
+	if toolsCfg, ok := e.config.GetProviderConfig(providerName).(ToolsProviderConfig); ok {
+		return MissingExecutables(toolsCfg.RequiredTools()...)
+	}
+
 	switch providerName {
 	case "aws":
 		return MissingExecutables("aws")
@@ -2,9 +2,12 @@ package core
 
 import (
 	"context"
+	"errors"
 	"io"
 	"log/slog"
+	"reflect"
 	"testing"
+	"time"
 )
 
 const (
@@ -81,6 +84,18 @@ func (c testEnabledConfig) Validate() error {
 	return nil
 }
 
+type testToolsConfig struct {
+	tools []string
+}
+
+func (c testToolsConfig) RequiredTools() []string {
+	return c.tools
+}
+
+func (c testToolsConfig) Validate() error {
+	return nil
+}
+
 func newTestLogger() *slog.Logger {
 	return slog.New(slog.NewTextHandler(io.Discard, &slog.HandlerOptions{}))
 }
@@ -127,7 +142,7 @@ func TestEngineExecute_MissingToolsSkipsProvider(t *testing.T) {
 	config := NewConfig()
 	engine := NewEngine(registry, backupManager, config, newTestLogger())
 
-	provider := &engineTestProvider{name: "aws"}
+	provider := &engineTestProvider{name: "granted"}
 	if err := registry.Register(provider); err != nil {
 		t.Fatalf("failed to register provider: %v", err)
 	}
@@ -139,14 +154,14 @@ func TestEngineExecute_MissingToolsSkipsProvider(t *testing.T) {
 	if provider.generateCalled {
 		t.Error("expected generate to be skipped")
 	}
-	result := results["aws"]
+	result := results["granted"]
 	if result == nil {
 		t.Fatal("expected result for provider")
 	}
 	if len(result.Warnings) != 1 {
 		t.Fatalf("expected warning, got %v", result.Warnings)
 	}
-	if result.Warnings[0] != "aws provider disabled: missing tools aws" {
+	if result.Warnings[0] != "granted provider disabled: missing tools granted" {
 		t.Fatalf("expected missing tools warning, got %v", result.Warnings)
 	}
 }
@@ -162,10 +177,10 @@ func TestEngineExecute_MissingToolsHonorsDisabledConfig(t *testing.T) {
 	registry := NewRegistry()
 	backupManager := NewBackupManager("")
 	config := NewConfig()
-	config.SetProviderConfig("aws", testEnabledConfig{enabled: false})
+	config.SetProviderConfig("granted", testEnabledConfig{enabled: false})
 	engine := NewEngine(registry, backupManager, config, newTestLogger())
 
-	provider := &engineTestProvider{name: "aws"}
+	provider := &engineTestProvider{name: "granted"}
 	if err := registry.Register(provider); err != nil {
 		t.Fatalf("failed to register provider: %v", err)
 	}
@@ -177,14 +192,14 @@ func TestEngineExecute_MissingToolsHonorsDisabledConfig(t *testing.T) {
 	if provider.generateCalled {
 		t.Error("expected generate to be skipped")
 	}
-	result := results["aws"]
+	result := results["granted"]
 	if result == nil {
 		t.Fatal("expected result for provider")
 	}
 	if len(result.Warnings) != 1 {
 		t.Fatalf("expected warning, got %v", result.Warnings)
 	}
-	if result.Warnings[0] != "aws provider is disabled" {
+	if result.Warnings[0] != "granted provider is disabled" {
 		t.Fatalf("expected disabled warning, got %v", result.Warnings)
 	}
 }
@@ -293,6 +308,13 @@ func TestEngineExecute_BackupDeciderSkipsBackup(t *testing.T) {
 }
 
 func TestEngineExecute_BackupDeciderError(t *testing.T) {
+	findExecutableHook = func(name string) (string, bool) {
+		return "/usr/bin/" + name, true
+	}
+	t.Cleanup(func() {
+		findExecutableHook = nil
+	})
+
 	registry := NewRegistry()
 	backupManager := NewBackupManager("")
 	config := NewConfig()
@@ -337,6 +359,297 @@ func TestEngineExecute_GenerateError(t *testing.T) {
 	}
 }
 
+func TestEngineExecute_ErrorIsProviderError(t *testing.T) {
+	registry := NewRegistry()
+	backupManager := NewBackupManager("")
+	config := NewConfig()
+	engine := NewEngine(registry, backupManager, config, newTestLogger())
+
+	provider := &engineTestProvider{name: "bad", validateErr: ErrInvalidProviderName}
+	if err := registry.Register(provider); err != nil {
+		t.Fatalf("failed to register provider: %v", err)
+	}
+
+	_, err := engine.Execute(context.Background(), &ExecuteOptions{})
+	var providerErr *ProviderError
+	if !errors.As(err, &providerErr) {
+		t.Fatalf("expected ProviderError, got %T", err)
+	}
+	if providerErr.Provider != "bad" || providerErr.Phase != PhaseValidation {
+		t.Fatalf("unexpected provider error: %+v", providerErr)
+	}
+	if !errors.Is(err, ErrInvalidProviderName) {
+		t.Fatal("expected wrapped provider error")
+	}
+	if err.Error() != `validation failed for provider "bad": provider name cannot be empty` {
+		t.Fatalf("unexpected message: %q", err.Error())
+	}
+}
+
+func TestEngineExecute_KeepGoingPartialFailure(t *testing.T) {
+	registry := NewRegistry()
+	backupManager := NewBackupManager("")
+	config := NewConfig()
+	engine := NewEngine(registry, backupManager, config, newTestLogger())
+
+	failing := &engineTestProvider{name: "a", generateErr: ErrInvalidProviderName}
+	healthy := &engineTestProvider{name: "b"}
+	for _, provider := range []*engineTestProvider{failing, healthy} {
+		if err := registry.Register(provider); err != nil {
+			t.Fatalf("failed to register provider: %v", err)
+		}
+	}
+
+	results, err := engine.Execute(context.Background(), &ExecuteOptions{
+		Providers: []string{"a", "b"},
+		KeepGoing: true,
+	})
+	if !healthy.generateCalled {
+		t.Fatal("expected healthy provider to run after failure")
+	}
+	if results["b"] == nil {
+		t.Fatal("expected result for healthy provider")
+	}
+	if results["a"] != nil {
+		t.Fatal("expected no result for failed provider")
+	}
+
+	var runErr *RunError
+	if !errors.As(err, &runErr) {
+		t.Fatalf("expected RunError, got %T", err)
+	}
+	if runErr.Attempted != 2 || len(runErr.Errors) != 1 {
+		t.Fatalf("unexpected run error: %+v", runErr)
+	}
+	if runErr.Failed("a") == nil || runErr.Failed("b") != nil {
+		t.Fatal("expected only provider a to be recorded as failed")
+	}
+	if !errors.Is(err, ErrPartialFailure) || errors.Is(err, ErrTotalFailure) {
+		t.Fatal("expected partial failure")
+	}
+	if !errors.Is(err, ErrInvalidProviderName) {
+		t.Fatal("expected provider errors to be reachable")
+	}
+}
+
+func TestEngineExecute_KeepGoingTotalFailure(t *testing.T) {
+	registry := NewRegistry()
+	backupManager := NewBackupManager("")
+	config := NewConfig()
+	engine := NewEngine(registry, backupManager, config, newTestLogger())
+
+	providerA := &engineTestProvider{name: "a", validateErr: ErrInvalidProviderName}
+	providerB := &engineTestProvider{name: "b", generateErr: ErrInvalidProviderName}
+	for _, provider := range []*engineTestProvider{providerA, providerB} {
+		if err := registry.Register(provider); err != nil {
+			t.Fatalf("failed to register provider: %v", err)
+		}
+	}
+
+	_, err := engine.Execute(context.Background(), &ExecuteOptions{KeepGoing: true})
+	if !errors.Is(err, ErrTotalFailure) || errors.Is(err, ErrPartialFailure) {
+		t.Fatalf("expected total failure, got %v", err)
+	}
+	if providerA.generateCalled {
+		t.Fatal("expected generate to be skipped after validation failure")
+	}
+}
+
+// blockingProvider waits in Generate until its context is done.
+type blockingProvider struct {
+	engineTestProvider
+}
+
+func (p *blockingProvider) Generate(ctx context.Context, _ *GenerateOptions) (*Result, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func TestEngineExecute_RecordsTimings(t *testing.T) {
+	registry := NewRegistry()
+	engine := NewEngine(registry, NewBackupManager(""), NewConfig(), newTestLogger())
+
+	result := &Result{}
+	result.RecordPhase(TimingDiscover, time.Now())
+	provider := &engineTestProvider{name: "timed", result: result}
+	if err := registry.Register(provider); err != nil {
+		t.Fatalf("failed to register provider: %v", err)
+	}
+
+	results, err := engine.Execute(context.Background(), &ExecuteOptions{NoBackup: true})
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+
+	var phases []string
+	for _, timing := range results["timed"].Timings {
+		phases = append(phases, timing.Phase)
+	}
+	want := []string{TimingValidate, TimingBackup, TimingDiscover, TimingGenerate}
+	if len(phases) != len(want) {
+		t.Fatalf("phases = %v, want %v", phases, want)
+	}
+	for i := range want {
+		if phases[i] != want[i] {
+			t.Fatalf("phases = %v, want %v", phases, want)
+		}
+	}
+}
+
+func TestEngineExecute_ProviderTimeout(t *testing.T) {
+	registry := NewRegistry()
+	config := NewConfig()
+	config.ProviderSettings["slow"] = ProviderSettings{Timeout: 10 * time.Millisecond}
+	engine := NewEngine(registry, NewBackupManager(""), config, newTestLogger())
+
+	slow := &blockingProvider{engineTestProvider{name: "slow"}}
+	fast := &engineTestProvider{name: "fast"}
+	for _, provider := range []Provider{slow, fast} {
+		if err := registry.Register(provider); err != nil {
+			t.Fatalf("failed to register provider: %v", err)
+		}
+	}
+
+	results, err := engine.Execute(context.Background(), &ExecuteOptions{KeepGoing: true})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline exceeded, got %v", err)
+	}
+	if !errors.Is(err, ErrPartialFailure) {
+		t.Fatalf("expected partial failure, got %v", err)
+	}
+	if results["fast"] == nil {
+		t.Fatal("expected fast provider to run after the slow provider timed out")
+	}
+}
+
+func TestEngineExecute_CancelStopsKeepGoing(t *testing.T) {
+	registry := NewRegistry()
+	engine := NewEngine(registry, NewBackupManager(""), NewConfig(), newTestLogger())
+
+	first := &engineTestProvider{name: "a"}
+	second := &engineTestProvider{name: "b"}
+	for _, provider := range []Provider{first, second} {
+		if err := registry.Register(provider); err != nil {
+			t.Fatalf("failed to register provider: %v", err)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := engine.Execute(ctx, &ExecuteOptions{KeepGoing: true})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context canceled, got %v", err)
+	}
+	if first.validateCalled || second.validateCalled {
+		t.Fatal("expected no provider to run after cancellation")
+	}
+}
+
+// cancellingProvider cancels the run from Generate.
+type cancellingProvider struct {
+	engineTestProvider
+	cancel context.CancelFunc
+}
+
+func (p *cancellingProvider) Generate(ctx context.Context, _ *GenerateOptions) (*Result, error) {
+	p.cancel()
+	return nil, ctx.Err()
+}
+
+func TestEngineExecute_CancelKeepsCollectedErrors(t *testing.T) {
+	registry := NewRegistry()
+	engine := NewEngine(registry, NewBackupManager(""), NewConfig(), newTestLogger())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	failing := &engineTestProvider{name: "a", generateErr: ErrInvalidProviderName}
+	cancelling := &cancellingProvider{engineTestProvider: engineTestProvider{name: "b"}, cancel: cancel}
+	last := &engineTestProvider{name: "c"}
+	for _, provider := range []Provider{failing, cancelling, last} {
+		if err := registry.Register(provider); err != nil {
+			t.Fatalf("failed to register provider: %v", err)
+		}
+	}
+
+	_, err := engine.Execute(ctx, &ExecuteOptions{Providers: []string{"a", "b", "c"}, KeepGoing: true, NoBackup: true})
+	var runErr *RunError
+	if !errors.As(err, &runErr) {
+		t.Fatalf("expected RunError, got %T: %v", err, err)
+	}
+	if runErr.Interrupted == nil || !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected interruption to be recorded, got %v", err)
+	}
+	if runErr.Failed("a") == nil || runErr.Failed("b") == nil {
+		t.Fatalf("expected collected errors to be kept, got %+v", runErr.Errors)
+	}
+	if !errors.Is(err, ErrInvalidProviderName) {
+		t.Fatal("expected provider errors to be reachable")
+	}
+	if last.validateCalled {
+		t.Fatal("expected no provider to run after cancellation")
+	}
+}
+
+func TestEngineExecute_RunTimeout(t *testing.T) {
+	registry := NewRegistry()
+	config := NewConfig()
+	config.Timeout = 10 * time.Millisecond
+	engine := NewEngine(registry, NewBackupManager(""), config, newTestLogger())
+
+	slow := &blockingProvider{engineTestProvider{name: "slow"}}
+	if err := registry.Register(slow); err != nil {
+		t.Fatalf("failed to register provider: %v", err)
+	}
+
+	_, err := engine.Execute(context.Background(), &ExecuteOptions{KeepGoing: true})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline exceeded, got %v", err)
+	}
+}
+
+// progressProvider reports a single step during Generate.
+type progressProvider struct {
+	engineTestProvider
+}
+
+func (p *progressProvider) Generate(_ context.Context, opts *GenerateOptions) (*Result, error) {
+	ReportProgress(opts.Progress, ProgressEvent{Kind: ProgressStep, Message: "working"})
+	return &Result{}, nil
+}
+
+func TestEngineExecute_ReportsProgress(t *testing.T) {
+	registry := NewRegistry()
+	engine := NewEngine(registry, NewBackupManager(""), NewConfig(), newTestLogger())
+
+	if err := registry.Register(&progressProvider{engineTestProvider{name: "busy"}}); err != nil {
+		t.Fatalf("failed to register provider: %v", err)
+	}
+
+	var events []ProgressEvent
+	progress := ProgressFunc(func(event ProgressEvent) {
+		events = append(events, event)
+	})
+
+	if _, err := engine.Execute(context.Background(), &ExecuteOptions{Progress: progress}); err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+
+	want := []ProgressEvent{
+		{Provider: "busy", Kind: ProgressStep, Message: "working"},
+		{Provider: "busy", Kind: ProgressDone},
+	}
+	if len(events) != len(want) {
+		t.Fatalf("events = %+v, want %+v", events, want)
+	}
+	for i := range want {
+		if events[i] != want[i] {
+			t.Fatalf("events = %+v, want %+v", events, want)
+		}
+	}
+}
+
 func TestEngineValidateAll(t *testing.T) {
 	registry := NewRegistry()
 	backupManager := NewBackupManager("")
@@ -489,6 +802,27 @@ func TestProviderMissingTools_AllMissing(t *testing.T) {
 	}
 }
 
+func TestProviderMissingTools_ConfigOverride(t *testing.T) {
+	findExecutableHook = func(string) (string, bool) {
+		return "", false
+	}
+	t.Cleanup(func() {
+		findExecutableHook = nil
+	})
+
+	config := NewConfig()
+	config.SetProviderConfig("aws", testToolsConfig{})
+	config.SetProviderConfig("granted", testToolsConfig{tools: []string{"granted", "assume"}})
+	engine := NewEngine(NewRegistry(), NewBackupManager(""), config, newTestLogger())
+
+	if missing := engine.providerMissingTools("aws"); len(missing) != 0 {
+		t.Fatalf("aws missing tools = %v, want none", missing)
+	}
+	if missing := engine.providerMissingTools("granted"); !reflect.DeepEqual(missing, []string{"granted", "assume"}) {
+		t.Fatalf("granted missing tools = %v", missing)
+	}
+}
+
 func TestReorderProvidersForDependencies_AWSFirst(t *testing.T) {
 	providers := []Provider{
 		&engineTestProvider{name: testProviderKubernetes},
@@ -0,0 +1,111 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Engine phases reported in ProviderError.
+const (
+	PhaseValidation  = "validation"
+	PhaseBackupCheck = "backup check"
+	PhaseGeneration  = "generation"
+)
+
+var (
+	// ErrPartialFailure matches a RunError where some, but not all, providers failed.
+	ErrPartialFailure = errors.New("some providers failed")
+
+	// ErrTotalFailure matches a RunError where every attempted provider failed.
+	ErrTotalFailure = errors.New("all providers failed")
+)
+
+// ProviderError describes a failure of a single provider during an engine phase.
+type ProviderError struct {
+	// Provider is the name of the provider that failed.
+	Provider string
+
+	// Phase is the engine phase that failed (validation, backup check, generation).
+	Phase string
+
+	// Err is the underlying error returned by the provider.
+	Err error
+}
+
+func (e *ProviderError) Error() string {
+	return fmt.Sprintf("%s failed for provider %q: %v", e.Phase, e.Provider, e.Err)
+}
+
+// Unwrap returns the underlying provider error.
+func (e *ProviderError) Unwrap() error {
+	return e.Err
+}
+
+// RunError aggregates provider failures collected when the engine keeps going
+// after an error. It matches ErrPartialFailure or ErrTotalFailure with errors.Is,
+// and each ProviderError is reachable with errors.As.
+type RunError struct {
+	// Errors lists the provider failures in execution order.
+	Errors []*ProviderError
+
+	// Attempted is the number of providers the engine tried to run.
+	// Providers skipped because they are disabled are not counted.
+	Attempted int
+
+	// Interrupted is the cancellation or run timeout that stopped the run
+	// before every provider was tried, or nil when the run finished.
+	Interrupted error
+}
+
+func (e *RunError) Error() string {
+	messages := make([]string, 0, len(e.Errors))
+	for _, providerErr := range e.Errors {
+		messages = append(messages, providerErr.Error())
+	}
+	message := fmt.Sprintf("%d of %d providers failed: %s", len(e.Errors), e.Attempted, strings.Join(messages, "; "))
+	if e.Interrupted != nil {
+		message = fmt.Sprintf("%v; %s", e.Interrupted, message)
+	}
+	return message
+}
+
+// Unwrap returns the individual provider errors, followed by the interruption
+// if the run was stopped.
+func (e *RunError) Unwrap() []error {
+	errs := make([]error, 0, len(e.Errors)+1)
+	for _, providerErr := range e.Errors {
+		errs = append(errs, providerErr)
+	}
+	if e.Interrupted != nil {
+		errs = append(errs, e.Interrupted)
+	}
+	return errs
+}
+
+// Is reports whether the run matches ErrPartialFailure or ErrTotalFailure.
+func (e *RunError) Is(target error) bool {
+	switch {
+	case errors.Is(target, ErrTotalFailure):
+		return !e.Partial()
+	case errors.Is(target, ErrPartialFailure):
+		return e.Partial()
+	default:
+		return false
+	}
+}
+
+// Partial reports whether at least one attempted provider succeeded.
+func (e *RunError) Partial() bool {
+	return len(e.Errors) < e.Attempted
+}
+
+// Failed returns the ProviderError for a provider, or nil if it did not fail.
+func (e *RunError) Failed(providerName string) *ProviderError {
+	for _, providerErr := range e.Errors {
+		if providerErr.Provider == providerName {
+			return providerErr
+		}
+	}
+	return nil
+}
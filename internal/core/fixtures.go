@@ -0,0 +1,162 @@
+package core
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	errFixtureAccountID = errors.New("account id cannot be empty")
+	errFixtureCluster   = errors.New("cluster needs a profile, region, name, and endpoint")
+)
+
+// DemoFixtures is fake discovery data loaded with --demo-fixtures. Providers
+// given fixtures discover from them instead of from AWS and the local shell
+// history, so demos, screenshots, and bug reproductions need no cloud access.
+type DemoFixtures struct {
+	// Accounts are the SSO accounts and the roles each one grants.
+	Accounts []AccountFixture `yaml:"accounts"`
+
+	// Clusters are the EKS clusters, found by scanning their profile and region.
+	Clusters []ClusterFixture `yaml:"clusters"`
+
+	// History holds shell history lines, in bash or zsh extended format.
+	History []string `yaml:"history"`
+}
+
+// AccountFixture is an SSO account.
+type AccountFixture struct {
+	ID    string   `yaml:"id"`
+	Name  string   `yaml:"name"`
+	Email string   `yaml:"email"`
+	Roles []string `yaml:"roles"`
+}
+
+// ClusterFixture is an EKS cluster.
+type ClusterFixture struct {
+	Profile  string `yaml:"profile"`
+	Region   string `yaml:"region"`
+	Name     string `yaml:"name"`
+	Endpoint string `yaml:"endpoint"`
+
+	// CertificateAuthority is the base64 encoded CA bundle, as DescribeCluster returns it.
+	CertificateAuthority string `yaml:"certificate_authority"`
+}
+
+// LoadDemoFixtures reads fixtures from a YAML file. Unknown keys are errors so
+// that a misspelled section does not silently produce an empty demo.
+func LoadDemoFixtures(path string) (*DemoFixtures, error) {
+	expanded, err := ExpandPath(path)
+	if err != nil {
+		return nil, err
+	}
+
+	// #nosec G304 -- fixture path is from user input
+	data, err := os.ReadFile(expanded)
+	if err != nil {
+		return nil, fmt.Errorf("read demo fixtures: %w", err)
+	}
+
+	fixtures := &DemoFixtures{}
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(fixtures); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("parse demo fixtures: %w", err)
+	}
+
+	if err := fixtures.validate(); err != nil {
+		return nil, fmt.Errorf("invalid demo fixtures: %w", err)
+	}
+	return fixtures, nil
+}
+
+func (f *DemoFixtures) validate() error {
+	for i, account := range f.Accounts {
+		if strings.TrimSpace(account.ID) == "" {
+			return fmt.Errorf("accounts[%d]: %w", i, errFixtureAccountID)
+		}
+	}
+	for i, cluster := range f.Clusters {
+		if cluster.Profile == "" || cluster.Region == "" || cluster.Name == "" || cluster.Endpoint == "" {
+			return fmt.Errorf("clusters[%d]: %w", i, errFixtureCluster)
+		}
+	}
+	return nil
+}
+
+// AccountProfiles returns a managed profile named "account/role" for each
+// role of each fixture account, the way the aws provider names them by
+// default. Accounts without a name are named by their ID.
+func (f *DemoFixtures) AccountProfiles() []AWSProfile {
+	if f == nil {
+		return nil
+	}
+	var profiles []AWSProfile
+	for _, account := range f.Accounts {
+		name := account.Name
+		if name == "" {
+			name = account.ID
+		}
+		for _, role := range account.Roles {
+			profiles = append(profiles, AWSProfile{
+				Name:        name + "/" + role,
+				AccountID:   account.ID,
+				AccountName: account.Name,
+				RoleName:    role,
+				Managed:     true,
+			})
+		}
+	}
+	return profiles
+}
+
+// ClusterProfiles returns the profiles the fixture clusters are found in,
+// sorted and without duplicates.
+func (f *DemoFixtures) ClusterProfiles() []string {
+	return f.uniqueClusterValues(func(cluster ClusterFixture) string { return cluster.Profile })
+}
+
+// ClusterRegions returns the regions of the fixture clusters, sorted and
+// without duplicates.
+func (f *DemoFixtures) ClusterRegions() []string {
+	return f.uniqueClusterValues(func(cluster ClusterFixture) string { return cluster.Region })
+}
+
+// ProfileClusterRegions returns the regions of the fixture clusters by
+// profile, each sorted and without duplicates.
+func (f *DemoFixtures) ProfileClusterRegions() map[string][]string {
+	regions := make(map[string][]string)
+	for _, profile := range f.ClusterProfiles() {
+		clusters := DemoFixtures{}
+		for _, cluster := range f.Clusters {
+			if cluster.Profile == profile {
+				clusters.Clusters = append(clusters.Clusters, cluster)
+			}
+		}
+		regions[profile] = clusters.ClusterRegions()
+	}
+	return regions
+}
+
+func (f *DemoFixtures) uniqueClusterValues(value func(ClusterFixture) string) []string {
+	if f == nil {
+		return nil
+	}
+	seen := make(map[string]bool, len(f.Clusters))
+	values := []string{}
+	for _, cluster := range f.Clusters {
+		if v := value(cluster); !seen[v] {
+			seen[v] = true
+			values = append(values, v)
+		}
+	}
+	sort.Strings(values)
+	return values
+}
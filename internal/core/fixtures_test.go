@@ -0,0 +1,118 @@
+package core
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const testDemoFixtures = `accounts:
+  - id: "111111111111"
+    name: prod
+    roles: [AdminAccess, ReadOnly]
+clusters:
+  - profile: prod/AdminAccess
+    region: us-west-2
+    name: web
+    endpoint: https://web.eks.example.com
+  - profile: prod/AdminAccess
+    region: eu-west-1
+    name: batch
+    endpoint: https://batch.eks.example.com
+  - profile: dev/AdminAccess
+    region: us-west-2
+    name: sandbox
+    endpoint: https://sandbox.eks.example.com
+history:
+  - ssh deploy@bastion.example.com
+`
+
+func writeFixtures(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "fixtures.yaml")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("write fixtures: %v", err)
+	}
+	return path
+}
+
+func TestLoadDemoFixtures(t *testing.T) {
+	fixtures, err := LoadDemoFixtures(writeFixtures(t, testDemoFixtures))
+	if err != nil {
+		t.Fatalf("LoadDemoFixtures: %v", err)
+	}
+
+	wantAccounts := []AccountFixture{{ID: "111111111111", Name: "prod", Roles: []string{"AdminAccess", "ReadOnly"}}}
+	if !reflect.DeepEqual(fixtures.Accounts, wantAccounts) {
+		t.Fatalf("accounts = %+v, want %+v", fixtures.Accounts, wantAccounts)
+	}
+	wantProfiles := []AWSProfile{
+		{Name: "prod/AdminAccess", AccountID: "111111111111", AccountName: "prod", RoleName: "AdminAccess", Managed: true},
+		{Name: "prod/ReadOnly", AccountID: "111111111111", AccountName: "prod", RoleName: "ReadOnly", Managed: true},
+	}
+	if got := fixtures.AccountProfiles(); !reflect.DeepEqual(got, wantProfiles) {
+		t.Fatalf("account profiles = %+v, want %+v", got, wantProfiles)
+	}
+	if got, want := fixtures.ClusterProfiles(), []string{"dev/AdminAccess", "prod/AdminAccess"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("cluster profiles = %v, want %v", got, want)
+	}
+	if got, want := fixtures.ClusterRegions(), []string{"eu-west-1", "us-west-2"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("cluster regions = %v, want %v", got, want)
+	}
+	wantRegions := map[string][]string{
+		"dev/AdminAccess":  {"us-west-2"},
+		"prod/AdminAccess": {"eu-west-1", "us-west-2"},
+	}
+	if got := fixtures.ProfileClusterRegions(); !reflect.DeepEqual(got, wantRegions) {
+		t.Fatalf("profile cluster regions = %v, want %v", got, wantRegions)
+	}
+	if len(fixtures.History) != 1 {
+		t.Fatalf("history = %v", fixtures.History)
+	}
+}
+
+func TestLoadDemoFixturesEmptyFile(t *testing.T) {
+	fixtures, err := LoadDemoFixtures(writeFixtures(t, ""))
+	if err != nil {
+		t.Fatalf("LoadDemoFixtures: %v", err)
+	}
+	if len(fixtures.Accounts) != 0 || len(fixtures.ClusterRegions()) != 0 {
+		t.Fatalf("fixtures = %+v, want empty", fixtures)
+	}
+}
+
+func TestLoadDemoFixturesErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		wantErr error
+		wantMsg string
+	}{
+		{name: "unknown key", content: "acounts: []\n", wantMsg: "field acounts not found"},
+		{name: "account id", content: "accounts:\n  - name: prod\n", wantErr: errFixtureAccountID},
+		{name: "cluster", content: "clusters:\n  - name: web\n", wantErr: errFixtureCluster},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := LoadDemoFixtures(writeFixtures(t, tt.content))
+			if err == nil {
+				t.Fatal("expected error")
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Fatalf("error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantMsg != "" && !strings.Contains(err.Error(), tt.wantMsg) {
+				t.Fatalf("error = %v, want %q", err, tt.wantMsg)
+			}
+		})
+	}
+}
+
+func TestLoadDemoFixturesMissingFile(t *testing.T) {
+	if _, err := LoadDemoFixtures(filepath.Join(t.TempDir(), "missing.yaml")); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("error = %v, want not exist", err)
+	}
+}
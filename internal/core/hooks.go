@@ -0,0 +1,215 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Hook phases, in the order the engine runs them for each provider.
+const (
+	HookPreValidate  = "pre_validate"
+	HookPreGenerate  = "pre_generate"
+	HookPostGenerate = "post_generate"
+)
+
+// maxHookOutput limits how much hook output is included in errors.
+const maxHookOutput = 2048
+
+// hookShell is the command used to run hooks. Override in tests.
+var hookShell = []string{"sh", "-c"}
+
+// Hook is a user command run around a provider phase.
+type Hook struct {
+	// Command is run with "sh -c".
+	Command string `yaml:"command"`
+
+	// ContinueOnError records a failure as a warning instead of failing the provider.
+	ContinueOnError bool `yaml:"continue_on_error"`
+
+	// RunInDryRun runs the hook during --dry-run. Hooks are skipped in dry-run by default.
+	RunInDryRun bool `yaml:"run_in_dry_run"`
+
+	// Timeout bounds the hook. Zero means the hook is bounded only by the provider.
+	Timeout time.Duration `yaml:"timeout"`
+}
+
+// UnmarshalYAML accepts either a command string or a full hook mapping.
+func (h *Hook) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		h.Command = node.Value
+		return nil
+	}
+
+	type rawHook Hook
+	var raw rawHook
+	if err := node.Decode(&raw); err != nil {
+		return err
+	}
+	*h = Hook(raw)
+	return nil
+}
+
+// HooksConfig lists hooks for each phase.
+type HooksConfig struct {
+	PreValidate  []Hook `yaml:"pre_validate"`
+	PreGenerate  []Hook `yaml:"pre_generate"`
+	PostGenerate []Hook `yaml:"post_generate"`
+}
+
+// forPhase returns the hooks configured for phase.
+func (h HooksConfig) forPhase(phase string) []Hook {
+	switch phase {
+	case HookPreValidate:
+		return h.PreValidate
+	case HookPreGenerate:
+		return h.PreGenerate
+	case HookPostGenerate:
+		return h.PostGenerate
+	default:
+		return nil
+	}
+}
+
+// validate checks that every hook has a command.
+func (h HooksConfig) validate() error {
+	for _, phase := range []string{HookPreValidate, HookPreGenerate, HookPostGenerate} {
+		for i, hook := range h.forPhase(phase) {
+			if strings.TrimSpace(hook.Command) == "" {
+				return fmt.Errorf("%s hook %d: command cannot be empty", phase, i)
+			}
+			if hook.Timeout < 0 {
+				return fmt.Errorf("%s hook %d: timeout must not be negative", phase, i)
+			}
+		}
+	}
+	return nil
+}
+
+// hookRun describes a single invocation of the hooks for a provider phase.
+type hookRun struct {
+	provider string
+	phase    string
+	dryRun   bool
+	result   *Result
+}
+
+// runHooks runs global hooks and then the provider's own hooks for a phase.
+// Failures of hooks marked continue_on_error are returned as warnings.
+func (e *Engine) runHooks(ctx context.Context, run hookRun) ([]string, error) {
+	hooks := append([]Hook{}, e.config.Hooks.forPhase(run.phase)...)
+	hooks = append(hooks, e.config.ProviderHooks(run.provider).forPhase(run.phase)...)
+
+	var warnings []string
+	for _, hook := range hooks {
+		if run.dryRun && !hook.RunInDryRun {
+			e.logger.Debug("skipping hook in dry-run", "provider", run.provider, "hook", run.phase, "command", hook.Command)
+			continue
+		}
+
+		err := e.runHook(ctx, hook, run)
+		if err == nil {
+			continue
+		}
+		if !hook.ContinueOnError {
+			return warnings, err
+		}
+		e.logger.Warn("hook failed, continuing", "provider", run.provider, "hook", run.phase, "error", err)
+		warnings = append(warnings, err.Error())
+	}
+
+	return warnings, nil
+}
+
+func (e *Engine) runHook(ctx context.Context, hook Hook, run hookRun) error {
+	if hook.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, hook.Timeout)
+		defer cancel()
+	}
+
+	stdin, err := hookInput(run)
+	if err != nil {
+		return err
+	}
+
+	args := append(append([]string{}, hookShell[1:]...), hook.Command)
+	// #nosec G204 -- hook commands come from the user's own configuration
+	cmd := exec.CommandContext(ctx, hookShell[0], args...)
+	cmd.Stdin = bytes.NewReader(stdin)
+	cmd.Env = append(os.Environ(), hookEnv(run)...)
+
+	var output bytes.Buffer
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+
+	e.logger.Debug("running hook", "provider", run.provider, "hook", run.phase, "command", hook.Command)
+	start := time.Now()
+	runErr := cmd.Run()
+	e.logger.Debug("hook finished", "provider", run.provider, "hook", run.phase, "duration", time.Since(start), "output", output.String())
+
+	if runErr != nil {
+		return fmt.Errorf("%s hook %q failed: %w%s", run.phase, hook.Command, runErr, formatHookOutput(output.String()))
+	}
+	return nil
+}
+
+// hookInput returns the JSON written to a hook's stdin. Pre-generate hooks
+// receive a result containing only the provider name.
+func hookInput(run hookRun) ([]byte, error) {
+	result := run.result
+	if result == nil {
+		result = &Result{Provider: run.provider}
+	}
+
+	data, err := json.Marshal(result)
+	if err == nil {
+		return data, nil
+	}
+
+	// Provider metadata may hold values that cannot be encoded; drop it
+	// rather than failing the hook.
+	trimmed := *result
+	trimmed.Metadata = nil
+	data, trimmedErr := json.Marshal(&trimmed)
+	if trimmedErr != nil {
+		return nil, fmt.Errorf("encode hook input: %w", errors.Join(err, trimmedErr))
+	}
+	return data, nil
+}
+
+func hookEnv(run hookRun) []string {
+	env := []string{
+		"CFGCTL_PROVIDER=" + run.provider,
+		"CFGCTL_HOOK=" + run.phase,
+		"CFGCTL_DRY_RUN=" + strconv.FormatBool(run.dryRun),
+	}
+	if run.result != nil {
+		env = append(env,
+			"CFGCTL_FILES_CREATED="+strings.Join(run.result.FilesCreated, string(os.PathListSeparator)),
+			"CFGCTL_FILES_SKIPPED="+strings.Join(run.result.FilesSkipped, string(os.PathListSeparator)),
+			"CFGCTL_BACKUP_PATH="+run.result.BackupPath,
+		)
+	}
+	return env
+}
+
+func formatHookOutput(output string) string {
+	output = strings.TrimSpace(output)
+	if output == "" {
+		return ""
+	}
+	if len(output) > maxHookOutput {
+		output = "..." + output[len(output)-maxHookOutput:]
+	}
+	return ": " + output
+}
@@ -0,0 +1,170 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func newHookTestEngine(t *testing.T, config *Config, provider Provider) *Engine {
+	t.Helper()
+
+	registry := NewRegistry()
+	if err := registry.Register(provider); err != nil {
+		t.Fatalf("failed to register provider: %v", err)
+	}
+	return NewEngine(registry, NewBackupManager(""), config, newTestLogger())
+}
+
+func TestEngineExecute_PostGenerateHook(t *testing.T) {
+	dir := t.TempDir()
+	inputPath := filepath.Join(dir, "input.json")
+	envPath := filepath.Join(dir, "env")
+
+	config := NewConfig()
+	config.ProviderSettings["hooked"] = ProviderSettings{Hooks: HooksConfig{
+		PostGenerate: []Hook{{Command: "cat > " + inputPath + "; env | grep '^CFGCTL_' > " + envPath}},
+	}}
+	provider := &engineTestProvider{name: "hooked", result: &Result{FilesCreated: []string{"/tmp/a", "/tmp/b"}}}
+	engine := newHookTestEngine(t, config, provider)
+
+	if _, err := engine.Execute(context.Background(), &ExecuteOptions{NoBackup: true}); err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+
+	data, err := os.ReadFile(inputPath)
+	if err != nil {
+		t.Fatalf("read hook input: %v", err)
+	}
+	var result Result
+	if err := json.Unmarshal(data, &result); err != nil {
+		t.Fatalf("decode hook input %q: %v", data, err)
+	}
+	if result.Provider != "hooked" || len(result.FilesCreated) != 2 {
+		t.Fatalf("hook input = %+v", result)
+	}
+
+	env, err := os.ReadFile(envPath)
+	if err != nil {
+		t.Fatalf("read hook env: %v", err)
+	}
+	for _, want := range []string{
+		"CFGCTL_PROVIDER=hooked",
+		"CFGCTL_HOOK=post_generate",
+		"CFGCTL_DRY_RUN=false",
+		"CFGCTL_FILES_CREATED=/tmp/a" + string(os.PathListSeparator) + "/tmp/b",
+	} {
+		if !strings.Contains(string(env), want) {
+			t.Errorf("expected %q in hook env %q", want, env)
+		}
+	}
+}
+
+func TestEngineExecute_PreGenerateHookAborts(t *testing.T) {
+	config := NewConfig()
+	config.Hooks.PreGenerate = []Hook{{Command: "echo vpn down >&2; exit 3"}}
+	provider := &engineTestProvider{name: "aborted"}
+	engine := newHookTestEngine(t, config, provider)
+
+	_, err := engine.Execute(context.Background(), &ExecuteOptions{NoBackup: true})
+	var providerErr *ProviderError
+	if !errors.As(err, &providerErr) {
+		t.Fatalf("expected ProviderError, got %v", err)
+	}
+	if providerErr.Phase != "pre_generate hook" {
+		t.Fatalf("phase = %q", providerErr.Phase)
+	}
+	if !strings.Contains(err.Error(), "vpn down") {
+		t.Fatalf("expected hook output in error, got %v", err)
+	}
+	if provider.generateCalled {
+		t.Fatal("expected generate to be skipped after hook failure")
+	}
+}
+
+func TestEngineExecute_HookContinueOnError(t *testing.T) {
+	config := NewConfig()
+	config.Hooks.PostGenerate = []Hook{{Command: "exit 1", ContinueOnError: true}}
+	engine := newHookTestEngine(t, config, &engineTestProvider{name: "lenient"})
+
+	results, err := engine.Execute(context.Background(), &ExecuteOptions{NoBackup: true})
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	if len(results["lenient"].Warnings) != 1 {
+		t.Fatalf("warnings = %v", results["lenient"].Warnings)
+	}
+}
+
+func TestEngineExecute_HooksRespectDryRun(t *testing.T) {
+	dir := t.TempDir()
+	skipped := filepath.Join(dir, "skipped")
+	ran := filepath.Join(dir, "ran")
+
+	config := NewConfig()
+	config.Hooks.PreValidate = []Hook{
+		{Command: "touch " + skipped},
+		{Command: "touch " + ran, RunInDryRun: true},
+	}
+	engine := newHookTestEngine(t, config, &engineTestProvider{name: "dry"})
+
+	if _, err := engine.Execute(context.Background(), &ExecuteOptions{DryRun: true}); err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	if _, err := os.Stat(skipped); !os.IsNotExist(err) {
+		t.Fatalf("expected hook to be skipped in dry-run, stat: %v", err)
+	}
+	if _, err := os.Stat(ran); err != nil {
+		t.Fatalf("expected run_in_dry_run hook to run: %v", err)
+	}
+}
+
+func TestLoadConfig_Hooks(t *testing.T) {
+	cfgPath := filepath.Join(t.TempDir(), "config.yaml")
+	yamlContent := `hooks:
+  pre_generate:
+    - ./check-vpn.sh
+providers:
+  kubernetes:
+    hooks:
+      post_generate:
+        - command: kubectx --refresh
+          continue_on_error: true
+          timeout: 30s
+`
+	if err := os.WriteFile(cfgPath, []byte(yamlContent), 0o600); err != nil {
+		t.Fatalf("failed to write test config: %v", err)
+	}
+
+	cfg, err := LoadConfig(cfgPath)
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+
+	if len(cfg.Hooks.PreGenerate) != 1 || cfg.Hooks.PreGenerate[0].Command != "./check-vpn.sh" {
+		t.Fatalf("global hooks = %+v", cfg.Hooks)
+	}
+	hooks := cfg.ProviderHooks("kubernetes").PostGenerate
+	if len(hooks) != 1 || hooks[0].Command != "kubectx --refresh" || !hooks[0].ContinueOnError || hooks[0].Timeout.String() != "30s" {
+		t.Fatalf("kubernetes hooks = %+v", hooks)
+	}
+}
+
+func TestLoadConfig_EmptyHookCommand(t *testing.T) {
+	cfgPath := filepath.Join(t.TempDir(), "config.yaml")
+	yamlContent := `hooks:
+  post_generate:
+    - command: ""
+`
+	if err := os.WriteFile(cfgPath, []byte(yamlContent), 0o600); err != nil {
+		t.Fatalf("failed to write test config: %v", err)
+	}
+
+	if _, err := LoadConfig(cfgPath); err == nil {
+		t.Fatal("expected error for empty hook command, got nil")
+	}
+}
@@ -0,0 +1,153 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+)
+
+// Log formats supported by LoggingConfig.Format.
+const (
+	LogFormatText = "text"
+	LogFormatJSON = "json"
+)
+
+// LoggingConfig configures where and how cfgctl writes logs.
+type LoggingConfig struct {
+	// Format is the log format: "text" (default) or "json".
+	Format string `yaml:"format"`
+
+	// File writes logs to this path instead of stderr.
+	File string `yaml:"file"`
+
+	// Level is the default log level (debug, info, warn, error). Defaults to error.
+	Level string `yaml:"level"`
+
+	// MaxSizeMB is the size at which the log file is rotated. Defaults to 10.
+	MaxSizeMB int `yaml:"max_size_mb"`
+
+	// MaxBackups is the number of rotated log files to keep. Defaults to 3.
+	MaxBackups int `yaml:"max_backups"`
+
+	// Providers overrides the log level for individual providers.
+	Providers map[string]string `yaml:"providers"`
+}
+
+// LogFactory builds the engine logger and per-provider loggers that share a
+// single output but apply their own levels.
+type LogFactory struct {
+	handler        slog.Handler
+	defaultLevel   slog.Level
+	providerLevels map[string]slog.Level
+	closer         io.Closer
+}
+
+// NewLogFactory creates a LogFactory from cfg. Logs go to out unless cfg.File
+// is set, in which case they go to a size-rotated file.
+func NewLogFactory(cfg LoggingConfig, out io.Writer) (*LogFactory, error) {
+	defaultLevel := slog.LevelError
+	if cfg.Level != "" {
+		level, err := ParseLogLevel(cfg.Level)
+		if err != nil {
+			return nil, err
+		}
+		defaultLevel = level
+	}
+
+	// The shared handler must let through the most verbose configured level;
+	// each logger then filters to its own level.
+	minLevel := defaultLevel
+	providerLevels := make(map[string]slog.Level, len(cfg.Providers))
+	for name, value := range cfg.Providers {
+		level, err := ParseLogLevel(value)
+		if err != nil {
+			return nil, fmt.Errorf("log level for provider %q: %w", name, err)
+		}
+		providerLevels[name] = level
+		minLevel = min(minLevel, level)
+	}
+
+	factory := &LogFactory{
+		defaultLevel:   defaultLevel,
+		providerLevels: providerLevels,
+	}
+
+	if cfg.File != "" {
+		file, err := OpenRotatingFile(cfg.File, int64(cfg.MaxSizeMB)*1024*1024, cfg.MaxBackups)
+		if err != nil {
+			return nil, err
+		}
+		out = file
+		factory.closer = file
+	}
+
+	handlerOpts := &slog.HandlerOptions{Level: minLevel}
+	switch strings.ToLower(cfg.Format) {
+	case "", LogFormatText:
+		factory.handler = slog.NewTextHandler(out, handlerOpts)
+	case LogFormatJSON:
+		factory.handler = slog.NewJSONHandler(out, handlerOpts)
+	default:
+		_ = factory.Close()
+		return nil, fmt.Errorf("unsupported log format %q (want %s or %s)", cfg.Format, LogFormatText, LogFormatJSON)
+	}
+
+	return factory, nil
+}
+
+// Logger returns the logger used by the engine and CLI.
+func (f *LogFactory) Logger() *slog.Logger {
+	return slog.New(&levelHandler{level: f.defaultLevel, next: f.handler})
+}
+
+// ProviderLogger returns a logger for a provider. Records carry a "provider"
+// attribute and honor the provider's level override, if any.
+func (f *LogFactory) ProviderLogger(providerName string) *slog.Logger {
+	level, ok := f.providerLevels[providerName]
+	if !ok {
+		level = f.defaultLevel
+	}
+	next := f.handler.WithAttrs([]slog.Attr{slog.String("provider", providerName)})
+	return slog.New(&levelHandler{level: level, next: next})
+}
+
+// Close closes the log file, if one was opened.
+func (f *LogFactory) Close() error {
+	if f == nil || f.closer == nil {
+		return nil
+	}
+	return f.closer.Close()
+}
+
+// ParseLogLevel parses a level name such as "debug" or "warn".
+func ParseLogLevel(value string) (slog.Level, error) {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(strings.TrimSpace(value))); err != nil {
+		return 0, fmt.Errorf("invalid log level %q", value)
+	}
+	return level, nil
+}
+
+// levelHandler filters records below level before passing them to next.
+type levelHandler struct {
+	level slog.Level
+	next  slog.Handler
+}
+
+func (h *levelHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return level >= h.level && h.next.Enabled(ctx, level)
+}
+
+func (h *levelHandler) Handle(ctx context.Context, record slog.Record) error {
+	return h.next.Handle(ctx, record)
+}
+
+func (h *levelHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &levelHandler{level: h.level, next: h.next.WithAttrs(attrs)}
+}
+
+func (h *levelHandler) WithGroup(name string) slog.Handler {
+	return &levelHandler{level: h.level, next: h.next.WithGroup(name)}
+}
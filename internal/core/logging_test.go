@@ -0,0 +1,106 @@
+package core
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLogFactory_ProviderLevels(t *testing.T) {
+	var out bytes.Buffer
+	factory, err := NewLogFactory(LoggingConfig{
+		Level:     "warn",
+		Providers: map[string]string{"kubernetes": "debug"},
+	}, &out)
+	if err != nil {
+		t.Fatalf("NewLogFactory failed: %v", err)
+	}
+
+	factory.Logger().Info("engine info")
+	factory.ProviderLogger("aws").Debug("aws debug")
+	factory.ProviderLogger("kubernetes").Debug("kubernetes debug")
+	factory.Logger().Warn("engine warn")
+
+	got := out.String()
+	if strings.Contains(got, "engine info") || strings.Contains(got, "aws debug") {
+		t.Fatalf("expected records below the default level to be dropped, got %q", got)
+	}
+	if !strings.Contains(got, "kubernetes debug") || !strings.Contains(got, "provider=kubernetes") {
+		t.Fatalf("expected kubernetes debug record, got %q", got)
+	}
+	if !strings.Contains(got, "engine warn") {
+		t.Fatalf("expected engine warn record, got %q", got)
+	}
+}
+
+func TestLogFactory_JSON(t *testing.T) {
+	var out bytes.Buffer
+	factory, err := NewLogFactory(LoggingConfig{Format: LogFormatJSON, Level: "info"}, &out)
+	if err != nil {
+		t.Fatalf("NewLogFactory failed: %v", err)
+	}
+
+	factory.ProviderLogger("ssh").Info("hello", "hosts", 2)
+
+	var record map[string]interface{}
+	if err := json.Unmarshal(out.Bytes(), &record); err != nil {
+		t.Fatalf("expected JSON log line, got %q: %v", out.String(), err)
+	}
+	if record["msg"] != "hello" || record["provider"] != "ssh" {
+		t.Fatalf("record = %v", record)
+	}
+}
+
+func TestLogFactory_File(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "logs", "cfgctl.log")
+	factory, err := NewLogFactory(LoggingConfig{File: path}, nil)
+	if err != nil {
+		t.Fatalf("NewLogFactory failed: %v", err)
+	}
+
+	factory.Logger().Error("boom")
+	if err := factory.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read log file: %v", err)
+	}
+	if !strings.Contains(string(data), "boom") {
+		t.Fatalf("log file = %q", data)
+	}
+}
+
+func TestNewLogFactory_Invalid(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  LoggingConfig
+	}{
+		{name: "format", cfg: LoggingConfig{Format: "xml"}},
+		{name: "level", cfg: LoggingConfig{Level: "loud"}},
+		{name: "provider level", cfg: LoggingConfig{Providers: map[string]string{"aws": "loud"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewLogFactory(tt.cfg, &bytes.Buffer{}); err == nil {
+				t.Fatal("expected error, got nil")
+			}
+		})
+	}
+}
+
+func TestParseLogLevel(t *testing.T) {
+	level, err := ParseLogLevel(" DEBUG ")
+	if err != nil {
+		t.Fatalf("ParseLogLevel failed: %v", err)
+	}
+	if level != slog.LevelDebug {
+		t.Fatalf("level = %v", level)
+	}
+}
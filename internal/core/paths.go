@@ -0,0 +1,31 @@
+package core
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ExpandPath expands environment variables and a leading "~" in path.
+func ExpandPath(path string) (string, error) {
+	path = os.ExpandEnv(path)
+	if path == "" || path[0] != '~' {
+		return path, nil
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil || home == "" {
+		return "", errors.New("failed to resolve home directory")
+	}
+
+	if path == "~" {
+		return home, nil
+	}
+
+	if strings.HasPrefix(path, "~/") {
+		return filepath.Join(home, path[2:]), nil
+	}
+
+	return path, nil
+}
@@ -0,0 +1,83 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+)
+
+var errPatternEmpty = errors.New("pattern cannot be empty")
+
+// Pattern matches names against a case-insensitive glob such as "prod-*", or
+// against a regular expression when written between slashes, such as
+// "/^prod-[0-9]+$/". Globs use path.Match syntax and must match the whole
+// name; regular expressions match anywhere unless anchored.
+type Pattern struct {
+	raw  string
+	glob string
+	re   *regexp.Regexp
+}
+
+// CompilePattern parses a glob or /regex/ pattern.
+func CompilePattern(raw string) (Pattern, error) {
+	trimmed := strings.TrimSpace(raw)
+	if trimmed == "" {
+		return Pattern{}, errPatternEmpty
+	}
+
+	if len(trimmed) > 2 && strings.HasPrefix(trimmed, "/") && strings.HasSuffix(trimmed, "/") {
+		re, err := regexp.Compile("(?i)" + trimmed[1:len(trimmed)-1])
+		if err != nil {
+			return Pattern{}, fmt.Errorf("invalid regular expression %q: %w", trimmed, err)
+		}
+		return Pattern{raw: trimmed, re: re}, nil
+	}
+
+	glob := strings.ToLower(trimmed)
+	if _, err := path.Match(glob, ""); err != nil {
+		return Pattern{}, fmt.Errorf("invalid glob %q: %w", trimmed, err)
+	}
+	return Pattern{raw: trimmed, glob: glob}, nil
+}
+
+// Match reports whether name matches the pattern.
+func (p Pattern) Match(name string) bool {
+	if p.re != nil {
+		return p.re.MatchString(name)
+	}
+	matched, _ := path.Match(p.glob, strings.ToLower(strings.TrimSpace(name)))
+	return matched
+}
+
+// String returns the pattern as written.
+func (p Pattern) String() string {
+	return p.raw
+}
+
+// Patterns is a list of patterns that matches a name if any of them does.
+type Patterns []Pattern
+
+// CompilePatterns parses every entry of raw.
+func CompilePatterns(raw []string) (Patterns, error) {
+	patterns := make(Patterns, 0, len(raw))
+	for _, entry := range raw {
+		pattern, err := CompilePattern(entry)
+		if err != nil {
+			return nil, err
+		}
+		patterns = append(patterns, pattern)
+	}
+	return patterns, nil
+}
+
+// Match returns the first pattern that matches name.
+func (p Patterns) Match(name string) (Pattern, bool) {
+	for _, pattern := range p {
+		if pattern.Match(name) {
+			return pattern, true
+		}
+	}
+	return Pattern{}, false
+}
@@ -0,0 +1,56 @@
+package core
+
+import "testing"
+
+func TestPatternMatch(t *testing.T) {
+	tests := []struct {
+		pattern string
+		name    string
+		want    bool
+	}{
+		{pattern: "prod-*", name: "prod-payments", want: true},
+		{pattern: "prod-*", name: "PROD-Payments", want: true},
+		{pattern: "prod-*", name: "preprod-payments", want: false},
+		{pattern: "Admin*", name: "AdministratorAccess", want: true},
+		{pattern: "prod-legacy", name: "prod-legacy", want: true},
+		{pattern: "prod-legacy", name: "prod-legacy-2", want: false},
+		{pattern: "/^prod-[0-9]+$/", name: "prod-42", want: true},
+		{pattern: "/^prod-[0-9]+$/", name: "prod-new", want: false},
+		{pattern: "/breakglass/", name: "Org-BreakGlass-Role", want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.pattern+" "+tt.name, func(t *testing.T) {
+			pattern, err := CompilePattern(tt.pattern)
+			if err != nil {
+				t.Fatalf("CompilePattern failed: %v", err)
+			}
+			if got := pattern.Match(tt.name); got != tt.want {
+				t.Fatalf("Match(%q) = %v, want %v", tt.name, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCompilePatternErrors(t *testing.T) {
+	for _, raw := range []string{"", "  ", "prod-[", "/prod-(/"} {
+		if _, err := CompilePattern(raw); err == nil {
+			t.Errorf("CompilePattern(%q) succeeded, want error", raw)
+		}
+	}
+}
+
+func TestPatternsMatch(t *testing.T) {
+	patterns, err := CompilePatterns([]string{"dev-*", "/sandbox/"})
+	if err != nil {
+		t.Fatalf("CompilePatterns failed: %v", err)
+	}
+
+	matched, ok := patterns.Match("team-sandbox")
+	if !ok || matched.String() != "/sandbox/" {
+		t.Fatalf("Match = %q, %v; want /sandbox/", matched, ok)
+	}
+	if _, ok := patterns.Match("prod"); ok {
+		t.Fatal("expected no match for prod")
+	}
+}
@@ -0,0 +1,105 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// ErrAlreadyRunning is returned by AcquirePIDFile when a live process holds the PID file.
+var ErrAlreadyRunning = errors.New("another instance is already running")
+
+// processAlive reports whether pid names a running process. Override in tests.
+var processAlive = func(pid int) bool {
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	err = process.Signal(syscall.Signal(0))
+	return err == nil || errors.Is(err, syscall.EPERM)
+}
+
+// PIDFile is a lock file holding the PID of the process that owns it.
+type PIDFile struct {
+	path string
+}
+
+// AcquirePIDFile creates path containing the current PID. It fails with
+// ErrAlreadyRunning when the file belongs to a process that is still alive,
+// and replaces files left behind by processes that have exited.
+func AcquirePIDFile(path string) (*PIDFile, error) {
+	expanded, err := ExpandPath(path)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Dir(expanded), 0o700); err != nil {
+		return nil, fmt.Errorf("create pid file directory: %w", err)
+	}
+
+	// The PID is written to a temporary file and hard-linked into place, so
+	// the lock file never exists without its PID and a concurrent reader
+	// cannot mistake a half-written file for a stale one.
+	temp, err := os.CreateTemp(filepath.Dir(expanded), filepath.Base(expanded)+".*.tmp")
+	if err != nil {
+		return nil, fmt.Errorf("create pid file: %w", err)
+	}
+	tempPath := temp.Name()
+	defer func() { _ = os.Remove(tempPath) }()
+	_, writeErr := fmt.Fprintf(temp, "%d\n", os.Getpid())
+	if err := errors.Join(writeErr, temp.Close()); err != nil {
+		return nil, fmt.Errorf("write pid file: %w", err)
+	}
+
+	// Retry once after removing a stale file.
+	for range 2 {
+		err := os.Link(tempPath, expanded)
+		if err == nil {
+			return &PIDFile{path: expanded}, nil
+		}
+		if !errors.Is(err, os.ErrExist) {
+			return nil, fmt.Errorf("create pid file: %w", err)
+		}
+
+		if pid, ok := readPID(expanded); ok && processAlive(pid) {
+			return nil, fmt.Errorf("%w (pid %d, %s)", ErrAlreadyRunning, pid, expanded)
+		}
+		if err := os.Remove(expanded); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("remove stale pid file: %w", err)
+		}
+	}
+
+	return nil, fmt.Errorf("%w (%s)", ErrAlreadyRunning, expanded)
+}
+
+// Path returns the location of the PID file.
+func (p *PIDFile) Path() string {
+	return p.path
+}
+
+// Release removes the PID file.
+func (p *PIDFile) Release() error {
+	if p == nil {
+		return nil
+	}
+	if err := os.Remove(p.path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("remove pid file: %w", err)
+	}
+	return nil
+}
+
+func readPID(path string) (int, bool) {
+	// #nosec G304 -- pid file path is from user configuration
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, false
+	}
+	pid, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil || pid <= 0 {
+		return 0, false
+	}
+	return pid, true
+}
@@ -0,0 +1,105 @@
+package core
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestAcquirePIDFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "run", "watch.pid")
+
+	lock, err := AcquirePIDFile(path)
+	if err != nil {
+		t.Fatalf("AcquirePIDFile failed: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read pid file: %v", err)
+	}
+	if got := strings.TrimSpace(string(data)); got != strconv.Itoa(os.Getpid()) {
+		t.Fatalf("pid file contains %q, want %d", got, os.Getpid())
+	}
+
+	if _, err := AcquirePIDFile(path); !errors.Is(err, ErrAlreadyRunning) {
+		t.Fatalf("expected ErrAlreadyRunning, got %v", err)
+	}
+
+	if err := lock.Release(); err != nil {
+		t.Fatalf("Release failed: %v", err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Fatalf("expected pid file to be removed, stat: %v", err)
+	}
+}
+
+func TestAcquirePIDFile_ReplacesStale(t *testing.T) {
+	original := processAlive
+	processAlive = func(int) bool { return false }
+	t.Cleanup(func() { processAlive = original })
+
+	path := filepath.Join(t.TempDir(), "watch.pid")
+	if err := os.WriteFile(path, []byte("12345\n"), 0o600); err != nil {
+		t.Fatalf("write stale pid file: %v", err)
+	}
+
+	lock, err := AcquirePIDFile(path)
+	if err != nil {
+		t.Fatalf("AcquirePIDFile failed: %v", err)
+	}
+	defer func() {
+		_ = lock.Release()
+	}()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read pid file: %v", err)
+	}
+	if got := strings.TrimSpace(string(data)); got != strconv.Itoa(os.Getpid()) {
+		t.Fatalf("pid file contains %q, want %d", got, os.Getpid())
+	}
+}
+
+func TestAcquirePIDFile_Concurrent(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "watch.pid")
+
+	const attempts = 16
+	var wg sync.WaitGroup
+	locks := make(chan *PIDFile, attempts)
+	for range attempts {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			lock, err := AcquirePIDFile(path)
+			if err == nil {
+				locks <- lock
+				return
+			}
+			if !errors.Is(err, ErrAlreadyRunning) {
+				t.Errorf("AcquirePIDFile failed: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+	close(locks)
+
+	if len(locks) != 1 {
+		t.Fatalf("%d goroutines acquired the pid file, want 1", len(locks))
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("read dir: %v", err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("dir holds %d entries, want only the pid file", len(entries))
+	}
+	if err := (<-locks).Release(); err != nil {
+		t.Fatalf("Release failed: %v", err)
+	}
+}
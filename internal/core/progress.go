@@ -0,0 +1,69 @@
+package core
+
+// ProgressKind classifies a ProgressEvent.
+type ProgressKind string
+
+const (
+	// ProgressStep reports the operation a provider is currently working on.
+	ProgressStep ProgressKind = "step"
+
+	// ProgressCount reports completion of Current out of Total work items.
+	ProgressCount ProgressKind = "count"
+
+	// ProgressWarning reports a non-fatal issue as it happens.
+	ProgressWarning ProgressKind = "warning"
+
+	// ProgressDone reports that a provider has finished, successfully or not.
+	ProgressDone ProgressKind = "done"
+)
+
+// ProgressEvent is a structured progress update emitted during generation.
+type ProgressEvent struct {
+	// Provider is the name of the provider emitting the event.
+	// The engine fills it in for providers.
+	Provider string
+
+	// Kind classifies the event.
+	Kind ProgressKind
+
+	// Message is a short human-readable description, e.g. "scanning prod in us-east-1".
+	Message string
+
+	// Current and Total describe counted progress for ProgressCount events.
+	Current int
+	Total   int
+}
+
+// ProgressReporter receives progress events from long-running provider work.
+// Report may be called concurrently from multiple goroutines.
+type ProgressReporter interface {
+	Report(event ProgressEvent)
+}
+
+// ProgressFunc adapts a function to the ProgressReporter interface.
+type ProgressFunc func(event ProgressEvent)
+
+// Report calls f(event).
+func (f ProgressFunc) Report(event ProgressEvent) {
+	f(event)
+}
+
+// ReportProgress sends event to reporter, ignoring a nil reporter so
+// providers can report unconditionally.
+func ReportProgress(reporter ProgressReporter, event ProgressEvent) {
+	if reporter == nil {
+		return
+	}
+	reporter.Report(event)
+}
+
+// providerProgress stamps events with the name of the provider that emitted them.
+type providerProgress struct {
+	provider string
+	reporter ProgressReporter
+}
+
+func (p providerProgress) Report(event ProgressEvent) {
+	event.Provider = p.provider
+	p.reporter.Report(event)
+}
@@ -41,6 +41,13 @@ type BackupDecider interface {
 	NeedsBackup(opts *GenerateOptions) (bool, error)
 }
 
+// Watchable is implemented by providers whose output is derived from local files.
+// Engine.Watch regenerates the provider when one of its paths changes.
+type Watchable interface {
+	// WatchPaths returns the files and directories the provider reads.
+	WatchPaths() []string
+}
+
 // GenerateOptions contains options that can be passed to Generate.
 type GenerateOptions struct {
 	// DryRun indicates whether to simulate generation without making changes.
@@ -54,6 +61,17 @@ type GenerateOptions struct {
 
 	// Config contains provider-specific configuration data.
 	Config ProviderConfig
+
+	// Progress receives progress events during generation. It may be nil.
+	Progress ProgressReporter
+
+	// Cache holds discovery results between runs. It may be nil, in which case
+	// providers always discover.
+	Cache *ProviderCache
+
+	// AWSProfiles holds the AWS profiles published by the aws provider earlier
+	// in the run. It may be nil.
+	AWSProfiles *AWSProfileInventory
 }
 
 // ProviderConfig is a marker interface for provider-specific configuration.
@@ -68,25 +86,34 @@ type EnabledProviderConfig interface {
 	IsEnabled() bool
 }
 
+// ToolsProviderConfig reports the external tools a provider needs with its
+// current settings, in place of the engine's built-in list.
+type ToolsProviderConfig interface {
+	RequiredTools() []string
+}
+
 // Result contains information about what was generated by a provider.
 type Result struct {
 	// Provider is the name of the provider that generated this result.
-	Provider string
+	Provider string `json:"provider"`
 
 	// FilesCreated lists all files that were created or modified.
-	FilesCreated []string
+	FilesCreated []string `json:"files_created"`
 
 	// FilesSkipped lists files that were skipped (e.g., already exist and no --force).
-	FilesSkipped []string
+	FilesSkipped []string `json:"files_skipped"`
 
 	// BackupPath is the location of the backup, if one was created.
-	BackupPath string
+	BackupPath string `json:"backup_path,omitempty"`
 
 	// Warnings contains any non-fatal issues encountered during generation.
-	Warnings []string
+	Warnings []string `json:"warnings"`
 
 	// Metadata contains provider-specific result data.
-	Metadata map[string]interface{}
+	Metadata map[string]interface{} `json:"metadata,omitempty"`
+
+	// Timings records how long each phase of the run took, in execution order.
+	Timings []PhaseTiming `json:"timings,omitempty"`
 }
 
 // ProviderConfigFactory is a function that creates a ProviderConfig from a raw map.
@@ -0,0 +1,45 @@
+package core
+
+import "time"
+
+// Phases recorded in Result.Timings. Providers may record additional
+// provider-specific phases alongside these.
+const (
+	TimingValidate = "validate"
+	TimingBackup   = "backup"
+	TimingDiscover = "discover"
+	TimingRender   = "render"
+	TimingWrite    = "write"
+	TimingGenerate = "generate"
+)
+
+// PhaseTiming records how long a single phase of a provider run took.
+type PhaseTiming struct {
+	// Phase is the name of the phase, e.g. "discover".
+	Phase string `json:"phase"`
+
+	// Duration is the wall-clock time spent in the phase, in nanoseconds when encoded.
+	Duration time.Duration `json:"duration"`
+}
+
+// RecordPhase appends the time elapsed since start as a timing for phase.
+func (r *Result) RecordPhase(phase string, start time.Time) {
+	if r == nil {
+		return
+	}
+	r.Timings = append(r.Timings, PhaseTiming{Phase: phase, Duration: time.Since(start)})
+}
+
+// PhaseDuration returns the total time recorded for phase.
+func (r *Result) PhaseDuration(phase string) time.Duration {
+	if r == nil {
+		return 0
+	}
+	var total time.Duration
+	for _, timing := range r.Timings {
+		if timing.Phase == phase {
+			total += timing.Duration
+		}
+	}
+	return total
+}
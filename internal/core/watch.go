@@ -0,0 +1,287 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// DefaultWatchDebounce is how long Watch waits for changes to settle before regenerating.
+const DefaultWatchDebounce = 2 * time.Second
+
+// ErrNothingToWatch is returned by Watch when none of the selected providers has input paths.
+var ErrNothingToWatch = errors.New("no provider input paths to watch")
+
+// watchOps are the file operations that trigger regeneration.
+const watchOps = fsnotify.Create | fsnotify.Write | fsnotify.Remove | fsnotify.Rename
+
+// WatchOptions contains options for Engine.Watch.
+type WatchOptions struct {
+	// Execute holds the options used for each regeneration. Its Providers field
+	// limits which providers are watched; if empty, all registered providers are.
+	Execute ExecuteOptions
+
+	// Debounce is how long to wait after the last change before regenerating.
+	// Defaults to DefaultWatchDebounce.
+	Debounce time.Duration
+
+	// OnRun is called after each regeneration with the providers that ran. It may be nil.
+	OnRun func(providers []string, results map[string]*Result, err error)
+}
+
+// Watch regenerates providers whenever their input files change, until ctx is
+// cancelled. Only providers implementing Watchable are watched, and each change
+// re-runs just the providers that read the changed path. Regeneration failures
+// are reported through OnRun and do not stop watching.
+func (e *Engine) Watch(ctx context.Context, opts *WatchOptions) error {
+	if opts == nil {
+		opts = &WatchOptions{}
+	}
+	debounce := opts.Debounce
+	if debounce <= 0 {
+		debounce = DefaultWatchDebounce
+	}
+
+	targets, err := e.watchTargets(opts.Execute.Providers)
+	if err != nil {
+		return err
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("create file watcher: %w", err)
+	}
+	defer func() {
+		_ = watcher.Close()
+	}()
+
+	watching := 0
+	for _, dir := range targets.watchDirs() {
+		if err := watcher.Add(dir); err != nil {
+			e.logger.Warn("cannot watch directory", "dir", dir, "error", err)
+			continue
+		}
+		e.logger.Debug("watching directory", "dir", dir)
+		watching++
+	}
+	if watching == 0 {
+		return ErrNothingToWatch
+	}
+
+	e.logger.Info("watching for changes", "providers", targets.providers(), "debounce", debounce)
+	return e.watchLoop(ctx, watcher, targets, debounce, opts)
+}
+
+func (e *Engine) watchLoop(ctx context.Context, watcher *fsnotify.Watcher, targets *watchTargets, debounce time.Duration, opts *WatchOptions) error {
+	pending := make(map[string]struct{})
+	timer := time.NewTimer(debounce)
+	timer.Stop()
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+			if !event.Has(watchOps) {
+				continue
+			}
+			providers := targets.match(event.Name)
+			if len(providers) == 0 {
+				continue
+			}
+			e.logger.Debug("input changed", "path", event.Name, "op", event.Op.String(), "providers", providers)
+			for _, name := range providers {
+				pending[name] = struct{}{}
+			}
+			timer.Reset(debounce)
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return nil
+			}
+			e.logger.Warn("file watcher error", "error", err)
+		case <-timer.C:
+			providers := sortedKeys(pending)
+			clear(pending)
+			e.regenerate(ctx, providers, targets, opts)
+		}
+	}
+}
+
+// regenerate runs the given providers and remembers the files they wrote so
+// that the resulting events do not trigger another run.
+func (e *Engine) regenerate(ctx context.Context, providers []string, targets *watchTargets, opts *WatchOptions) {
+	execOpts := opts.Execute
+	execOpts.Providers = providers
+
+	e.logger.Info("regenerating", "providers", providers)
+	results, err := e.Execute(ctx, &execOpts)
+	targets.ignoreOutputs(results)
+	if err != nil {
+		e.logger.Error("regeneration failed", "providers", providers, "error", err)
+	}
+
+	if opts.OnRun != nil {
+		opts.OnRun(providers, results, err)
+	}
+}
+
+// watchTargets collects the input paths of the watchable providers in names.
+func (e *Engine) watchTargets(names []string) (*watchTargets, error) {
+	providers, err := e.resolveProviders(names)
+	if err != nil {
+		return nil, err
+	}
+
+	targets := newWatchTargets()
+	for _, provider := range providers {
+		name := provider.Name()
+		watchable, ok := provider.(Watchable)
+		if !ok || e.skipResult(name) != nil {
+			continue
+		}
+		for _, path := range watchable.WatchPaths() {
+			if err := targets.add(name, path); err != nil {
+				e.logger.Warn("cannot watch path", "provider", name, "path", path, "error", err)
+			}
+		}
+	}
+
+	if len(targets.files) == 0 && len(targets.dirs) == 0 {
+		return nil, ErrNothingToWatch
+	}
+	return targets, nil
+}
+
+// watchTargets maps changed paths to the providers that read them. Files are
+// watched through their parent directory so that editors which replace files
+// by renaming are still noticed.
+type watchTargets struct {
+	files   map[string][]string
+	dirs    map[string][]string
+	outputs map[string]struct{}
+}
+
+func newWatchTargets() *watchTargets {
+	return &watchTargets{
+		files:   make(map[string][]string),
+		dirs:    make(map[string][]string),
+		outputs: make(map[string]struct{}),
+	}
+}
+
+// add registers path as an input of provider. Existing directories match any
+// change to their direct children; anything else is treated as a file.
+func (t *watchTargets) add(provider, path string) error {
+	if strings.TrimSpace(path) == "" {
+		return nil
+	}
+
+	expanded, err := ExpandPath(path)
+	if err != nil {
+		return err
+	}
+	expanded, err = filepath.Abs(expanded)
+	if err != nil {
+		return fmt.Errorf("resolve watch path: %w", err)
+	}
+
+	if info, err := os.Stat(expanded); err == nil && info.IsDir() {
+		t.dirs[expanded] = appendUnique(t.dirs[expanded], provider)
+	} else {
+		t.files[expanded] = appendUnique(t.files[expanded], provider)
+	}
+	return nil
+}
+
+// watchDirs returns the directories that must be watched.
+func (t *watchTargets) watchDirs() []string {
+	dirs := make(map[string]struct{}, len(t.dirs)+len(t.files))
+	for dir := range t.dirs {
+		dirs[dir] = struct{}{}
+	}
+	for file := range t.files {
+		dirs[filepath.Dir(file)] = struct{}{}
+	}
+	return sortedKeys(dirs)
+}
+
+// providers returns every provider with at least one watched path.
+func (t *watchTargets) providers() []string {
+	names := make(map[string]struct{})
+	for _, group := range []map[string][]string{t.files, t.dirs} {
+		for _, providers := range group {
+			for _, name := range providers {
+				names[name] = struct{}{}
+			}
+		}
+	}
+	return sortedKeys(names)
+}
+
+// match returns the providers affected by a change to path.
+func (t *watchTargets) match(path string) []string {
+	path = filepath.Clean(path)
+	if t.isOutput(path) {
+		return nil
+	}
+
+	var providers []string
+	for _, name := range t.files[path] {
+		providers = appendUnique(providers, name)
+	}
+	for _, name := range t.dirs[filepath.Dir(path)] {
+		providers = appendUnique(providers, name)
+	}
+	return providers
+}
+
+// ignoreOutputs records the files written by a run. Changes to those files,
+// and to siblings such as "<file>.<timestamp>.bak", are not treated as input changes.
+func (t *watchTargets) ignoreOutputs(results map[string]*Result) {
+	for _, result := range results {
+		if result == nil {
+			continue
+		}
+		for _, file := range result.FilesCreated {
+			t.outputs[filepath.Clean(file)] = struct{}{}
+		}
+	}
+}
+
+func (t *watchTargets) isOutput(path string) bool {
+	for output := range t.outputs {
+		if path == output || strings.HasPrefix(path, output+".") {
+			return true
+		}
+	}
+	return false
+}
+
+func appendUnique(values []string, value string) []string {
+	for _, existing := range values {
+		if existing == value {
+			return values
+		}
+	}
+	return append(values, value)
+}
+
+func sortedKeys(set map[string]struct{}) []string {
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,145 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type watchTestProvider struct {
+	engineTestProvider
+	paths []string
+}
+
+func (p *watchTestProvider) WatchPaths() []string {
+	return p.paths
+}
+
+func TestWatchTargetsMatch(t *testing.T) {
+	dir := t.TempDir()
+	awsConfig := filepath.Join(dir, "aws-config")
+	kubeDir := filepath.Join(dir, "kube")
+	if err := os.Mkdir(kubeDir, 0o700); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+
+	targets := newWatchTargets()
+	for _, target := range []struct{ provider, path string }{
+		{provider: "kubernetes", path: awsConfig},
+		{provider: "kubernetes", path: kubeDir},
+		{provider: "steampipe", path: awsConfig},
+	} {
+		if err := targets.add(target.provider, target.path); err != nil {
+			t.Fatalf("add %s: %v", target.path, err)
+		}
+	}
+
+	if got, want := targets.watchDirs(), []string{dir, kubeDir}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("watchDirs = %v, want %v", got, want)
+	}
+
+	tests := []struct {
+		path string
+		want []string
+	}{
+		{path: awsConfig, want: []string{"kubernetes", "steampipe"}},
+		{path: filepath.Join(kubeDir, "dev.yaml"), want: []string{"kubernetes"}},
+		{path: filepath.Join(dir, "other"), want: nil},
+		{path: filepath.Join(kubeDir, "nested", "dev.yaml"), want: nil},
+	}
+	for _, tt := range tests {
+		if got := targets.match(tt.path); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("match(%q) = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+
+	output := filepath.Join(kubeDir, "config")
+	targets.ignoreOutputs(map[string]*Result{"kubernetes": {FilesCreated: []string{output}}})
+	for _, path := range []string{output, output + ".20260101-120000.bak", output + ".lock"} {
+		if got := targets.match(path); got != nil {
+			t.Errorf("expected output %q to be ignored, got %v", path, got)
+		}
+	}
+}
+
+func TestEngineWatch_NothingToWatch(t *testing.T) {
+	registry := NewRegistry()
+	if err := registry.Register(&engineTestProvider{name: "plain"}); err != nil {
+		t.Fatalf("register: %v", err)
+	}
+	engine := NewEngine(registry, NewBackupManager(""), NewConfig(), newTestLogger())
+
+	err := engine.Watch(context.Background(), &WatchOptions{})
+	if !errors.Is(err, ErrNothingToWatch) {
+		t.Fatalf("expected ErrNothingToWatch, got %v", err)
+	}
+}
+
+func TestEngineWatch_RegeneratesOnChange(t *testing.T) {
+	dir := t.TempDir()
+	input := filepath.Join(dir, "input")
+	output := filepath.Join(dir, "input.generated")
+
+	watched := &watchTestProvider{
+		engineTestProvider: engineTestProvider{name: "watched", result: &Result{Provider: "watched", FilesCreated: []string{output}}},
+		paths:              []string{input},
+	}
+	registry := NewRegistry()
+	for _, provider := range []Provider{watched, &engineTestProvider{name: "unwatched"}} {
+		if err := registry.Register(provider); err != nil {
+			t.Fatalf("register: %v", err)
+		}
+	}
+	engine := NewEngine(registry, NewBackupManager(""), NewConfig(), newTestLogger())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	runs := make(chan []string, 4)
+	done := make(chan error, 1)
+	go func() {
+		done <- engine.Watch(ctx, &WatchOptions{
+			Execute:  ExecuteOptions{NoBackup: true},
+			Debounce: 20 * time.Millisecond,
+			OnRun: func(providers []string, _ map[string]*Result, _ error) {
+				runs <- providers
+			},
+		})
+	}()
+
+	// Give the watcher time to register before writing.
+	time.Sleep(100 * time.Millisecond)
+	for range 3 {
+		if err := os.WriteFile(input, []byte("changed"), 0o600); err != nil {
+			t.Fatalf("write input: %v", err)
+		}
+	}
+
+	select {
+	case providers := <-runs:
+		if !reflect.DeepEqual(providers, []string{"watched"}) {
+			t.Fatalf("regenerated %v, want [watched]", providers)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for regeneration")
+	}
+
+	// Writing the provider's own output must not trigger another run.
+	if err := os.WriteFile(output, []byte("generated"), 0o600); err != nil {
+		t.Fatalf("write output: %v", err)
+	}
+	select {
+	case providers := <-runs:
+		t.Fatalf("unexpected regeneration of %v", providers)
+	case <-time.After(200 * time.Millisecond):
+	}
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatalf("Watch returned %v", err)
+	}
+}
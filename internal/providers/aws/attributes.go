@@ -0,0 +1,94 @@
+package aws
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	errProfileAttributeKey      = errors.New("profile attribute key is invalid")
+	errProfileAttributeReserved = errors.New("profile attribute key is set by cfgctl")
+	errProfileAttributeValue    = errors.New("profile attribute value cannot span lines")
+	errProfileAttributeRepeated = errors.New("profile attribute is set more than once")
+)
+
+// reservedProfileKeys are written by the generator and cannot be overridden
+// by profile attributes. AWS config keys are case-insensitive, so attribute
+// keys are lowercased before they are looked up here.
+var reservedProfileKeys = map[string]bool{
+	"cfgctl_sso_account_id": true,
+	"cfgctl_sso_role_name":  true,
+	"cfgctl_sso_session":    true,
+	"cfgctl_variant_of":     true,
+	"credential_process":    true,
+	"sso_account_id":        true,
+	"sso_account_name":      true,
+	"sso_region":            true,
+	"sso_role_name":         true,
+	"sso_session":           true,
+	"sso_start_url":         true,
+}
+
+// ProfileAttributeRule adds keys, such as region, output, cli_pager, or
+// duration_seconds, to the generated profiles that Match selects. An empty
+// Match selects every profile.
+type ProfileAttributeRule struct {
+	Match FilterRule        `yaml:"match"`
+	Set   map[string]string `yaml:"set"`
+}
+
+type attributeRule struct {
+	match compiledRule
+	set   map[string]string
+}
+
+// compileProfileAttributes validates rules and compiles their selectors.
+func compileProfileAttributes(rules []ProfileAttributeRule, markerKey string) ([]attributeRule, error) {
+	compiled := make([]attributeRule, 0, len(rules))
+	for i, rule := range rules {
+		name := fmt.Sprintf("profile_attributes[%d]", i)
+		match, err := compileRule(name+" match", rule.Match)
+		if err != nil {
+			return nil, err
+		}
+
+		set := make(map[string]string, len(rule.Set))
+		for key, value := range rule.Set {
+			key = strings.ToLower(strings.TrimSpace(key))
+			if key == "" || strings.ContainsAny(key, " \t\r\n=[]#;") {
+				return nil, fmt.Errorf("%s: %w: %q", name, errProfileAttributeKey, key)
+			}
+			if reservedProfileKeys[key] || strings.EqualFold(key, strings.TrimSpace(markerKey)) {
+				return nil, fmt.Errorf("%s: %w: %q", name, errProfileAttributeReserved, key)
+			}
+			if _, ok := set[key]; ok {
+				return nil, fmt.Errorf("%s: %w: %q", name, errProfileAttributeRepeated, key)
+			}
+			if strings.ContainsAny(value, "\r\n") {
+				return nil, fmt.Errorf("%s: %w: %q", name, errProfileAttributeValue, key)
+			}
+			set[key] = strings.TrimSpace(value)
+		}
+		compiled = append(compiled, attributeRule{match: match, set: set})
+	}
+	return compiled, nil
+}
+
+// resolveAttributes returns the keys every matching rule sets for profile,
+// with later rules overriding earlier ones.
+func resolveAttributes(rules []attributeRule, profile DiscoveredProfile) map[string]string {
+	var attributes map[string]string
+	for _, rule := range rules {
+		if _, ok := rule.match.match(profile); !ok {
+			continue
+		}
+		if attributes == nil {
+			attributes = make(map[string]string, len(rule.set))
+		}
+		for key, value := range rule.set {
+			attributes[key] = value
+		}
+	}
+	return attributes
+}
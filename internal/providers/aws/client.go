@@ -5,6 +5,7 @@ import (
 	"context"
 	"fmt"
 
+	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/credentials"
 	"github.com/aws/aws-sdk-go-v2/service/sso"
@@ -14,17 +15,21 @@ import (
 type SSOClient interface {
 	ListAccounts(ctx context.Context, params *sso.ListAccountsInput, optFns ...func(*sso.Options)) (*sso.ListAccountsOutput, error)
 	ListAccountRoles(ctx context.Context, params *sso.ListAccountRolesInput, optFns ...func(*sso.Options)) (*sso.ListAccountRolesOutput, error)
+	GetRoleCredentials(ctx context.Context, params *sso.GetRoleCredentialsInput, optFns ...func(*sso.Options)) (*sso.GetRoleCredentialsOutput, error)
 }
 
 // SSOClientFactory creates SSO clients with the appropriate credentials.
 type SSOClientFactory func(ctx context.Context, region, accessToken string) (SSOClient, error)
 
-// NewSSOClientFactory returns a default SSO client factory.
-func NewSSOClientFactory() SSOClientFactory {
-	return newSSOClient
+// NewSSOClientFactory returns a default SSO client factory. A non-empty
+// endpointURL replaces the SSO endpoint.
+func NewSSOClientFactory(endpointURL string) SSOClientFactory {
+	return func(ctx context.Context, region, accessToken string) (SSOClient, error) {
+		return newSSOClient(ctx, region, accessToken, endpointURL)
+	}
 }
 
-func newSSOClient(ctx context.Context, region, accessToken string) (SSOClient, error) {
+func newSSOClient(ctx context.Context, region, accessToken, endpointURL string) (SSOClient, error) {
 	cfg, err := config.LoadDefaultConfig(ctx, config.WithRegion(region))
 	if err != nil {
 		return nil, fmt.Errorf("load aws config: %w", err)
@@ -32,6 +37,9 @@ func newSSOClient(ctx context.Context, region, accessToken string) (SSOClient, e
 
 	client := sso.NewFromConfig(cfg, func(opts *sso.Options) {
 		opts.Credentials = credentials.NewStaticCredentialsProvider("", "", accessToken)
+		if endpointURL != "" {
+			opts.BaseEndpoint = aws.String(endpointURL)
+		}
 	})
 
 	return client, nil
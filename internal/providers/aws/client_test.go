@@ -3,11 +3,24 @@ package aws
 import (
 	"context"
 	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sso"
 )
 
 func TestNewSSOClient(t *testing.T) {
-	_, err := newSSOClient(context.Background(), "us-east-1", "token")
+	_, err := newSSOClient(context.Background(), "us-east-1", "token", "")
 	if err != nil {
 		t.Fatalf("newSSOClient failed: %v", err)
 	}
 }
+
+func TestNewSSOClientEndpointURL(t *testing.T) {
+	client, err := NewSSOClientFactory("http://localhost:4566")(context.Background(), "us-east-1", "token")
+	if err != nil {
+		t.Fatalf("NewSSOClientFactory failed: %v", err)
+	}
+	if got := aws.ToString(client.(*sso.Client).Options().BaseEndpoint); got != "http://localhost:4566" {
+		t.Fatalf("BaseEndpoint = %q", got)
+	}
+}
@@ -25,6 +25,13 @@ const (
 	defaultDemoStartURL    = "https://example.awsapps.com/start"
 	defaultSSOScopes       = "sso:account:access"
 	defaultSSOSessionName  = "cfgctl"
+	defaultParallelWorkers = 8
+)
+
+// SSO login methods.
+const (
+	ssoLoginNative = "native"
+	ssoLoginCLI    = "cli"
 )
 
 var (
@@ -33,6 +40,9 @@ var (
 	errSSORegionEmpty       = errors.New("sso region cannot be empty")
 	errSSOStartURLEmpty     = errors.New("sso start url cannot be empty")
 	errTokenCachePathsEmpty = errors.New("token cache paths cannot be empty")
+	errSSOLoginInvalid      = errors.New("sso login must be native or cli")
+	errSSOSessionDuplicate  = errors.New("sso session name is used more than once")
+	errParallelWorkersBound = errors.New("parallel workers must be greater than zero")
 )
 
 // Config represents AWS provider-specific configuration.
@@ -43,6 +53,9 @@ type Config struct {
 	// Demo enables fake data without AWS calls.
 	Demo bool `yaml:"-"`
 
+	// Fixtures replaces the built-in demo accounts when Demo is set.
+	Fixtures *core.DemoFixtures `yaml:"-"`
+
 	// ConfigPath is the output path for the AWS config file.
 	ConfigPath string `yaml:"config_path"`
 
@@ -63,20 +76,77 @@ type Config struct {
 
 	// ProfileTemplate is the template used for profile names.
 	ProfileTemplate string `yaml:"profile_template"`
+
+	// ProfileAttributes adds keys to the generated profiles each rule selects.
+	ProfileAttributes []ProfileAttributeRule `yaml:"profile_attributes"`
+
+	// RegionVariants generates region-suffixed copies of selected profiles.
+	RegionVariants []RegionVariantRule `yaml:"region_variants"`
+
+	// ClusterRegions lists the regions with EKS clusters by profile name,
+	// for region variant rules that set eks_regions. DiscoverClusterRegions
+	// fills it from this run's profiles when set.
+	ClusterRegions map[string][]string `yaml:"-"`
+
+	// DiscoverClusterRegions scans this run's profiles for EKS clusters for
+	// region variant rules that set eks_regions.
+	DiscoverClusterRegions ClusterRegionsFunc `yaml:"-"`
+
+	// AccountAliases renames accounts, keyed by account name or ID, in
+	// generated profile names.
+	AccountAliases map[string]string `yaml:"account_aliases"`
+
+	// AccountAliasesFile is a YAML file of further account aliases. Entries
+	// in AccountAliases take precedence.
+	AccountAliasesFile string `yaml:"account_aliases_file"`
+
 	// Roles limits discovery to matching role names.
 	Roles []string `yaml:"roles"`
 
+	// Filters selects accounts and roles by name, ID, or pattern.
+	Filters ProfileFilters `yaml:"filters"`
+
+	// Organizations adds account tags and OU paths to discovered profiles.
+	Organizations OrganizationsConfig `yaml:"organizations"`
+
+	// ExplainFilters records why each discovered profile was kept or dropped.
+	ExplainFilters bool `yaml:"-"`
+
+	// ParallelWorkers controls how many accounts have their roles listed at once.
+	ParallelWorkers int `yaml:"parallel_workers"`
+
 	// SSO contains shared SSO configuration.
 	SSO SSOConfig `yaml:"sso"`
 
+	// SSOSessions lists IAM Identity Center instances to discover, each
+	// written as its own [sso-session] block. When set it takes the place of
+	// SSO, whose values, like Roles, ProfilePrefix, and ProfileTemplate,
+	// become defaults for anything a session leaves unset.
+	SSOSessions []SSOSessionConfig `yaml:"sso_sessions"`
+
 	// TokenCachePaths lists cache locations for SSO tokens.
 	TokenCachePaths []string `yaml:"token_cache_paths"`
 
 	// RoleChains define cross-account role assumptions.
 	RoleChains []RoleChain `yaml:"role_chains"`
 
+	// RoleChainRules generate role chains from discovered profiles.
+	RoleChainRules []RoleChainRule `yaml:"role_chain_rules"`
+
 	// UseCredentialProcess configures profiles to use credential_process.
 	UseCredentialProcess bool `yaml:"use_credential_process"`
+
+	// ConsoleFederationURL is the federation endpoint that exchanges role
+	// credentials for console sign-in tokens. Empty uses the endpoint of the
+	// partition sso_region is in.
+	ConsoleFederationURL string `yaml:"console_federation_url"`
+
+	// CredentialProcessHelper selects the credential_process command:
+	// "granted" (the default) or "cfgctl" to serve credentials itself.
+	CredentialProcessHelper string `yaml:"credential_process_helper"`
+
+	// EndpointURLs overrides the AWS service endpoints, e.g. for LocalStack.
+	EndpointURLs EndpointURLsConfig `yaml:"endpoint_urls"`
 }
 
 // SSOConfig represents shared SSO configuration.
@@ -85,14 +155,43 @@ type SSOConfig struct {
 	RegistrationScopes string `yaml:"registration_scopes"`
 	SessionName        string `yaml:"session_name"`
 	StartURL           string `yaml:"start_url"`
+
+	// Login selects how expired sessions are renewed: "cli" (the default)
+	// runs `aws sso login`, and "native" runs the device authorization flow
+	// directly, without the AWS CLI.
+	Login string `yaml:"login"`
+}
+
+// SSOSessionConfig configures one IAM Identity Center instance in SSOSessions.
+type SSOSessionConfig struct {
+	SSOConfig `yaml:",inline"`
+
+	// Roles limits discovery in this session to matching role names.
+	Roles []string `yaml:"roles"`
+
+	// Filters selects accounts and roles in this session.
+	Filters ProfileFilters `yaml:"filters"`
+
+	// ProfilePrefix is prepended to profile names generated for this session.
+	ProfilePrefix string `yaml:"profile_prefix"`
+
+	// ProfileTemplate is the template used for this session's profile names.
+	ProfileTemplate string `yaml:"profile_template"`
 }
 
-// RoleChain defines a cross-account role assumption profile.
+// RoleChain defines a cross-account role assumption profile. It assumes
+// RoleARN with the credentials of SourceProfile, or of CredentialSource
+// (Environment, Ec2InstanceMetadata, or EcsContainer) when there is none.
 type RoleChain struct {
-	Name          string `yaml:"name"`
-	Region        string `yaml:"region,omitempty"`
-	RoleARN       string `yaml:"role_arn"`
-	SourceProfile string `yaml:"source_profile"`
+	Name             string `yaml:"name"`
+	Region           string `yaml:"region,omitempty"`
+	RoleARN          string `yaml:"role_arn"`
+	SourceProfile    string `yaml:"source_profile,omitempty"`
+	CredentialSource string `yaml:"credential_source,omitempty"`
+	MFASerial        string `yaml:"mfa_serial,omitempty"`
+	ExternalID       string `yaml:"external_id,omitempty"`
+	RoleSessionName  string `yaml:"role_session_name,omitempty"`
+	DurationSeconds  int    `yaml:"duration_seconds,omitempty"`
 }
 
 // ConfigFromMap builds a typed configuration from a raw provider map.
@@ -131,6 +230,10 @@ func ConfigFromMap(raw map[string]interface{}) (*Config, error) {
 		cfg.MarkerKey = defaultMarkerKey
 	}
 
+	if cfg.ParallelWorkers == 0 {
+		cfg.ParallelWorkers = defaultParallelWorkers
+	}
+
 	if cfg.SSO.RegistrationScopes == "" {
 		cfg.SSO.RegistrationScopes = defaultSSOScopes
 	}
@@ -150,6 +253,7 @@ func DefaultConfig() *Config {
 		Enabled:              true,
 		GenerateCredentials:  false,
 		MarkerKey:            defaultMarkerKey,
+		ParallelWorkers:      defaultParallelWorkers,
 		ProfilePrefix:        "",
 		ProfileTemplate:      defaultProfileTemplate,
 		Prune:                false,
@@ -167,18 +271,15 @@ func (c *Config) Validate() error {
 		return errors.New("aws config is nil")
 	}
 
-	if !c.Demo {
-		if strings.TrimSpace(c.SSO.StartURL) == "" {
-			return errSSOStartURLEmpty
-		}
-
-		if strings.TrimSpace(c.SSO.Region) == "" {
-			return errSSORegionEmpty
-		}
+	if !c.Demo && len(c.TokenCachePaths) == 0 {
+		return errTokenCachePathsEmpty
+	}
 
-		if len(c.TokenCachePaths) == 0 {
-			return errTokenCachePathsEmpty
-		}
+	if c.ParallelWorkers < 0 {
+		return errParallelWorkersBound
+	}
+	if c.ParallelWorkers == 0 {
+		c.ParallelWorkers = defaultParallelWorkers
 	}
 
 	configPath, err := normalizeConfigPath(c.ConfigPath)
@@ -186,8 +287,10 @@ func (c *Config) Validate() error {
 		return err
 	}
 
+	// The credentials path is normalized even when it is not generated,
+	// since lint still reads it.
 	credentialsPath := ""
-	if c.GenerateCredentials {
+	if c.GenerateCredentials || strings.TrimSpace(c.CredentialsPath) != "" {
 		credentialsPath, err = normalizeCredentialsPath(c.CredentialsPath)
 		if err != nil {
 			return err
@@ -202,8 +305,9 @@ func (c *Config) Validate() error {
 		normalized = append(normalized, normalizedPath)
 	}
 
-	c.SSO.Region = strings.TrimSpace(c.SSO.Region)
-	c.SSO.StartURL = strings.TrimSpace(c.SSO.StartURL)
+	if err := c.SSO.normalize(); err != nil {
+		return err
+	}
 	if c.Demo {
 		if c.SSO.Region == "" {
 			c.SSO.Region = defaultDemoRegion
@@ -212,24 +316,215 @@ func (c *Config) Validate() error {
 			c.SSO.StartURL = defaultDemoStartURL
 		}
 	}
+	if c.SSO.Login == "" {
+		c.SSO.Login = ssoLoginCLI
+	}
 	if c.SSO.SessionName == "" {
 		c.SSO.SessionName = defaultSSOSessionName
 	}
 	if strings.TrimSpace(c.SSO.RegistrationScopes) == "" {
 		c.SSO.RegistrationScopes = defaultSSOScopes
 	}
+	for i := range c.SSOSessions {
+		if err := c.SSOSessions[i].normalize(); err != nil {
+			return err
+		}
+	}
 	if strings.TrimSpace(c.ProfileTemplate) == "" {
 		c.ProfileTemplate = defaultProfileTemplate
 	}
 	if strings.TrimSpace(c.MarkerKey) == "" {
 		c.MarkerKey = defaultMarkerKey
 	}
-	c.ConfigPath = configPath
-	if c.GenerateCredentials {
-		c.CredentialsPath = credentialsPath
+	switch strings.TrimSpace(c.CredentialProcessHelper) {
+	case "":
+		c.CredentialProcessHelper = credentialProcessGranted
+	case credentialProcessGranted, credentialProcessCfgctl:
+		c.CredentialProcessHelper = strings.TrimSpace(c.CredentialProcessHelper)
+	default:
+		return fmt.Errorf("%w: %q", errCredentialProcessCmd, c.CredentialProcessHelper)
+	}
+	c.ConsoleFederationURL = strings.TrimSpace(c.ConsoleFederationURL)
+	if err := ValidateEndpointURL(c.ConsoleFederationURL); err != nil {
+		return fmt.Errorf("console_federation_url: %w", err)
+	}
+	if err := c.EndpointURLs.normalize(); err != nil {
+		return err
+	}
+	c.Organizations.Profile = strings.TrimSpace(c.Organizations.Profile)
+	c.Organizations.Region = strings.TrimSpace(c.Organizations.Region)
+	if c.Organizations.Enabled && c.Organizations.Region == "" {
+		c.Organizations.Region = defaultOrganizationsRegion
 	}
+	c.ConfigPath = configPath
+	c.CredentialsPath = credentialsPath
 	c.TokenCachePaths = normalized
 
+	if err := c.loadAccountAliasesFile(); err != nil {
+		return err
+	}
+	if _, err := compileProfileAttributes(c.ProfileAttributes, c.MarkerKey); err != nil {
+		return err
+	}
+	if _, err := compileRegionVariants(c.RegionVariants); err != nil {
+		return err
+	}
+	if err := c.validateRoleChains(); err != nil {
+		return err
+	}
+
+	return c.validateSessions()
+}
+
+// loadAccountAliasesFile merges AccountAliasesFile into AccountAliases,
+// keeping the entries already configured inline.
+func (c *Config) loadAccountAliasesFile() error {
+	if strings.TrimSpace(c.AccountAliasesFile) == "" {
+		return nil
+	}
+
+	path, err := normalizePath(c.AccountAliasesFile)
+	if err != nil {
+		return err
+	}
+	aliases, err := loadAccountAliases(path)
+	if err != nil {
+		return err
+	}
+	if c.AccountAliases == nil {
+		c.AccountAliases = make(map[string]string, len(aliases))
+	}
+	for name, alias := range aliases {
+		if _, ok := c.AccountAliases[name]; !ok {
+			c.AccountAliases[name] = alias
+		}
+	}
+	c.AccountAliasesFile = path
+	return nil
+}
+
+// validateSessions checks that every session has a start URL and region once
+// defaults are applied, that session names are unique, and that filters
+// compile.
+func (c *Config) validateSessions() error {
+	seen := make(map[string]bool, len(c.SSOSessions))
+	for _, session := range c.sessionConfigs() {
+		name := session.SSO.SessionName
+		if seen[name] {
+			return fmt.Errorf("%w: %q", errSSOSessionDuplicate, name)
+		}
+		seen[name] = true
+
+		if _, err := newProfileFilter(session.Roles, session.Filters); err != nil {
+			return sessionError(c, name, err)
+		}
+		if c.Demo {
+			continue
+		}
+		if session.SSO.StartURL == "" {
+			return sessionError(c, name, errSSOStartURLEmpty)
+		}
+		if session.SSO.Region == "" {
+			return sessionError(c, name, errSSORegionEmpty)
+		}
+	}
+	return nil
+}
+
+// sessionError names the session in err when sso_sessions is in use.
+func sessionError(c *Config, name string, err error) error {
+	if len(c.SSOSessions) == 0 {
+		return err
+	}
+	return fmt.Errorf("sso session %q: %w", name, err)
+}
+
+// sessionConfigs returns one configuration per IAM Identity Center session.
+// Without SSOSessions that is c itself. Otherwise each session gets a copy of
+// c with its SSO settings, roles, prefix, and template, falling back to the
+// top-level values for anything it leaves unset.
+func (c *Config) sessionConfigs() []*Config {
+	if len(c.SSOSessions) == 0 {
+		return []*Config{c}
+	}
+
+	configs := make([]*Config, 0, len(c.SSOSessions))
+	for _, session := range c.SSOSessions {
+		cfg := *c
+		cfg.SSOSessions = nil
+		cfg.SSO = session.SSOConfig.withDefaults(c.SSO)
+		if session.Roles != nil {
+			cfg.Roles = session.Roles
+		}
+		if !session.Filters.IsZero() {
+			cfg.Filters = session.Filters
+		}
+		if session.ProfilePrefix != "" {
+			cfg.ProfilePrefix = session.ProfilePrefix
+		}
+		if strings.TrimSpace(session.ProfileTemplate) != "" {
+			cfg.ProfileTemplate = session.ProfileTemplate
+		}
+		configs = append(configs, &cfg)
+	}
+	return configs
+}
+
+// sessionNames returns the names of the configured sessions, in order.
+func (c *Config) sessionNames() []string {
+	sessions := c.sessionConfigs()
+	names := make([]string, 0, len(sessions))
+	for _, session := range sessions {
+		names = append(names, session.SSO.SessionName)
+	}
+	return names
+}
+
+// normalize trims the settings and checks the login method.
+func (s *SSOConfig) normalize() error {
+	s.Region = strings.TrimSpace(s.Region)
+	s.StartURL = strings.TrimSpace(s.StartURL)
+	s.SessionName = strings.TrimSpace(s.SessionName)
+	s.Login = strings.ToLower(strings.TrimSpace(s.Login))
+	switch s.Login {
+	case "", ssoLoginNative, ssoLoginCLI:
+		return nil
+	default:
+		return fmt.Errorf("%w: %q", errSSOLoginInvalid, s.Login)
+	}
+}
+
+// withDefaults fills the settings s leaves empty from defaults.
+func (s SSOConfig) withDefaults(defaults SSOConfig) SSOConfig {
+	if s.Region == "" {
+		s.Region = defaults.Region
+	}
+	if strings.TrimSpace(s.RegistrationScopes) == "" {
+		s.RegistrationScopes = defaults.RegistrationScopes
+	}
+	if s.SessionName == "" {
+		s.SessionName = defaults.SessionName
+	}
+	if s.StartURL == "" {
+		s.StartURL = defaults.StartURL
+	}
+	if s.Login == "" {
+		s.Login = defaults.Login
+	}
+	return s
+}
+
+// RequiredTools returns the AWS CLI when sessions are renewed with
+// `aws sso login`. Demo runs and native logins need no external tools.
+func (c *Config) RequiredTools() []string {
+	if c == nil || c.Demo {
+		return nil
+	}
+	for _, session := range c.sessionConfigs() {
+		if session.SSO.Login != ssoLoginNative {
+			return []string{"aws"}
+		}
+	}
 	return nil
 }
 
@@ -248,6 +543,7 @@ func defaultSSOConfig() SSOConfig {
 		RegistrationScopes: defaultSSOScopes,
 		SessionName:        defaultSSOSessionName,
 		StartURL:           "",
+		Login:              ssoLoginCLI,
 	}
 }
 
@@ -1,6 +1,7 @@
 package aws
 
 import (
+	"os"
 	"path/filepath"
 	"reflect"
 	"testing"
@@ -112,11 +113,17 @@ func TestConfigValidate(t *testing.T) {
 	cfg.SSO.Region = testRegion
 	cfg.SSO.StartURL = testStartURL
 	cfg.TokenCachePaths = []string{"~/.aws/sso/cache"}
+	cfg.CredentialsPath = "~/.aws/credentials"
 
 	if err := cfg.Validate(); err != nil {
 		t.Fatalf("Validate failed: %v", err)
 	}
 
+	// Lint reads the credentials file even when it is not generated.
+	if cfg.CredentialsPath != filepath.Join(home, ".aws", "credentials") {
+		t.Fatalf("credentials path = %q", cfg.CredentialsPath)
+	}
+
 	expected := filepath.Join(home, ".aws", "sso", "cache")
 	if cfg.TokenCachePaths[0] != expected {
 		t.Fatalf("normalized path = %q", cfg.TokenCachePaths[0])
@@ -220,6 +227,38 @@ func TestConfigFromMapWithEmptyMarkerKey(t *testing.T) {
 	}
 }
 
+func TestConfigFromMapWithOrganizations(t *testing.T) {
+	raw := map[string]interface{}{
+		"sso": map[string]interface{}{
+			"region":    testRegion,
+			"start_url": testStartURL,
+		},
+		"organizations": map[string]interface{}{
+			"enabled": true,
+			"profile": " management ",
+		},
+		"filters": map[string]interface{}{
+			"include": []interface{}{
+				map[string]interface{}{"ous": []interface{}{"/Workloads/*"}, "tags": map[string]interface{}{"env": "prod"}},
+			},
+		},
+	}
+	cfg, err := ConfigFromMap(raw)
+	if err != nil {
+		t.Fatalf("ConfigFromMap failed: %v", err)
+	}
+	cfg.TokenCachePaths = []string{t.TempDir()}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("Validate failed: %v", err)
+	}
+	if cfg.Organizations.Profile != "management" || cfg.Organizations.Region != defaultOrganizationsRegion {
+		t.Fatalf("organizations = %+v", cfg.Organizations)
+	}
+	if rule := cfg.Filters.Include[0]; rule.OUs[0] != "/Workloads/*" || rule.Tags["env"] != "prod" {
+		t.Fatalf("include rule = %+v", rule)
+	}
+}
+
 func TestNormalizeConfigPath(t *testing.T) {
 	tests := []struct {
 		name    string
@@ -332,6 +371,138 @@ func TestConfigValidateErrors(t *testing.T) {
 				return &cfg
 			}(),
 		},
+		{
+			name: "unknown sso login",
+			cfg: func() *Config {
+				cfg := *base
+				cfg.SSO.Login = "browser"
+				return &cfg
+			}(),
+		},
+		{
+			name: "duplicate sso session",
+			cfg: func() *Config {
+				cfg := *base
+				cfg.SSOSessions = []SSOSessionConfig{
+					{SSOConfig: SSOConfig{StartURL: testStartURL}},
+					{SSOConfig: SSOConfig{StartURL: "https://acquired.awsapps.com/start"}},
+				}
+				return &cfg
+			}(),
+		},
+		{
+			name: "sso session missing start url",
+			cfg: func() *Config {
+				cfg := *base
+				cfg.SSO.StartURL = ""
+				cfg.SSOSessions = []SSOSessionConfig{{SSOConfig: SSOConfig{SessionName: "prod"}}}
+				return &cfg
+			}(),
+		},
+		{
+			name: "negative parallel workers",
+			cfg: func() *Config {
+				cfg := *base
+				cfg.ParallelWorkers = -1
+				return &cfg
+			}(),
+		},
+		{
+			name: "invalid filter pattern",
+			cfg: func() *Config {
+				cfg := *base
+				cfg.Filters = ProfileFilters{Include: []FilterRule{{Accounts: []string{"prod-["}}}}
+				return &cfg
+			}(),
+		},
+		{
+			name: "reserved profile attribute",
+			cfg: func() *Config {
+				cfg := *base
+				cfg.ProfileAttributes = []ProfileAttributeRule{{Set: map[string]string{"sso_role_name": "Admin"}}}
+				return &cfg
+			}(),
+		},
+		{
+			name: "marker key profile attribute",
+			cfg: func() *Config {
+				cfg := *base
+				cfg.ProfileAttributes = []ProfileAttributeRule{{Set: map[string]string{defaultMarkerKey: "false"}}}
+				return &cfg
+			}(),
+		},
+		{
+			name: "reserved profile attribute in another case",
+			cfg: func() *Config {
+				cfg := *base
+				cfg.ProfileAttributes = []ProfileAttributeRule{{Set: map[string]string{"SSO_Account_ID": "111111111111"}}}
+				return &cfg
+			}(),
+		},
+		{
+			name: "marker key profile attribute in another case",
+			cfg: func() *Config {
+				cfg := *base
+				cfg.ProfileAttributes = []ProfileAttributeRule{{Set: map[string]string{"SSO_Auto_Populated": "false"}}}
+				return &cfg
+			}(),
+		},
+		{
+			name: "profile attribute set twice",
+			cfg: func() *Config {
+				cfg := *base
+				cfg.ProfileAttributes = []ProfileAttributeRule{{Set: map[string]string{"Region": "us-west-2", "region": "us-east-1"}}}
+				return &cfg
+			}(),
+		},
+		{
+			name: "invalid profile attribute key",
+			cfg: func() *Config {
+				cfg := *base
+				cfg.ProfileAttributes = []ProfileAttributeRule{{Set: map[string]string{"cli pager": ""}}}
+				return &cfg
+			}(),
+		},
+		{
+			name: "role chain with two sources",
+			cfg: func() *Config {
+				cfg := *base
+				cfg.RoleChains = []RoleChain{{Name: "ci", RoleARN: "arn:aws:iam::111111111111:role/Deploy", SourceProfile: "prod", CredentialSource: "Environment"}}
+				return &cfg
+			}(),
+		},
+		{
+			name: "role chain duration out of range",
+			cfg: func() *Config {
+				cfg := *base
+				cfg.RoleChains = []RoleChain{{Name: "ci", RoleARN: "arn:aws:iam::111111111111:role/Deploy", SourceProfile: "prod", DurationSeconds: 60}}
+				return &cfg
+			}(),
+		},
+		{
+			name: "role chain rule invalid account id",
+			cfg: func() *Config {
+				cfg := *base
+				cfg.RoleChainRules = []RoleChainRule{{RoleName: "ReadOnly", TargetAccountIDs: []string{"prod"}}}
+				return &cfg
+			}(),
+		},
+		{
+			name: "relative console federation url",
+			cfg: func() *Config {
+				cfg := *base
+				cfg.ConsoleFederationURL = "signin.aws.amazon.com/federation"
+				return &cfg
+			}(),
+		},
+		{
+			name: "missing account aliases file",
+			cfg: func() *Config {
+				cfg := *base
+				cfg.AccountAliasesFile = "/nonexistent/aliases.yaml"
+				return &cfg
+			}(),
+		},
 		{
 			name: "relative cache path",
 			cfg: func() *Config {
@@ -379,3 +550,112 @@ func TestConfigValidateErrors(t *testing.T) {
 		})
 	}
 }
+
+func TestConfigFromMapWithSSOSessions(t *testing.T) {
+	raw := map[string]interface{}{
+		"roles": []interface{}{"Admin"},
+		"sso": map[string]interface{}{
+			"region": testRegion,
+		},
+		"sso_sessions": []interface{}{
+			map[string]interface{}{
+				"session_name": "prod",
+				"start_url":    testStartURL,
+			},
+			map[string]interface{}{
+				"session_name":   "acquired",
+				"start_url":      "https://acquired.awsapps.com/start",
+				"region":         "eu-west-1",
+				"roles":          []interface{}{"ReadOnly"},
+				"profile_prefix": "acq-",
+			},
+		},
+		"token_cache_paths": []interface{}{"/cache"},
+	}
+
+	cfg, err := ConfigFromMap(raw)
+	if err != nil {
+		t.Fatalf("ConfigFromMap failed: %v", err)
+	}
+	cfg.ConfigPath = "/tmp/config"
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("Validate failed: %v", err)
+	}
+
+	sessions := cfg.sessionConfigs()
+	if len(sessions) != 2 {
+		t.Fatalf("sessions = %d, want 2", len(sessions))
+	}
+
+	prod, acquired := sessions[0], sessions[1]
+	if prod.SSO.SessionName != "prod" || prod.SSO.Region != testRegion || prod.SSO.Login != ssoLoginCLI {
+		t.Fatalf("prod session did not inherit defaults: %+v", prod.SSO)
+	}
+	if !reflect.DeepEqual(prod.Roles, []string{"Admin"}) || prod.ProfilePrefix != "" {
+		t.Fatalf("prod session roles = %v, prefix = %q", prod.Roles, prod.ProfilePrefix)
+	}
+	if acquired.SSO.Region != "eu-west-1" || acquired.SSO.RegistrationScopes != defaultSSOScopes {
+		t.Fatalf("unexpected acquired session: %+v", acquired.SSO)
+	}
+	if !reflect.DeepEqual(acquired.Roles, []string{"ReadOnly"}) || acquired.ProfilePrefix != "acq-" {
+		t.Fatalf("acquired session roles = %v, prefix = %q", acquired.Roles, acquired.ProfilePrefix)
+	}
+	if !reflect.DeepEqual(cfg.sessionNames(), []string{"prod", "acquired"}) {
+		t.Fatalf("session names = %v", cfg.sessionNames())
+	}
+}
+
+func TestConfigValidateLoadsAccountAliasesFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "aliases.yaml")
+	content := "\"123456789012\": payments\nAcme Legacy Prod: legacy\n"
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("write aliases: %v", err)
+	}
+
+	cfg := DefaultConfig()
+	cfg.SSO.Region = testRegion
+	cfg.SSO.StartURL = testStartURL
+	cfg.TokenCachePaths = []string{"/cache"}
+	cfg.ConfigPath = "/tmp/config"
+	cfg.AccountAliasesFile = path
+	cfg.AccountAliases = map[string]string{"123456789012": "pay"}
+
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("Validate failed: %v", err)
+	}
+
+	expected := map[string]string{"123456789012": "pay", "Acme Legacy Prod": "legacy"}
+	if !reflect.DeepEqual(cfg.AccountAliases, expected) {
+		t.Fatalf("account aliases = %#v, want %#v", cfg.AccountAliases, expected)
+	}
+}
+
+func TestConfigRequiredTools(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  *Config
+		want []string
+	}{
+		{name: "default cli login", cfg: &Config{}, want: []string{"aws"}},
+		{name: "native login", cfg: &Config{SSO: SSOConfig{Login: ssoLoginNative}}},
+		{name: "demo", cfg: &Config{Demo: true}},
+		{
+			name: "one cli session",
+			cfg: &Config{
+				SSO: SSOConfig{Login: ssoLoginNative},
+				SSOSessions: []SSOSessionConfig{
+					{SSOConfig: SSOConfig{SessionName: "a"}},
+					{SSOConfig: SSOConfig{SessionName: "b", Login: ssoLoginCLI}},
+				},
+			},
+			want: []string{"aws"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.cfg.RequiredTools(); !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("RequiredTools() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
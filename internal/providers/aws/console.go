@@ -0,0 +1,203 @@
+package aws
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	defaultConsoleFederationURL  = "https://signin.aws.amazon.com/federation"
+	defaultConsoleURL            = "https://console.aws.amazon.com"
+	govCloudConsoleFederationURL = "https://signin.amazonaws-us-gov.com/federation"
+	govCloudConsoleURL           = "https://console.amazonaws-us-gov.com"
+	chinaConsoleFederationURL    = "https://signin.amazonaws.cn/federation"
+	chinaConsoleURL              = "https://console.amazonaws.cn"
+	consoleIssuer                = "cfgctl"
+	consoleRequestTimeout        = 30 * time.Second
+)
+
+var errSigninTokenEmpty = errors.New("federation endpoint returned no sign-in token")
+
+// consoleServicePaths maps service names to their console paths where the
+// path is not simply "<service>/home".
+var consoleServicePaths = map[string]string{
+	"billing":        "billing/home",
+	"cloudformation": "cloudformation/home",
+	"cloudwatch":     "cloudwatch/home",
+	"dynamodb":       "dynamodbv2/home",
+	"ecr":            "ecr/home",
+	"ecs":            "ecs/v2/home",
+	"eks":            "eks/home",
+	"iam":            "iam/home",
+	"lambda":         "lambda/home",
+	"logs":           "cloudwatch/home#logsV2:",
+	"rds":            "rds/home",
+	"route53":        "route53/v2/home",
+	"s3":             "s3/home",
+	"secretsmanager": "secretsmanager/home",
+	"sqs":            "sqs/v3/home",
+	"ssm":            "systems-manager/home",
+	"vpc":            "vpc/home",
+}
+
+var consoleHTTPClient = &http.Client{Timeout: consoleRequestTimeout}
+
+// ConsoleOptions selects where a console sign-in URL lands.
+type ConsoleOptions struct {
+	// Service is a console service such as ec2 or s3; empty opens the
+	// console home page.
+	Service string
+
+	// Region overrides the profile's region.
+	Region string
+}
+
+// ConsoleURL returns a console sign-in URL for the SSO profile named profile,
+// exchanging its role credentials for a federation sign-in token.
+func ConsoleURL(ctx context.Context, cfg *Config, profile, cacheDir string, opts ConsoleOptions) (string, error) {
+	if cfg == nil {
+		return "", errors.New("aws config is nil")
+	}
+
+	target, err := resolveProfile(cfg, profile)
+	if err != nil {
+		return "", err
+	}
+	creds, err := targetCredentials(ctx, target, cacheDir, NewSSOClientFactory(cfg.EndpointURLs.SSO), time.Now().UTC())
+	if err != nil {
+		return "", err
+	}
+
+	region := opts.Region
+	if region == "" {
+		region = target.region
+	}
+	if region == "" {
+		region = target.session.SSO.Region
+	}
+	federationURL, consoleURL := consoleEndpoints(target.session.SSO.Region)
+	if cfg.ConsoleFederationURL != "" {
+		federationURL = cfg.ConsoleFederationURL
+	}
+	return consoleSigninURL(ctx, federationURL, creds, consoleDestination(consoleURL, opts.Service, region))
+}
+
+// arnPartition returns the ARN partition region is in: aws-us-gov for
+// us-gov-*, aws-cn for cn-*, and aws otherwise.
+func arnPartition(region string) string {
+	region = strings.ToLower(strings.TrimSpace(region))
+	switch {
+	case strings.HasPrefix(region, "us-gov-"):
+		return "aws-us-gov"
+	case strings.HasPrefix(region, "cn-"):
+		return "aws-cn"
+	default:
+		return "aws"
+	}
+}
+
+// consoleEndpoints returns the federation endpoint and console URL of the
+// partition region is in.
+func consoleEndpoints(region string) (string, string) {
+	switch arnPartition(region) {
+	case "aws-us-gov":
+		return govCloudConsoleFederationURL, govCloudConsoleURL
+	case "aws-cn":
+		return chinaConsoleFederationURL, chinaConsoleURL
+	default:
+		return defaultConsoleFederationURL, defaultConsoleURL
+	}
+}
+
+// OpenBrowser opens url in the user's browser.
+func OpenBrowser(url string) error {
+	return openBrowser(url)
+}
+
+// consoleDestination returns the page of the console at consoleURL for
+// service in region.
+func consoleDestination(consoleURL, service, region string) string {
+	service = strings.ToLower(strings.TrimSpace(service))
+	path := "console/home"
+	if service != "" {
+		path = service + "/home"
+		if known, ok := consoleServicePaths[service]; ok {
+			path = known
+		}
+	}
+
+	destination := consoleURL + "/" + path
+	if region == "" {
+		return destination
+	}
+	// Fragments such as "#logsV2:" must follow the query string.
+	base, fragment, _ := strings.Cut(destination, "#")
+	destination = base + "?region=" + url.QueryEscape(region)
+	if fragment != "" {
+		destination += "#" + fragment
+	}
+	return destination
+}
+
+// consoleSigninURL exchanges creds for a sign-in token at the federation
+// endpoint and returns the login URL that lands on destination.
+func consoleSigninURL(ctx context.Context, federationURL string, creds RoleCredentials, destination string) (string, error) {
+	if strings.TrimSpace(federationURL) == "" {
+		federationURL = defaultConsoleFederationURL
+	}
+
+	session, err := json.Marshal(map[string]string{
+		"sessionId":    creds.AccessKeyID,
+		"sessionKey":   creds.SecretAccessKey,
+		"sessionToken": creds.SessionToken,
+	})
+	if err != nil {
+		return "", fmt.Errorf("encode federation session: %w", err)
+	}
+
+	query := url.Values{}
+	query.Set("Action", "getSigninToken")
+	query.Set("Session", string(session))
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, federationURL+"?"+query.Encode(), nil)
+	if err != nil {
+		return "", fmt.Errorf("create federation request: %w", err)
+	}
+
+	resp, err := consoleHTTPClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("request sign-in token: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return "", fmt.Errorf("read sign-in token: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("request sign-in token: %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+
+	var token struct {
+		SigninToken string `json:"SigninToken"`
+	}
+	if err := json.Unmarshal(body, &token); err != nil {
+		return "", fmt.Errorf("decode sign-in token: %w", err)
+	}
+	if token.SigninToken == "" {
+		return "", errSigninTokenEmpty
+	}
+
+	login := url.Values{}
+	login.Set("Action", "login")
+	login.Set("Issuer", consoleIssuer)
+	login.Set("Destination", destination)
+	login.Set("SigninToken", token.SigninToken)
+	return federationURL + "?" + login.Encode(), nil
+}
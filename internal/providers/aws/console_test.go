@@ -0,0 +1,120 @@
+package aws
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestConsoleDestination(t *testing.T) {
+	tests := []struct {
+		service string
+		region  string
+		want    string
+	}{
+		{want: "https://console.aws.amazon.com/console/home"},
+		{region: "us-west-2", want: "https://console.aws.amazon.com/console/home?region=us-west-2"},
+		{service: "ec2", region: "us-west-2", want: "https://console.aws.amazon.com/ec2/home?region=us-west-2"},
+		{service: "DynamoDB", region: "eu-west-1", want: "https://console.aws.amazon.com/dynamodbv2/home?region=eu-west-1"},
+		{service: "logs", region: "us-east-1", want: "https://console.aws.amazon.com/cloudwatch/home?region=us-east-1#logsV2:"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.service+" "+tt.region, func(t *testing.T) {
+			if got := consoleDestination(defaultConsoleURL, tt.service, tt.region); got != tt.want {
+				t.Fatalf("consoleDestination() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConsoleEndpoints(t *testing.T) {
+	tests := []struct {
+		region     string
+		federation string
+		console    string
+	}{
+		{region: "us-east-1", federation: defaultConsoleFederationURL, console: defaultConsoleURL},
+		{region: "", federation: defaultConsoleFederationURL, console: defaultConsoleURL},
+		{region: "us-gov-west-1", federation: "https://signin.amazonaws-us-gov.com/federation", console: "https://console.amazonaws-us-gov.com"},
+		{region: "cn-north-1", federation: "https://signin.amazonaws.cn/federation", console: "https://console.amazonaws.cn"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.region, func(t *testing.T) {
+			federation, console := consoleEndpoints(tt.region)
+			if federation != tt.federation || console != tt.console {
+				t.Fatalf("consoleEndpoints(%q) = %q, %q, want %q, %q", tt.region, federation, console, tt.federation, tt.console)
+			}
+		})
+	}
+}
+
+func TestConsoleSigninURL(t *testing.T) {
+	creds := RoleCredentials{AccessKeyID: "AKIA", SecretAccessKey: "secret", SessionToken: "session", Expiration: time.Now().Add(time.Hour)}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("Action") != "getSigninToken" {
+			http.Error(w, "unexpected action", http.StatusBadRequest)
+			return
+		}
+		var session map[string]string
+		if err := json.Unmarshal([]byte(r.URL.Query().Get("Session")), &session); err != nil || session["sessionId"] != "AKIA" || session["sessionToken"] != "session" {
+			http.Error(w, "bad session", http.StatusBadRequest)
+			return
+		}
+		_, _ = w.Write([]byte(`{"SigninToken":"signin-token"}`))
+	}))
+	defer server.Close()
+
+	destination := consoleDestination(defaultConsoleURL, "s3", "us-west-2")
+	signin, err := consoleSigninURL(context.Background(), server.URL, creds, destination)
+	if err != nil {
+		t.Fatalf("consoleSigninURL failed: %v", err)
+	}
+
+	parsed, err := url.Parse(signin)
+	if err != nil {
+		t.Fatalf("parse sign-in url: %v", err)
+	}
+	query := parsed.Query()
+	if query.Get("Action") != "login" || query.Get("SigninToken") != "signin-token" || query.Get("Destination") != destination || query.Get("Issuer") != consoleIssuer {
+		t.Fatalf("unexpected sign-in url: %s", signin)
+	}
+}
+
+func TestConsoleSigninURLErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		body    string
+		wantErr error
+	}{
+		{name: "status", status: http.StatusBadRequest, body: "nope"},
+		{name: "empty token", status: http.StatusOK, body: `{}`, wantErr: errSigninTokenEmpty},
+		{name: "invalid json", status: http.StatusOK, body: `not json`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				w.WriteHeader(tt.status)
+				_, _ = w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			_, err := consoleSigninURL(context.Background(), server.URL, RoleCredentials{}, consoleDestination(defaultConsoleURL, "", ""))
+			if err == nil {
+				t.Fatal("expected error")
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Fatalf("expected %v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
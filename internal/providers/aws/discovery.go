@@ -4,16 +4,20 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"slices"
 	"sort"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/sso"
 	"github.com/aws/aws-sdk-go-v2/service/sso/types"
+	"github.com/jmreicha/cfgctl/internal/core"
+	"golang.org/x/sync/errgroup"
 )
 
-var errSSOLoginRequired = errors.New("sso session missing or expired, run 'aws sso login' to refresh")
+var errSSOLoginRequired = errors.New("sso session missing or expired, sign in to refresh it")
 
 // DiscoveredProfile represents an account/role from SSO.
 type DiscoveredProfile struct {
@@ -22,55 +26,78 @@ type DiscoveredProfile struct {
 	RoleName    string
 	SSORegion   string
 	SSOStartURL string
+	SSOSession  string
+
+	// Tags and OU come from Organizations when it is enabled.
+	Tags map[string]string
+	OU   string
 }
 
 // DiscoverProfiles uses the AWS SSO API to enumerate accounts and roles.
-func DiscoverProfiles(ctx context.Context, cfg *Config, factory SSOClientFactory) ([]DiscoveredProfile, error) {
-	return discoverProfiles(ctx, cfg, factory, LoadMatchingToken, time.Now().UTC())
+// Progress events are sent to progress, which may be nil. Accounts whose roles
+// cannot be listed are skipped: their IDs are returned after the warnings that
+// report them.
+func DiscoverProfiles(ctx context.Context, cfg *Config, factory SSOClientFactory, progress core.ProgressReporter) ([]DiscoveredProfile, []string, []string, error) {
+	return discoverProfiles(ctx, cfg, factory, LoadMatchingToken, time.Now().UTC(), progress)
 }
 
 type tokenLoader func(cachePaths []string, startURL, region string, now time.Time) (SSOToken, error)
 
-func discoverProfiles(ctx context.Context, cfg *Config, factory SSOClientFactory, loader tokenLoader, now time.Time) ([]DiscoveredProfile, error) {
+func discoverProfiles(ctx context.Context, cfg *Config, factory SSOClientFactory, loader tokenLoader, now time.Time, progress core.ProgressReporter) ([]DiscoveredProfile, []string, []string, error) {
 	if cfg == nil {
-		return nil, errors.New("aws config is nil")
+		return nil, nil, nil, errors.New("aws config is nil")
 	}
 
 	if cfg.Demo {
-		return demoProfiles(cfg), nil
+		if cfg.Fixtures == nil {
+			return demoProfiles(cfg), nil, nil, nil
+		}
+		factory = fixtureSSOClientFactory(cfg.Fixtures)
+		loader = fixtureToken
 	}
 
 	if factory == nil {
-		factory = NewSSOClientFactory()
+		factory = NewSSOClientFactory(cfg.EndpointURLs.SSO)
 	}
 
 	token, err := loader(cfg.TokenCachePaths, cfg.SSO.StartURL, cfg.SSO.Region, now)
 	if err != nil {
 		if errors.Is(err, errNoValidToken) {
-			return nil, errSSOLoginRequired
+			return nil, nil, nil, errSSOLoginRequired
 		}
-		return nil, err
+		return nil, nil, nil, err
 	}
 
 	client, err := factory(ctx, cfg.SSO.Region, token.AccessToken)
 	if err != nil {
-		return nil, fmt.Errorf("create sso client: %w", err)
+		return nil, nil, nil, fmt.Errorf("create sso client: %w", err)
+	}
+
+	limiter := newThrottle()
+	core.ReportProgress(progress, core.ProgressEvent{Kind: core.ProgressStep, Message: "listing sso accounts"})
+	accounts, err := listAccounts(ctx, client, token.AccessToken, limiter)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	// Accounts the filters rule out are dropped before their roles are
+	// listed, which is where filtered runs save time and requests.
+	filter, err := newAccountFilter(cfg)
+	if err != nil {
+		return nil, nil, nil, err
 	}
+	accounts = slices.DeleteFunc(accounts, func(account types.AccountInfo) bool {
+		return !filter.keep(aws.ToString(account.AccountName), aws.ToString(account.AccountId))
+	})
 
-	roleFilter := normalizeRoleFilter(cfg.Roles)
-	accounts, err := listAccounts(ctx, client, token.AccessToken)
+	roles, warnings, skipped, err := enumerateRoles(ctx, cfg, client, token.AccessToken, accounts, limiter, progress)
 	if err != nil {
-		return nil, err
+		return nil, nil, nil, err
 	}
 
 	profiles := []DiscoveredProfile{}
-	for _, account := range accounts {
-		roles, err := listAccountRoles(ctx, client, token.AccessToken, account)
-		if err != nil {
-			return nil, err
-		}
-		for _, role := range roles {
-			if !roleFilter[role] && len(roleFilter) > 0 {
+	for i, account := range accounts {
+		for _, role := range roles[i] {
+			if hasRoleFilter(cfg.Roles) && !core.MatchesAWSRole(role, cfg.Roles) {
 				continue
 			}
 			profiles = append(profiles, DiscoveredProfile{
@@ -79,12 +106,63 @@ func discoverProfiles(ctx context.Context, cfg *Config, factory SSOClientFactory
 				RoleName:    role,
 				SSORegion:   cfg.SSO.Region,
 				SSOStartURL: cfg.SSO.StartURL,
+				SSOSession:  cfg.SSO.SessionName,
 			})
 		}
 	}
 
 	sortProfiles(profiles)
-	return profiles, nil
+	return profiles, warnings, skipped, nil
+}
+
+// enumerateRoles lists the roles of every account, running up to
+// cfg.ParallelWorkers requests at once. The result is indexed like accounts.
+// An account whose roles cannot be listed gets no roles, a warning, and its
+// ID in the skipped list, and discovery only fails if every account does.
+func enumerateRoles(ctx context.Context, cfg *Config, client SSOClient, accessToken string, accounts []types.AccountInfo, limiter *throttle, progress core.ProgressReporter) ([][]string, []string, []string, error) {
+	roles := make([][]string, len(accounts))
+	errs := make([]error, len(accounts))
+
+	var (
+		g    errgroup.Group
+		mu   sync.Mutex
+		done int
+	)
+	g.SetLimit(max(cfg.ParallelWorkers, 1))
+	for i, account := range accounts {
+		g.Go(func() error {
+			roles[i], errs[i] = listAccountRoles(ctx, client, accessToken, account, limiter)
+
+			// Counts are reported under the lock so that they arrive in order.
+			mu.Lock()
+			defer mu.Unlock()
+			done++
+			core.ReportProgress(progress, core.ProgressEvent{
+				Kind:    core.ProgressCount,
+				Message: "accounts",
+				Current: done,
+				Total:   len(accounts),
+			})
+			return nil
+		})
+	}
+	_ = g.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return nil, nil, nil, err
+	}
+
+	var warnings, skipped []string
+	for i, err := range errs {
+		if err != nil {
+			warnings = append(warnings, fmt.Sprintf("skipped account: %v", err))
+			skipped = append(skipped, aws.ToString(accounts[i].AccountId))
+		}
+	}
+	if len(skipped) > 0 && len(skipped) == len(accounts) {
+		return nil, nil, nil, errors.Join(errs...)
+	}
+	return roles, warnings, skipped, nil
 }
 
 func demoProfiles(cfg *Config) []DiscoveredProfile {
@@ -95,6 +173,7 @@ func demoProfiles(cfg *Config) []DiscoveredProfile {
 			RoleName:    "AdminAccess",
 			SSORegion:   cfg.SSO.Region,
 			SSOStartURL: cfg.SSO.StartURL,
+			SSOSession:  cfg.SSO.SessionName,
 		},
 		{
 			AccountID:   "111111111111",
@@ -102,6 +181,7 @@ func demoProfiles(cfg *Config) []DiscoveredProfile {
 			RoleName:    "ReadOnly",
 			SSORegion:   cfg.SSO.Region,
 			SSOStartURL: cfg.SSO.StartURL,
+			SSOSession:  cfg.SSO.SessionName,
 		},
 		{
 			AccountID:   "222222222222",
@@ -109,16 +189,21 @@ func demoProfiles(cfg *Config) []DiscoveredProfile {
 			RoleName:    "AdminAccess",
 			SSORegion:   cfg.SSO.Region,
 			SSOStartURL: cfg.SSO.StartURL,
+			SSOSession:  cfg.SSO.SessionName,
 		},
 	}
 }
 
-func listAccounts(ctx context.Context, client SSOClient, accessToken string) ([]types.AccountInfo, error) {
+func listAccounts(ctx context.Context, client SSOClient, accessToken string, limiter *throttle) ([]types.AccountInfo, error) {
 	input := &sso.ListAccountsInput{AccessToken: aws.String(accessToken)}
 	accounts := []types.AccountInfo{}
 
 	for {
-		output, err := client.ListAccounts(ctx, input)
+		var output *sso.ListAccountsOutput
+		err := limiter.do(ctx, func() (err error) {
+			output, err = client.ListAccounts(ctx, input)
+			return err
+		})
 		if err != nil {
 			return nil, fmt.Errorf("list sso accounts: %w", err)
 		}
@@ -132,7 +217,7 @@ func listAccounts(ctx context.Context, client SSOClient, accessToken string) ([]
 	return accounts, nil
 }
 
-func listAccountRoles(ctx context.Context, client SSOClient, accessToken string, account types.AccountInfo) ([]string, error) {
+func listAccountRoles(ctx context.Context, client SSOClient, accessToken string, account types.AccountInfo, limiter *throttle) ([]string, error) {
 	if account.AccountId == nil || aws.ToString(account.AccountId) == "" {
 		return nil, errors.New("account id missing")
 	}
@@ -144,7 +229,11 @@ func listAccountRoles(ctx context.Context, client SSOClient, accessToken string,
 	roles := []string{}
 
 	for {
-		output, err := client.ListAccountRoles(ctx, input)
+		var output *sso.ListAccountRolesOutput
+		err := limiter.do(ctx, func() (err error) {
+			output, err = client.ListAccountRoles(ctx, input)
+			return err
+		})
 		if err != nil {
 			return nil, fmt.Errorf("list account roles for %s: %w", aws.ToString(account.AccountId), err)
 		}
@@ -165,17 +254,14 @@ func listAccountRoles(ctx context.Context, client SSOClient, accessToken string,
 	return roles, nil
 }
 
-func normalizeRoleFilter(roles []string) map[string]bool {
-	filter := make(map[string]bool)
+// hasRoleFilter reports whether roles contains a non-blank entry.
+func hasRoleFilter(roles []string) bool {
 	for _, role := range roles {
-		trimmed := strings.TrimSpace(role)
-		if trimmed == "" {
-			continue
+		if strings.TrimSpace(role) != "" {
+			return true
 		}
-		filter[trimmed] = true
 	}
-
-	return filter
+	return false
 }
 
 func sortProfiles(profiles []DiscoveredProfile) {
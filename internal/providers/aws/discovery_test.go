@@ -4,12 +4,15 @@ import (
 	"context"
 	"errors"
 	"reflect"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/sso"
 	"github.com/aws/aws-sdk-go-v2/service/sso/types"
+	"github.com/jmreicha/cfgctl/internal/core"
 )
 
 const (
@@ -18,10 +21,14 @@ const (
 )
 
 type mockSSOClient struct {
-	accountsPages []*sso.ListAccountsOutput
-	rolesPages    map[string][]*sso.ListAccountRolesOutput
-	listErr       error
-	rolesErr      error
+	mu              sync.Mutex
+	accountsPages   []*sso.ListAccountsOutput
+	rolesPages      map[string][]*sso.ListAccountRolesOutput
+	listErr         error
+	rolesErr        error
+	accountErrs     map[string]error
+	credentials     *types.RoleCredentials
+	credentialCalls []*sso.GetRoleCredentialsInput
 }
 
 func (m *mockSSOClient) ListAccounts(_ context.Context, _ *sso.ListAccountsInput, _ ...func(*sso.Options)) (*sso.ListAccountsOutput, error) {
@@ -37,12 +44,18 @@ func (m *mockSSOClient) ListAccounts(_ context.Context, _ *sso.ListAccountsInput
 }
 
 func (m *mockSSOClient) ListAccountRoles(_ context.Context, params *sso.ListAccountRolesInput, _ ...func(*sso.Options)) (*sso.ListAccountRolesOutput, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
 	if m.rolesErr != nil {
 		return nil, m.rolesErr
 	}
 	if params == nil || params.AccountId == nil {
 		return nil, errors.New("account id missing")
 	}
+	if err := m.accountErrs[aws.ToString(params.AccountId)]; err != nil {
+		return nil, err
+	}
 	pages := m.rolesPages[aws.ToString(params.AccountId)]
 	if len(pages) == 0 {
 		return &sso.ListAccountRolesOutput{}, nil
@@ -52,6 +65,17 @@ func (m *mockSSOClient) ListAccountRoles(_ context.Context, params *sso.ListAcco
 	return output, nil
 }
 
+func (m *mockSSOClient) GetRoleCredentials(_ context.Context, params *sso.GetRoleCredentialsInput, _ ...func(*sso.Options)) (*sso.GetRoleCredentialsOutput, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.credentialCalls = append(m.credentialCalls, params)
+	if m.credentials == nil {
+		return nil, errors.New("no credentials")
+	}
+	return &sso.GetRoleCredentialsOutput{RoleCredentials: m.credentials}, nil
+}
+
 func TestDiscoverProfiles(t *testing.T) {
 	cfg := DefaultConfig()
 	cfg.SSO.Region = discoveryTestRegion
@@ -109,11 +133,22 @@ func TestDiscoverProfiles(t *testing.T) {
 		}, nil
 	}
 
-	profiles, err := discoverProfiles(context.Background(), cfg, factory, loader, time.Now())
+	var counts []core.ProgressEvent
+	progress := core.ProgressFunc(func(event core.ProgressEvent) {
+		if event.Kind == core.ProgressCount {
+			counts = append(counts, event)
+		}
+	})
+
+	profiles, _, _, err := discoverProfiles(context.Background(), cfg, factory, loader, time.Now(), progress)
 	if err != nil {
 		t.Fatalf("discoverProfiles failed: %v", err)
 	}
 
+	if len(counts) != 2 || counts[1].Current != 2 || counts[1].Total != 2 {
+		t.Fatalf("count events = %+v", counts)
+	}
+
 	expected := []DiscoveredProfile{
 		{
 			AccountID:   "111111111111",
@@ -121,6 +156,7 @@ func TestDiscoverProfiles(t *testing.T) {
 			RoleName:    "Admin",
 			SSORegion:   cfg.SSO.Region,
 			SSOStartURL: cfg.SSO.StartURL,
+			SSOSession:  cfg.SSO.SessionName,
 		},
 		{
 			AccountID:   "111111111111",
@@ -128,6 +164,7 @@ func TestDiscoverProfiles(t *testing.T) {
 			RoleName:    "ReadOnly",
 			SSORegion:   cfg.SSO.Region,
 			SSOStartURL: cfg.SSO.StartURL,
+			SSOSession:  cfg.SSO.SessionName,
 		},
 		{
 			AccountID:   "222222222222",
@@ -135,6 +172,7 @@ func TestDiscoverProfiles(t *testing.T) {
 			RoleName:    "ReadOnly",
 			SSORegion:   cfg.SSO.Region,
 			SSOStartURL: cfg.SSO.StartURL,
+			SSOSession:  cfg.SSO.SessionName,
 		},
 	}
 
@@ -149,7 +187,7 @@ func TestDiscoverProfilesDemo(t *testing.T) {
 	cfg.SSO.Region = discoveryTestRegion
 	cfg.SSO.StartURL = discoveryTestStartURL
 
-	profiles, err := discoverProfiles(context.Background(), cfg, nil, nil, time.Now())
+	profiles, _, _, err := discoverProfiles(context.Background(), cfg, nil, nil, time.Now(), nil)
 	if err != nil {
 		t.Fatalf("discoverProfiles failed: %v", err)
 	}
@@ -158,6 +196,37 @@ func TestDiscoverProfilesDemo(t *testing.T) {
 	}
 }
 
+func TestDiscoverProfilesDemoFixtures(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Demo = true
+	cfg.Roles = []string{"AdminAccess"}
+	cfg.SSO.Region = discoveryTestRegion
+	cfg.SSO.StartURL = discoveryTestStartURL
+	cfg.Fixtures = &core.DemoFixtures{Accounts: []core.AccountFixture{
+		{ID: "333333333333", Name: "staging", Roles: []string{"ReadOnly", "AdminAccess"}},
+		{ID: "444444444444", Name: "audit", Roles: []string{"ReadOnly"}},
+	}}
+
+	profiles, warnings, _, err := discoverProfiles(context.Background(), cfg, nil, nil, time.Now(), nil)
+	if err != nil {
+		t.Fatalf("discoverProfiles failed: %v", err)
+	}
+	if len(warnings) != 0 {
+		t.Fatalf("warnings = %v", warnings)
+	}
+	want := []DiscoveredProfile{{
+		AccountID:   "333333333333",
+		AccountName: "staging",
+		RoleName:    "AdminAccess",
+		SSORegion:   discoveryTestRegion,
+		SSOStartURL: discoveryTestStartURL,
+		SSOSession:  cfg.SSO.SessionName,
+	}}
+	if !reflect.DeepEqual(profiles, want) {
+		t.Fatalf("profiles = %+v, want %+v", profiles, want)
+	}
+}
+
 func TestDiscoverProfilesErrors(t *testing.T) {
 	cfg := DefaultConfig()
 	cfg.SSO.Region = discoveryTestRegion
@@ -168,7 +237,7 @@ func TestDiscoverProfilesErrors(t *testing.T) {
 		return SSOToken{}, errNoValidToken
 	}
 
-	_, err := discoverProfiles(context.Background(), cfg, nil, loader, time.Now())
+	_, _, _, err := discoverProfiles(context.Background(), cfg, nil, loader, time.Now(), nil)
 	if err == nil {
 		t.Fatal("expected error for missing token")
 	}
@@ -190,7 +259,7 @@ func TestDiscoverProfilesErrorsInvalidSession(t *testing.T) {
 		}, nil
 	}
 
-	_, err := discoverProfiles(context.Background(), cfg, nil, loader, time.Now())
+	_, _, _, err := discoverProfiles(context.Background(), cfg, nil, loader, time.Now(), nil)
 	if err == nil {
 		t.Fatal("expected error for mismatched token")
 	}
@@ -223,12 +292,101 @@ func TestDiscoverProfilesErrorsAccountRolesFailure(t *testing.T) {
 		}, nil
 	}
 
-	_, err := discoverProfiles(context.Background(), cfg, factory, loader, time.Now())
+	_, _, _, err := discoverProfiles(context.Background(), cfg, factory, loader, time.Now(), nil)
 	if err == nil {
 		t.Fatal("expected error for account role failure")
 	}
 }
 
+func TestDiscoverProfilesSkipsFailedAccounts(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.SSO.Region = discoveryTestRegion
+	cfg.SSO.StartURL = discoveryTestStartURL
+	cfg.TokenCachePaths = []string{"/cache"}
+
+	factory := func(_ context.Context, _, _ string) (SSOClient, error) {
+		return &mockSSOClient{
+			accountsPages: []*sso.ListAccountsOutput{
+				{
+					AccountList: []types.AccountInfo{
+						{AccountId: aws.String("111111111111"), AccountName: aws.String("prod")},
+						{AccountId: aws.String("222222222222"), AccountName: aws.String("staging")},
+					},
+				},
+			},
+			rolesPages: map[string][]*sso.ListAccountRolesOutput{
+				"111111111111": {{RoleList: []types.RoleInfo{{RoleName: aws.String("Admin")}}}},
+			},
+			accountErrs: map[string]error{"222222222222": errors.New("access denied")},
+		}, nil
+	}
+
+	loader := func(_ []string, _, _ string, _ time.Time) (SSOToken, error) {
+		return SSOToken{AccessToken: "token", ExpiresAt: time.Now().Add(time.Hour)}, nil
+	}
+
+	profiles, warnings, skipped, err := discoverProfiles(context.Background(), cfg, factory, loader, time.Now(), nil)
+	if err != nil {
+		t.Fatalf("discoverProfiles failed: %v", err)
+	}
+	if len(profiles) != 1 || profiles[0].AccountID != "111111111111" {
+		t.Fatalf("profiles = %+v, want only the prod account", profiles)
+	}
+	if len(warnings) != 1 || !strings.Contains(warnings[0], "222222222222") {
+		t.Fatalf("warnings = %v, want one warning for the staging account", warnings)
+	}
+	if !reflect.DeepEqual(skipped, []string{"222222222222"}) {
+		t.Fatalf("skipped = %v, want the staging account", skipped)
+	}
+}
+
+func TestDiscoverProfilesSkipsFilteredAccounts(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.SSO.Region = discoveryTestRegion
+	cfg.SSO.StartURL = discoveryTestStartURL
+	cfg.TokenCachePaths = []string{"/cache"}
+	cfg.Filters = ProfileFilters{
+		Include: []FilterRule{{Accounts: []string{"prod-*"}}},
+		Exclude: []FilterRule{{Accounts: []string{"prod-legacy"}}},
+	}
+
+	// Listing the roles of a filtered account fails, so a call would show up
+	// as a skipped account.
+	errFiltered := errors.New("filtered account was listed")
+	factory := func(_ context.Context, _, _ string) (SSOClient, error) {
+		return &mockSSOClient{
+			accountsPages: []*sso.ListAccountsOutput{
+				{
+					AccountList: []types.AccountInfo{
+						{AccountId: aws.String("111111111111"), AccountName: aws.String("prod-payments")},
+						{AccountId: aws.String("222222222222"), AccountName: aws.String("prod-legacy")},
+						{AccountId: aws.String("333333333333"), AccountName: aws.String("sandbox")},
+					},
+				},
+			},
+			rolesPages: map[string][]*sso.ListAccountRolesOutput{
+				"111111111111": {{RoleList: []types.RoleInfo{{RoleName: aws.String("Admin")}}}},
+			},
+			accountErrs: map[string]error{"222222222222": errFiltered, "333333333333": errFiltered},
+		}, nil
+	}
+
+	loader := func(_ []string, _, _ string, _ time.Time) (SSOToken, error) {
+		return SSOToken{AccessToken: "token", ExpiresAt: time.Now().Add(time.Hour)}, nil
+	}
+
+	profiles, warnings, skipped, err := discoverProfiles(context.Background(), cfg, factory, loader, time.Now(), nil)
+	if err != nil {
+		t.Fatalf("discoverProfiles failed: %v", err)
+	}
+	if len(warnings) != 0 || len(skipped) != 0 {
+		t.Fatalf("warnings = %v, skipped = %v, want filtered accounts not listed", warnings, skipped)
+	}
+	if len(profiles) != 1 || profiles[0].AccountName != "prod-payments" {
+		t.Fatalf("profiles = %+v, want only prod-payments", profiles)
+	}
+}
+
 func TestDiscoverProfilesErrorsAccountListFailure(t *testing.T) {
 	cfg := DefaultConfig()
 	cfg.SSO.Region = discoveryTestRegion
@@ -251,7 +409,7 @@ func TestDiscoverProfilesErrorsAccountListFailure(t *testing.T) {
 		}, nil
 	}
 
-	_, err := discoverProfiles(context.Background(), cfg, factory, loader, time.Now())
+	_, _, _, err := discoverProfiles(context.Background(), cfg, factory, loader, time.Now(), nil)
 	if err == nil {
 		t.Fatal("expected error for account list failure")
 	}
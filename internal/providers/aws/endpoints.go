@@ -0,0 +1,66 @@
+package aws
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+)
+
+var errEndpointURL = errors.New("endpoint url must be an http or https url")
+
+// EndpointURLsConfig overrides the endpoints of the AWS services cfgctl
+// calls, for LocalStack and other local stand-ins. Empty fields use the
+// AWS_ENDPOINT_URL_<SERVICE> and AWS_ENDPOINT_URL environment variables, and
+// then the real AWS endpoint.
+type EndpointURLsConfig struct {
+	// SSO is the IAM Identity Center portal API used for discovery and role credentials.
+	SSO string `yaml:"sso"`
+
+	// SSOOIDC is the OIDC API used to sign in and refresh tokens.
+	SSOOIDC string `yaml:"sso_oidc"`
+
+	// Organizations is the Organizations API used for account enrichment.
+	Organizations string `yaml:"organizations"`
+}
+
+func (e *EndpointURLsConfig) normalize() error {
+	for _, endpoint := range []*string{&e.SSO, &e.SSOOIDC, &e.Organizations} {
+		*endpoint = strings.TrimSpace(*endpoint)
+		if err := ValidateEndpointURL(*endpoint); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ValidateEndpointURL reports whether endpoint is empty or an http or https
+// URL.
+func ValidateEndpointURL(endpoint string) error {
+	if endpoint == "" {
+		return nil
+	}
+	if parsed, err := url.Parse(endpoint); err != nil || (parsed.Scheme != "https" && parsed.Scheme != "http") || parsed.Host == "" {
+		return fmt.Errorf("%w: %q", errEndpointURL, endpoint)
+	}
+	return nil
+}
+
+// resolveEndpointURL returns configured, or else the endpoint the AWS SDK
+// would take from the environment for service, whose name is in the form of
+// the AWS_ENDPOINT_URL_<SERVICE> variables. It is only needed for clients
+// that are not built from a loaded SDK config, which resolves the
+// environment itself.
+func resolveEndpointURL(configured, service string) string {
+	if configured != "" {
+		return configured
+	}
+	if strings.EqualFold(os.Getenv("AWS_IGNORE_CONFIGURED_ENDPOINT_URLS"), "true") {
+		return ""
+	}
+	if endpoint := os.Getenv("AWS_ENDPOINT_URL_" + service); endpoint != "" {
+		return endpoint
+	}
+	return os.Getenv("AWS_ENDPOINT_URL")
+}
@@ -0,0 +1,97 @@
+package aws
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ssooidc"
+)
+
+func TestResolveEndpointURL(t *testing.T) {
+	t.Setenv("AWS_ENDPOINT_URL", "http://global:4566")
+	t.Setenv("AWS_ENDPOINT_URL_SSO_OIDC", "http://oidc:4566")
+	t.Setenv("AWS_IGNORE_CONFIGURED_ENDPOINT_URLS", "")
+
+	if got := resolveEndpointURL("http://configured:4566", "SSO_OIDC"); got != "http://configured:4566" {
+		t.Fatalf("configured endpoint = %q", got)
+	}
+	if got := resolveEndpointURL("", "SSO_OIDC"); got != "http://oidc:4566" {
+		t.Fatalf("service endpoint = %q", got)
+	}
+	if got := resolveEndpointURL("", "ORGANIZATIONS"); got != "http://global:4566" {
+		t.Fatalf("global endpoint = %q", got)
+	}
+
+	t.Setenv("AWS_IGNORE_CONFIGURED_ENDPOINT_URLS", "true")
+	if got := resolveEndpointURL("", "SSO_OIDC"); got != "" {
+		t.Fatalf("ignored endpoint = %q", got)
+	}
+	if got := resolveEndpointURL("http://configured:4566", "SSO_OIDC"); got != "http://configured:4566" {
+		t.Fatalf("configured endpoint with env ignored = %q", got)
+	}
+}
+
+func TestConfigValidateEndpointURLs(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.SSO.Region = testRegion
+	cfg.SSO.StartURL = testStartURL
+	cfg.EndpointURLs = EndpointURLsConfig{SSO: " http://localhost:4566 ", Organizations: "localhost:4566"}
+
+	if err := cfg.Validate(); !errors.Is(err, errEndpointURL) {
+		t.Fatalf("Validate() error = %v, want %v", err, errEndpointURL)
+	}
+	if cfg.EndpointURLs.SSO != "http://localhost:4566" {
+		t.Fatalf("sso endpoint = %q, want trimmed", cfg.EndpointURLs.SSO)
+	}
+
+	cfg.EndpointURLs.Organizations = "https://organizations.example.com"
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+}
+
+func TestNewOIDCClientEndpointURL(t *testing.T) {
+	t.Setenv("AWS_IGNORE_CONFIGURED_ENDPOINT_URLS", "")
+	t.Setenv("AWS_ENDPOINT_URL_SSO_OIDC", "http://oidc:4566")
+
+	client, err := newOIDCClient(context.Background(), testRegion, "")
+	if err != nil {
+		t.Fatalf("newOIDCClient failed: %v", err)
+	}
+	if got := aws.ToString(client.(*ssooidc.Client).Options().BaseEndpoint); got != "http://oidc:4566" {
+		t.Fatalf("BaseEndpoint = %q", got)
+	}
+}
+
+func TestNewOrganizationsClientEndpointURL(t *testing.T) {
+	t.Setenv("AWS_IGNORE_CONFIGURED_ENDPOINT_URLS", "")
+	t.Setenv("AWS_ENDPOINT_URL", "")
+	t.Setenv("AWS_ENDPOINT_URL_ORGANIZATIONS", "")
+
+	client, err := newOrganizationsClient(context.Background(), OrganizationsConfig{Region: "us-east-1"}, "")
+	if err != nil {
+		t.Fatalf("newOrganizationsClient failed: %v", err)
+	}
+	if got := client.(*organizationsClient).client.Options().BaseEndpoint; got != nil {
+		t.Fatalf("default BaseEndpoint = %q, want unset", aws.ToString(got))
+	}
+
+	client, err = newOrganizationsClient(context.Background(), OrganizationsConfig{Region: "us-east-1"}, "http://localhost:4566")
+	if err != nil {
+		t.Fatalf("newOrganizationsClient failed: %v", err)
+	}
+	if got := aws.ToString(client.(*organizationsClient).client.Options().BaseEndpoint); got != "http://localhost:4566" {
+		t.Fatalf("configured BaseEndpoint = %q", got)
+	}
+
+	t.Setenv("AWS_ENDPOINT_URL_ORGANIZATIONS", "http://orgs:4566")
+	client, err = newOrganizationsClient(context.Background(), OrganizationsConfig{Region: "us-east-1"}, "")
+	if err != nil {
+		t.Fatalf("newOrganizationsClient failed: %v", err)
+	}
+	if got := aws.ToString(client.(*organizationsClient).client.Options().BaseEndpoint); got != "http://orgs:4566" {
+		t.Fatalf("environment BaseEndpoint = %q", got)
+	}
+}
@@ -0,0 +1,238 @@
+package aws
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Export formats for ExportProfiles.
+const (
+	ExportFormatJSON               = "json"
+	ExportFormatCSV                = "csv"
+	ExportFormatYAML               = "yaml"
+	ExportFormatAWSVault           = "aws-vault"
+	ExportFormatLeapp              = "leapp"
+	ExportFormatGrantedRegistry    = "granted-registry"
+	ExportFormatTerraformProviders = "terraform-providers"
+)
+
+// ExportFormats lists the supported export formats.
+var ExportFormats = []string{
+	ExportFormatJSON,
+	ExportFormatCSV,
+	ExportFormatYAML,
+	ExportFormatAWSVault,
+	ExportFormatLeapp,
+	ExportFormatGrantedRegistry,
+	ExportFormatTerraformProviders,
+}
+
+var errExportFormat = errors.New("export format must be one of " + strings.Join(ExportFormats, ", "))
+
+// validateExportFormat reports whether format is one of ExportFormats.
+func validateExportFormat(format string) error {
+	for _, known := range ExportFormats {
+		if format == known {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %q", errExportFormat, format)
+}
+
+// ExportedProfile is one generated profile in the json, yaml, and csv exports.
+type ExportedProfile struct {
+	Profile     string `json:"profile" yaml:"profile"`
+	AccountID   string `json:"account_id" yaml:"account_id"`
+	AccountName string `json:"account_name" yaml:"account_name"`
+	RoleName    string `json:"role_name" yaml:"role_name"`
+	Region      string `json:"region" yaml:"region"`
+	SSOSession  string `json:"sso_session" yaml:"sso_session"`
+	SSOStartURL string `json:"sso_start_url" yaml:"sso_start_url"`
+	SSORegion   string `json:"sso_region" yaml:"sso_region"`
+
+	// OU and Tags are set with Organizations enrichment.
+	OU   string            `json:"ou,omitempty" yaml:"ou,omitempty"`
+	Tags map[string]string `json:"tags,omitempty" yaml:"tags,omitempty"`
+}
+
+// ExportProfiles renders the profiles cfgctl would generate for the
+// discovered profiles in format, for tools that keep their own account lists.
+func ExportProfiles(cfg *Config, profiles []DiscoveredProfile, format string) (string, error) {
+	if cfg == nil {
+		return "", errors.New("aws config is nil")
+	}
+
+	exported, err := exportedProfiles(cfg, profiles)
+	if err != nil {
+		return "", err
+	}
+
+	switch format {
+	case ExportFormatJSON:
+		data, err := json.MarshalIndent(exported, "", "  ")
+		if err != nil {
+			return "", fmt.Errorf("encode profiles: %w", err)
+		}
+		return string(data) + "\n", nil
+	case ExportFormatYAML:
+		data, err := yaml.Marshal(exported)
+		if err != nil {
+			return "", fmt.Errorf("encode profiles: %w", err)
+		}
+		return string(data), nil
+	case ExportFormatCSV:
+		return exportCSV(exported)
+	case ExportFormatAWSVault:
+		return exportAWSVault(exported), nil
+	case ExportFormatLeapp:
+		return exportLeapp(cfg), nil
+	case ExportFormatGrantedRegistry:
+		return exportGrantedRegistry(cfg, exported)
+	case ExportFormatTerraformProviders:
+		return exportTerraformProviders(exported), nil
+	default:
+		return "", fmt.Errorf("%w: %q", errExportFormat, format)
+	}
+}
+
+// exportedProfiles names the discovered profiles as the generator does and
+// completes them with their session and region.
+func exportedProfiles(cfg *Config, profiles []DiscoveredProfile) ([]ExportedProfile, error) {
+	names, lookup, err := buildProfileIndex(cfg, profiles)
+	if err != nil {
+		return nil, err
+	}
+
+	sessions := cfg.sessionConfigs()
+	exported := make([]ExportedProfile, 0, len(names))
+	for _, name := range names {
+		profile := lookup[name]
+		session := findSession(sessions, profile.SSOSession)
+		region := profile.Attributes["region"]
+		if region == "" {
+			region = session.SSO.Region
+		}
+		exported = append(exported, ExportedProfile{
+			Profile:     profile.Name,
+			AccountID:   profile.AccountID,
+			AccountName: profile.AccountName,
+			RoleName:    profile.RoleName,
+			Region:      region,
+			SSOSession:  session.SSO.SessionName,
+			SSOStartURL: session.SSO.StartURL,
+			SSORegion:   session.SSO.Region,
+			OU:          profile.OU,
+			Tags:        profile.Tags,
+		})
+	}
+	return exported, nil
+}
+
+func exportCSV(profiles []ExportedProfile) (string, error) {
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+	records := [][]string{{"profile", "account_id", "account_name", "role_name", "region", "sso_session", "sso_start_url", "sso_region"}}
+	for _, p := range profiles {
+		records = append(records, []string{p.Profile, p.AccountID, p.AccountName, p.RoleName, p.Region, p.SSOSession, p.SSOStartURL, p.SSORegion})
+	}
+	if err := writer.WriteAll(records); err != nil {
+		return "", fmt.Errorf("encode profiles: %w", err)
+	}
+	return buf.String(), nil
+}
+
+// exportAWSVault writes profiles with the per-profile sso keys aws-vault
+// reads, since it does not resolve sso-session sections.
+func exportAWSVault(profiles []ExportedProfile) string {
+	builder := &strings.Builder{}
+	for _, p := range profiles {
+		writeSectionHeader(builder, profileSectionPrefix+p.Profile)
+		writeKeyValue(builder, "sso_start_url", p.SSOStartURL)
+		writeKeyValue(builder, "sso_region", p.SSORegion)
+		writeKeyValue(builder, "sso_account_id", p.AccountID)
+		writeKeyValue(builder, "sso_role_name", p.RoleName)
+		writeKeyValue(builder, "region", p.Region)
+		builder.WriteString("\n")
+	}
+	return builder.String()
+}
+
+// exportLeapp writes a `leapp integration create` command per SSO session.
+// Leapp discovers the accounts and roles of an integration itself, so the
+// session is the unit it imports.
+func exportLeapp(cfg *Config) string {
+	builder := &strings.Builder{}
+	for _, session := range cfg.sessionConfigs() {
+		fmt.Fprintf(builder, "leapp integration create --integrationType AWS-SSO --integrationAlias %s --integrationPortalUrl %s --integrationRegion %s\n",
+			shellQuote(session.SSO.SessionName), shellQuote(session.SSO.StartURL), shellQuote(session.SSO.Region))
+	}
+	return builder.String()
+}
+
+// exportGrantedRegistry writes an AWS config file for a Granted profile
+// registry. Registry profiles always use sso_session, since Granted adds its
+// own credential_process when it syncs them.
+func exportGrantedRegistry(cfg *Config, profiles []ExportedProfile) (string, error) {
+	builder := &strings.Builder{}
+	builder.WriteString("# List this file under awsConfig in the registry's granted.yml.\n\n")
+	if err := writeSSOSession(builder, cfg); err != nil {
+		return "", err
+	}
+	for _, p := range profiles {
+		writeSectionHeader(builder, profileSectionPrefix+p.Profile)
+		writeKeyValue(builder, "sso_session", p.SSOSession)
+		writeKeyValue(builder, "sso_account_id", p.AccountID)
+		writeKeyValue(builder, "sso_role_name", p.RoleName)
+		writeKeyValue(builder, "region", p.Region)
+		builder.WriteString("\n")
+	}
+	return builder.String(), nil
+}
+
+// exportTerraformProviders writes an aliased aws provider block per profile.
+func exportTerraformProviders(profiles []ExportedProfile) string {
+	builder := &strings.Builder{}
+	used := make(map[string]bool, len(profiles))
+	for i, p := range profiles {
+		if i > 0 {
+			builder.WriteString("\n")
+		}
+		alias := terraformAlias(p.Profile)
+		for n := 2; used[alias]; n++ {
+			alias = fmt.Sprintf("%s_%d", terraformAlias(p.Profile), n)
+		}
+		used[alias] = true
+
+		fmt.Fprintf(builder, "# %s (%s) %s\n", p.AccountName, p.AccountID, p.RoleName)
+		builder.WriteString("provider \"aws\" {\n")
+		fmt.Fprintf(builder, "  alias   = %q\n", alias)
+		fmt.Fprintf(builder, "  profile = %q\n", p.Profile)
+		fmt.Fprintf(builder, "  region  = %q\n", p.Region)
+		builder.WriteString("}\n")
+	}
+	return builder.String()
+}
+
+// terraformAlias turns a profile name into a Terraform identifier.
+func terraformAlias(profile string) string {
+	alias := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9', r == '_':
+			return r
+		case r >= 'A' && r <= 'Z':
+			return r + ('a' - 'A')
+		default:
+			return '_'
+		}
+	}, profile)
+	if alias == "" || (alias[0] >= '0' && alias[0] <= '9') {
+		alias = "_" + alias
+	}
+	return alias
+}
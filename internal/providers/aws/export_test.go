@@ -0,0 +1,150 @@
+package aws
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/jmreicha/cfgctl/internal/core"
+)
+
+func exportTestConfig() *Config {
+	cfg := DefaultConfig()
+	cfg.SSO.Region = testRegion
+	cfg.SSO.StartURL = testStartURL
+	cfg.ProfileAttributes = []ProfileAttributeRule{{
+		Match: FilterRule{Accounts: []string{"prod"}},
+		Set:   map[string]string{"region": "us-west-2"},
+	}}
+	return cfg
+}
+
+var exportTestProfiles = []DiscoveredProfile{
+	{AccountID: "111111111111", AccountName: "prod", RoleName: "Admin"},
+	{AccountID: "222222222222", AccountName: "dev", RoleName: "ReadOnly"},
+}
+
+func TestExportProfilesJSON(t *testing.T) {
+	output, err := ExportProfiles(exportTestConfig(), exportTestProfiles, ExportFormatJSON)
+	if err != nil {
+		t.Fatalf("ExportProfiles failed: %v", err)
+	}
+
+	var exported []ExportedProfile
+	if err := json.Unmarshal([]byte(output), &exported); err != nil {
+		t.Fatalf("decode export: %v", err)
+	}
+	want := []ExportedProfile{
+		{Profile: "dev/readonly", AccountID: "222222222222", AccountName: "dev", RoleName: "ReadOnly", Region: testRegion, SSOSession: "cfgctl", SSOStartURL: testStartURL, SSORegion: testRegion},
+		{Profile: "prod/admin", AccountID: "111111111111", AccountName: "prod", RoleName: "Admin", Region: "us-west-2", SSOSession: "cfgctl", SSOStartURL: testStartURL, SSORegion: testRegion},
+	}
+	if len(exported) != len(want) {
+		t.Fatalf("exported %d profiles, want %d", len(exported), len(want))
+	}
+	for i := range want {
+		if !reflect.DeepEqual(exported[i], want[i]) {
+			t.Fatalf("profile %d = %+v, want %+v", i, exported[i], want[i])
+		}
+	}
+}
+
+func TestExportProfilesFormats(t *testing.T) {
+	tests := []struct {
+		format string
+		want   []string
+	}{
+		{format: ExportFormatCSV, want: []string{
+			"profile,account_id,account_name,role_name,region,sso_session,sso_start_url,sso_region\n",
+			"prod/admin,111111111111,prod,Admin,us-west-2,cfgctl," + testStartURL + "," + testRegion + "\n",
+		}},
+		{format: ExportFormatYAML, want: []string{"- profile: dev/readonly\n", "  account_id: \"111111111111\"\n"}},
+		{format: ExportFormatAWSVault, want: []string{
+			"[profile prod/admin]\nsso_start_url = " + testStartURL + "\nsso_region = " + testRegion + "\nsso_account_id = 111111111111\nsso_role_name = Admin\nregion = us-west-2\n",
+		}},
+		{format: ExportFormatLeapp, want: []string{
+			"leapp integration create --integrationType AWS-SSO --integrationAlias 'cfgctl' --integrationPortalUrl '" + testStartURL + "' --integrationRegion '" + testRegion + "'\n",
+		}},
+		{format: ExportFormatGrantedRegistry, want: []string{
+			"[sso-session cfgctl]\n",
+			"[profile dev/readonly]\nsso_session = cfgctl\nsso_account_id = 222222222222\nsso_role_name = ReadOnly\nregion = " + testRegion + "\n",
+		}},
+		{format: ExportFormatTerraformProviders, want: []string{
+			"# prod (111111111111) Admin\nprovider \"aws\" {\n  alias   = \"prod_admin\"\n  profile = \"prod/admin\"\n  region  = \"us-west-2\"\n}\n",
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			output, err := ExportProfiles(exportTestConfig(), exportTestProfiles, tt.format)
+			if err != nil {
+				t.Fatalf("ExportProfiles failed: %v", err)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(output, want) {
+					t.Fatalf("output missing %q:\n%s", want, output)
+				}
+			}
+		})
+	}
+}
+
+func TestProviderExportFiltersWithoutWriting(t *testing.T) {
+	cfg := exportTestConfig()
+	cfg.ConfigPath = filepath.Join(t.TempDir(), "config")
+	cfg.Filters = ProfileFilters{Exclude: []FilterRule{{Accounts: []string{"dev"}}}}
+	provider := NewProvider(cfg)
+	provider.discover = func(context.Context, *Config, core.ProgressReporter) ([]DiscoveredProfile, []string, []string, error) {
+		return exportTestProfiles, []string{"skipped account"}, nil, nil
+	}
+
+	output, warnings, err := provider.Export(context.Background(), ExportFormatCSV, nil, nil)
+	if err != nil {
+		t.Fatalf("Export failed: %v", err)
+	}
+	if strings.Contains(output, "dev/readonly") || !strings.Contains(output, "prod/admin") {
+		t.Fatalf("unexpected export:\n%s", output)
+	}
+	if len(warnings) != 1 {
+		t.Fatalf("warnings = %v", warnings)
+	}
+	if _, err := os.Stat(cfg.ConfigPath); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("expected no config file, got %v", err)
+	}
+}
+
+func TestExportProfilesUnknownFormat(t *testing.T) {
+	if _, err := ExportProfiles(exportTestConfig(), exportTestProfiles, "ansible"); !errors.Is(err, errExportFormat) {
+		t.Fatalf("expected errExportFormat, got %v", err)
+	}
+}
+
+func TestProviderExportUnknownFormatSkipsDiscovery(t *testing.T) {
+	provider := NewProvider(exportTestConfig())
+	provider.discover = func(context.Context, *Config, core.ProgressReporter) ([]DiscoveredProfile, []string, []string, error) {
+		t.Fatal("discovery ran for an unknown format")
+		return nil, nil, nil, nil
+	}
+
+	if _, _, err := provider.Export(context.Background(), "ansible", nil, nil); !errors.Is(err, errExportFormat) {
+		t.Fatalf("expected errExportFormat, got %v", err)
+	}
+}
+
+func TestTerraformAlias(t *testing.T) {
+	tests := map[string]string{
+		"prod/admin":         "prod_admin",
+		"Team-A.Prod/Admin":  "team_a_prod_admin",
+		"123/readonly":       "_123_readonly",
+		"already_valid_name": "already_valid_name",
+	}
+	for profile, want := range tests {
+		if got := terraformAlias(profile); got != want {
+			t.Fatalf("terraformAlias(%q) = %q, want %q", profile, got, want)
+		}
+	}
+}
@@ -0,0 +1,356 @@
+package aws
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/jmreicha/cfgctl/internal/core"
+)
+
+// MetadataFilterDecisions is the result metadata key holding one line per
+// discovered profile explaining why it was kept or dropped. It is only set
+// when Config.ExplainFilters is enabled.
+const MetadataFilterDecisions = "filter_decisions"
+
+var errFilterRuleEmpty = errors.New("filter rule must set accounts, account_ids, roles, ous, or tags")
+
+// ProfileFilters selects which discovered accounts and roles become profiles.
+// A profile is kept when it matches an include rule, or there are none, and
+// matches no exclude rule.
+type ProfileFilters struct {
+	Include []FilterRule `yaml:"include"`
+	Exclude []FilterRule `yaml:"exclude"`
+}
+
+// FilterRule matches a profile when its account name, account ID, role, and
+// OU path each match one of the listed patterns, and every listed account tag
+// matches its pattern; an empty list matches anything. Patterns are
+// case-insensitive globs, or regular expressions when written between
+// slashes. OUs and Tags need Organizations enrichment.
+type FilterRule struct {
+	Accounts   []string          `yaml:"accounts"`
+	AccountIDs []string          `yaml:"account_ids"`
+	Roles      []string          `yaml:"roles"`
+	OUs        []string          `yaml:"ous"`
+	Tags       map[string]string `yaml:"tags"`
+}
+
+// IsZero reports whether no rules are configured.
+func (f ProfileFilters) IsZero() bool {
+	return len(f.Include) == 0 && len(f.Exclude) == 0
+}
+
+// profileFilter is the compiled form of Roles and Filters.
+type profileFilter struct {
+	roles   []string
+	include []compiledRule
+	exclude []compiledRule
+}
+
+type compiledRule struct {
+	name       string
+	accounts   core.Patterns
+	accountIDs core.Patterns
+	roles      core.Patterns
+	ous        core.Patterns
+	tags       []tagPattern
+}
+
+// tagPattern matches the value of one account tag.
+type tagPattern struct {
+	key     string
+	pattern core.Pattern
+}
+
+// filterDecision records whether a profile was kept and why.
+type filterDecision struct {
+	Profile DiscoveredProfile
+	Kept    bool
+	Reason  string
+}
+
+// String formats the decision for --aws-explain-filters.
+func (d filterDecision) String() string {
+	verdict := "drop"
+	if d.Kept {
+		verdict = "keep"
+	}
+	return fmt.Sprintf("%s %s/%s (%s): %s", verdict, d.Profile.AccountName, d.Profile.RoleName, d.Profile.AccountID, d.Reason)
+}
+
+func newProfileFilter(roles []string, filters ProfileFilters) (*profileFilter, error) {
+	include, err := compileRules("include", filters.Include)
+	if err != nil {
+		return nil, err
+	}
+	exclude, err := compileRules("exclude", filters.Exclude)
+	if err != nil {
+		return nil, err
+	}
+	return &profileFilter{roles: roles, include: include, exclude: exclude}, nil
+}
+
+func compileRules(kind string, rules []FilterRule) ([]compiledRule, error) {
+	compiled := make([]compiledRule, 0, len(rules))
+	for i, rule := range rules {
+		name := fmt.Sprintf("%s[%d]", kind, i)
+		if rule.isEmpty() {
+			return nil, fmt.Errorf("filters %s: %w", name, errFilterRuleEmpty)
+		}
+		compiledRule, err := compileRule(name, rule)
+		if err != nil {
+			return nil, fmt.Errorf("filters %w", err)
+		}
+		compiled = append(compiled, compiledRule)
+	}
+	return compiled, nil
+}
+
+// compileRule compiles the patterns of rule. A rule without patterns
+// matches every profile.
+func compileRule(name string, rule FilterRule) (compiledRule, error) {
+	accounts, err := core.CompilePatterns(rule.Accounts)
+	if err != nil {
+		return compiledRule{}, fmt.Errorf("%s accounts: %w", name, err)
+	}
+	accountIDs, err := core.CompilePatterns(rule.AccountIDs)
+	if err != nil {
+		return compiledRule{}, fmt.Errorf("%s account_ids: %w", name, err)
+	}
+	roles, err := core.CompilePatterns(rule.Roles)
+	if err != nil {
+		return compiledRule{}, fmt.Errorf("%s roles: %w", name, err)
+	}
+	ous, err := core.CompilePatterns(rule.OUs)
+	if err != nil {
+		return compiledRule{}, fmt.Errorf("%s ous: %w", name, err)
+	}
+
+	keys := make([]string, 0, len(rule.Tags))
+	for key := range rule.Tags {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	tags := make([]tagPattern, 0, len(keys))
+	for _, key := range keys {
+		pattern, err := core.CompilePattern(rule.Tags[key])
+		if err != nil {
+			return compiledRule{}, fmt.Errorf("%s tags %s: %w", name, key, err)
+		}
+		tags = append(tags, tagPattern{key: key, pattern: pattern})
+	}
+
+	return compiledRule{name: name, accounts: accounts, accountIDs: accountIDs, roles: roles, ous: ous, tags: tags}, nil
+}
+
+func (r FilterRule) isEmpty() bool {
+	return len(r.Accounts) == 0 && len(r.AccountIDs) == 0 && len(r.Roles) == 0 && len(r.OUs) == 0 && len(r.Tags) == 0
+}
+
+// decide reports whether profile passes the filter and why.
+func (f *profileFilter) decide(profile DiscoveredProfile) filterDecision {
+	decision := filterDecision{Profile: profile}
+
+	if hasRoleFilter(f.roles) && !core.MatchesAWSRole(profile.RoleName, f.roles) {
+		decision.Reason = "role not listed in roles"
+		return decision
+	}
+	for _, rule := range f.exclude {
+		if matched, ok := rule.match(profile); ok {
+			decision.Reason = fmt.Sprintf("matched %s (%s)", rule.name, matched)
+			return decision
+		}
+	}
+	if len(f.include) == 0 {
+		decision.Kept = true
+		decision.Reason = "no include rules"
+		return decision
+	}
+	for _, rule := range f.include {
+		if matched, ok := rule.match(profile); ok {
+			decision.Kept = true
+			decision.Reason = fmt.Sprintf("matched %s (%s)", rule.name, matched)
+			return decision
+		}
+	}
+	decision.Reason = "matched no include rule"
+	return decision
+}
+
+// match reports whether every field the rule sets matches profile, and
+// describes the patterns that did.
+func (r compiledRule) match(profile DiscoveredProfile) (string, bool) {
+	var matched []string
+	fields := []struct {
+		label    string
+		patterns core.Patterns
+		value    string
+	}{
+		{label: "account", patterns: r.accounts, value: profile.AccountName},
+		{label: "account id", patterns: r.accountIDs, value: profile.AccountID},
+		{label: "role", patterns: r.roles, value: profile.RoleName},
+		{label: "ou", patterns: r.ous, value: profile.OU},
+	}
+	for _, field := range fields {
+		if len(field.patterns) == 0 {
+			continue
+		}
+		pattern, ok := field.patterns.Match(field.value)
+		if !ok {
+			return "", false
+		}
+		matched = append(matched, fmt.Sprintf("%s %s", field.label, pattern))
+	}
+	// An account without the tag never matches, even a "*" pattern.
+	for _, tag := range r.tags {
+		value, ok := profile.Tags[tag.key]
+		if !ok || !tag.pattern.Match(value) {
+			return "", false
+		}
+		matched = append(matched, fmt.Sprintf("tag %s=%s", tag.key, tag.pattern))
+	}
+	return strings.Join(matched, ", "), true
+}
+
+// accountFilter drops accounts before their roles are listed. Only account
+// names and IDs are known at that point, so an account is dropped when an
+// exclude rule matches it on those alone, or when no include rule could
+// match any of its roles. Rules on roles, OUs or tags are left to
+// filterProfiles.
+type accountFilter struct {
+	include []compiledRule
+	exclude []compiledRule
+	key     string
+}
+
+// newAccountFilter returns the account filter for cfg's filters, or nil when
+// they cannot drop an account before its roles are listed. Explaining filters
+// needs a decision for every role, so it turns the account filter off.
+func newAccountFilter(cfg *Config) (*accountFilter, error) {
+	if cfg.ExplainFilters {
+		return nil, nil
+	}
+	filter, err := newProfileFilter(nil, cfg.Filters)
+	if err != nil {
+		return nil, err
+	}
+
+	var exclude []compiledRule
+	for _, rule := range filter.exclude {
+		if rule.accountOnly() {
+			exclude = append(exclude, rule)
+		}
+	}
+	include := filter.include
+	for _, rule := range include {
+		if len(rule.accounts) == 0 && len(rule.accountIDs) == 0 {
+			include = nil
+			break
+		}
+	}
+	if len(include) == 0 && len(exclude) == 0 {
+		return nil, nil
+	}
+
+	key := struct {
+		Include []FilterRule `json:"include"`
+		Exclude []FilterRule `json:"exclude"`
+	}{Include: cfg.Filters.Include, Exclude: cfg.Filters.Exclude}
+	data, err := json.Marshal(key)
+	if err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256(data)
+	return &accountFilter{include: include, exclude: exclude, key: hex.EncodeToString(sum[:8])}, nil
+}
+
+// cacheKey fingerprints the filters for core.CacheKey.Filter. It is empty for
+// a nil filter, so unfiltered discovery keeps its cache entries.
+func (f *accountFilter) cacheKey() string {
+	if f == nil {
+		return ""
+	}
+	return f.key
+}
+
+// keep reports whether any role of the account could pass the filters.
+func (f *accountFilter) keep(name, id string) bool {
+	if f == nil {
+		return true
+	}
+	account := DiscoveredProfile{AccountName: name, AccountID: id}
+	for _, rule := range f.exclude {
+		if _, ok := rule.match(account); ok {
+			return false
+		}
+	}
+	if len(f.include) == 0 {
+		return true
+	}
+	for _, rule := range f.include {
+		if rule.matchAccount(account) {
+			return true
+		}
+	}
+	return false
+}
+
+// accountOnly reports whether the rule matches on account names and IDs
+// alone.
+func (r compiledRule) accountOnly() bool {
+	return len(r.roles) == 0 && len(r.ous) == 0 && len(r.tags) == 0
+}
+
+// matchAccount reports whether the account name and ID patterns of the rule
+// match account, ignoring its other fields.
+func (r compiledRule) matchAccount(account DiscoveredProfile) bool {
+	if len(r.accounts) > 0 {
+		if _, ok := r.accounts.Match(account.AccountName); !ok {
+			return false
+		}
+	}
+	if len(r.accountIDs) > 0 {
+		if _, ok := r.accountIDs.Match(account.AccountID); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// filterProfiles returns the profiles that pass the roles and filters of the
+// session they were discovered in, along with the decision made for each.
+func filterProfiles(cfg *Config, profiles []DiscoveredProfile) ([]DiscoveredProfile, []filterDecision, error) {
+	sessions := cfg.sessionConfigs()
+	filters := make(map[*Config]*profileFilter, len(sessions))
+	for _, session := range sessions {
+		filter, err := newProfileFilter(session.Roles, session.Filters)
+		if err != nil {
+			return nil, nil, err
+		}
+		filters[session] = filter
+	}
+
+	kept := make([]DiscoveredProfile, 0, len(profiles))
+	decisions := make([]filterDecision, 0, len(profiles))
+	for _, profile := range profiles {
+		decision := filters[findSession(sessions, profile.SSOSession)].decide(profile)
+		decisions = append(decisions, decision)
+		if decision.Kept {
+			kept = append(kept, profile)
+		}
+	}
+	return kept, decisions, nil
+}
+
+func formatDecisions(decisions []filterDecision) []string {
+	lines := make([]string, 0, len(decisions))
+	for _, decision := range decisions {
+		lines = append(lines, decision.String())
+	}
+	return lines
+}
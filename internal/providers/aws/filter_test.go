@@ -0,0 +1,225 @@
+package aws
+
+import (
+	"errors"
+	"reflect"
+	"slices"
+	"testing"
+)
+
+func filterTestProfiles() []DiscoveredProfile {
+	return []DiscoveredProfile{
+		{AccountID: "111111111111", AccountName: "prod-payments", RoleName: "AdministratorAccess"},
+		{AccountID: "111111111111", AccountName: "prod-payments", RoleName: "BreakGlass"},
+		{AccountID: "222222222222", AccountName: "prod-legacy", RoleName: "ReadOnly"},
+		{AccountID: "333333333333", AccountName: "sandbox-alice", RoleName: "AdminAccess"},
+		{AccountID: "333333333333", AccountName: "sandbox-alice", RoleName: "ReadOnly"},
+		{AccountID: "444444444444", AccountName: "dev", RoleName: "ReadOnly"},
+	}
+}
+
+func profileKeys(profiles []DiscoveredProfile) []string {
+	keys := make([]string, 0, len(profiles))
+	for _, profile := range profiles {
+		keys = append(keys, profile.AccountName+"/"+profile.RoleName)
+	}
+	return keys
+}
+
+func TestFilterProfiles(t *testing.T) {
+	tests := []struct {
+		name    string
+		roles   []string
+		filters ProfileFilters
+		want    []string
+	}{
+		{
+			name: "no filters",
+			want: profileKeys(filterTestProfiles()),
+		},
+		{
+			name:  "roles list",
+			roles: []string{"ReadOnly"},
+			want:  []string{"prod-legacy/ReadOnly", "sandbox-alice/ReadOnly", "dev/ReadOnly"},
+		},
+		{
+			name: "prod accounts except legacy",
+			filters: ProfileFilters{
+				Include: []FilterRule{{Accounts: []string{"prod-*"}}},
+				Exclude: []FilterRule{{Accounts: []string{"prod-legacy"}}},
+			},
+			want: []string{"prod-payments/AdministratorAccess", "prod-payments/BreakGlass"},
+		},
+		{
+			name: "admin roles in sandbox accounts",
+			filters: ProfileFilters{
+				Include: []FilterRule{
+					{Accounts: []string{"sandbox-*"}, Roles: []string{"Admin*"}},
+					{Accounts: []string{"prod-*", "dev"}},
+				},
+			},
+			want: []string{"prod-payments/AdministratorAccess", "prod-payments/BreakGlass", "prod-legacy/ReadOnly", "sandbox-alice/AdminAccess", "dev/ReadOnly"},
+		},
+		{
+			name: "break-glass deny list",
+			filters: ProfileFilters{
+				Exclude: []FilterRule{{Roles: []string{"/break.?glass/"}}},
+			},
+			want: []string{"prod-payments/AdministratorAccess", "prod-legacy/ReadOnly", "sandbox-alice/AdminAccess", "sandbox-alice/ReadOnly", "dev/ReadOnly"},
+		},
+		{
+			name: "account ids",
+			filters: ProfileFilters{
+				Include: []FilterRule{{AccountIDs: []string{"/^(2|4)/"}}},
+			},
+			want: []string{"prod-legacy/ReadOnly", "dev/ReadOnly"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &Config{Roles: tt.roles, Filters: tt.filters}
+			kept, decisions, err := filterProfiles(cfg, filterTestProfiles())
+			if err != nil {
+				t.Fatalf("filterProfiles failed: %v", err)
+			}
+			if got := profileKeys(kept); !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("kept = %v, want %v", got, tt.want)
+			}
+			if len(decisions) != len(filterTestProfiles()) {
+				t.Fatalf("expected a decision per profile, got %d", len(decisions))
+			}
+		})
+	}
+}
+
+func TestFilterProfilesPerSession(t *testing.T) {
+	cfg := &Config{
+		SSOSessions: []SSOSessionConfig{
+			{SSOConfig: SSOConfig{SessionName: "corp"}, Filters: ProfileFilters{Include: []FilterRule{{Accounts: []string{"prod-*"}}}}},
+			{SSOConfig: SSOConfig{SessionName: "lab"}},
+		},
+	}
+	profiles := []DiscoveredProfile{
+		{AccountName: "prod-payments", RoleName: "ReadOnly", SSOSession: "corp"},
+		{AccountName: "dev", RoleName: "ReadOnly", SSOSession: "corp"},
+		{AccountName: "dev", RoleName: "ReadOnly", SSOSession: "lab"},
+	}
+
+	kept, _, err := filterProfiles(cfg, profiles)
+	if err != nil {
+		t.Fatalf("filterProfiles failed: %v", err)
+	}
+	want := []DiscoveredProfile{profiles[0], profiles[2]}
+	if !reflect.DeepEqual(kept, want) {
+		t.Fatalf("kept = %#v, want %#v", kept, want)
+	}
+}
+
+func TestFilterDecisionReasons(t *testing.T) {
+	cfg := &Config{
+		Filters: ProfileFilters{
+			Include: []FilterRule{{Accounts: []string{"prod-*"}, Roles: []string{"Admin*"}}},
+			Exclude: []FilterRule{{Roles: []string{"BreakGlass"}}},
+		},
+	}
+
+	_, decisions, err := filterProfiles(cfg, filterTestProfiles()[:3])
+	if err != nil {
+		t.Fatalf("filterProfiles failed: %v", err)
+	}
+
+	want := []string{
+		"keep prod-payments/AdministratorAccess (111111111111): matched include[0] (account prod-*, role Admin*)",
+		"drop prod-payments/BreakGlass (111111111111): matched exclude[0] (role BreakGlass)",
+		"drop prod-legacy/ReadOnly (222222222222): matched no include rule",
+	}
+	if got := formatDecisions(decisions); !reflect.DeepEqual(got, want) {
+		t.Fatalf("decisions = %#v, want %#v", got, want)
+	}
+}
+
+func TestNewProfileFilterErrors(t *testing.T) {
+	if _, err := newProfileFilter(nil, ProfileFilters{Include: []FilterRule{{}}}); !errors.Is(err, errFilterRuleEmpty) {
+		t.Fatalf("expected errFilterRuleEmpty, got %v", err)
+	}
+	if _, err := newProfileFilter(nil, ProfileFilters{Exclude: []FilterRule{{Roles: []string{"/(/"}}}}); err == nil {
+		t.Fatal("expected invalid regex error")
+	}
+}
+
+func TestAccountFilter(t *testing.T) {
+	tests := []struct {
+		name    string
+		filters ProfileFilters
+		explain bool
+		want    []string
+		active  bool
+	}{
+		{
+			name:    "no filters",
+			filters: ProfileFilters{},
+			want:    []string{"prod-payments", "prod-legacy", "sandbox-alice", "dev"},
+		},
+		{
+			name: "account include with exclude",
+			filters: ProfileFilters{
+				Include: []FilterRule{{Accounts: []string{"prod-*"}}},
+				Exclude: []FilterRule{{Accounts: []string{"prod-legacy"}}},
+			},
+			want:   []string{"prod-payments"},
+			active: true,
+		},
+		{
+			name:    "include by account id",
+			filters: ProfileFilters{Include: []FilterRule{{AccountIDs: []string{"333333333333"}}}},
+			want:    []string{"sandbox-alice"},
+			active:  true,
+		},
+		{
+			name:    "role exclude is left to profile filtering",
+			filters: ProfileFilters{Exclude: []FilterRule{{Accounts: []string{"prod-*"}, Roles: []string{"BreakGlass"}}}},
+			want:    []string{"prod-payments", "prod-legacy", "sandbox-alice", "dev"},
+		},
+		{
+			name: "include without account patterns keeps every account",
+			filters: ProfileFilters{Include: []FilterRule{
+				{Accounts: []string{"sandbox-*"}, Roles: []string{"Admin*"}},
+				{Roles: []string{"ReadOnly"}},
+			}},
+			want: []string{"prod-payments", "prod-legacy", "sandbox-alice", "dev"},
+		},
+		{
+			name:    "include on account and role",
+			filters: ProfileFilters{Include: []FilterRule{{Accounts: []string{"sandbox-*"}, Roles: []string{"Admin*"}}}},
+			want:    []string{"sandbox-alice"},
+			active:  true,
+		},
+		{
+			name:    "explain turns it off",
+			filters: ProfileFilters{Exclude: []FilterRule{{Accounts: []string{"prod-*"}}}},
+			explain: true,
+			want:    []string{"prod-payments", "prod-legacy", "sandbox-alice", "dev"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filter, err := newAccountFilter(&Config{Filters: tt.filters, ExplainFilters: tt.explain})
+			if err != nil {
+				t.Fatalf("newAccountFilter failed: %v", err)
+			}
+			if (filter.cacheKey() != "") != tt.active {
+				t.Fatalf("cacheKey() = %q, want active %v", filter.cacheKey(), tt.active)
+			}
+			var kept []string
+			for _, profile := range filterTestProfiles() {
+				if filter.keep(profile.AccountName, profile.AccountID) && !slices.Contains(kept, profile.AccountName) {
+					kept = append(kept, profile.AccountName)
+				}
+			}
+			if !reflect.DeepEqual(kept, tt.want) {
+				t.Fatalf("kept accounts = %v, want %v", kept, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,68 @@
+package aws
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sso"
+	"github.com/aws/aws-sdk-go-v2/service/sso/types"
+	"github.com/jmreicha/cfgctl/internal/core"
+)
+
+var errFixtureCredentials = errors.New("demo fixtures have no role credentials")
+
+// fixtureSSOClient answers SSO calls from demo fixtures.
+type fixtureSSOClient struct {
+	accounts []core.AccountFixture
+}
+
+// fixtureSSOClientFactory returns a factory for clients that serve fixtures.
+func fixtureSSOClientFactory(fixtures *core.DemoFixtures) SSOClientFactory {
+	return func(context.Context, string, string) (SSOClient, error) {
+		return &fixtureSSOClient{accounts: fixtures.Accounts}, nil
+	}
+}
+
+// fixtureToken stands in for the SSO token cache, which fixtures do not need.
+func fixtureToken(_ []string, startURL, region string, now time.Time) (SSOToken, error) {
+	return SSOToken{
+		AccessToken: "demo",
+		ExpiresAt:   now.Add(time.Hour),
+		IssuedAt:    now,
+		Region:      region,
+		StartURL:    startURL,
+	}, nil
+}
+
+func (c *fixtureSSOClient) ListAccounts(context.Context, *sso.ListAccountsInput, ...func(*sso.Options)) (*sso.ListAccountsOutput, error) {
+	accounts := make([]types.AccountInfo, 0, len(c.accounts))
+	for _, account := range c.accounts {
+		accounts = append(accounts, types.AccountInfo{
+			AccountId:    aws.String(account.ID),
+			AccountName:  aws.String(account.Name),
+			EmailAddress: aws.String(account.Email),
+		})
+	}
+	return &sso.ListAccountsOutput{AccountList: accounts}, nil
+}
+
+func (c *fixtureSSOClient) ListAccountRoles(_ context.Context, params *sso.ListAccountRolesInput, _ ...func(*sso.Options)) (*sso.ListAccountRolesOutput, error) {
+	for _, account := range c.accounts {
+		if account.ID != aws.ToString(params.AccountId) {
+			continue
+		}
+		roles := make([]types.RoleInfo, 0, len(account.Roles))
+		for _, role := range account.Roles {
+			roles = append(roles, types.RoleInfo{AccountId: aws.String(account.ID), RoleName: aws.String(role)})
+		}
+		return &sso.ListAccountRolesOutput{RoleList: roles}, nil
+	}
+	return nil, fmt.Errorf("account %s is not in the demo fixtures", aws.ToString(params.AccountId))
+}
+
+func (c *fixtureSSOClient) GetRoleCredentials(context.Context, *sso.GetRoleCredentialsInput, ...func(*sso.Options)) (*sso.GetRoleCredentialsOutput, error) {
+	return nil, errFixtureCredentials
+}
@@ -3,9 +3,10 @@ package aws
 import (
 	"errors"
 	"fmt"
-	"html/template"
 	"sort"
+	"strconv"
 	"strings"
+	"text/template"
 )
 
 const (
@@ -13,13 +14,23 @@ const (
 	ssoSessionSection    = "sso-session"
 )
 
-var errProfileTemplateEmpty = errors.New("profile template cannot be empty")
+var (
+	errProfileTemplateEmpty = errors.New("profile template cannot be empty")
+	errProfileNameCollision = errors.New("profile name is generated by more than one sso session")
+)
 
 type generatedProfile struct {
 	AccountID   string
 	AccountName string
+	Attributes  map[string]string
 	Name        string
+	OU          string
 	RoleName    string
+	SSOSession  string
+	Tags        map[string]string
+
+	// VariantOf is the profile a region variant was copied from.
+	VariantOf string
 }
 
 // BuildConfigContent renders the AWS shared config content for discovered profiles.
@@ -38,8 +49,10 @@ func BuildGeneratedConfigContent(cfg *Config, profiles []DiscoveredProfile) (str
 		return "", nil, nil, errors.New("aws config is nil")
 	}
 
-	if strings.TrimSpace(cfg.SSO.SessionName) == "" {
-		return "", nil, nil, errors.New("sso session name is empty")
+	for _, name := range cfg.sessionNames() {
+		if strings.TrimSpace(name) == "" {
+			return "", nil, nil, errors.New("sso session name is empty")
+		}
 	}
 
 	profileNames, lookup, err := buildProfileIndex(cfg, profiles)
@@ -47,12 +60,7 @@ func BuildGeneratedConfigContent(cfg *Config, profiles []DiscoveredProfile) (str
 		return "", nil, nil, err
 	}
 
-	sourceProfiles := make(map[string]bool, len(lookup))
-	for name := range lookup {
-		sourceProfiles[name] = true
-	}
-
-	roleChainNames, roleChainLookup, warnings, err := buildRoleChainIndex(cfg, sourceProfiles)
+	roleChainNames, roleChainLookup, warnings, err := buildRoleChainIndex(cfg, lookup)
 	if err != nil {
 		return "", nil, nil, err
 	}
@@ -86,19 +94,26 @@ func buildProfileIndex(cfg *Config, profiles []DiscoveredProfile) ([]string, map
 		return nil, nil, errors.New("aws config is nil")
 	}
 
-	if strings.TrimSpace(cfg.ProfileTemplate) == "" {
-		return nil, nil, errProfileTemplateEmpty
+	attributes, err := compileProfileAttributes(cfg.ProfileAttributes, cfg.MarkerKey)
+	if err != nil {
+		return nil, nil, err
 	}
 
-	profileTemplate, err := template.New("profile").Option("missingkey=error").Parse(cfg.ProfileTemplate)
-	if err != nil {
-		return nil, nil, fmt.Errorf("parse profile template: %w", err)
+	sessions := cfg.sessionConfigs()
+	funcs := profileTemplateFuncs(cfg.AccountAliases)
+	templates := make(map[string]*template.Template, len(sessions))
+	for _, session := range sessions {
+		if err := parseProfileTemplate(templates, funcs, session.ProfileTemplate); err != nil {
+			return nil, nil, err
+		}
 	}
 
 	profileMap := make(map[string]generatedProfile)
+	discovered := make(map[string]DiscoveredProfile, len(profiles))
 	order := make([]string, 0, len(profiles))
 	for _, profile := range profiles {
-		name, err := executeTemplate(profileTemplate, profile)
+		session := findSession(sessions, profile.SSOSession)
+		name, err := executeTemplate(templates[session.ProfileTemplate], cfg.AccountAliases, profile)
 		if err != nil {
 			return nil, nil, err
 		}
@@ -106,61 +121,126 @@ func buildProfileIndex(cfg *Config, profiles []DiscoveredProfile) ([]string, map
 		if name == "" {
 			return nil, nil, errors.New("generated profile name is empty")
 		}
-		name = cfg.ProfilePrefix + name
-		if _, exists := profileMap[name]; !exists {
+		name = session.ProfilePrefix + name
+		existing, exists := profileMap[name]
+		if !exists {
 			order = append(order, name)
+		} else if existing.SSOSession != session.SSO.SessionName {
+			return nil, nil, fmt.Errorf("%w: %q comes from sessions %q and %q; set profile_prefix or profile_template to tell them apart",
+				errProfileNameCollision, name, existing.SSOSession, session.SSO.SessionName)
 		}
 		profileMap[name] = generatedProfile{
 			AccountID:   profile.AccountID,
 			AccountName: profile.AccountName,
+			Attributes:  resolveAttributes(attributes, profile),
 			Name:        name,
+			OU:          profile.OU,
 			RoleName:    profile.RoleName,
+			SSOSession:  session.SSO.SessionName,
+			Tags:        profile.Tags,
 		}
+		discovered[name] = profile
+	}
+
+	variants, err := addRegionVariants(cfg, discovered, profileMap)
+	if err != nil {
+		return nil, nil, err
 	}
+	order = append(order, variants...)
 
 	sort.Strings(order)
 	return order, profileMap, nil
 }
 
-func executeTemplate(tmpl *template.Template, profile DiscoveredProfile) (string, error) {
+// parseProfileTemplate parses text with funcs into templates unless a session
+// sharing the same template already did.
+func parseProfileTemplate(templates map[string]*template.Template, funcs template.FuncMap, text string) error {
+	if _, ok := templates[text]; ok {
+		return nil
+	}
+	if strings.TrimSpace(text) == "" {
+		return errProfileTemplateEmpty
+	}
+
+	tmpl, err := template.New("profile").Funcs(funcs).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return fmt.Errorf("parse profile template: %w", err)
+	}
+	templates[text] = tmpl
+	return nil
+}
+
+// findSession returns the session configuration named name, or the first
+// one for profiles that do not record their session.
+func findSession(sessions []*Config, name string) *Config {
+	for _, session := range sessions {
+		if session.SSO.SessionName == name {
+			return session
+		}
+	}
+	return sessions[0]
+}
+
+func executeTemplate(tmpl *template.Template, aliases map[string]string, profile DiscoveredProfile) (string, error) {
 	var builder strings.Builder
-	if err := tmpl.Execute(&builder, newTemplateData(profile)); err != nil {
+	if err := tmpl.Execute(&builder, newTemplateData(profile, accountAlias(aliases, profile))); err != nil {
 		return "", fmt.Errorf("execute profile template: %w", err)
 	}
 
 	return builder.String(), nil
 }
 
-func newTemplateData(profile DiscoveredProfile) map[string]string {
-	return map[string]string{
-		"AccountID":    profile.AccountID,
-		"AccountName":  profile.AccountName,
-		"RoleName":     profile.RoleName,
-		"SSORegion":    profile.SSORegion,
-		"account":      profile.AccountName,
-		"account_id":   profile.AccountID,
-		"account_name": profile.AccountName,
-		"role":         profile.RoleName,
-		"role_name":    profile.RoleName,
-		"sso_region":   profile.SSORegion,
+// newTemplateData exposes a profile to its name template. The account name
+// is replaced by accountName, its alias, while SSOAccountName keeps the name
+// IAM Identity Center reports. Tags is never nil, so `index .Tags "env"` is
+// empty for accounts without the tag.
+func newTemplateData(profile DiscoveredProfile, accountName string) map[string]any {
+	tags := profile.Tags
+	if tags == nil {
+		tags = map[string]string{}
+	}
+	return map[string]any{
+		"AccountID":        profile.AccountID,
+		"AccountName":      accountName,
+		"OU":               profile.OU,
+		"RoleName":         profile.RoleName,
+		"SSOAccountName":   profile.AccountName,
+		"SSORegion":        profile.SSORegion,
+		"Tags":             tags,
+		"account":          accountName,
+		"account_id":       profile.AccountID,
+		"account_name":     accountName,
+		"ou":               profile.OU,
+		"role":             profile.RoleName,
+		"role_name":        profile.RoleName,
+		"sso_account_name": profile.AccountName,
+		"sso_region":       profile.SSORegion,
+		"tags":             tags,
 	}
 }
 
 type roleChainProfile struct {
-	Name          string
-	Region        string
-	RoleARN       string
-	SourceProfile string
+	CredentialSource string
+	DurationSeconds  int
+	ExternalID       string
+	MFASerial        string
+	Name             string
+	Region           string
+	RoleARN          string
+	RoleSessionName  string
+	SourceProfile    string
 }
 
-func buildRoleChainIndex(cfg *Config, sourceProfiles map[string]bool) ([]string, map[string]roleChainProfile, []string, error) {
+func buildRoleChainIndex(cfg *Config, profiles map[string]generatedProfile) ([]string, map[string]roleChainProfile, []string, error) {
 	if cfg == nil {
 		return nil, nil, nil, errors.New("aws config is nil")
 	}
 
-	profileMap := make(map[string]roleChainProfile)
-	order := make([]string, 0, len(cfg.RoleChains))
-	warnings := []string{}
+	chains, warnings, err := expandRoleChainRules(cfg, profiles)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
 	for i, chain := range cfg.RoleChains {
 		name := strings.ToLower(strings.TrimSpace(chain.Name))
 		if name == "" {
@@ -168,9 +248,8 @@ func buildRoleChainIndex(cfg *Config, sourceProfiles map[string]bool) ([]string,
 		}
 		name = cfg.ProfilePrefix + name
 
-		sourceProfile := strings.ToLower(strings.TrimSpace(chain.SourceProfile))
-		if sourceProfile == "" {
-			return nil, nil, nil, fmt.Errorf("role chain source_profile is empty for %s", name)
+		if err := chain.validate(); err != nil {
+			return nil, nil, nil, fmt.Errorf("role chain %s: %w", name, err)
 		}
 
 		roleARN := strings.TrimSpace(chain.RoleARN)
@@ -178,20 +257,36 @@ func buildRoleChainIndex(cfg *Config, sourceProfiles map[string]bool) ([]string,
 			return nil, nil, nil, fmt.Errorf("role chain role_arn is empty for %s", name)
 		}
 
-		if _, ok := sourceProfiles[sourceProfile]; !ok {
-			warnings = append(warnings, fmt.Sprintf("source_profile %q not found in discovered profiles", sourceProfile))
-		}
+		chains = append(chains, roleChainProfile{
+			CredentialSource: strings.TrimSpace(chain.CredentialSource),
+			DurationSeconds:  chain.DurationSeconds,
+			ExternalID:       strings.TrimSpace(chain.ExternalID),
+			MFASerial:        strings.TrimSpace(chain.MFASerial),
+			Name:             name,
+			Region:           strings.TrimSpace(chain.Region),
+			RoleARN:          roleARN,
+			RoleSessionName:  strings.TrimSpace(chain.RoleSessionName),
+			SourceProfile:    strings.ToLower(strings.TrimSpace(chain.SourceProfile)),
+		})
+	}
 
-		if _, exists := profileMap[name]; !exists {
-			order = append(order, name)
+	profileMap := make(map[string]roleChainProfile, len(chains))
+	order := make([]string, 0, len(chains))
+	for _, chain := range chains {
+		if _, exists := profileMap[chain.Name]; !exists {
+			order = append(order, chain.Name)
 		}
-		profileMap[name] = roleChainProfile{
-			Name:          name,
-			Region:        strings.TrimSpace(chain.Region),
-			RoleARN:       roleARN,
-			SourceProfile: sourceProfile,
+		profileMap[chain.Name] = chain
+	}
+	var external map[string]bool
+	if cfg.Prune && len(profileMap) > 0 {
+		if external, err = handWrittenProfiles(cfg.ConfigPath, cfg.MarkerKey); err != nil {
+			return nil, nil, nil, err
 		}
 	}
+	if err := checkRoleChainSources(profileMap, profiles, external); err != nil {
+		return nil, nil, nil, err
+	}
 
 	sort.Strings(order)
 	return order, profileMap, warnings, nil
@@ -203,8 +298,9 @@ func writeHeader(builder *strings.Builder) {
 
 func writeDefaultSection(builder *strings.Builder, cfg *Config) {
 	writeSectionHeader(builder, "default")
-	writeKeyValue(builder, "region", cfg.SSO.Region)
+	writeKeyValue(builder, "region", cfg.sessionConfigs()[0].SSO.Region)
 	writeKeyValue(builder, "output", "json")
+	writeMarker(builder, cfg)
 	builder.WriteString("\n")
 }
 
@@ -216,11 +312,13 @@ func writeSSOSession(builder *strings.Builder, cfg *Config) error {
 		return errors.New("config builder is nil")
 	}
 
-	writeSectionHeader(builder, fmt.Sprintf("%s %s", ssoSessionSection, cfg.SSO.SessionName))
-	writeKeyValue(builder, "sso_start_url", cfg.SSO.StartURL)
-	writeKeyValue(builder, "sso_region", cfg.SSO.Region)
-	writeKeyValue(builder, "sso_registration_scopes", cfg.SSO.RegistrationScopes)
-	builder.WriteString("\n")
+	for _, session := range cfg.sessionConfigs() {
+		writeSectionHeader(builder, fmt.Sprintf("%s %s", ssoSessionSection, session.SSO.SessionName))
+		writeKeyValue(builder, "sso_start_url", session.SSO.StartURL)
+		writeKeyValue(builder, "sso_region", session.SSO.Region)
+		writeKeyValue(builder, "sso_registration_scopes", session.SSO.RegistrationScopes)
+		builder.WriteString("\n")
+	}
 
 	return nil
 }
@@ -234,11 +332,27 @@ func writeProfileSection(builder *strings.Builder, cfg *Config, profile generate
 
 func writeRoleChainSection(builder *strings.Builder, cfg *Config, profile roleChainProfile) {
 	writeSectionHeader(builder, profileSectionPrefix+profile.Name)
-	writeKeyValue(builder, "source_profile", profile.SourceProfile)
+	if profile.SourceProfile != "" {
+		writeKeyValue(builder, "source_profile", profile.SourceProfile)
+	} else {
+		writeKeyValue(builder, "credential_source", profile.CredentialSource)
+	}
 	writeKeyValue(builder, "role_arn", profile.RoleARN)
 	if profile.Region != "" {
 		writeKeyValue(builder, "region", profile.Region)
 	}
+	if profile.MFASerial != "" {
+		writeKeyValue(builder, "mfa_serial", profile.MFASerial)
+	}
+	if profile.ExternalID != "" {
+		writeKeyValue(builder, "external_id", profile.ExternalID)
+	}
+	if profile.RoleSessionName != "" {
+		writeKeyValue(builder, "role_session_name", profile.RoleSessionName)
+	}
+	if profile.DurationSeconds != 0 {
+		writeKeyValue(builder, "duration_seconds", strconv.Itoa(profile.DurationSeconds))
+	}
 	writeMarker(builder, cfg)
 	builder.WriteString("\n")
 }
@@ -254,12 +368,7 @@ func BuildCredentialProcessContent(cfg *Config, profiles []DiscoveredProfile) (s
 		return "", nil, nil, err
 	}
 
-	sourceProfiles := make(map[string]bool, len(lookup))
-	for name := range lookup {
-		sourceProfiles[name] = true
-	}
-
-	roleChainNames, roleChainLookup, warnings, err := buildRoleChainIndex(cfg, sourceProfiles)
+	roleChainNames, roleChainLookup, warnings, err := buildRoleChainIndex(cfg, lookup)
 	if err != nil {
 		return "", nil, nil, err
 	}
@@ -268,34 +377,50 @@ func BuildCredentialProcessContent(cfg *Config, profiles []DiscoveredProfile) (s
 	generatedNames := make([]string, 0, len(profileNames)+len(roleChainNames))
 	for _, name := range profileNames {
 		profile := lookup[name]
-		writeCredentialProcessSection(builder, profile.Name)
+		writeCredentialProcessSection(builder, cfg, profile.Name)
 		generatedNames = append(generatedNames, profile.Name)
 	}
 
 	for _, name := range roleChainNames {
 		profile := roleChainLookup[name]
-		writeCredentialProcessSection(builder, profile.Name)
+		writeCredentialProcessSection(builder, cfg, profile.Name)
 		generatedNames = append(generatedNames, profile.Name)
 	}
 
 	return strings.TrimRight(builder.String(), "\n"), generatedNames, warnings, nil
 }
 
-func writeCredentialProcessSection(builder *strings.Builder, profileName string) {
+func writeCredentialProcessSection(builder *strings.Builder, cfg *Config, profileName string) {
 	writeSectionHeader(builder, profileName)
-	writeKeyValue(builder, "credential_process", "granted credential-process --profile "+profileName)
+	writeKeyValue(builder, "credential_process", credentialProcessCommand(cfg, profileName))
+	writeMarker(builder, cfg)
 	builder.WriteString("\n")
 }
 
 func writeProfileEntry(builder *strings.Builder, cfg *Config, profile generatedProfile) {
 	if cfg.UseCredentialProcess {
-		writeKeyValue(builder, "credential_process", "granted credential-process --profile "+profile.Name)
-		return
+		writeKeyValue(builder, "credential_process", credentialProcessCommand(cfg, profile.Name))
+		if cfg.CredentialProcessHelper == credentialProcessCfgctl {
+			writeKeyValue(builder, cfgctlKeyPrefix+"sso_session", profile.SSOSession)
+			writeKeyValue(builder, cfgctlKeyPrefix+"sso_account_id", profile.AccountID)
+			writeKeyValue(builder, cfgctlKeyPrefix+"sso_role_name", profile.RoleName)
+		}
+	} else {
+		writeKeyValue(builder, "sso_session", profile.SSOSession)
+		writeKeyValue(builder, "sso_account_id", profile.AccountID)
+		writeKeyValue(builder, "sso_account_name", profile.AccountName)
+		writeKeyValue(builder, "sso_role_name", profile.RoleName)
+	}
+	writeVariantOf(builder, profile)
+
+	keys := make([]string, 0, len(profile.Attributes))
+	for key := range profile.Attributes {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		writeKeyValue(builder, key, profile.Attributes[key])
 	}
-	writeKeyValue(builder, "sso_session", cfg.SSO.SessionName)
-	writeKeyValue(builder, "sso_account_id", profile.AccountID)
-	writeKeyValue(builder, "sso_account_name", profile.AccountName)
-	writeKeyValue(builder, "sso_role_name", profile.RoleName)
 }
 
 func writeSectionHeader(builder *strings.Builder, name string) {
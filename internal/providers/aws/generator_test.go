@@ -1,6 +1,9 @@
 package aws
 
 import (
+	"errors"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
@@ -12,7 +15,8 @@ const testProfilePrefix = "sso_"
 const generatedHeader = "# This file was generated by cfgctl. Do not edit manually.\n\n" +
 	"[default]\n" +
 	"region = us-east-1\n" +
-	"output = json\n\n"
+	"output = json\n" +
+	"sso_auto_populated = true\n\n"
 
 func TestBuildConfigContent(t *testing.T) {
 	cfg := DefaultConfig()
@@ -67,6 +71,61 @@ sso_auto_populated = true`
 	}
 }
 
+func TestBuildConfigContentMultipleSessions(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.SSO.Region = testRegion
+	cfg.SSOSessions = []SSOSessionConfig{
+		{SSOConfig: SSOConfig{SessionName: "prod", StartURL: testStartURL}},
+		{
+			SSOConfig:     SSOConfig{SessionName: "acquired", StartURL: "https://acquired.awsapps.com/start", Region: "eu-west-1"},
+			ProfilePrefix: "acq-",
+		},
+	}
+
+	profiles := []DiscoveredProfile{
+		{AccountID: "111111111111", AccountName: "prod", RoleName: "Admin", SSOSession: "prod"},
+		{AccountID: "222222222222", AccountName: "prod", RoleName: "Admin", SSOSession: "acquired"},
+	}
+
+	content, _, err := BuildConfigContent(cfg, profiles)
+	if err != nil {
+		t.Fatalf("BuildConfigContent failed: %v", err)
+	}
+
+	expected := generatedHeader + `[sso-session prod]
+sso_start_url = https://example.awsapps.com/start
+sso_region = us-east-1
+sso_registration_scopes = sso:account:access
+
+[sso-session acquired]
+sso_start_url = https://acquired.awsapps.com/start
+sso_region = eu-west-1
+sso_registration_scopes = sso:account:access
+
+[profile acq-prod/admin]
+sso_session = acquired
+sso_account_id = 222222222222
+sso_account_name = prod
+sso_role_name = Admin
+sso_auto_populated = true
+
+[profile prod/admin]
+sso_session = prod
+sso_account_id = 111111111111
+sso_account_name = prod
+sso_role_name = Admin
+sso_auto_populated = true`
+
+	if content != expected {
+		t.Fatalf("config content = %q", content)
+	}
+
+	cfg.SSOSessions[1].ProfilePrefix = ""
+	if _, _, err := BuildConfigContent(cfg, profiles); !errors.Is(err, errProfileNameCollision) {
+		t.Fatalf("expected errProfileNameCollision, got %v", err)
+	}
+}
+
 func TestBuildConfigContentCredentialProcess(t *testing.T) {
 	cfg := DefaultConfig()
 	cfg.ProfilePrefix = testProfilePrefix
@@ -142,9 +201,11 @@ func TestBuildCredentialProcessContent(t *testing.T) {
 
 	expected := `[sso_prod/admin]
 credential_process = granted credential-process --profile sso_prod/admin
+sso_auto_populated = true
 
 [sso_prod-readonly]
-credential_process = granted credential-process --profile sso_prod-readonly`
+credential_process = granted credential-process --profile sso_prod-readonly
+sso_auto_populated = true`
 
 	if content != expected {
 		t.Fatalf("credential content = %q", content)
@@ -187,6 +248,121 @@ sso_auto_populated = true`
 	}
 }
 
+func TestBuildConfigContentTemplateFunctions(t *testing.T) {
+	tests := []struct {
+		name     string
+		template string
+		aliases  map[string]string
+		want     string
+	}{
+		{name: "kebab", template: "{{ kebab .AccountName }}_{{ .RoleName }}", want: "acme-prod-payments_adminaccess"},
+		{name: "replace", template: `{{ .AccountName | replace " " "" }}`, want: "acme:prod_payments"},
+		{name: "regex", template: `{{ .AccountName | regexReplace "^Acme: " "" | lower }}`, want: "prod_payments"},
+		{name: "trim prefix", template: `{{ trimPrefix "Acme: " .AccountName }}`, want: "prod_payments"},
+		{name: "upper", template: "{{ upper .RoleName }}", want: "adminaccess"},
+		{name: "ampersand not escaped", template: "{{ .AccountName }}&{{ .RoleName }}", want: "acme: prod_payments&adminaccess"},
+		{
+			name:     "alias by id",
+			template: "{{ .AccountName }}/{{ .RoleName }}",
+			aliases:  map[string]string{"123456789012": "payments"},
+			want:     "payments/adminaccess",
+		},
+		{
+			name:     "alias by name keeps sso name",
+			template: "{{ .AccountName }}.{{ kebab .SSOAccountName }}",
+			aliases:  map[string]string{"Acme: prod_payments": "payments"},
+			want:     "payments.acme-prod-payments",
+		},
+		{
+			name:     "alias function",
+			template: "{{ alias .account_id }}",
+			aliases:  map[string]string{"123456789012": "payments"},
+			want:     "payments",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := DefaultConfig()
+			cfg.ProfileTemplate = tt.template
+			cfg.AccountAliases = tt.aliases
+			cfg.SSO.Region = testRegion
+			cfg.SSO.StartURL = testStartURL
+
+			profiles := []DiscoveredProfile{{AccountID: "123456789012", AccountName: "Acme: prod_payments", RoleName: "AdminAccess"}}
+			names, _, err := buildProfileIndex(cfg, profiles)
+			if err != nil {
+				t.Fatalf("buildProfileIndex failed: %v", err)
+			}
+			if len(names) != 1 || names[0] != tt.want {
+				t.Fatalf("profile names = %v, want %q", names, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuildConfigContentRegexReplaceError(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.ProfileTemplate = `{{ .AccountName | regexReplace "(" "" }}`
+	cfg.SSO.Region = testRegion
+	cfg.SSO.StartURL = testStartURL
+
+	_, _, err := BuildConfigContent(cfg, []DiscoveredProfile{{AccountID: "1", AccountName: "prod", RoleName: "Admin"}})
+	if err == nil || !strings.Contains(err.Error(), "regexReplace") {
+		t.Fatalf("expected regexReplace error, got %v", err)
+	}
+}
+
+func TestBuildConfigContentProfileAttributes(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.SSO.Region = testRegion
+	cfg.SSO.StartURL = testStartURL
+	cfg.ProfileAttributes = []ProfileAttributeRule{
+		{Set: map[string]string{"output": "json", "CLI_Pager": ""}},
+		{Match: FilterRule{Accounts: []string{"prod-*"}}, Set: map[string]string{"region": "us-west-2", "output": "table"}},
+		{Match: FilterRule{Roles: []string{"Admin*"}}, Set: map[string]string{"duration_seconds": "3600"}},
+	}
+
+	profiles := []DiscoveredProfile{
+		{AccountID: "111111111111", AccountName: "prod-payments", RoleName: "AdminAccess"},
+		{AccountID: "222222222222", AccountName: "dev", RoleName: "ReadOnly"},
+	}
+
+	content, _, err := BuildConfigContent(cfg, profiles)
+	if err != nil {
+		t.Fatalf("BuildConfigContent failed: %v", err)
+	}
+
+	expected := generatedHeader + `[sso-session cfgctl]
+sso_start_url = https://example.awsapps.com/start
+sso_region = us-east-1
+sso_registration_scopes = sso:account:access
+
+[profile dev/readonly]
+sso_session = cfgctl
+sso_account_id = 222222222222
+sso_account_name = dev
+sso_role_name = ReadOnly
+cli_pager = 
+output = json
+sso_auto_populated = true
+
+[profile prod-payments/adminaccess]
+sso_session = cfgctl
+sso_account_id = 111111111111
+sso_account_name = prod-payments
+sso_role_name = AdminAccess
+cli_pager = 
+duration_seconds = 3600
+output = table
+region = us-west-2
+sso_auto_populated = true`
+
+	if content != expected {
+		t.Fatalf("config content = %q", content)
+	}
+}
+
 func TestBuildConfigContentOverwritesOnCollision(t *testing.T) {
 	cfg := DefaultConfig()
 	cfg.ProfileTemplate = "{{ .AccountName }}-{{ .RoleName }}"
@@ -379,7 +555,7 @@ func TestBuildConfigContentWithPrune(t *testing.T) {
 
 	// buildConfigContent is the internal function that handles prune logic
 	// When the output file doesn't exist, prune merge should still work
-	content, _, err := buildConfigContent(cfg, "/nonexistent/path/config", profiles, result)
+	content, _, err := buildConfigContent(cfg, "/nonexistent/path/config", profiles, nil, result)
 	if err != nil {
 		t.Fatalf("buildConfigContent failed: %v", err)
 	}
@@ -406,7 +582,7 @@ func TestBuildConfigContentWithoutPrune(t *testing.T) {
 		Metadata: make(map[string]interface{}),
 	}
 
-	content, names, err := buildConfigContent(cfg, "/tmp/config", profiles, result)
+	content, names, err := buildConfigContent(cfg, "/tmp/config", profiles, nil, result)
 	if err != nil {
 		t.Fatalf("buildConfigContent failed: %v", err)
 	}
@@ -426,7 +602,7 @@ func TestBuildCredentialProcessContentNilConfig(t *testing.T) {
 	}
 }
 
-func TestBuildConfigContentRoleChainWarnings(t *testing.T) {
+func TestBuildConfigContentRoleChainDanglingSource(t *testing.T) {
 	cfg := DefaultConfig()
 	cfg.ProfilePrefix = testProfilePrefix
 	cfg.SSO.Region = testRegion
@@ -443,11 +619,205 @@ func TestBuildConfigContentRoleChainWarnings(t *testing.T) {
 
 	profiles := []DiscoveredProfile{}
 
-	_, warnings, err := BuildConfigContent(cfg, profiles)
+	_, _, err := BuildConfigContent(cfg, profiles)
+	if !errors.Is(err, errRoleChainDanglingSource) {
+		t.Fatalf("expected dangling source error, got %v", err)
+	}
+}
+
+func TestBuildConfigContentRoleChainCycle(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.SSO.Region = testRegion
+	cfg.SSO.StartURL = testStartURL
+	cfg.RoleChains = []RoleChain{
+		{Name: "a", RoleARN: "arn:aws:iam::111111111111:role/A", SourceProfile: "b"},
+		{Name: "b", RoleARN: "arn:aws:iam::111111111111:role/B", SourceProfile: "c"},
+		{Name: "c", RoleARN: "arn:aws:iam::111111111111:role/C", SourceProfile: "a"},
+	}
+
+	_, _, err := BuildConfigContent(cfg, nil)
+	if !errors.Is(err, errRoleChainCycle) {
+		t.Fatalf("expected cycle error, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "a -> b -> c -> a") {
+		t.Fatalf("cycle error does not show the loop: %v", err)
+	}
+}
+
+func TestBuildConfigContentRoleChainCollision(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.SSO.Region = testRegion
+	cfg.SSO.StartURL = testStartURL
+	cfg.RoleChains = []RoleChain{
+		{Name: "prod/admin", RoleARN: "arn:aws:iam::111111111111:role/A", CredentialSource: "Environment"},
+	}
+
+	_, _, err := BuildConfigContent(cfg, []DiscoveredProfile{{AccountID: "111111111111", AccountName: "prod", RoleName: "Admin"}})
+	if !errors.Is(err, errRoleChainCollision) {
+		t.Fatalf("expected collision error, got %v", err)
+	}
+}
+
+func TestBuildConfigContentRoleChainOptions(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.SSO.Region = testRegion
+	cfg.SSO.StartURL = testStartURL
+	cfg.MarkerKey = ""
+	cfg.RoleChains = []RoleChain{
+		{
+			Name:            "audit",
+			RoleARN:         "arn:aws:iam::333333333333:role/Audit",
+			SourceProfile:   "prod/admin",
+			MFASerial:       "arn:aws:iam::111111111111:mfa/alice",
+			ExternalID:      "cfgctl-audit",
+			RoleSessionName: "alice",
+			DurationSeconds: 3600,
+		},
+		{
+			Name:             "ci",
+			RoleARN:          "arn:aws:iam::333333333333:role/Deploy",
+			CredentialSource: "Ec2InstanceMetadata",
+		},
+		{
+			Name:          "audit-readonly",
+			RoleARN:       "arn:aws:iam::444444444444:role/ReadOnly",
+			SourceProfile: "audit",
+		},
+	}
+
+	content, _, err := BuildConfigContent(cfg, []DiscoveredProfile{{AccountID: "111111111111", AccountName: "prod", RoleName: "Admin"}})
 	if err != nil {
 		t.Fatalf("BuildConfigContent failed: %v", err)
 	}
-	if len(warnings) != 1 {
-		t.Fatalf("expected 1 warning, got %v", warnings)
+
+	expected := `[profile audit]
+source_profile = prod/admin
+role_arn = arn:aws:iam::333333333333:role/Audit
+mfa_serial = arn:aws:iam::111111111111:mfa/alice
+external_id = cfgctl-audit
+role_session_name = alice
+duration_seconds = 3600
+
+[profile audit-readonly]
+source_profile = audit
+role_arn = arn:aws:iam::444444444444:role/ReadOnly
+
+[profile ci]
+credential_source = Ec2InstanceMetadata
+role_arn = arn:aws:iam::333333333333:role/Deploy`
+
+	if !strings.HasSuffix(content, expected) {
+		t.Fatalf("config content = %q", content)
+	}
+}
+
+func TestBuildConfigContentRoleChainRules(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.SSO.Region = testRegion
+	cfg.SSO.StartURL = testStartURL
+	cfg.MarkerKey = ""
+	cfg.AccountAliases = map[string]string{"333333333333": "audit"}
+	cfg.RoleChainRules = []RoleChainRule{
+		{
+			Match:            FilterRule{Roles: []string{"Admin*"}},
+			RoleName:         "OrganizationAccountAccessRole",
+			TargetAccountIDs: []string{"333333333333", "444444444444"},
+			Region:           "eu-west-1",
+		},
+		{
+			Match:            FilterRule{Accounts: []string{"nothing-*"}},
+			RoleName:         "ReadOnly",
+			TargetAccountIDs: []string{"444444444444"},
+		},
+	}
+
+	profiles := []DiscoveredProfile{
+		{AccountID: "111111111111", AccountName: "prod", RoleName: "AdminAccess"},
+		{AccountID: "111111111111", AccountName: "prod", RoleName: "ReadOnly"},
+	}
+
+	content, names, warnings, err := BuildGeneratedConfigContent(cfg, profiles)
+	if err != nil {
+		t.Fatalf("BuildGeneratedConfigContent failed: %v", err)
+	}
+	if len(warnings) != 1 || !strings.Contains(warnings[0], "role_chain_rules[1]") {
+		t.Fatalf("warnings = %v", warnings)
+	}
+
+	wantNames := []string{"prod/adminaccess", "prod/readonly", "prod/adminaccess/444444444444/organizationaccountaccessrole", "prod/adminaccess/audit/organizationaccountaccessrole"}
+	if strings.Join(names, ",") != strings.Join(wantNames, ",") {
+		t.Fatalf("names = %v, want %v", names, wantNames)
+	}
+
+	expected := `[profile prod/adminaccess/audit/organizationaccountaccessrole]
+source_profile = prod/adminaccess
+role_arn = arn:aws:iam::333333333333:role/OrganizationAccountAccessRole
+region = eu-west-1`
+	if !strings.HasSuffix(content, expected) {
+		t.Fatalf("config content = %q", content)
+	}
+}
+
+func TestBuildConfigContentRoleChainRulesPrefixAndPartition(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.ProfilePrefix = testProfilePrefix
+	cfg.SSO.Region = "us-gov-west-1"
+	cfg.SSO.StartURL = testStartURL
+	cfg.MarkerKey = ""
+	cfg.RoleChainRules = []RoleChainRule{
+		{RoleName: "Deploy", TargetAccountIDs: []string{"333333333333"}},
+	}
+
+	profiles := []DiscoveredProfile{{AccountID: "111111111111", AccountName: "prod", RoleName: "Admin"}}
+
+	content, names, _, err := BuildGeneratedConfigContent(cfg, profiles)
+	if err != nil {
+		t.Fatalf("BuildGeneratedConfigContent failed: %v", err)
+	}
+
+	wantNames := []string{"sso_prod/admin", "sso_prod/admin/333333333333/deploy"}
+	if strings.Join(names, ",") != strings.Join(wantNames, ",") {
+		t.Fatalf("names = %v, want %v", names, wantNames)
+	}
+
+	expected := `[profile sso_prod/admin/333333333333/deploy]
+source_profile = sso_prod/admin
+role_arn = arn:aws-us-gov:iam::333333333333:role/Deploy`
+	if !strings.HasSuffix(content, expected) {
+		t.Fatalf("config content = %q", content)
+	}
+}
+
+func TestBuildConfigContentRoleChainHandWrittenSource(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config")
+	existing := `[profile legacy]
+aws_access_key_id = AKIAEXAMPLE
+
+[profile sso_prod/admin]
+sso_account_id = 111111111111
+sso_auto_populated = true
+`
+	if err := os.WriteFile(path, []byte(existing), 0o600); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+
+	cfg := DefaultConfig()
+	cfg.ConfigPath = path
+	cfg.SSO.Region = testRegion
+	cfg.SSO.StartURL = testStartURL
+	cfg.RoleChains = []RoleChain{{Name: "ci", RoleARN: "arn:aws:iam::111111111111:role/Deploy", SourceProfile: "legacy"}}
+
+	if _, _, err := BuildConfigContent(cfg, nil); !errors.Is(err, errRoleChainDanglingSource) {
+		t.Fatalf("expected dangling source error without prune, got %v", err)
+	}
+
+	cfg.Prune = true
+	if _, _, err := BuildConfigContent(cfg, nil); err != nil {
+		t.Fatalf("hand-written source with prune: %v", err)
+	}
+
+	cfg.RoleChains[0].SourceProfile = "sso_prod/admin"
+	if _, _, err := BuildConfigContent(cfg, nil); !errors.Is(err, errRoleChainDanglingSource) {
+		t.Fatalf("expected dangling source error for a managed profile, got %v", err)
 	}
 }
@@ -0,0 +1,263 @@
+package aws
+
+import (
+	"strings"
+)
+
+// iniFile is a parsed AWS config or credentials file. It keeps every line as
+// written, so rendering an unedited file reproduces it byte-for-byte, and
+// edits touch only the lines they change.
+type iniFile struct {
+	// preamble holds the lines before the first section.
+	preamble []string
+	sections []*iniSection
+
+	// cr is "\r" for files with CRLF line endings, which is kept on new lines.
+	cr string
+}
+
+// iniSection is a section header with the lines that follow it up to the
+// next section. Comment lines directly above the header belong to it, so
+// they move or disappear with the section.
+type iniSection struct {
+	lines  []string
+	header int
+	name   string
+}
+
+// iniProperty is a top-level "key = value" line.
+type iniProperty struct {
+	key   string
+	value string
+}
+
+func parseINI(content string) *iniFile {
+	file := &iniFile{}
+	if strings.Contains(content, "\r\n") {
+		file.cr = "\r"
+	}
+
+	var current *iniSection
+	for _, line := range strings.Split(content, "\n") {
+		name, ok := iniSectionName(line)
+		if !ok {
+			if current == nil {
+				file.preamble = append(file.preamble, line)
+			} else {
+				current.lines = append(current.lines, line)
+			}
+			continue
+		}
+
+		section := &iniSection{name: name}
+		if current == nil {
+			file.preamble, section.lines = splitLeadingComments(file.preamble)
+		} else {
+			current.lines, section.lines = splitLeadingComments(current.lines)
+		}
+		section.header = len(section.lines)
+		section.lines = append(section.lines, line)
+		file.sections = append(file.sections, section)
+		current = section
+	}
+
+	return file
+}
+
+// iniSectionName returns the normalized name of a section header line, such
+// as "profile prod" for "[ profile   prod ]".
+func iniSectionName(line string) (string, bool) {
+	trimmed := strings.TrimSpace(line)
+	if len(trimmed) < 2 || trimmed[0] != '[' || trimmed[len(trimmed)-1] != ']' {
+		return "", false
+	}
+	return strings.Join(strings.Fields(trimmed[1:len(trimmed)-1]), " "), true
+}
+
+// splitLeadingComments splits off the comment lines at the end of lines,
+// which describe the section header that follows them.
+func splitLeadingComments(lines []string) ([]string, []string) {
+	start := len(lines)
+	for start > 0 && isINIComment(lines[start-1]) {
+		start--
+	}
+	leading := append([]string(nil), lines[start:]...)
+	return lines[:start], leading
+}
+
+func isINIComment(line string) bool {
+	trimmed := strings.TrimSpace(line)
+	return strings.HasPrefix(trimmed, "#") || strings.HasPrefix(trimmed, ";")
+}
+
+// parseINIProperty parses a top-level "key = value" line. Comments, blank
+// lines, and indented sub-property lines are not properties.
+func parseINIProperty(line string) (iniProperty, bool) {
+	if line == "" || line[0] == ' ' || line[0] == '\t' || isINIComment(line) {
+		return iniProperty{}, false
+	}
+	key, value, ok := strings.Cut(line, "=")
+	key = strings.TrimSpace(key)
+	if !ok || key == "" {
+		return iniProperty{}, false
+	}
+	return iniProperty{key: key, value: strings.TrimSpace(value)}, true
+}
+
+func isINIContinuation(line string) bool {
+	return line != "" && (line[0] == ' ' || line[0] == '\t') && strings.TrimSpace(line) != ""
+}
+
+// String renders the file.
+func (f *iniFile) String() string {
+	lines := append([]string(nil), f.preamble...)
+	for _, section := range f.sections {
+		lines = append(lines, section.lines...)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// section returns the section with the given normalized name, or nil.
+func (f *iniFile) section(name string) *iniSection {
+	for _, section := range f.sections {
+		if section.name == name {
+			return section
+		}
+	}
+	return nil
+}
+
+// remove deletes section and its lines.
+func (f *iniFile) remove(section *iniSection) {
+	for i, candidate := range f.sections {
+		if candidate == section {
+			f.sections = append(f.sections[:i], f.sections[i+1:]...)
+			return
+		}
+	}
+}
+
+// appendSection adds section at the end of the file, separated from the
+// preceding content by one blank line.
+func (f *iniFile) appendSection(section *iniSection) {
+	lines := &f.preamble
+	if len(f.sections) > 0 {
+		lines = &f.sections[len(f.sections)-1].lines
+	}
+	trimmed := trimTrailingBlank(*lines)
+	if len(trimmed) > 0 || len(f.sections) > 0 {
+		trimmed = append(trimmed, f.cr)
+	}
+	*lines = trimmed
+
+	body := make([]string, 0, len(section.lines)+1)
+	for _, line := range trimTrailingBlank(section.lines) {
+		body = append(body, strings.TrimSuffix(line, "\r")+f.cr)
+	}
+	f.sections = append(f.sections, &iniSection{
+		lines:  append(body, ""),
+		header: section.header,
+		name:   section.name,
+	})
+}
+
+func trimTrailingBlank(lines []string) []string {
+	end := len(lines)
+	for end > 0 && strings.TrimSpace(lines[end-1]) == "" {
+		end--
+	}
+	return lines[:end]
+}
+
+// properties returns the section's top-level properties in order.
+func (s *iniSection) properties() []iniProperty {
+	var properties []iniProperty
+	for _, line := range s.lines[s.header+1:] {
+		if property, ok := parseINIProperty(line); ok {
+			properties = append(properties, property)
+		}
+	}
+	return properties
+}
+
+// hasKey reports whether the section sets key, ignoring case.
+func (s *iniSection) hasKey(key string) bool {
+	key = strings.TrimSpace(key)
+	if key == "" {
+		return false
+	}
+	for _, property := range s.properties() {
+		if strings.EqualFold(property.key, key) {
+			return true
+		}
+	}
+	return false
+}
+
+// update makes the section's properties match want. Changed values are
+// rewritten keeping the line's own spacing, properties missing from want are
+// deleted with their sub-properties, and new ones are added after the last
+// property. Comments and blank lines are left where they are.
+func (s *iniSection) update(want []iniProperty, cr string) {
+	values := make(map[string]string, len(want))
+	for _, property := range want {
+		values[strings.ToLower(property.key)] = property.value
+	}
+
+	lines := append([]string(nil), s.lines[:s.header+1]...)
+	seen := make(map[string]bool, len(want))
+	insertAt := len(lines)
+	kept, dropped := false, false
+	for _, line := range s.lines[s.header+1:] {
+		if isINIContinuation(line) && (kept || dropped) {
+			if kept {
+				lines = append(lines, line)
+				insertAt = len(lines)
+			}
+			continue
+		}
+		kept, dropped = false, false
+
+		property, ok := parseINIProperty(line)
+		if !ok {
+			lines = append(lines, line)
+			continue
+		}
+		key := strings.ToLower(property.key)
+		value, keep := values[key]
+		if !keep {
+			dropped = true
+			continue
+		}
+		seen[key], kept = true, true
+		if value != property.value {
+			line = rewriteINIValue(line, value)
+		}
+		lines = append(lines, line)
+		insertAt = len(lines)
+	}
+
+	var added []string
+	for _, property := range want {
+		if !seen[strings.ToLower(property.key)] {
+			added = append(added, property.key+" = "+property.value+cr)
+		}
+	}
+	lines = append(lines[:insertAt], append(added, lines[insertAt:]...)...)
+	s.lines = lines
+}
+
+// rewriteINIValue replaces the value in a "key = value" line, keeping the
+// key, the spacing around "=", and any carriage return.
+func rewriteINIValue(line, value string) string {
+	cr := ""
+	if strings.HasSuffix(line, "\r") {
+		cr = "\r"
+		line = strings.TrimSuffix(line, "\r")
+	}
+	idx := strings.Index(line, "=") + 1
+	for idx < len(line) && (line[idx] == ' ' || line[idx] == '\t') {
+		idx++
+	}
+	return line[:idx] + value + cr
+}
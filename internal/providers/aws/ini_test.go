@@ -0,0 +1,156 @@
+package aws
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseINIRoundTrip(t *testing.T) {
+	inputs := []string{
+		"",
+		"no sections\n",
+		"[default]\nregion = us-east-1",
+		"# header\n\n[default]\r\nregion=us-east-1\r\n\r\n; note\r\n[ profile  x ]\r\n  indented = 1\r\n\r\n\r\n",
+		"[profile a]\nkey = value\n# about b\n# more\n[profile b]\n\n\n",
+		"[profile a]\nregion = us-east-1\nregion = us-west-2\n",
+		"[default]\nregion = us-east-1\n\n# shared\n\n; x\n[profile x]\nregion = eu-west-1\n",
+		"[profile a]\r\nkey = value\r\n[profile b]\r\nkey = value",
+	}
+	for _, input := range inputs {
+		if got := parseINI(input).String(); got != input {
+			t.Errorf("round trip of %q = %q", input, got)
+		}
+	}
+}
+
+func TestParseINISections(t *testing.T) {
+	file := parseINI("top\n[default]\nregion = us-east-1\n# about prod\n[ profile   prod ]\nsso_role_name = Admin\ns3 =\n  max = 1\n")
+
+	if !reflect.DeepEqual(file.preamble, []string{"top"}) {
+		t.Fatalf("preamble = %q", file.preamble)
+	}
+	prod := file.section("profile prod")
+	if prod == nil {
+		t.Fatal("expected normalized section name \"profile prod\"")
+	}
+	if prod.lines[0] != "# about prod" || prod.header != 1 {
+		t.Fatalf("expected leading comment to belong to prod, got %q (header %d)", prod.lines, prod.header)
+	}
+	want := []iniProperty{{key: "sso_role_name", value: "Admin"}, {key: "s3", value: ""}}
+	if got := prod.properties(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("properties = %+v, want %+v", got, want)
+	}
+	if !prod.hasKey("SSO_ROLE_NAME") || prod.hasKey("max") {
+		t.Fatal("hasKey should match top-level keys case-insensitively")
+	}
+}
+
+func TestParseINIEdgeCases(t *testing.T) {
+	t.Run("comments and blank lines between sections", func(t *testing.T) {
+		file := parseINI("[profile a]\nkey = 1\n\n# trailing note\n\n# about b\n[profile b]\nkey = 2\n")
+		a, b := file.section("profile a"), file.section("profile b")
+		if a == nil || b == nil {
+			t.Fatalf("sections = %+v", file.sections)
+		}
+		// Only the comment block directly above a header belongs to it.
+		if !reflect.DeepEqual(b.lines[:b.header], []string{"# about b"}) {
+			t.Fatalf("b leading lines = %q", b.lines[:b.header])
+		}
+		if !reflect.DeepEqual(a.lines, []string{"[profile a]", "key = 1", "", "# trailing note", ""}) {
+			t.Fatalf("a lines = %q", a.lines)
+		}
+		if want := []iniProperty{{key: "key", value: "1"}}; !reflect.DeepEqual(a.properties(), want) {
+			t.Fatalf("a properties = %+v", a.properties())
+		}
+	})
+
+	t.Run("duplicate keys", func(t *testing.T) {
+		file := parseINI("[profile a]\nregion = us-east-1\nRegion = us-west-2\n")
+		want := []iniProperty{{key: "region", value: "us-east-1"}, {key: "Region", value: "us-west-2"}}
+		if got := file.section("profile a").properties(); !reflect.DeepEqual(got, want) {
+			t.Fatalf("properties = %+v, want %+v", got, want)
+		}
+		// Later values win, as in the AWS CLI.
+		if got := iniValues(file.section("profile a"))["region"]; got != "us-west-2" {
+			t.Fatalf("region = %q, want us-west-2", got)
+		}
+	})
+
+	t.Run("default alongside profile", func(t *testing.T) {
+		file := parseINI("[default]\nregion = us-east-1\n[profile default]\nregion = eu-west-1\n[profile x]\nregion = us-west-2\n")
+		if len(file.sections) != 3 {
+			t.Fatalf("sections = %d, want 3", len(file.sections))
+		}
+		for name, region := range map[string]string{"default": "us-east-1", "profile default": "eu-west-1", "profile x": "us-west-2"} {
+			section := file.section(name)
+			if section == nil || iniValues(section)["region"] != region {
+				t.Fatalf("section %q = %+v, want region %s", name, section, region)
+			}
+		}
+		if file.section("x") != nil {
+			t.Fatal("expected [profile x] not to match \"x\"")
+		}
+	})
+
+	t.Run("no trailing newline", func(t *testing.T) {
+		file := parseINI("[profile a]\r\nkey = value\r\n[profile b]\r\nkey = last")
+		if got := file.section("profile b").properties(); !reflect.DeepEqual(got, []iniProperty{{key: "key", value: "last"}}) {
+			t.Fatalf("properties = %+v", got)
+		}
+		if got := file.section("profile a").properties(); !reflect.DeepEqual(got, []iniProperty{{key: "key", value: "value"}}) {
+			t.Fatalf("properties = %+v", got)
+		}
+	})
+}
+
+func TestMergeINIEdgeCases(t *testing.T) {
+	managed := func(section *iniSection) bool { return section.hasKey("cfgctl_managed") }
+	tests := []struct {
+		name      string
+		existing  string
+		generated string
+		want      string
+	}{
+		{
+			name:      "comments and blank lines between sections",
+			existing:  "# mine\n[profile keep]\nregion = us-east-1\n\n# about old\n[profile old]\ncfgctl_managed = true\n\n# about new\n[profile new]\ncfgctl_managed = true\nregion = us-east-1\n",
+			generated: "[profile new]\ncfgctl_managed = true\nregion = us-west-2\n",
+			want:      "# mine\n[profile keep]\nregion = us-east-1\n\n# about new\n[profile new]\ncfgctl_managed = true\nregion = us-west-2\n",
+		},
+		{
+			name:      "duplicate keys",
+			existing:  "[profile a]\ncfgctl_managed = true\nregion = us-east-1\nregion = eu-west-1\n",
+			generated: "[profile a]\ncfgctl_managed = true\nregion = us-west-2\n",
+			want:      "[profile a]\ncfgctl_managed = true\nregion = us-west-2\nregion = us-west-2\n",
+		},
+		{
+			name:      "default alongside profile",
+			existing:  "[default]\nregion = us-east-1\n\n[profile default]\ncfgctl_managed = true\n\n[profile x]\ncfgctl_managed = true\n",
+			generated: "[profile x]\ncfgctl_managed = true\nregion = eu-west-1\n",
+			want:      "[default]\nregion = us-east-1\n\n[profile x]\ncfgctl_managed = true\nregion = eu-west-1\n",
+		},
+		{
+			name:      "no trailing newline",
+			existing:  "[profile keep]\nregion = us-east-1",
+			generated: "[profile new]\ncfgctl_managed = true\n",
+			want:      "[profile keep]\nregion = us-east-1\n\n[profile new]\ncfgctl_managed = true\n",
+		},
+		{
+			name:      "pruned last section without trailing newline",
+			existing:  "[profile keep]\nregion = us-east-1\n\n[profile old]\ncfgctl_managed = true",
+			generated: "",
+			want:      "[profile keep]\nregion = us-east-1\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file, kept := mergeINI(parseINI(tt.existing), parseINI(tt.generated), managed, nil)
+			if len(kept) != 0 {
+				t.Fatalf("kept = %v", kept)
+			}
+			if got := file.String(); got != tt.want {
+				t.Fatalf("merged =\n%q\nwant\n%q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,38 @@
+package aws
+
+import (
+	"strings"
+
+	"github.com/jmreicha/cfgctl/internal/core"
+)
+
+// buildInventory returns the profiles in the written config content for
+// publishing to other providers. Generated profiles are completed from the
+// discovered accounts, since credential_process profiles do not record them;
+// hand-written profiles that kept a generated name are left as written.
+func buildInventory(cfg *Config, content string, profiles []DiscoveredProfile) ([]core.AWSProfile, error) {
+	_, lookup, err := buildProfileIndex(cfg, profiles)
+	if err != nil {
+		return nil, err
+	}
+
+	marked := strings.TrimSpace(cfg.MarkerKey) != ""
+	inventory := core.ParseAWSProfilesContent(content, cfg.MarkerKey)
+	for i := range inventory {
+		generated, ok := lookup[inventory[i].Name]
+		if !ok || (marked && !inventory[i].Managed) {
+			continue
+		}
+		entry := &inventory[i]
+		if entry.AccountID == "" {
+			entry.AccountID = generated.AccountID
+		}
+		if entry.AccountName == "" {
+			entry.AccountName = generated.AccountName
+		}
+		if entry.RoleName == "" {
+			entry.RoleName = generated.RoleName
+		}
+	}
+	return inventory, nil
+}
@@ -0,0 +1,414 @@
+package aws
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/jmreicha/cfgctl/internal/core"
+)
+
+// Lint checks reported by LintFiles.
+const (
+	LintDuplicateSection     = "duplicate-section"
+	LintMissingSSOSession    = "missing-sso-session"
+	LintMissingSourceProfile = "missing-source-profile"
+	LintRoleChainCycle       = "role-chain-cycle"
+	LintLegacySSO            = "legacy-sso"
+	LintStaticKeys           = "static-keys"
+	LintStaleProfile         = "stale-profile"
+	LintFilePermissions      = "file-permissions"
+)
+
+// ErrLintFindings is returned by callers that fail when findings remain.
+var ErrLintFindings = errors.New("aws config has lint findings")
+
+// LintFinding is one problem found in the AWS config or credentials file.
+type LintFinding struct {
+	Check   string
+	Path    string
+	Section string
+	Message string
+
+	// Fixed reports that --fix rewrote the file to resolve the finding.
+	Fixed bool
+}
+
+// String renders the finding as "path [section] check: message".
+func (f LintFinding) String() string {
+	location := f.Path
+	if f.Section != "" {
+		location += " [" + f.Section + "]"
+	}
+	text := fmt.Sprintf("%s %s: %s", location, f.Check, f.Message)
+	if f.Fixed {
+		text += " (fixed)"
+	}
+	return text
+}
+
+// LintOptions controls LintFiles.
+type LintOptions struct {
+	// Generated lists the profile names the current discovery generates.
+	// Marker-tagged profiles missing from it are reported as stale; nil
+	// skips the check.
+	Generated []string
+
+	// Fix applies the safe rewrites: stale generated profiles are removed,
+	// legacy sso profiles move to a matching sso-session, and file modes are
+	// tightened to 0600.
+	Fix bool
+
+	// Backups, when set, keeps a copy of each file Fix rewrites, taken
+	// before it is written.
+	Backups *core.BackupManager
+}
+
+// lintFile is a config or credentials file being linted.
+type lintFile struct {
+	path    string
+	file    *iniFile
+	mode    fs.FileMode
+	changed bool
+}
+
+// LintFiles checks the AWS config and credentials files at cfg's paths.
+// Missing files are skipped.
+func LintFiles(cfg *Config, opts LintOptions) ([]LintFinding, error) {
+	if cfg == nil {
+		return nil, errors.New("aws config is nil")
+	}
+
+	configFile, err := readLintFile(cfg.ConfigPath)
+	if err != nil {
+		return nil, err
+	}
+	credentialsFile, err := readLintFile(cfg.CredentialsPath)
+	if err != nil {
+		return nil, err
+	}
+
+	var findings []LintFinding
+	for _, file := range []*lintFile{configFile, credentialsFile} {
+		if file != nil {
+			findings = append(findings, lintDuplicates(file)...)
+		}
+	}
+	if configFile != nil {
+		findings = append(findings, lintReferences(configFile, credentialsFile)...)
+		findings = append(findings, lintRoleChainCycles(configFile)...)
+		findings = append(findings, lintLegacySSO(configFile, opts.Fix)...)
+	}
+	for _, file := range []*lintFile{configFile, credentialsFile} {
+		if file == nil {
+			continue
+		}
+		findings = append(findings, lintStaticKeys(file, file == configFile)...)
+		if opts.Generated != nil {
+			findings = append(findings, lintStale(file, file == configFile, cfg.MarkerKey, opts.Generated, opts.Fix)...)
+		}
+		findings = append(findings, lintPermissions(file, opts.Fix)...)
+	}
+
+	if opts.Fix {
+		for _, file := range []*lintFile{configFile, credentialsFile} {
+			if file == nil || !file.changed {
+				continue
+			}
+			if opts.Backups != nil {
+				if _, _, err := opts.Backups.Backup(ProviderName, file.path); err != nil {
+					return nil, fmt.Errorf("back up %s: %w", file.path, err)
+				}
+			}
+			if err := os.WriteFile(file.path, []byte(file.file.String()), 0o600); err != nil {
+				return nil, fmt.Errorf("write %s: %w", file.path, err)
+			}
+		}
+	}
+	return findings, nil
+}
+
+func readLintFile(path string) (*lintFile, error) {
+	if strings.TrimSpace(path) == "" {
+		return nil, nil
+	}
+	info, err := os.Stat(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("stat %s: %w", path, err)
+	}
+	content, err := readConfigFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return &lintFile{path: path, file: parseINI(content), mode: info.Mode().Perm()}, nil
+}
+
+// profileName returns the profile a section defines, and false for
+// sso-session and other non-profile sections of the config file. Every
+// section of the credentials file is a profile.
+func profileName(sectionName string, config bool) (string, bool) {
+	if !config {
+		return sectionName, true
+	}
+	if sectionName == "default" {
+		return sectionName, true
+	}
+	return strings.CutPrefix(sectionName, profileSectionPrefix)
+}
+
+func lintDuplicates(file *lintFile) []LintFinding {
+	var findings []LintFinding
+	seen := make(map[string]bool, len(file.file.sections))
+	for _, section := range file.file.sections {
+		if seen[section.name] {
+			findings = append(findings, LintFinding{
+				Check:   LintDuplicateSection,
+				Path:    file.path,
+				Section: section.name,
+				Message: "section is defined more than once",
+			})
+		}
+		seen[section.name] = true
+	}
+	return findings
+}
+
+// lintReferences reports profiles whose sso_session or source_profile names
+// something neither file defines.
+func lintReferences(configFile, credentialsFile *lintFile) []LintFinding {
+	profiles := map[string]bool{}
+	for _, file := range []*lintFile{configFile, credentialsFile} {
+		if file == nil {
+			continue
+		}
+		for _, section := range file.file.sections {
+			if name, ok := profileName(section.name, file == configFile); ok {
+				profiles[name] = true
+			}
+		}
+	}
+
+	var findings []LintFinding
+	for _, section := range configFile.file.sections {
+		if _, ok := profileName(section.name, true); !ok {
+			continue
+		}
+		values := iniValues(section)
+		if session := values["sso_session"]; session != "" && configFile.file.section(ssoSessionSection+" "+session) == nil {
+			findings = append(findings, LintFinding{
+				Check:   LintMissingSSOSession,
+				Path:    configFile.path,
+				Section: section.name,
+				Message: fmt.Sprintf("sso_session %q is not defined", session),
+			})
+		}
+		if source := values["source_profile"]; source != "" && !profiles[source] {
+			findings = append(findings, LintFinding{
+				Check:   LintMissingSourceProfile,
+				Path:    configFile.path,
+				Section: section.name,
+				Message: fmt.Sprintf("source_profile %q is not defined", source),
+			})
+		}
+	}
+	return findings
+}
+
+// lintRoleChainCycles reports source_profile loops. A profile that sources
+// itself is not a cycle: it assumes its role with its own static keys.
+func lintRoleChainCycles(configFile *lintFile) []LintFinding {
+	sources := map[string]string{}
+	for _, section := range configFile.file.sections {
+		name, ok := profileName(section.name, true)
+		if !ok {
+			continue
+		}
+		if source := iniValues(section)["source_profile"]; source != "" && source != name {
+			sources[name] = source
+		}
+	}
+
+	names := make([]string, 0, len(sources))
+	for name := range sources {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	const (
+		visiting = iota + 1
+		done
+	)
+	var findings []LintFinding
+	state := make(map[string]int, len(sources))
+	for _, start := range names {
+		path := []string{}
+		for name := start; ; name = sources[name] {
+			if _, ok := sources[name]; !ok || state[name] == done {
+				break
+			}
+			if state[name] == visiting {
+				cycle := path[indexOf(path, name):]
+				findings = append(findings, LintFinding{
+					Check:   LintRoleChainCycle,
+					Path:    configFile.path,
+					Section: profileSectionPrefix + name,
+					Message: fmt.Sprintf("source_profile cycle %s -> %s", strings.Join(cycle, " -> "), name),
+				})
+				break
+			}
+			state[name] = visiting
+			path = append(path, name)
+		}
+		for _, name := range path {
+			state[name] = done
+		}
+	}
+	return findings
+}
+
+func indexOf(values []string, value string) int {
+	for i, candidate := range values {
+		if candidate == value {
+			return i
+		}
+	}
+	return -1
+}
+
+// lintLegacySSO reports profiles that set sso_start_url themselves. With fix,
+// those whose start URL and region match an sso-session section are moved to
+// it.
+func lintLegacySSO(configFile *lintFile, fix bool) []LintFinding {
+	sessions := map[string]string{}
+	for _, section := range configFile.file.sections {
+		name, ok := strings.CutPrefix(section.name, ssoSessionSection+" ")
+		if !ok {
+			continue
+		}
+		values := iniValues(section)
+		key := strings.TrimRight(values["sso_start_url"], "/") + " " + values["sso_region"]
+		if _, exists := sessions[key]; !exists {
+			sessions[key] = name
+		}
+	}
+
+	var findings []LintFinding
+	for _, section := range configFile.file.sections {
+		if _, ok := profileName(section.name, true); !ok {
+			continue
+		}
+		values := iniValues(section)
+		if values["sso_start_url"] == "" {
+			continue
+		}
+
+		finding := LintFinding{
+			Check:   LintLegacySSO,
+			Path:    configFile.path,
+			Section: section.name,
+			Message: "profile sets sso_start_url; move it to an sso-session section to get refreshable tokens",
+		}
+		session, ok := sessions[strings.TrimRight(values["sso_start_url"], "/")+" "+values["sso_region"]]
+		if ok {
+			finding.Message = fmt.Sprintf("profile sets sso_start_url; use sso_session = %s instead", session)
+		}
+		if ok && fix && values["sso_session"] == "" {
+			want := make([]iniProperty, 0, len(values))
+			for _, property := range section.properties() {
+				switch strings.ToLower(property.key) {
+				case "sso_start_url", "sso_region":
+				default:
+					want = append(want, property)
+				}
+			}
+			want = append(want, iniProperty{key: "sso_session", value: session})
+			section.update(want, configFile.file.cr)
+			configFile.changed = true
+			finding.Fixed = true
+		}
+		findings = append(findings, finding)
+	}
+	return findings
+}
+
+// lintStaticKeys reports long-lived access keys: an access key without a
+// session token.
+func lintStaticKeys(file *lintFile, config bool) []LintFinding {
+	var findings []LintFinding
+	for _, section := range file.file.sections {
+		if _, ok := profileName(section.name, config); !ok {
+			continue
+		}
+		values := iniValues(section)
+		if values["aws_access_key_id"] == "" || values["aws_session_token"] != "" {
+			continue
+		}
+		findings = append(findings, LintFinding{
+			Check:   LintStaticKeys,
+			Path:    file.path,
+			Section: section.name,
+			Message: "profile stores a long-lived access key; prefer SSO or credential_process",
+		})
+	}
+	return findings
+}
+
+// lintStale reports profiles tagged with markerKey that the current
+// discovery no longer generates. With fix they are removed, as the next
+// generate would. The [default] profile is always generated.
+func lintStale(file *lintFile, config bool, markerKey string, generated []string, fix bool) []LintFinding {
+	if strings.TrimSpace(markerKey) == "" {
+		return nil
+	}
+	wanted := make(map[string]bool, len(generated))
+	for _, name := range generated {
+		wanted[name] = true
+	}
+
+	var findings []LintFinding
+	for _, section := range append([]*iniSection(nil), file.file.sections...) {
+		name, ok := profileName(section.name, config)
+		if !ok || !section.hasKey(markerKey) || wanted[name] || (config && name == "default") {
+			continue
+		}
+		finding := LintFinding{
+			Check:   LintStaleProfile,
+			Path:    file.path,
+			Section: section.name,
+			Message: "generated profile no longer matches a discovered account and role",
+		}
+		if fix {
+			file.file.remove(section)
+			file.changed = true
+			finding.Fixed = true
+		}
+		findings = append(findings, finding)
+	}
+	return findings
+}
+
+// lintPermissions reports files other users can read or write.
+func lintPermissions(file *lintFile, fix bool) []LintFinding {
+	if file.mode&0o077 == 0 {
+		return nil
+	}
+	finding := LintFinding{
+		Check:   LintFilePermissions,
+		Path:    file.path,
+		Message: fmt.Sprintf("mode %04o lets other users access the file; use 0600", file.mode),
+	}
+	if fix {
+		if err := os.Chmod(file.path, 0o600); err != nil {
+			finding.Message += fmt.Sprintf("; chmod failed: %v", err)
+		} else {
+			finding.Fixed = true
+		}
+	}
+	return []LintFinding{finding}
+}
@@ -0,0 +1,209 @@
+package aws
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jmreicha/cfgctl/internal/core"
+)
+
+const lintTestConfig = `[default]
+region = us-east-1
+output = json
+sso_auto_populated = true
+
+[sso-session cfgctl]
+sso_start_url = https://example.awsapps.com/start
+sso_region = us-east-1
+
+[profile legacy]
+sso_start_url = https://example.awsapps.com/start/
+sso_region = us-east-1
+sso_account_id = 111111111111
+sso_role_name = Admin
+
+[profile legacy-other]
+sso_start_url = https://other.awsapps.com/start
+sso_region = us-east-1
+sso_account_id = 111111111111
+sso_role_name = Admin
+
+[profile prod/admin]
+sso_session = cfgctl
+sso_account_id = 111111111111
+sso_role_name = Admin
+sso_auto_populated = true
+
+[profile gone/admin]
+sso_session = cfgctl
+sso_account_id = 222222222222
+sso_role_name = Admin
+sso_auto_populated = true
+
+[profile orphan]
+sso_session = missing
+
+[profile chained]
+source_profile = nowhere
+role_arn = arn:aws:iam::111111111111:role/Admin
+
+[profile a]
+source_profile = b
+role_arn = arn:aws:iam::111111111111:role/A
+
+[profile b]
+source_profile = a
+role_arn = arn:aws:iam::111111111111:role/B
+
+[profile self]
+source_profile = self
+role_arn = arn:aws:iam::111111111111:role/Self
+
+[profile prod/admin]
+region = us-west-2
+`
+
+const lintTestCredentials = `[self]
+aws_access_key_id = AKIA
+aws_secret_access_key = secret
+
+[temporary]
+aws_access_key_id = ASIA
+aws_secret_access_key = secret
+aws_session_token = token
+`
+
+func lintTestFiles(t *testing.T, configMode os.FileMode) *Config {
+	t.Helper()
+
+	dir := t.TempDir()
+	cfg := DefaultConfig()
+	cfg.ConfigPath = filepath.Join(dir, "config")
+	cfg.CredentialsPath = filepath.Join(dir, "credentials")
+	if err := os.WriteFile(cfg.ConfigPath, []byte(lintTestConfig), configMode); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	if err := os.Chmod(cfg.ConfigPath, configMode); err != nil {
+		t.Fatalf("chmod config: %v", err)
+	}
+	if err := os.WriteFile(cfg.CredentialsPath, []byte(lintTestCredentials), 0o600); err != nil {
+		t.Fatalf("write credentials: %v", err)
+	}
+	return cfg
+}
+
+func TestLintFiles(t *testing.T) {
+	cfg := lintTestFiles(t, 0o644)
+
+	findings, err := LintFiles(cfg, LintOptions{Generated: []string{"prod/admin"}})
+	if err != nil {
+		t.Fatalf("LintFiles failed: %v", err)
+	}
+
+	got := make([]string, 0, len(findings))
+	for _, finding := range findings {
+		if finding.Fixed {
+			t.Fatalf("finding fixed without --fix: %s", finding)
+		}
+		got = append(got, finding.Check+" "+finding.Section)
+	}
+	want := []string{
+		LintDuplicateSection + " profile prod/admin",
+		LintMissingSSOSession + " profile orphan",
+		LintMissingSourceProfile + " profile chained",
+		LintRoleChainCycle + " profile a",
+		LintLegacySSO + " profile legacy",
+		LintLegacySSO + " profile legacy-other",
+		LintStaleProfile + " profile gone/admin",
+		LintFilePermissions + " ",
+		LintStaticKeys + " self",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("findings:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestLintFilesFix(t *testing.T) {
+	cfg := lintTestFiles(t, 0o644)
+	backups := core.NewBackupManager(t.TempDir())
+
+	findings, err := LintFiles(cfg, LintOptions{Generated: []string{"prod/admin"}, Fix: true, Backups: backups})
+	if err != nil {
+		t.Fatalf("LintFiles failed: %v", err)
+	}
+
+	fixed := map[string]bool{}
+	for _, finding := range findings {
+		if finding.Fixed {
+			fixed[finding.Check+" "+finding.Section] = true
+		}
+	}
+	for _, want := range []string{LintLegacySSO + " profile legacy", LintStaleProfile + " profile gone/admin", LintFilePermissions + " "} {
+		if !fixed[want] {
+			t.Fatalf("expected %q to be fixed, got %v", want, fixed)
+		}
+	}
+	if fixed[LintLegacySSO+" profile legacy-other"] {
+		t.Fatal("legacy profile without a matching sso-session should not be fixed")
+	}
+
+	data, err := os.ReadFile(cfg.ConfigPath)
+	if err != nil {
+		t.Fatalf("read config: %v", err)
+	}
+	content := string(data)
+	if strings.Contains(content, "[profile gone/admin]") {
+		t.Fatalf("stale profile not removed:\n%s", content)
+	}
+	if !strings.Contains(content, "[profile legacy]\nsso_account_id = 111111111111\nsso_role_name = Admin\nsso_session = cfgctl\n") {
+		t.Fatalf("legacy profile not migrated:\n%s", content)
+	}
+	info, err := os.Stat(cfg.ConfigPath)
+	if err != nil {
+		t.Fatalf("stat config: %v", err)
+	}
+	if info.Mode().Perm() != 0o600 {
+		t.Fatalf("config mode = %04o, want 0600", info.Mode().Perm())
+	}
+
+	// Only the config file was rewritten, and its backup holds the original.
+	saved, err := backups.List(ProviderName)
+	if err != nil {
+		t.Fatalf("list backups: %v", err)
+	}
+	if len(saved) != 1 || saved[0].OriginalPath != cfg.ConfigPath {
+		t.Fatalf("backups = %+v, want one of %s", saved, cfg.ConfigPath)
+	}
+	backup, err := os.ReadFile(saved[0].BackupPath)
+	if err != nil {
+		t.Fatalf("read backup: %v", err)
+	}
+	if string(backup) != lintTestConfig {
+		t.Fatalf("backup content =\n%s\nwant the original config", backup)
+	}
+}
+
+func TestLintPermissionsReportsChmodError(t *testing.T) {
+	file := &lintFile{path: filepath.Join(t.TempDir(), "missing"), mode: 0o644}
+
+	findings := lintPermissions(file, true)
+	if len(findings) != 1 || findings[0].Fixed || !strings.Contains(findings[0].Message, "chmod failed") {
+		t.Fatalf("findings = %+v, want an unfixed finding with the chmod error", findings)
+	}
+}
+
+func TestLintFilesMissing(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.ConfigPath = filepath.Join(t.TempDir(), "config")
+	cfg.CredentialsPath = filepath.Join(t.TempDir(), "credentials")
+
+	findings, err := LintFiles(cfg, LintOptions{})
+	if err != nil {
+		t.Fatalf("LintFiles failed: %v", err)
+	}
+	if len(findings) != 0 {
+		t.Fatalf("expected no findings, got %v", findings)
+	}
+}
@@ -6,22 +6,123 @@ import (
 	"os"
 	"os/exec"
 	"strings"
+	"time"
 )
 
 // awsCommand is the AWS CLI binary name. Overridden in tests.
 var awsCommand = "aws"
 
-// runSSOLogin ensures the sso-session block exists in the AWS config file
-// and runs `aws sso login` interactively so the user can authenticate.
+// runSSOLogin ensures the sso-session block exists in the AWS config file and
+// signs the user in, either with the built-in device authorization flow or,
+// when sso.login is "cli", by running `aws sso login` interactively.
 func runSSOLogin(ctx context.Context, cfg *Config) error {
 	if err := ensureSSOSessionBlock(cfg); err != nil {
 		return fmt.Errorf("ensure sso-session block: %w", err)
 	}
 
+	if cfg.SSO.Login == ssoLoginCLI {
+		return runCLILogin(ctx, cfg)
+	}
+	return runNativeLogin(ctx, cfg)
+}
+
+// runNativeLogin runs the OIDC device authorization flow and writes the token
+// where the AWS CLI would, so LoadMatchingToken and other tools find it.
+func runNativeLogin(ctx context.Context, cfg *Config) error {
+	path, err := tokenCacheFile(cfg.TokenCachePaths, cfg.SSO.SessionName)
+	if err != nil {
+		return err
+	}
+
+	client, err := newOIDCClient(ctx, cfg.SSO.Region, cfg.EndpointURLs.SSOOIDC)
+	if err != nil {
+		return fmt.Errorf("create oidc client: %w", err)
+	}
+
+	token, err := newDeviceAuthorizer(client, os.Stderr).login(ctx, cfg)
+	if err != nil {
+		return fmt.Errorf("sso login: %w", err)
+	}
+
+	return writeToken(path, token)
+}
+
+// refreshSSOToken renews an expired access token for the configured session
+// with its refresh token and writes it back to the cache file it was read
+// from. It returns errNoValidToken when no cached token can be refreshed.
+func refreshSSOToken(ctx context.Context, cfg *Config, now time.Time) error {
+	token, err := loadRefreshableToken(cfg.TokenCachePaths, cfg.SSO.StartURL, cfg.SSO.Region, now)
+	if err != nil {
+		return err
+	}
+
+	client, err := newOIDCClient(ctx, cfg.SSO.Region, cfg.EndpointURLs.SSOOIDC)
+	if err != nil {
+		return fmt.Errorf("create oidc client: %w", err)
+	}
+
+	refreshed, err := refreshAccessToken(ctx, client, token, now)
+	if err != nil {
+		return err
+	}
+
+	return writeToken(token.path, refreshed)
+}
+
+// registrationExpiryWarning returns a warning when the client registration
+// behind the session's current token expires within registrationExpiryWindow.
+// Once it expires the token can no longer be refreshed and the next run has
+// to sign in again.
+func registrationExpiryWarning(cfg *Config, now time.Time) string {
+	token, err := LoadMatchingToken(cfg.TokenCachePaths, cfg.SSO.StartURL, cfg.SSO.Region, now)
+	if err != nil || token.RegistrationExpiresAt.IsZero() || token.RefreshToken == "" {
+		return ""
+	}
+	if token.RegistrationExpiresAt.Sub(now) > registrationExpiryWindow {
+		return ""
+	}
+	return fmt.Sprintf("sso client registration for session %q expires at %s; sign in again after that to keep refreshing tokens",
+		cfg.SSO.SessionName, token.RegistrationExpiresAt.UTC().Format(time.RFC3339))
+}
+
+// tokenExpiryWarning returns a warning when the session's current token
+// expires within tokenExpiryWindow and cannot be refreshed, so the next run
+// after that has to sign in again. Tokens that can be refreshed are renewed
+// when they are used and are not reported.
+func tokenExpiryWarning(cfg *Config, now time.Time) string {
+	token, err := LoadMatchingToken(cfg.TokenCachePaths, cfg.SSO.StartURL, cfg.SSO.Region, now)
+	if err != nil || token.CanRefresh(now) {
+		return ""
+	}
+	if token.ExpiresAt.Sub(now) > tokenExpiryWindow {
+		return ""
+	}
+	return fmt.Sprintf("sso token for session %q expires in %s; sign in again after that",
+		cfg.SSO.SessionName, FormatCountdown(token.ExpiresAt.Sub(now)))
+}
+
+// sessionExpiryWarnings returns the token and client registration expiry
+// warnings for every configured SSO session.
+func sessionExpiryWarnings(cfg *Config, now time.Time) []string {
+	var warnings []string
+	for _, session := range cfg.sessionConfigs() {
+		for _, warning := range []string{tokenExpiryWarning(session, now), registrationExpiryWarning(session, now)} {
+			if warning != "" {
+				warnings = append(warnings, warning)
+			}
+		}
+	}
+	return warnings
+}
+
+// runCLILogin signs in with aws sso login. Its output goes to stderr, since
+// logins can start from commands whose stdout is parsed, such as aws env and
+// credential-process.
+func runCLILogin(ctx context.Context, cfg *Config) error {
 	// #nosec G204 -- session name is from user configuration, not external input.
 	cmd := exec.CommandContext(ctx, awsCommand, "sso", "login", "--sso-session", cfg.SSO.SessionName)
 	cmd.Stdin = os.Stdin
-	cmd.Stdout = os.Stdout
+	cmd.Stdout = os.Stderr
 	cmd.Stderr = os.Stderr
 
 	if err := cmd.Run(); err != nil {
@@ -6,6 +6,7 @@ import (
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 )
 
 func TestEnsureSSOSessionBlockCreatesFile(t *testing.T) {
@@ -107,6 +108,7 @@ func TestRunSSOLoginMissingBinary(t *testing.T) {
 	cfg.ConfigPath = filepath.Join(t.TempDir(), "config")
 	cfg.SSO.StartURL = testStartURL
 	cfg.SSO.Region = testRegion
+	cfg.SSO.Login = ssoLoginCLI
 
 	err := runSSOLogin(context.Background(), cfg)
 	if err == nil {
@@ -116,3 +118,116 @@ func TestRunSSOLoginMissingBinary(t *testing.T) {
 		t.Fatalf("unexpected error: %v", err)
 	}
 }
+
+func TestRunCLILoginKeepsStdoutClean(t *testing.T) {
+	dir := t.TempDir()
+	script := filepath.Join(dir, "aws")
+	if err := os.WriteFile(script, []byte("#!/bin/sh\necho \"open https://device.sso.example/\"\n"), 0o755); err != nil {
+		t.Fatalf("write mock script: %v", err)
+	}
+	orig := awsCommand
+	awsCommand = script
+	t.Cleanup(func() { awsCommand = orig })
+
+	stdout, err := os.Create(filepath.Join(dir, "stdout"))
+	if err != nil {
+		t.Fatalf("create stdout: %v", err)
+	}
+	stderr, err := os.Create(filepath.Join(dir, "stderr"))
+	if err != nil {
+		t.Fatalf("create stderr: %v", err)
+	}
+	origStdout, origStderr := os.Stdout, os.Stderr
+	os.Stdout, os.Stderr = stdout, stderr
+	t.Cleanup(func() { os.Stdout, os.Stderr = origStdout, origStderr })
+
+	cfg := DefaultConfig()
+	cfg.SSO.SessionName = "cfgctl"
+	if err := runCLILogin(context.Background(), cfg); err != nil {
+		t.Fatalf("runCLILogin failed: %v", err)
+	}
+	os.Stdout, os.Stderr = origStdout, origStderr
+	_ = stdout.Close()
+	_ = stderr.Close()
+
+	if data, _ := os.ReadFile(stdout.Name()); len(data) != 0 {
+		t.Fatalf("stdout = %q, want empty", data)
+	}
+	if data, _ := os.ReadFile(stderr.Name()); !strings.Contains(string(data), "device.sso.example") {
+		t.Fatalf("stderr = %q, want the login output", data)
+	}
+}
+
+func TestRegistrationExpiryWarning(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	cfg := DefaultConfig()
+	cfg.SSO.StartURL = testStartURL
+	cfg.SSO.Region = testRegion
+	cfg.TokenCachePaths = []string{t.TempDir()}
+
+	if warning := registrationExpiryWarning(cfg, now); warning != "" {
+		t.Fatalf("expected no warning without a token, got %q", warning)
+	}
+
+	token := SSOToken{
+		AccessToken:  "token",
+		ExpiresAt:    now.Add(time.Hour),
+		Region:       testRegion,
+		StartURL:     testStartURL,
+		ClientID:     "client-id",
+		ClientSecret: "client-secret",
+		RefreshToken: "refresh",
+	}
+	path := filepath.Join(cfg.TokenCachePaths[0], "token.json")
+
+	token.RegistrationExpiresAt = now.Add(30 * 24 * time.Hour)
+	if err := writeToken(path, token); err != nil {
+		t.Fatalf("writeToken failed: %v", err)
+	}
+	if warning := registrationExpiryWarning(cfg, now); warning != "" {
+		t.Fatalf("expected no warning for a distant expiry, got %q", warning)
+	}
+
+	token.RegistrationExpiresAt = now.Add(2 * 24 * time.Hour)
+	if err := writeToken(path, token); err != nil {
+		t.Fatalf("writeToken failed: %v", err)
+	}
+	warning := registrationExpiryWarning(cfg, now)
+	if !strings.Contains(warning, "2026-01-03T12:00:00Z") {
+		t.Fatalf("expected warning with the registration expiry, got %q", warning)
+	}
+}
+
+func TestTokenExpiryWarning(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	cfg := DefaultConfig()
+	cfg.SSO.SessionName = "cfgctl"
+	cfg.SSO.StartURL = testStartURL
+	cfg.SSO.Region = testRegion
+	cfg.TokenCachePaths = []string{t.TempDir()}
+	path := filepath.Join(cfg.TokenCachePaths[0], "token.json")
+
+	token := SSOToken{AccessToken: "token", ExpiresAt: now.Add(3 * time.Hour), Region: testRegion, StartURL: testStartURL}
+	if err := writeToken(path, token); err != nil {
+		t.Fatalf("writeToken failed: %v", err)
+	}
+	if warning := tokenExpiryWarning(cfg, now); warning != "" {
+		t.Fatalf("expected no warning for a distant expiry, got %q", warning)
+	}
+
+	token.ExpiresAt = now.Add(20 * time.Minute)
+	if err := writeToken(path, token); err != nil {
+		t.Fatalf("writeToken failed: %v", err)
+	}
+	if warning := tokenExpiryWarning(cfg, now); !strings.Contains(warning, "expires in 20m") {
+		t.Fatalf("expected expiry warning, got %q", warning)
+	}
+
+	token.ClientID, token.ClientSecret, token.RefreshToken = "client-id", "client-secret", "refresh"
+	if err := writeToken(path, token); err != nil {
+		t.Fatalf("writeToken failed: %v", err)
+	}
+	if warning := tokenExpiryWarning(cfg, now); warning != "" {
+		t.Fatalf("expected no warning for a refreshable token, got %q", warning)
+	}
+}
@@ -0,0 +1,229 @@
+package aws
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ssooidc"
+	"github.com/aws/aws-sdk-go-v2/service/ssooidc/types"
+)
+
+const (
+	oidcClientName       = "cfgctl"
+	oidcClientType       = "public"
+	deviceCodeGrantType  = "urn:ietf:params:oauth:grant-type:device_code"
+	refreshGrantType     = "refresh_token"
+	defaultPollInterval  = 5 * time.Second
+	slowDownPollIncrease = 5 * time.Second
+)
+
+var errDeviceAuthorizationExpired = errors.New("device authorization expired before it was approved")
+
+// OIDCClient defines the IAM Identity Center OIDC operations used for login.
+type OIDCClient interface {
+	RegisterClient(ctx context.Context, params *ssooidc.RegisterClientInput, optFns ...func(*ssooidc.Options)) (*ssooidc.RegisterClientOutput, error)
+	StartDeviceAuthorization(ctx context.Context, params *ssooidc.StartDeviceAuthorizationInput, optFns ...func(*ssooidc.Options)) (*ssooidc.StartDeviceAuthorizationOutput, error)
+	CreateToken(ctx context.Context, params *ssooidc.CreateTokenInput, optFns ...func(*ssooidc.Options)) (*ssooidc.CreateTokenOutput, error)
+}
+
+// OIDCClientFactory creates OIDC clients for a region. A non-empty
+// endpointURL replaces the OIDC endpoint.
+type OIDCClientFactory func(ctx context.Context, region, endpointURL string) (OIDCClient, error)
+
+// newOIDCClient creates the OIDC client used by native login. Overridden in tests.
+var newOIDCClient OIDCClientFactory = func(_ context.Context, region, endpointURL string) (OIDCClient, error) {
+	// The OIDC operations are unauthenticated, so no credentials are loaded,
+	// and the endpoint environment variables are read here instead.
+	opts := ssooidc.Options{Region: region}
+	if endpoint := resolveEndpointURL(endpointURL, "SSO_OIDC"); endpoint != "" {
+		opts.BaseEndpoint = aws.String(endpoint)
+	}
+	return ssooidc.New(opts), nil
+}
+
+// openBrowser opens url in the user's browser. Overridden in tests.
+var openBrowser = func(url string) error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("open", url)
+	case "windows":
+		cmd = exec.Command("rundll32", "url.dll,FileProtocolHandler", url)
+	default:
+		cmd = exec.Command("xdg-open", url)
+	}
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	// Reap the opener so it does not linger as a zombie.
+	go func() { _ = cmd.Wait() }()
+	return nil
+}
+
+// deviceAuthorizer runs the OIDC device authorization flow.
+type deviceAuthorizer struct {
+	client OIDCClient
+	out    io.Writer
+	now    func() time.Time
+	wait   func(ctx context.Context, d time.Duration) error
+}
+
+func newDeviceAuthorizer(client OIDCClient, out io.Writer) *deviceAuthorizer {
+	return &deviceAuthorizer{client: client, out: out, now: time.Now, wait: waitContext}
+}
+
+// login registers a client, asks the user to approve the device, and polls
+// until a token is issued.
+func (d *deviceAuthorizer) login(ctx context.Context, cfg *Config) (SSOToken, error) {
+	registration, err := d.client.RegisterClient(ctx, &ssooidc.RegisterClientInput{
+		ClientName: aws.String(oidcClientName),
+		ClientType: aws.String(oidcClientType),
+		Scopes:     splitScopes(cfg.SSO.RegistrationScopes),
+	})
+	if err != nil {
+		return SSOToken{}, fmt.Errorf("register oidc client: %w", err)
+	}
+
+	authorization, err := d.client.StartDeviceAuthorization(ctx, &ssooidc.StartDeviceAuthorizationInput{
+		ClientId:     registration.ClientId,
+		ClientSecret: registration.ClientSecret,
+		StartUrl:     aws.String(cfg.SSO.StartURL),
+	})
+	if err != nil {
+		return SSOToken{}, fmt.Errorf("start device authorization: %w", err)
+	}
+
+	d.prompt(authorization)
+
+	output, err := d.poll(ctx, registration, authorization)
+	if err != nil {
+		return SSOToken{}, err
+	}
+
+	issued := d.now().UTC().Truncate(time.Second)
+	token := SSOToken{
+		AccessToken:  aws.ToString(output.AccessToken),
+		ExpiresAt:    issued.Add(time.Duration(output.ExpiresIn) * time.Second),
+		IssuedAt:     issued,
+		Region:       cfg.SSO.Region,
+		StartURL:     cfg.SSO.StartURL,
+		ClientID:     aws.ToString(registration.ClientId),
+		ClientSecret: aws.ToString(registration.ClientSecret),
+		RefreshToken: aws.ToString(output.RefreshToken),
+	}
+	if registration.ClientSecretExpiresAt > 0 {
+		token.RegistrationExpiresAt = time.Unix(registration.ClientSecretExpiresAt, 0).UTC()
+	}
+	return token, nil
+}
+
+// prompt tells the user where to approve the login and tries to open it.
+func (d *deviceAuthorizer) prompt(authorization *ssooidc.StartDeviceAuthorizationOutput) {
+	url := aws.ToString(authorization.VerificationUriComplete)
+	if url == "" {
+		url = aws.ToString(authorization.VerificationUri)
+	}
+
+	_, _ = fmt.Fprintf(d.out, "Approve the login in your browser: %s\n", url)
+	_, _ = fmt.Fprintf(d.out, "Confirm the code matches: %s\n", aws.ToString(authorization.UserCode))
+	if err := openBrowser(url); err != nil {
+		_, _ = fmt.Fprintln(d.out, "Could not open a browser; open the URL above manually.")
+	}
+}
+
+// poll calls CreateToken at the server's interval until the user approves the
+// device, slowing down when asked, or the device code expires.
+func (d *deviceAuthorizer) poll(ctx context.Context, registration *ssooidc.RegisterClientOutput, authorization *ssooidc.StartDeviceAuthorizationOutput) (*ssooidc.CreateTokenOutput, error) {
+	interval := time.Duration(authorization.Interval) * time.Second
+	if interval <= 0 {
+		interval = defaultPollInterval
+	}
+	deadline := d.now().Add(time.Duration(authorization.ExpiresIn) * time.Second)
+
+	for {
+		output, err := d.client.CreateToken(ctx, &ssooidc.CreateTokenInput{
+			ClientId:     registration.ClientId,
+			ClientSecret: registration.ClientSecret,
+			DeviceCode:   authorization.DeviceCode,
+			GrantType:    aws.String(deviceCodeGrantType),
+		})
+		if err == nil {
+			return output, nil
+		}
+
+		var (
+			pending  *types.AuthorizationPendingException
+			slowDown *types.SlowDownException
+			expired  *types.ExpiredTokenException
+		)
+		switch {
+		case errors.As(err, &pending):
+		case errors.As(err, &slowDown):
+			interval += slowDownPollIncrease
+		case errors.As(err, &expired):
+			return nil, errDeviceAuthorizationExpired
+		default:
+			return nil, fmt.Errorf("create sso token: %w", err)
+		}
+
+		if authorization.ExpiresIn > 0 && !d.now().Add(interval).Before(deadline) {
+			return nil, errDeviceAuthorizationExpired
+		}
+		if err := d.wait(ctx, interval); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// refreshAccessToken exchanges token's refresh token for a new access token.
+// The client registration is kept, and so is the refresh token unless the
+// server rotates it.
+func refreshAccessToken(ctx context.Context, client OIDCClient, token SSOToken, now time.Time) (SSOToken, error) {
+	output, err := client.CreateToken(ctx, &ssooidc.CreateTokenInput{
+		ClientId:     aws.String(token.ClientID),
+		ClientSecret: aws.String(token.ClientSecret),
+		GrantType:    aws.String(refreshGrantType),
+		RefreshToken: aws.String(token.RefreshToken),
+	})
+	if err != nil {
+		return SSOToken{}, fmt.Errorf("refresh sso token: %w", err)
+	}
+
+	issued := now.UTC().Truncate(time.Second)
+	refreshed := token
+	refreshed.AccessToken = aws.ToString(output.AccessToken)
+	refreshed.ExpiresAt = issued.Add(time.Duration(output.ExpiresIn) * time.Second)
+	refreshed.IssuedAt = issued
+	if refreshToken := aws.ToString(output.RefreshToken); refreshToken != "" {
+		refreshed.RefreshToken = refreshToken
+	}
+	return refreshed, nil
+}
+
+func waitContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+func splitScopes(scopes string) []string {
+	var split []string
+	for _, scope := range strings.Split(scopes, ",") {
+		if scope = strings.TrimSpace(scope); scope != "" {
+			split = append(split, scope)
+		}
+	}
+	return split
+}
@@ -0,0 +1,260 @@
+package aws
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ssooidc"
+)
+
+// fakeOIDCServer stands in for the IAM Identity Center OIDC endpoint.
+type fakeOIDCServer struct {
+	mu       sync.Mutex
+	pending  int
+	tokenErr string
+	requests map[string]map[string]any
+}
+
+func (f *fakeOIDCServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	var input map[string]any
+	_ = json.Unmarshal(body, &input)
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.requests[r.URL.Path] = input
+
+	w.Header().Set("Content-Type", "application/json")
+	switch r.URL.Path {
+	case "/client/register":
+		_, _ = io.WriteString(w, `{"clientId":"client-id","clientSecret":"client-secret","clientIdIssuedAt":1700000000,"clientSecretExpiresAt":1900000000}`)
+	case "/device_authorization":
+		_, _ = io.WriteString(w, `{"deviceCode":"device-code","userCode":"ABCD-EFGH","verificationUri":"https://device.sso.example/","verificationUriComplete":"https://device.sso.example/?user_code=ABCD-EFGH","expiresIn":600,"interval":1}`)
+	case "/token":
+		if f.pending > 0 {
+			f.pending--
+			writeOIDCError(w, "AuthorizationPendingException", "authorization_pending")
+			return
+		}
+		if f.tokenErr != "" {
+			writeOIDCError(w, f.tokenErr, "error")
+			return
+		}
+		_, _ = io.WriteString(w, `{"accessToken":"access-token","expiresIn":3600,"refreshToken":"refresh-token","tokenType":"Bearer"}`)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func writeOIDCError(w http.ResponseWriter, errorType, code string) {
+	w.Header().Set("X-Amzn-Errortype", errorType)
+	w.WriteHeader(http.StatusBadRequest)
+	_, _ = io.WriteString(w, `{"error":"`+code+`"}`)
+}
+
+func newFakeOIDC(t *testing.T, fake *fakeOIDCServer) {
+	t.Helper()
+
+	fake.requests = make(map[string]map[string]any)
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+
+	origClient, origOpen := newOIDCClient, openBrowser
+	newOIDCClient = func(_ context.Context, region, _ string) (OIDCClient, error) {
+		return ssooidc.New(ssooidc.Options{Region: region, BaseEndpoint: aws.String(server.URL)}), nil
+	}
+	openBrowser = func(string) error { return errors.New("no browser") }
+	t.Cleanup(func() { newOIDCClient, openBrowser = origClient, origOpen })
+}
+
+func TestDeviceAuthorizerLogin(t *testing.T) {
+	fake := &fakeOIDCServer{pending: 2}
+	newFakeOIDC(t, fake)
+
+	client, err := newOIDCClient(context.Background(), testRegion, "")
+	if err != nil {
+		t.Fatalf("newOIDCClient failed: %v", err)
+	}
+
+	cfg := DefaultConfig()
+	cfg.SSO.StartURL = testStartURL
+	cfg.SSO.Region = testRegion
+
+	var out strings.Builder
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	waits := []time.Duration{}
+	authorizer := newDeviceAuthorizer(client, &out)
+	authorizer.now = func() time.Time { return now }
+	authorizer.wait = func(_ context.Context, d time.Duration) error {
+		waits = append(waits, d)
+		return nil
+	}
+
+	token, err := authorizer.login(context.Background(), cfg)
+	if err != nil {
+		t.Fatalf("login failed: %v", err)
+	}
+
+	if token.AccessToken != "access-token" || token.RefreshToken != "refresh-token" {
+		t.Fatalf("unexpected token: %+v", token)
+	}
+	if !token.ExpiresAt.Equal(now.Add(time.Hour)) {
+		t.Fatalf("ExpiresAt = %s, want %s", token.ExpiresAt, now.Add(time.Hour))
+	}
+	if token.ClientID != "client-id" || token.ClientSecret != "client-secret" || token.RegistrationExpiresAt.Unix() != 1900000000 {
+		t.Fatalf("unexpected client registration in token: %+v", token)
+	}
+	if len(waits) != 2 || waits[0] != time.Second {
+		t.Fatalf("waits = %v, want two 1s waits", waits)
+	}
+	if !strings.Contains(out.String(), "https://device.sso.example/?user_code=ABCD-EFGH") || !strings.Contains(out.String(), "ABCD-EFGH") {
+		t.Fatalf("prompt missing verification URL or code:\n%s", out.String())
+	}
+
+	if got := fake.requests["/device_authorization"]["startUrl"]; got != testStartURL {
+		t.Fatalf("startUrl = %v, want %s", got, testStartURL)
+	}
+	if got := fake.requests["/token"]["grantType"]; got != deviceCodeGrantType {
+		t.Fatalf("grantType = %v, want %s", got, deviceCodeGrantType)
+	}
+}
+
+func TestDeviceAuthorizerLoginExpired(t *testing.T) {
+	fake := &fakeOIDCServer{tokenErr: "ExpiredTokenException"}
+	newFakeOIDC(t, fake)
+
+	client, err := newOIDCClient(context.Background(), testRegion, "")
+	if err != nil {
+		t.Fatalf("newOIDCClient failed: %v", err)
+	}
+
+	cfg := DefaultConfig()
+	cfg.SSO.StartURL = testStartURL
+	cfg.SSO.Region = testRegion
+
+	_, err = newDeviceAuthorizer(client, io.Discard).login(context.Background(), cfg)
+	if !errors.Is(err, errDeviceAuthorizationExpired) {
+		t.Fatalf("expected errDeviceAuthorizationExpired, got %v", err)
+	}
+}
+
+func TestRunSSOLoginNativeWritesToken(t *testing.T) {
+	newFakeOIDC(t, &fakeOIDCServer{})
+
+	cacheDir := filepath.Join(t.TempDir(), "sso", "cache")
+	cfg := DefaultConfig()
+	cfg.ConfigPath = filepath.Join(t.TempDir(), "config")
+	cfg.SSO.StartURL = testStartURL
+	cfg.SSO.Region = testRegion
+	cfg.SSO.Login = ssoLoginNative
+	cfg.TokenCachePaths = []string{cacheDir}
+
+	if err := runSSOLogin(context.Background(), cfg); err != nil {
+		t.Fatalf("runSSOLogin failed: %v", err)
+	}
+
+	path, err := tokenCacheFile(cfg.TokenCachePaths, cfg.SSO.SessionName)
+	if err != nil {
+		t.Fatalf("tokenCacheFile failed: %v", err)
+	}
+	// The AWS CLI names the cache file after the SHA-1 of the session name.
+	if filepath.Base(path) != "eb370614327d979c1f7b91a1596505dce87d50a9.json" {
+		t.Fatalf("token cache file = %s", filepath.Base(path))
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("expected token file: %v", err)
+	}
+	if info.Mode().Perm() != 0o600 {
+		t.Fatalf("token file mode = %v, want 0600", info.Mode().Perm())
+	}
+
+	token, err := LoadMatchingToken(cfg.TokenCachePaths, testStartURL, testRegion, time.Now().UTC())
+	if err != nil {
+		t.Fatalf("LoadMatchingToken failed: %v", err)
+	}
+	if token.AccessToken != "access-token" || token.RefreshToken != "refresh-token" {
+		t.Fatalf("unexpected token: %+v", token)
+	}
+}
+
+func TestRefreshSSOTokenWritesBack(t *testing.T) {
+	fake := &fakeOIDCServer{}
+	newFakeOIDC(t, fake)
+
+	cacheDir := t.TempDir()
+	cfg := DefaultConfig()
+	cfg.SSO.StartURL = testStartURL
+	cfg.SSO.Region = testRegion
+	cfg.TokenCachePaths = []string{cacheDir}
+
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	path := filepath.Join(cacheDir, "granted.json")
+	expired := SSOToken{
+		AccessToken:           "expired",
+		ExpiresAt:             now.Add(-time.Hour),
+		Region:                testRegion,
+		StartURL:              testStartURL,
+		ClientID:              "client-id",
+		ClientSecret:          "client-secret",
+		RegistrationExpiresAt: now.Add(30 * 24 * time.Hour),
+		RefreshToken:          "old-refresh-token",
+	}
+	if err := writeToken(path, expired); err != nil {
+		t.Fatalf("writeToken failed: %v", err)
+	}
+
+	if err := refreshSSOToken(context.Background(), cfg, now); err != nil {
+		t.Fatalf("refreshSSOToken failed: %v", err)
+	}
+
+	request := fake.requests["/token"]
+	if request["grantType"] != refreshGrantType || request["refreshToken"] != "old-refresh-token" || request["clientId"] != "client-id" {
+		t.Fatalf("unexpected refresh request: %v", request)
+	}
+	if _, ok := fake.requests["/device_authorization"]; ok {
+		t.Fatal("refresh should not start a device authorization")
+	}
+
+	refreshed, err := readToken(path)
+	if err != nil {
+		t.Fatalf("readToken failed: %v", err)
+	}
+	if refreshed.AccessToken != "access-token" || refreshed.RefreshToken != "refresh-token" {
+		t.Fatalf("unexpected refreshed token: %+v", refreshed)
+	}
+	if !refreshed.ExpiresAt.Equal(now.Add(time.Hour)) || !refreshed.RegistrationExpiresAt.Equal(expired.RegistrationExpiresAt) {
+		t.Fatalf("unexpected refreshed expiry: %+v", refreshed)
+	}
+}
+
+func TestRefreshSSOTokenNothingToRefresh(t *testing.T) {
+	newFakeOIDC(t, &fakeOIDCServer{})
+
+	cacheDir := t.TempDir()
+	cfg := DefaultConfig()
+	cfg.SSO.StartURL = testStartURL
+	cfg.SSO.Region = testRegion
+	cfg.TokenCachePaths = []string{cacheDir}
+
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	noRefresh := SSOToken{AccessToken: "expired", ExpiresAt: now.Add(-time.Hour), Region: testRegion, StartURL: testStartURL}
+	if err := writeToken(filepath.Join(cacheDir, "token.json"), noRefresh); err != nil {
+		t.Fatalf("writeToken failed: %v", err)
+	}
+
+	if err := refreshSSOToken(context.Background(), cfg, now); !errors.Is(err, errNoValidToken) {
+		t.Fatalf("expected errNoValidToken, got %v", err)
+	}
+}
@@ -0,0 +1,303 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/organizations"
+	"github.com/jmreicha/cfgctl/internal/core"
+	"golang.org/x/sync/errgroup"
+)
+
+const (
+	defaultOrganizationsRegion = "us-east-1"
+	organizationsCachePrefix   = "organizations/"
+	organizationsParentRoot    = "ROOT"
+)
+
+// OrganizationsConfig enables account tags and OU paths from AWS
+// Organizations, read with the credentials of a management or delegated
+// administrator account profile.
+type OrganizationsConfig struct {
+	Enabled bool `yaml:"enabled"`
+
+	// Profile is the AWS profile used to call Organizations. Empty uses the
+	// default credential chain.
+	Profile string `yaml:"profile"`
+
+	// Region is the Organizations endpoint region, us-east-1 by default.
+	Region string `yaml:"region"`
+}
+
+// OrganizationsAccount is an account listed by Organizations.
+type OrganizationsAccount struct {
+	ID   string
+	Name string
+}
+
+// OrganizationsParent is the root or organizational unit directly above an
+// account or OU.
+type OrganizationsParent struct {
+	ID   string
+	Type string
+}
+
+// OrganizationsClient defines the AWS Organizations operations used to
+// enrich discovered accounts. Implementations return every page.
+type OrganizationsClient interface {
+	ListAccounts(ctx context.Context) ([]OrganizationsAccount, error)
+	ListTagsForResource(ctx context.Context, resourceID string) (map[string]string, error)
+	ListParents(ctx context.Context, childID string) ([]OrganizationsParent, error)
+	DescribeOrganizationalUnit(ctx context.Context, ouID string) (string, error)
+}
+
+// OrganizationsClientFactory creates Organizations clients for cfg. A
+// non-empty endpointURL replaces the Organizations endpoint.
+type OrganizationsClientFactory func(ctx context.Context, cfg OrganizationsConfig, endpointURL string) (OrganizationsClient, error)
+
+// AccountMetadata is what Organizations adds to a discovered account.
+type AccountMetadata struct {
+	Tags map[string]string `json:"tags,omitempty"`
+
+	// OU is the account's organizational unit path, such as
+	// "/Workloads/Prod", or "/" for accounts directly under the root.
+	OU string `json:"ou,omitempty"`
+}
+
+// enrichProfiles sets the tags and OU path of every profile from
+// Organizations when it is enabled. The metadata is cached like discovery
+// results.
+func (p *Provider) enrichProfiles(ctx context.Context, profiles []DiscoveredProfile, cache *core.ProviderCache, progress core.ProgressReporter) error {
+	orgs := p.config.Organizations
+	if !orgs.Enabled || p.config.Demo || len(profiles) == 0 {
+		return nil
+	}
+
+	key := core.CacheKey{Profile: organizationsCachePrefix + orgs.Profile, Region: orgs.Region}
+	var metadata map[string]AccountMetadata
+	found, err := cache.Load(key, &metadata)
+	if err != nil {
+		return err
+	}
+	if found {
+		p.logger.Debug("using cached organizations metadata", "count", len(metadata))
+	} else {
+		core.ReportProgress(progress, core.ProgressEvent{Kind: core.ProgressStep, Message: "listing organizations accounts"})
+		client, err := p.organizations(ctx, orgs, p.config.EndpointURLs.Organizations)
+		if err != nil {
+			return fmt.Errorf("create organizations client: %w", err)
+		}
+		metadata, err = fetchAccountMetadata(ctx, client, newThrottle(), p.config.ParallelWorkers)
+		if err != nil {
+			return fmt.Errorf("organizations: %w", err)
+		}
+		if err := cache.Store(key, metadata); err != nil {
+			p.logger.Warn("failed to cache organizations metadata", "error", err)
+		}
+	}
+
+	for i := range profiles {
+		account := metadata[profiles[i].AccountID]
+		profiles[i].Tags = account.Tags
+		profiles[i].OU = account.OU
+	}
+	return nil
+}
+
+// fetchAccountMetadata reads the tags and OU path of every account in the
+// organization, looking up up to workers accounts at once.
+func fetchAccountMetadata(ctx context.Context, client OrganizationsClient, limiter *throttle, workers int) (map[string]AccountMetadata, error) {
+	var accounts []OrganizationsAccount
+	err := limiter.do(ctx, func() error {
+		var err error
+		accounts, err = client.ListAccounts(ctx)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("list accounts: %w", err)
+	}
+
+	paths := &ouPaths{client: client, limiter: limiter, paths: map[string]string{}}
+	metadata := make([]AccountMetadata, len(accounts))
+	g, groupCtx := errgroup.WithContext(ctx)
+	g.SetLimit(max(workers, 1))
+	for i, account := range accounts {
+		g.Go(func() error {
+			var tags map[string]string
+			err := limiter.do(groupCtx, func() error {
+				var err error
+				tags, err = client.ListTagsForResource(groupCtx, account.ID)
+				return err
+			})
+			if err != nil {
+				return fmt.Errorf("list tags for %s: %w", account.ID, err)
+			}
+			ou, err := paths.path(groupCtx, account.ID)
+			if err != nil {
+				return fmt.Errorf("list parents of %s: %w", account.ID, err)
+			}
+			metadata[i] = AccountMetadata{Tags: tags, OU: ou}
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+
+	byID := make(map[string]AccountMetadata, len(accounts))
+	for i, account := range accounts {
+		byID[account.ID] = metadata[i]
+	}
+	return byID, nil
+}
+
+// ouPaths resolves OU paths, remembering the path of each OU so that
+// accounts sharing an OU look it up once.
+type ouPaths struct {
+	client  OrganizationsClient
+	limiter *throttle
+
+	mu    sync.Mutex
+	paths map[string]string
+}
+
+// path returns the path of the OU that holds the account or OU childID.
+func (o *ouPaths) path(ctx context.Context, childID string) (string, error) {
+	var parents []OrganizationsParent
+	err := o.limiter.do(ctx, func() error {
+		var err error
+		parents, err = o.client.ListParents(ctx, childID)
+		return err
+	})
+	if err != nil {
+		return "", err
+	}
+	if len(parents) == 0 || parents[0].Type == organizationsParentRoot {
+		return "/", nil
+	}
+	return o.ouPath(ctx, parents[0].ID)
+}
+
+// ouPath returns the path of the OU ouID itself.
+func (o *ouPaths) ouPath(ctx context.Context, ouID string) (string, error) {
+	o.mu.Lock()
+	cached, ok := o.paths[ouID]
+	o.mu.Unlock()
+	if ok {
+		return cached, nil
+	}
+
+	var name string
+	err := o.limiter.do(ctx, func() error {
+		var err error
+		name, err = o.client.DescribeOrganizationalUnit(ctx, ouID)
+		return err
+	})
+	if err != nil {
+		return "", err
+	}
+	parentPath, err := o.path(ctx, ouID)
+	if err != nil {
+		return "", err
+	}
+
+	path := strings.TrimSuffix(parentPath, "/") + "/" + name
+	o.mu.Lock()
+	o.paths[ouID] = path
+	o.mu.Unlock()
+	return path, nil
+}
+
+// NewOrganizationsClientFactory returns the default Organizations client
+// factory.
+func NewOrganizationsClientFactory() OrganizationsClientFactory {
+	return newOrganizationsClient
+}
+
+// organizationsClient adapts the SDK Organizations client to
+// OrganizationsClient, following every page of the list operations.
+type organizationsClient struct {
+	client *organizations.Client
+}
+
+func newOrganizationsClient(ctx context.Context, cfg OrganizationsConfig, endpointURL string) (OrganizationsClient, error) {
+	region := cfg.Region
+	if region == "" {
+		region = defaultOrganizationsRegion
+	}
+	opts := []func(*config.LoadOptions) error{config.WithRegion(region)}
+	if cfg.Profile != "" {
+		opts = append(opts, config.WithSharedConfigProfile(cfg.Profile))
+	}
+	awsConfig, err := config.LoadDefaultConfig(ctx, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("load aws config: %w", err)
+	}
+
+	client := organizations.NewFromConfig(awsConfig, func(opts *organizations.Options) {
+		if endpointURL != "" {
+			opts.BaseEndpoint = aws.String(endpointURL)
+		}
+	})
+	return &organizationsClient{client: client}, nil
+}
+
+func (c *organizationsClient) ListAccounts(ctx context.Context) ([]OrganizationsAccount, error) {
+	var accounts []OrganizationsAccount
+	paginator := organizations.NewListAccountsPaginator(c.client, &organizations.ListAccountsInput{})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, account := range page.Accounts {
+			accounts = append(accounts, OrganizationsAccount{ID: aws.ToString(account.Id), Name: aws.ToString(account.Name)})
+		}
+	}
+	return accounts, nil
+}
+
+func (c *organizationsClient) ListTagsForResource(ctx context.Context, resourceID string) (map[string]string, error) {
+	tags := map[string]string{}
+	paginator := organizations.NewListTagsForResourcePaginator(c.client, &organizations.ListTagsForResourceInput{ResourceId: aws.String(resourceID)})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, tag := range page.Tags {
+			tags[aws.ToString(tag.Key)] = aws.ToString(tag.Value)
+		}
+	}
+	return tags, nil
+}
+
+func (c *organizationsClient) ListParents(ctx context.Context, childID string) ([]OrganizationsParent, error) {
+	var parents []OrganizationsParent
+	paginator := organizations.NewListParentsPaginator(c.client, &organizations.ListParentsInput{ChildId: aws.String(childID)})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, parent := range page.Parents {
+			parents = append(parents, OrganizationsParent{ID: aws.ToString(parent.Id), Type: string(parent.Type)})
+		}
+	}
+	return parents, nil
+}
+
+func (c *organizationsClient) DescribeOrganizationalUnit(ctx context.Context, ouID string) (string, error) {
+	output, err := c.client.DescribeOrganizationalUnit(ctx, &organizations.DescribeOrganizationalUnitInput{OrganizationalUnitId: aws.String(ouID)})
+	if err != nil {
+		return "", err
+	}
+	if output.OrganizationalUnit == nil {
+		return "", nil
+	}
+	return aws.ToString(output.OrganizationalUnit.Name), nil
+}
@@ -0,0 +1,229 @@
+package aws
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/jmreicha/cfgctl/internal/core"
+)
+
+type mockOrganizationsClient struct {
+	accounts []OrganizationsAccount
+	tags     map[string]map[string]string
+	parents  map[string]OrganizationsParent
+	ouNames  map[string]string
+
+	mu            sync.Mutex
+	describeCalls int
+}
+
+func (m *mockOrganizationsClient) ListAccounts(context.Context) ([]OrganizationsAccount, error) {
+	return m.accounts, nil
+}
+
+func (m *mockOrganizationsClient) ListTagsForResource(_ context.Context, resourceID string) (map[string]string, error) {
+	return m.tags[resourceID], nil
+}
+
+func (m *mockOrganizationsClient) ListParents(_ context.Context, childID string) ([]OrganizationsParent, error) {
+	parent, ok := m.parents[childID]
+	if !ok {
+		return nil, errors.New("unknown child " + childID)
+	}
+	return []OrganizationsParent{parent}, nil
+}
+
+func (m *mockOrganizationsClient) DescribeOrganizationalUnit(_ context.Context, ouID string) (string, error) {
+	m.mu.Lock()
+	m.describeCalls++
+	m.mu.Unlock()
+	return m.ouNames[ouID], nil
+}
+
+func newMockOrganizationsClient() *mockOrganizationsClient {
+	root := OrganizationsParent{ID: "r-root", Type: organizationsParentRoot}
+	workloads := OrganizationsParent{ID: "ou-workloads", Type: "ORGANIZATIONAL_UNIT"}
+	prod := OrganizationsParent{ID: "ou-prod", Type: "ORGANIZATIONAL_UNIT"}
+	return &mockOrganizationsClient{
+		accounts: []OrganizationsAccount{
+			{ID: "111111111111", Name: "payments"},
+			{ID: "222222222222", Name: "ledger"},
+			{ID: "333333333333", Name: "sandbox"},
+			{ID: "444444444444", Name: "management"},
+		},
+		tags: map[string]map[string]string{
+			"111111111111": {"env": "prod", "team": "payments"},
+			"222222222222": {"env": "prod"},
+			"333333333333": {"env": "dev"},
+		},
+		parents: map[string]OrganizationsParent{
+			"111111111111": prod,
+			"222222222222": prod,
+			"333333333333": workloads,
+			"444444444444": root,
+			"ou-prod":      workloads,
+			"ou-workloads": root,
+		},
+		ouNames: map[string]string{"ou-prod": "Prod", "ou-workloads": "Workloads"},
+	}
+}
+
+func TestFetchAccountMetadata(t *testing.T) {
+	client := newMockOrganizationsClient()
+
+	metadata, err := fetchAccountMetadata(context.Background(), client, newThrottle(), 1)
+	if err != nil {
+		t.Fatalf("fetchAccountMetadata failed: %v", err)
+	}
+
+	want := map[string]string{
+		"111111111111": "/Workloads/Prod",
+		"222222222222": "/Workloads/Prod",
+		"333333333333": "/Workloads",
+		"444444444444": "/",
+	}
+	for accountID, ou := range want {
+		if metadata[accountID].OU != ou {
+			t.Fatalf("OU of %s = %q, want %q", accountID, metadata[accountID].OU, ou)
+		}
+	}
+	if metadata["111111111111"].Tags["team"] != "payments" {
+		t.Fatalf("unexpected tags: %v", metadata["111111111111"].Tags)
+	}
+	if client.describeCalls != 2 {
+		t.Fatalf("expected each OU to be described once, got %d calls", client.describeCalls)
+	}
+}
+
+func TestProviderEnrichesProfiles(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.SSO.Region = testRegion
+	cfg.SSO.StartURL = testStartURL
+	cfg.ProfileTemplate = `{{ index .Tags "env" }}/{{ .AccountName }}/{{ .RoleName }}`
+	cfg.Organizations = OrganizationsConfig{Enabled: true, Profile: "management", Region: defaultOrganizationsRegion}
+	cfg.Filters = ProfileFilters{
+		Include: []FilterRule{{OUs: []string{"/workloads/*"}}, {Tags: map[string]string{"env": "dev"}}},
+		Exclude: []FilterRule{{Tags: map[string]string{"team": "payments"}}},
+	}
+
+	provider := NewProvider(cfg)
+	provider.discover = func(context.Context, *Config, core.ProgressReporter) ([]DiscoveredProfile, []string, []string, error) {
+		return []DiscoveredProfile{
+			{AccountID: "111111111111", AccountName: "payments", RoleName: "Admin"},
+			{AccountID: "222222222222", AccountName: "ledger", RoleName: "Admin"},
+			{AccountID: "333333333333", AccountName: "sandbox", RoleName: "Admin"},
+			{AccountID: "444444444444", AccountName: "management", RoleName: "Admin"},
+		}, nil, nil, nil
+	}
+	factoryCalls := 0
+	provider.organizations = func(_ context.Context, orgs OrganizationsConfig, _ string) (OrganizationsClient, error) {
+		factoryCalls++
+		if orgs.Profile != "management" {
+			t.Fatalf("unexpected organizations profile %q", orgs.Profile)
+		}
+		return newMockOrganizationsClient(), nil
+	}
+
+	cache, err := core.NewCache(t.TempDir(), core.CacheDefault)
+	if err != nil {
+		t.Fatalf("NewCache failed: %v", err)
+	}
+	providerCache := cache.ForProvider(ProviderName, 0)
+
+	for range 2 {
+		output, _, err := provider.Export(context.Background(), ExportFormatJSON, providerCache, nil)
+		if err != nil {
+			t.Fatalf("Export failed: %v", err)
+		}
+		var exported []ExportedProfile
+		if err := json.Unmarshal([]byte(output), &exported); err != nil {
+			t.Fatalf("decode export: %v", err)
+		}
+		names := make([]string, 0, len(exported))
+		for _, profile := range exported {
+			names = append(names, profile.Profile+" "+profile.OU)
+		}
+		if got := strings.Join(names, ", "); got != "dev/sandbox/admin /Workloads, prod/ledger/admin /Workloads/Prod" {
+			t.Fatalf("exported profiles = %s", got)
+		}
+	}
+	if factoryCalls != 1 {
+		t.Fatalf("expected cached organizations metadata, got %d client creations", factoryCalls)
+	}
+}
+
+func TestOrganizationsClient(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 ") {
+			http.Error(w, "unsigned", http.StatusForbidden)
+			return
+		}
+		var input map[string]string
+		_ = json.NewDecoder(r.Body).Decode(&input)
+
+		w.Header().Set("Content-Type", "application/x-amz-json-1.1")
+		switch r.Header.Get("X-Amz-Target") {
+		case "AWSOrganizationsV20161128.ListAccounts":
+			if input["NextToken"] == "" {
+				_, _ = w.Write([]byte(`{"Accounts":[{"Id":"111111111111","Name":"payments"}],"NextToken":"page2"}`))
+				return
+			}
+			_, _ = w.Write([]byte(`{"Accounts":[{"Id":"222222222222","Name":"ledger"}]}`))
+		case "AWSOrganizationsV20161128.ListParents":
+			_, _ = w.Write([]byte(`{"Parents":[{"Id":"ou-prod","Type":"ORGANIZATIONAL_UNIT"}]}`))
+		case "AWSOrganizationsV20161128.DescribeOrganizationalUnit":
+			_, _ = w.Write([]byte(`{"OrganizationalUnit":{"Id":"ou-prod","Name":"Prod"}}`))
+		case "AWSOrganizationsV20161128.ListTagsForResource":
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"__type":"com.amazonaws.organizations#TooManyRequestsException","Message":"slow down"}`))
+		default:
+			http.Error(w, "unexpected target", http.StatusBadRequest)
+		}
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	t.Setenv("AWS_CONFIG_FILE", filepath.Join(dir, "config"))
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", filepath.Join(dir, "credentials"))
+	t.Setenv("AWS_ACCESS_KEY_ID", "AKIA")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "secret")
+	t.Setenv("AWS_MAX_ATTEMPTS", "1")
+
+	client, err := newOrganizationsClient(context.Background(), OrganizationsConfig{}, server.URL)
+	if err != nil {
+		t.Fatalf("newOrganizationsClient failed: %v", err)
+	}
+
+	accounts, err := client.ListAccounts(context.Background())
+	if err != nil {
+		t.Fatalf("ListAccounts failed: %v", err)
+	}
+	if len(accounts) != 2 || accounts[1] != (OrganizationsAccount{ID: "222222222222", Name: "ledger"}) {
+		t.Fatalf("unexpected accounts: %+v", accounts)
+	}
+
+	parents, err := client.ListParents(context.Background(), "111111111111")
+	if err != nil {
+		t.Fatalf("ListParents failed: %v", err)
+	}
+	if len(parents) != 1 || parents[0] != (OrganizationsParent{ID: "ou-prod", Type: "ORGANIZATIONAL_UNIT"}) {
+		t.Fatalf("unexpected parents: %+v", parents)
+	}
+
+	name, err := client.DescribeOrganizationalUnit(context.Background(), "ou-prod")
+	if err != nil || name != "Prod" {
+		t.Fatalf("DescribeOrganizationalUnit = %q, %v", name, err)
+	}
+
+	_, err = client.ListTagsForResource(context.Background(), "111111111111")
+	if !isThrottlingError(err) {
+		t.Fatalf("expected a throttling error, got %v", err)
+	}
+}
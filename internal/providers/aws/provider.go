@@ -5,10 +5,14 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"log/slog"
 	"os"
 	"path/filepath"
+	"sync"
+	"time"
 
 	"github.com/jmreicha/cfgctl/internal/core"
+	"golang.org/x/sync/errgroup"
 )
 
 // ProviderName is the unique identifier for the AWS provider.
@@ -18,25 +22,46 @@ var errProviderConfigNil = errors.New("aws provider configuration is nil")
 
 // Provider implements the core.Provider interface for AWS configuration management.
 type Provider struct {
-	config   *Config
-	discover discoverProfilesFunc
+	config        *Config
+	discover      discoverProfilesFunc
+	organizations OrganizationsClientFactory
+	logger        *slog.Logger
+	loginMu       sync.Mutex
 }
 
-type discoverProfilesFunc func(context.Context, *Config) ([]DiscoveredProfile, error)
+type discoverProfilesFunc func(context.Context, *Config, core.ProgressReporter) ([]DiscoveredProfile, []string, []string, error)
 
-func defaultDiscoverProfiles(ctx context.Context, cfg *Config) ([]DiscoveredProfile, error) {
-	return DiscoverProfiles(ctx, cfg, nil)
+func defaultDiscoverProfiles(ctx context.Context, cfg *Config, progress core.ProgressReporter) ([]DiscoveredProfile, []string, []string, error) {
+	return DiscoverProfiles(ctx, cfg, nil, progress)
 }
 
 // NewProvider creates a new AWS provider instance with the given configuration.
-func NewProvider(config *Config) *Provider {
+func NewProvider(config *Config, opts ...ProviderOption) *Provider {
 	if config == nil {
 		config = DefaultConfig()
 	}
 
-	return &Provider{
-		config:   config,
-		discover: defaultDiscoverProfiles,
+	p := &Provider{
+		config:        config,
+		discover:      defaultDiscoverProfiles,
+		organizations: NewOrganizationsClientFactory(),
+		logger:        slog.New(slog.NewTextHandler(os.Stderr, nil)),
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
+}
+
+// ProviderOption configures a Provider.
+type ProviderOption func(*Provider)
+
+// WithLogger sets the logger for the provider.
+func WithLogger(logger *slog.Logger) ProviderOption {
+	return func(p *Provider) {
+		if logger != nil {
+			p.logger = logger
+		}
 	}
 }
 
@@ -58,6 +83,15 @@ func (p *Provider) Validate(_ context.Context) error {
 	return p.config.Validate()
 }
 
+// ExpiryWarnings returns warnings for SSO tokens and client registrations of
+// the configured sessions that expire soon. It reads only the token cache.
+func (p *Provider) ExpiryWarnings(now time.Time) []string {
+	if p.config == nil || !p.config.Enabled || p.config.Demo {
+		return nil
+	}
+	return sessionExpiryWarnings(p.config, now)
+}
+
 // Generate creates the configuration files for this provider.
 func (p *Provider) Generate(ctx context.Context, opts *core.GenerateOptions) (*core.Result, error) {
 	result := &core.Result{
@@ -86,35 +120,75 @@ func (p *Provider) Generate(ctx context.Context, opts *core.GenerateOptions) (*c
 		return result, nil
 	}
 
-	profiles, err := p.discover(ctx, p.config)
+	var (
+		progress core.ProgressReporter
+		cache    *core.ProviderCache
+	)
+	if opts != nil {
+		progress = opts.Progress
+		cache = opts.Cache
+	}
+
+	start := time.Now()
+	profiles, warnings, skipped, err := p.discoverCached(ctx, cache, progress)
 	if err != nil {
-		if !errors.Is(err, errSSOLoginRequired) {
-			return nil, err
-		}
-		if loginErr := runSSOLogin(ctx, p.config); loginErr != nil {
-			return nil, fmt.Errorf("auto sso login: %w", loginErr)
-		}
-		profiles, err = p.discover(ctx, p.config)
+		return nil, err
+	}
+	result.Warnings = append(result.Warnings, warnings...)
+	profiles, decisions, err := filterProfiles(p.config, profiles)
+	if err != nil {
+		return nil, err
+	}
+	if p.config.ExplainFilters {
+		result.Metadata[MetadataFilterDecisions] = formatDecisions(decisions)
+	}
+	result.RecordPhase(core.TimingDiscover, start)
+	p.logger.Debug("discovered sso profiles", "count", len(profiles))
+	result.Warnings = append(result.Warnings, p.ExpiryWarnings(time.Now().UTC())...)
+
+	if err := p.resolveClusterRegions(ctx, profiles, cache); err != nil {
+		return nil, err
+	}
+
+	start = time.Now()
+	var keep map[string]bool
+	if p.config.Prune {
+		keep, err = skippedAccountProfiles(outputPath, p.config.MarkerKey, skipped)
 		if err != nil {
 			return nil, err
 		}
 	}
-
-	finalContent, _, err := buildConfigContent(p.config, outputPath, profiles, result)
+	finalContent, generatedNames, err := buildConfigContent(p.config, outputPath, profiles, keep, result)
 	if err != nil {
 		return nil, err
 	}
+	for _, name := range generatedNames {
+		delete(keep, name)
+	}
+	if len(keep) > 0 {
+		result.Warnings = append(result.Warnings, fmt.Sprintf("prune kept %d profiles of accounts that could not be listed", len(keep)))
+	}
 
-	credentialsEnabled, credentialsPath, credentialsContent, err := buildCredentialsContent(p.config, profiles, result)
+	credentialsEnabled, credentialsPath, credentialsContent, err := buildCredentialsContent(p.config, profiles, keep, result)
 	if err != nil {
 		return nil, err
 	}
+	result.RecordPhase(core.TimingRender, start)
 
 	if opts != nil && opts.DryRun {
 		applyDryRunMetadata(result, outputPath, finalContent, credentialsEnabled, credentialsPath, credentialsContent)
+		// Demo runs are dry runs, so later providers would otherwise read
+		// the real config file the demo did not write.
+		if p.config.Demo {
+			if err := p.publishProfiles(opts, outputPath, finalContent, profiles); err != nil {
+				return nil, err
+			}
+		}
 		return result, nil
 	}
 
+	p.logger.Debug("writing aws config", "path", outputPath)
+	start = time.Now()
 	if err := os.MkdirAll(filepath.Dir(outputPath), 0700); err != nil {
 		return nil, fmt.Errorf("failed to create config directory: %w", err)
 	}
@@ -127,16 +201,282 @@ func (p *Provider) Generate(ctx context.Context, opts *core.GenerateOptions) (*c
 	result.FilesCreated = append(result.FilesCreated, outputPath)
 	result.Metadata["discovered_profiles"] = len(profiles)
 
+	if err := p.publishProfiles(opts, outputPath, finalContent, profiles); err != nil {
+		return nil, err
+	}
+
 	if credentialsEnabled && credentialsWriteAllowed {
 		if err := writeCredentialsFile(credentialsPath, credentialsContent); err != nil {
 			return nil, err
 		}
 		result.FilesCreated = append(result.FilesCreated, credentialsPath)
 	}
+	result.RecordPhase(core.TimingWrite, start)
 
 	return result, nil
 }
 
+// resolveClusterRegions sets ClusterRegions from an EKS scan of profiles when
+// a region variant rule takes eks_regions. The config file may not hold this
+// run's profiles yet, so the scan reads the config rendered without those
+// variants from a temporary file.
+func (p *Provider) resolveClusterRegions(ctx context.Context, profiles []DiscoveredProfile, cache *core.ProviderCache) error {
+	if !p.config.UsesEKSRegions() || p.config.DiscoverClusterRegions == nil {
+		return nil
+	}
+	p.config.ClusterRegions = nil
+	if len(profiles) == 0 {
+		return nil
+	}
+
+	content, _, _, err := BuildGeneratedConfigContent(p.config, profiles)
+	if err != nil {
+		return err
+	}
+	inventory, err := buildInventory(p.config, content, profiles)
+	if err != nil {
+		return err
+	}
+
+	dir, err := os.MkdirTemp("", "cfgctl-eks-regions-")
+	if err != nil {
+		return fmt.Errorf("create eks regions directory: %w", err)
+	}
+	defer func() { _ = os.RemoveAll(dir) }()
+	path := filepath.Join(dir, "config")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		return fmt.Errorf("write eks regions config: %w", err)
+	}
+
+	regions, err := p.config.DiscoverClusterRegions(ctx, path, inventory, cache)
+	if err != nil {
+		return fmt.Errorf("discover eks regions: %w", err)
+	}
+	p.config.ClusterRegions = regions
+	return nil
+}
+
+// publishProfiles shares the profiles in content, the config file at path,
+// with the providers that run after this one.
+func (p *Provider) publishProfiles(opts *core.GenerateOptions, path, content string, profiles []DiscoveredProfile) error {
+	if opts == nil || opts.AWSProfiles == nil {
+		return nil
+	}
+	inventory, err := buildInventory(p.config, content, profiles)
+	if err != nil {
+		return err
+	}
+	opts.AWSProfiles.Publish(path, inventory)
+	return nil
+}
+
+// Export discovers and filters profiles as Generate does and renders them in
+// format without writing any files. An unknown format is rejected before
+// discovery. The cache may be nil.
+func (p *Provider) Export(ctx context.Context, format string, cache *core.ProviderCache, progress core.ProgressReporter) (string, []string, error) {
+	if err := validateExportFormat(format); err != nil {
+		return "", nil, err
+	}
+	if p.discover == nil {
+		p.discover = defaultDiscoverProfiles
+	}
+
+	profiles, warnings, _, err := p.discoverCached(ctx, cache, progress)
+	if err != nil {
+		return "", nil, err
+	}
+	profiles, _, err = filterProfiles(p.config, profiles)
+	if err != nil {
+		return "", nil, err
+	}
+	if err := p.resolveClusterRegions(ctx, profiles, cache); err != nil {
+		return "", nil, err
+	}
+
+	output, err := ExportProfiles(p.config, profiles, format)
+	if err != nil {
+		return "", nil, err
+	}
+	return output, warnings, nil
+}
+
+// Lint checks the AWS config and credentials files. With discover set it
+// also discovers profiles, through cache, to report generated profiles that
+// no longer match an account and role; opts.Generated is then replaced.
+func (p *Provider) Lint(ctx context.Context, discover bool, opts LintOptions, cache *core.ProviderCache) ([]LintFinding, error) {
+	if discover {
+		if p.discover == nil {
+			p.discover = defaultDiscoverProfiles
+		}
+		profiles, _, skipped, err := p.discoverCached(ctx, cache, nil)
+		if err != nil {
+			return nil, err
+		}
+		profiles, _, err = filterProfiles(p.config, profiles)
+		if err != nil {
+			return nil, err
+		}
+		if err := p.resolveClusterRegions(ctx, profiles, cache); err != nil {
+			return nil, err
+		}
+		_, generated, _, err := BuildGeneratedConfigContent(p.config, profiles)
+		if err != nil {
+			return nil, err
+		}
+		// Profiles of accounts that could not be listed are not stale, the
+		// same as prune keeps them.
+		keep, err := skippedAccountProfiles(p.config.ConfigPath, p.config.MarkerKey, skipped)
+		if err != nil {
+			return nil, err
+		}
+		for name := range keep {
+			generated = append(generated, name)
+		}
+		opts.Generated = generated
+	}
+	return LintFiles(p.config, opts)
+}
+
+// discoverCached discovers the profiles of every configured SSO session and
+// adds their Organizations metadata when that is enabled. It also returns the
+// IDs of the accounts whose roles could not be listed.
+func (p *Provider) discoverCached(ctx context.Context, cache *core.ProviderCache, progress core.ProgressReporter) ([]DiscoveredProfile, []string, []string, error) {
+	profiles, warnings, skipped, err := p.discoverSessions(ctx, cache, progress)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if err := p.enrichProfiles(ctx, profiles, cache, progress); err != nil {
+		return nil, nil, nil, err
+	}
+	return profiles, warnings, skipped, nil
+}
+
+// discoverSessions discovers the profiles of every configured SSO session,
+// running the sessions in parallel when there is more than one.
+func (p *Provider) discoverSessions(ctx context.Context, cache *core.ProviderCache, progress core.ProgressReporter) ([]DiscoveredProfile, []string, []string, error) {
+	sessions := p.config.sessionConfigs()
+	if len(sessions) == 1 {
+		return p.discoverSession(ctx, sessions[0], cache, progress)
+	}
+
+	discovered := make([][]DiscoveredProfile, len(sessions))
+	sessionWarnings := make([][]string, len(sessions))
+	sessionSkipped := make([][]string, len(sessions))
+	g, groupCtx := errgroup.WithContext(ctx)
+	for i, session := range sessions {
+		g.Go(func() error {
+			profiles, warnings, skipped, err := p.discoverSession(groupCtx, session, cache, progress)
+			if err != nil {
+				return fmt.Errorf("sso session %s: %w", session.SSO.SessionName, err)
+			}
+			discovered[i] = profiles
+			sessionSkipped[i] = skipped
+			for _, warning := range warnings {
+				sessionWarnings[i] = append(sessionWarnings[i], fmt.Sprintf("sso session %s: %s", session.SSO.SessionName, warning))
+			}
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return nil, nil, nil, err
+	}
+
+	var (
+		profiles          []DiscoveredProfile
+		warnings, skipped []string
+	)
+	for i := range sessions {
+		profiles = append(profiles, discovered[i]...)
+		warnings = append(warnings, sessionWarnings[i]...)
+		skipped = append(skipped, sessionSkipped[i]...)
+	}
+	return profiles, warnings, skipped, nil
+}
+
+// discoverSession returns the SSO profiles for one session from the cache
+// when it holds a fresh entry for the start URL and region, and discovers and
+// caches them otherwise. Accounts the filters rule out are not discovered, and
+// those filters are part of the cache key; the remaining profiles are
+// returned and cached before filtering by role, OU and tag, so changing those
+// does not require rediscovery. Partial results from a run that skipped
+// accounts are not cached at all.
+func (p *Provider) discoverSession(ctx context.Context, session *Config, cache *core.ProviderCache, progress core.ProgressReporter) ([]DiscoveredProfile, []string, []string, error) {
+	if session.Demo {
+		cache = nil
+	}
+
+	filter, err := newAccountFilter(session)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	key := core.CacheKey{StartURL: session.SSO.StartURL, Region: session.SSO.Region, Filter: filter.cacheKey()}
+	var (
+		profiles          []DiscoveredProfile
+		warnings, skipped []string
+	)
+	found, err := cache.Load(key, &profiles)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if found {
+		p.logger.Debug("using cached sso profiles", "session", session.SSO.SessionName, "count", len(profiles))
+	} else {
+		unfiltered := *session
+		unfiltered.Roles = nil
+		profiles, warnings, skipped, err = p.discoverWithLogin(ctx, &unfiltered, progress)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		if len(skipped) == 0 {
+			if err := cache.Store(key, profiles); err != nil {
+				p.logger.Warn("failed to cache sso profiles", "error", err)
+			}
+		}
+	}
+
+	// Sessions sharing a start URL share cache entries, so the session is
+	// recorded after loading.
+	for i := range profiles {
+		profiles[i].SSOSession = session.SSO.SessionName
+	}
+	return profiles, warnings, skipped, nil
+}
+
+// discoverWithLogin discovers profiles, retrying once if the SSO session is
+// missing or expired. An expired token is refreshed silently when the cache
+// holds a refresh token; otherwise, or if that fails, the user signs in to
+// IAM Identity Center.
+func (p *Provider) discoverWithLogin(ctx context.Context, cfg *Config, progress core.ProgressReporter) ([]DiscoveredProfile, []string, []string, error) {
+	profiles, warnings, skipped, err := p.discover(ctx, cfg, progress)
+	if err == nil || !errors.Is(err, errSSOLoginRequired) {
+		return profiles, warnings, skipped, err
+	}
+
+	// Sessions are discovered in parallel, but only one may refresh or sign
+	// in at a time so that login prompts do not interleave.
+	p.loginMu.Lock()
+	defer p.loginMu.Unlock()
+
+	refreshErr := refreshSSOToken(ctx, cfg, time.Now().UTC())
+	switch {
+	case refreshErr == nil:
+		p.logger.Info("refreshed expired sso token", "session", cfg.SSO.SessionName)
+		profiles, warnings, skipped, err = p.discover(ctx, cfg, progress)
+		if err == nil || !errors.Is(err, errSSOLoginRequired) {
+			return profiles, warnings, skipped, err
+		}
+	case !errors.Is(refreshErr, errNoValidToken):
+		p.logger.Warn("failed to refresh sso token", "session", cfg.SSO.SessionName, "error", refreshErr)
+	}
+
+	p.logger.Info("sso session missing or expired, starting sso login", "session", cfg.SSO.SessionName, "method", cfg.SSO.Login)
+	core.ReportProgress(progress, core.ProgressEvent{Kind: core.ProgressWarning, Message: "sso session expired, starting sso login"})
+	if loginErr := runSSOLogin(ctx, cfg); loginErr != nil {
+		return nil, nil, nil, fmt.Errorf("auto sso login: %w", loginErr)
+	}
+	return p.discover(ctx, cfg, progress)
+}
+
 func (p *Provider) applyGenerateOptions(opts *core.GenerateOptions) error {
 	if p.config == nil {
 		return errProviderConfigNil
@@ -162,7 +502,9 @@ func checkExistingOutput(outputPath string, opts *core.GenerateOptions, result *
 	return false
 }
 
-func buildConfigContent(cfg *Config, outputPath string, profiles []DiscoveredProfile, result *core.Result) (string, []string, error) {
+// buildConfigContent renders the config file. With prune enabled it is merged
+// into the existing file, keeping the managed profiles named in keep.
+func buildConfigContent(cfg *Config, outputPath string, profiles []DiscoveredProfile, keep map[string]bool, result *core.Result) (string, []string, error) {
 	configContent, generatedNames, warnings, err := BuildGeneratedConfigContent(cfg, profiles)
 	if err != nil {
 		return "", nil, err
@@ -171,17 +513,18 @@ func buildConfigContent(cfg *Config, outputPath string, profiles []DiscoveredPro
 
 	finalContent := configContent
 	if cfg.Prune {
-		mergedContent, err := mergeConfigContent(outputPath, configContent, generatedNames, cfg.MarkerKey, cfg.SSO.SessionName)
+		mergedContent, mergeWarnings, err := mergeConfigContent(outputPath, configContent, cfg.MarkerKey, cfg.sessionNames(), keep)
 		if err != nil {
 			return "", nil, err
 		}
+		result.Warnings = append(result.Warnings, mergeWarnings...)
 		finalContent = mergedContent
 	}
 
 	return finalContent, generatedNames, nil
 }
 
-func buildCredentialsContent(cfg *Config, profiles []DiscoveredProfile, result *core.Result) (bool, string, string, error) {
+func buildCredentialsContent(cfg *Config, profiles []DiscoveredProfile, keep map[string]bool, result *core.Result) (bool, string, string, error) {
 	credentialsEnabled := cfg.GenerateCredentials && cfg.UseCredentialProcess
 	credentialsPath := cfg.CredentialsPath
 	credentialsContent := ""
@@ -194,6 +537,14 @@ func buildCredentialsContent(cfg *Config, profiles []DiscoveredProfile, result *
 			return false, "", "", err
 		}
 		result.Warnings = append(result.Warnings, warnings...)
+		if cfg.Prune {
+			var mergeWarnings []string
+			content, mergeWarnings, err = mergeCredentialsContent(credentialsPath, content, cfg.MarkerKey, keep)
+			if err != nil {
+				return false, "", "", err
+			}
+			result.Warnings = append(result.Warnings, mergeWarnings...)
+		}
 		credentialsContent = content
 		result.Metadata["credential_profiles"] = len(credentialProfiles)
 	}
@@ -3,10 +3,16 @@ package aws
 import (
 	"context"
 	"errors"
+	"fmt"
+	"io"
+	"log/slog"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/jmreicha/cfgctl/internal/core"
 )
@@ -67,8 +73,8 @@ func TestProviderGenerateCredentialsDisabledWhenUseCredentialProcessFalse(t *tes
 	cfg.SSO.StartURL = testStartURL
 	cfg.TokenCachePaths = []string{t.TempDir()}
 	provider := NewProvider(cfg)
-	provider.discover = func(context.Context, *Config) ([]DiscoveredProfile, error) {
-		return []DiscoveredProfile{}, nil
+	provider.discover = func(context.Context, *Config, core.ProgressReporter) ([]DiscoveredProfile, []string, []string, error) {
+		return []DiscoveredProfile{}, nil, nil, nil
 	}
 
 	result, err := provider.Generate(context.Background(), &core.GenerateOptions{Force: true})
@@ -94,14 +100,14 @@ func TestProviderGenerateCredentialsWithCredentialProcess(t *testing.T) {
 	cfg.SSO.StartURL = testStartURL
 	cfg.TokenCachePaths = []string{t.TempDir()}
 	provider := NewProvider(cfg)
-	provider.discover = func(context.Context, *Config) ([]DiscoveredProfile, error) {
+	provider.discover = func(context.Context, *Config, core.ProgressReporter) ([]DiscoveredProfile, []string, []string, error) {
 		return []DiscoveredProfile{
 			{
 				AccountID:   "111111111111",
 				AccountName: "prod",
 				RoleName:    "Admin",
 			},
-		}, nil
+		}, nil, nil, nil
 	}
 
 	result, err := provider.Generate(context.Background(), &core.GenerateOptions{Force: true})
@@ -575,10 +581,10 @@ func TestProviderGenerateDryRun(t *testing.T) {
 	cfg.SSO.StartURL = testStartURL
 	cfg.TokenCachePaths = []string{t.TempDir()}
 	provider := NewProvider(cfg)
-	provider.discover = func(context.Context, *Config) ([]DiscoveredProfile, error) {
+	provider.discover = func(context.Context, *Config, core.ProgressReporter) ([]DiscoveredProfile, []string, []string, error) {
 		return []DiscoveredProfile{
 			{AccountID: "111111111111", AccountName: "prod", RoleName: "Admin"},
-		}, nil
+		}, nil, nil, nil
 	}
 
 	result, err := provider.Generate(context.Background(), &core.GenerateOptions{DryRun: true})
@@ -592,3 +598,450 @@ func TestProviderGenerateDryRun(t *testing.T) {
 		t.Fatal("expected no file to be created in dry-run mode")
 	}
 }
+
+func TestProviderGeneratePruneKeepsSkippedAccounts(t *testing.T) {
+	configDir := t.TempDir()
+	cfg := DefaultConfig()
+	cfg.ConfigPath = filepath.Join(configDir, "config")
+	cfg.SSO.Region = testRegion
+	cfg.SSO.StartURL = testStartURL
+	cfg.TokenCachePaths = []string{t.TempDir()}
+	cfg.Prune = true
+
+	existing := `[profile prod/admin]
+sso_session = cfgctl
+sso_account_id = 111111111111
+sso_role_name = Admin
+sso_auto_populated = true
+
+[profile staging/Admin]
+sso_session = cfgctl
+sso_account_id = 222222222222
+sso_role_name = Admin
+sso_auto_populated = true
+
+[profile staging/Admin/deploy]
+role_arn = arn:aws:iam::444444444444:role/Deploy
+source_profile = staging/Admin
+sso_auto_populated = true
+
+[profile retired/Admin]
+sso_session = cfgctl
+sso_account_id = 333333333333
+sso_role_name = Admin
+sso_auto_populated = true
+`
+	if err := os.WriteFile(cfg.ConfigPath, []byte(existing), 0o600); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+
+	provider := NewProvider(cfg)
+	provider.discover = func(context.Context, *Config, core.ProgressReporter) ([]DiscoveredProfile, []string, []string, error) {
+		return []DiscoveredProfile{
+			{AccountID: "111111111111", AccountName: "prod", RoleName: "Admin"},
+		}, []string{"skipped account: 222222222222: throttled"}, []string{"222222222222"}, nil
+	}
+
+	result, err := provider.Generate(context.Background(), &core.GenerateOptions{Force: true})
+	if err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+	data, err := os.ReadFile(cfg.ConfigPath)
+	if err != nil {
+		t.Fatalf("read config: %v", err)
+	}
+	content := string(data)
+	for _, name := range []string{"[profile prod/admin]", "[profile staging/Admin]", "[profile staging/Admin/deploy]"} {
+		if !strings.Contains(content, name) {
+			t.Errorf("expected %s to be kept:\n%s", name, content)
+		}
+	}
+	if strings.Contains(content, "[profile retired/Admin]") {
+		t.Errorf("expected the profile of an account that was listed to be pruned:\n%s", content)
+	}
+	if !slices.Contains(result.Warnings, "prune kept 2 profiles of accounts that could not be listed") {
+		t.Errorf("warnings = %v", result.Warnings)
+	}
+}
+
+func TestProviderGenerateRecordsTimings(t *testing.T) {
+	configDir := t.TempDir()
+	cfg := DefaultConfig()
+	cfg.ConfigPath = filepath.Join(configDir, "config")
+	cfg.SSO.Region = testRegion
+	cfg.SSO.StartURL = testStartURL
+	cfg.TokenCachePaths = []string{t.TempDir()}
+	provider := NewProvider(cfg)
+	provider.discover = func(context.Context, *Config, core.ProgressReporter) ([]DiscoveredProfile, []string, []string, error) {
+		return []DiscoveredProfile{}, nil, nil, nil
+	}
+
+	result, err := provider.Generate(context.Background(), &core.GenerateOptions{Force: true})
+	if err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+
+	phases := make([]string, 0, len(result.Timings))
+	for _, timing := range result.Timings {
+		phases = append(phases, timing.Phase)
+	}
+	want := []string{core.TimingDiscover, core.TimingRender, core.TimingWrite}
+	if strings.Join(phases, ",") != strings.Join(want, ",") {
+		t.Fatalf("phases = %v, want %v", phases, want)
+	}
+}
+
+func TestProviderGenerateUsesDiscoveryCache(t *testing.T) {
+	configDir := t.TempDir()
+	cfg := DefaultConfig()
+	cfg.ConfigPath = filepath.Join(configDir, "config")
+	cfg.SSO.Region = testRegion
+	cfg.SSO.StartURL = testStartURL
+	cfg.TokenCachePaths = []string{t.TempDir()}
+	provider := NewProvider(cfg)
+
+	discoveries := 0
+	provider.discover = func(_ context.Context, discoverCfg *Config, _ core.ProgressReporter) ([]DiscoveredProfile, []string, []string, error) {
+		discoveries++
+		if len(discoverCfg.Roles) != 0 {
+			t.Errorf("expected unfiltered discovery, got roles %v", discoverCfg.Roles)
+		}
+		return []DiscoveredProfile{
+			{AccountID: "111111111111", AccountName: "prod", RoleName: "Admin"},
+			{AccountID: "111111111111", AccountName: "prod", RoleName: "ReadOnly"},
+		}, nil, nil, nil
+	}
+
+	cacheDir := t.TempDir()
+	generate := func(mode core.CacheMode) (*core.Result, error) {
+		cache, err := core.NewCache(cacheDir, mode)
+		if err != nil {
+			t.Fatalf("NewCache failed: %v", err)
+		}
+		return provider.Generate(context.Background(), &core.GenerateOptions{
+			Force: true,
+			Cache: cache.ForProvider(ProviderName, time.Hour),
+		})
+	}
+
+	if _, err := generate(core.CacheOffline); !errors.Is(err, core.ErrCacheMiss) {
+		t.Fatalf("expected ErrCacheMiss offline with an empty cache, got %v", err)
+	}
+
+	if _, err := generate(core.CacheDefault); err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+
+	cfg.Roles = []string{"Admin"}
+	result, err := generate(core.CacheOffline)
+	if err != nil {
+		t.Fatalf("offline Generate failed: %v", err)
+	}
+	if discoveries != 1 {
+		t.Fatalf("discoveries = %d, want 1", discoveries)
+	}
+	if result.Metadata["discovered_profiles"] != 1 {
+		t.Fatalf("expected role filter to apply to cached profiles, got %v", result.Metadata["discovered_profiles"])
+	}
+
+	if _, err := generate(core.CacheRefresh); err != nil {
+		t.Fatalf("refresh Generate failed: %v", err)
+	}
+	if discoveries != 2 {
+		t.Fatalf("discoveries after refresh = %d, want 2", discoveries)
+	}
+}
+
+func TestProviderGeneratePublishesProfiles(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.ConfigPath = filepath.Join(t.TempDir(), "config")
+	cfg.SSO.Region = testRegion
+	cfg.SSO.StartURL = testStartURL
+	cfg.UseCredentialProcess = true
+	provider := NewProvider(cfg)
+	provider.discover = func(context.Context, *Config, core.ProgressReporter) ([]DiscoveredProfile, []string, []string, error) {
+		return []DiscoveredProfile{{AccountID: "111111111111", AccountName: "prod", RoleName: "Admin"}}, nil, nil, nil
+	}
+
+	inventory := &core.AWSProfileInventory{}
+	if _, err := provider.Generate(context.Background(), &core.GenerateOptions{Force: true, AWSProfiles: inventory}); err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+
+	profiles, ok := inventory.Profiles(cfg.ConfigPath)
+	if !ok {
+		t.Fatal("expected profiles to be published for the config path")
+	}
+	var published *core.AWSProfile
+	for i := range profiles {
+		if profiles[i].Managed {
+			published = &profiles[i]
+		}
+	}
+	if published == nil {
+		t.Fatalf("expected a managed profile, got %+v", profiles)
+	}
+	if published.AccountID != "111111111111" || published.AccountName != "prod" || published.RoleName != "Admin" {
+		t.Fatalf("credential_process profile not completed from discovery: %+v", published)
+	}
+}
+
+func TestProviderGenerateDiscoversEachSession(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.ConfigPath = filepath.Join(t.TempDir(), "config")
+	cfg.SSO.Region = testRegion
+	cfg.TokenCachePaths = []string{t.TempDir()}
+	cfg.SSOSessions = []SSOSessionConfig{
+		{SSOConfig: SSOConfig{SessionName: "prod", StartURL: testStartURL}},
+		{
+			SSOConfig:     SSOConfig{SessionName: "acquired", StartURL: "https://acquired.awsapps.com/start"},
+			Roles:         []string{"ReadOnly"},
+			ProfilePrefix: "acq-",
+		},
+	}
+	provider := NewProvider(cfg)
+
+	var mu sync.Mutex
+	started := map[string]bool{}
+	provider.discover = func(_ context.Context, discoverCfg *Config, _ core.ProgressReporter) ([]DiscoveredProfile, []string, []string, error) {
+		mu.Lock()
+		started[discoverCfg.SSO.SessionName] = true
+		mu.Unlock()
+		return []DiscoveredProfile{
+			{AccountID: "111111111111", AccountName: "shared", RoleName: "Admin", SSOStartURL: discoverCfg.SSO.StartURL},
+			{AccountID: "111111111111", AccountName: "shared", RoleName: "ReadOnly", SSOStartURL: discoverCfg.SSO.StartURL},
+		}, nil, nil, nil
+	}
+
+	result, err := provider.Generate(context.Background(), &core.GenerateOptions{Force: true, DryRun: true})
+	if err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+	if !started["prod"] || !started["acquired"] {
+		t.Fatalf("expected discovery for both sessions, got %v", started)
+	}
+
+	content, _ := result.Metadata["config_content"].(string)
+	for _, want := range []string{
+		"[sso-session prod]",
+		"[sso-session acquired]",
+		"[profile shared/admin]\nsso_session = prod",
+		"[profile shared/readonly]\nsso_session = prod",
+		"[profile acq-shared/readonly]\nsso_session = acquired",
+	} {
+		if !strings.Contains(content, want) {
+			t.Fatalf("config content missing %q:\n%s", want, content)
+		}
+	}
+	if strings.Contains(content, "acq-shared/admin") {
+		t.Fatalf("session role filter not applied:\n%s", content)
+	}
+}
+
+func TestProviderGenerateRefreshesExpiredToken(t *testing.T) {
+	fake := &fakeOIDCServer{}
+	newFakeOIDC(t, fake)
+
+	cfg := DefaultConfig()
+	cfg.ConfigPath = filepath.Join(t.TempDir(), "config")
+	cfg.SSO.Region = testRegion
+	cfg.SSO.StartURL = testStartURL
+	cfg.TokenCachePaths = []string{t.TempDir()}
+	expired := SSOToken{
+		AccessToken:           "expired",
+		ExpiresAt:             time.Now().UTC().Add(-time.Hour),
+		Region:                testRegion,
+		StartURL:              testStartURL,
+		ClientID:              "client-id",
+		ClientSecret:          "client-secret",
+		RegistrationExpiresAt: time.Now().UTC().Add(24 * time.Hour),
+		RefreshToken:          "refresh-token",
+	}
+	if err := writeToken(filepath.Join(cfg.TokenCachePaths[0], "token.json"), expired); err != nil {
+		t.Fatalf("writeToken failed: %v", err)
+	}
+
+	provider := NewProvider(cfg)
+	provider.discover = func(_ context.Context, discoverCfg *Config, _ core.ProgressReporter) ([]DiscoveredProfile, []string, []string, error) {
+		if _, err := LoadMatchingToken(discoverCfg.TokenCachePaths, testStartURL, testRegion, time.Now().UTC()); err != nil {
+			return nil, nil, nil, errSSOLoginRequired
+		}
+		return []DiscoveredProfile{{AccountID: "111111111111", AccountName: "prod", RoleName: "Admin"}}, nil, nil, nil
+	}
+
+	result, err := provider.Generate(context.Background(), &core.GenerateOptions{Force: true})
+	if err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+	if _, ok := fake.requests["/device_authorization"]; ok {
+		t.Fatal("expected a silent refresh, not a device login")
+	}
+	if fake.requests["/token"]["grantType"] != refreshGrantType {
+		t.Fatalf("unexpected token request: %v", fake.requests["/token"])
+	}
+	if result.Metadata["discovered_profiles"] != 1 {
+		t.Fatalf("discovered_profiles = %v, want 1", result.Metadata["discovered_profiles"])
+	}
+
+	// The refreshed token keeps the registration, which expires within the
+	// warning window.
+	found := false
+	for _, warning := range result.Warnings {
+		found = found || strings.Contains(warning, "client registration")
+	}
+	if !found {
+		t.Fatalf("expected a registration expiry warning, got %v", result.Warnings)
+	}
+}
+
+func TestWithLogger(t *testing.T) {
+	t.Run("nil logger keeps default", func(t *testing.T) {
+		provider := NewProvider(nil, WithLogger(nil))
+		if provider.logger == nil {
+			t.Error("expected non-nil logger when WithLogger(nil) is used")
+		}
+	})
+
+	t.Run("non-nil logger is set", func(t *testing.T) {
+		customLogger := slog.New(slog.NewTextHandler(io.Discard, nil))
+		provider := NewProvider(nil, WithLogger(customLogger))
+		if provider.logger != customLogger {
+			t.Error("expected custom logger to be set")
+		}
+	})
+}
+
+func TestProviderLintKeepsSkippedAccounts(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.ConfigPath = filepath.Join(t.TempDir(), "config")
+	cfg.CredentialsPath = ""
+	cfg.SSO.Region = testRegion
+	cfg.SSO.StartURL = testStartURL
+	cfg.TokenCachePaths = []string{t.TempDir()}
+
+	existing := `[profile prod/admin]
+sso_account_id = 111111111111
+sso_auto_populated = true
+
+[profile staging/admin]
+sso_account_id = 222222222222
+sso_auto_populated = true
+
+[profile retired/admin]
+sso_account_id = 333333333333
+sso_auto_populated = true
+`
+	if err := os.WriteFile(cfg.ConfigPath, []byte(existing), 0o600); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+
+	provider := NewProvider(cfg)
+	provider.discover = func(context.Context, *Config, core.ProgressReporter) ([]DiscoveredProfile, []string, []string, error) {
+		return []DiscoveredProfile{
+			{AccountID: "111111111111", AccountName: "prod", RoleName: "Admin"},
+		}, []string{"skipped account: 222222222222: throttled"}, []string{"222222222222"}, nil
+	}
+
+	findings, err := provider.Lint(context.Background(), true, LintOptions{Fix: true}, nil)
+	if err != nil {
+		t.Fatalf("Lint failed: %v", err)
+	}
+	var stale []string
+	for _, finding := range findings {
+		if finding.Check == LintStaleProfile {
+			stale = append(stale, finding.Section)
+		}
+	}
+	if !slices.Equal(stale, []string{"profile retired/admin"}) {
+		t.Fatalf("stale = %v, want only the listed account's retired profile", stale)
+	}
+
+	data, err := os.ReadFile(cfg.ConfigPath)
+	if err != nil {
+		t.Fatalf("read config: %v", err)
+	}
+	if !strings.Contains(string(data), "[profile staging/admin]") {
+		t.Fatalf("expected the skipped account's profile to survive --fix:\n%s", data)
+	}
+}
+
+func TestProviderGenerateDemoDryRunPublishesProfiles(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.ConfigPath = filepath.Join(t.TempDir(), "config")
+	cfg.Demo = true
+	cfg.SSO.Region = testRegion
+	cfg.SSO.StartURL = testStartURL
+	provider := NewProvider(cfg)
+	provider.discover = func(context.Context, *Config, core.ProgressReporter) ([]DiscoveredProfile, []string, []string, error) {
+		return []DiscoveredProfile{{AccountID: "111111111111", AccountName: "prod", RoleName: "Admin"}}, nil, nil, nil
+	}
+
+	inventory := &core.AWSProfileInventory{}
+	if _, err := provider.Generate(context.Background(), &core.GenerateOptions{DryRun: true, AWSProfiles: inventory}); err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+	if _, err := os.Stat(cfg.ConfigPath); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("expected a dry run not to write the config, stat err = %v", err)
+	}
+	profiles, ok := inventory.Profiles(cfg.ConfigPath)
+	if !ok || !slices.ContainsFunc(profiles, func(profile core.AWSProfile) bool { return profile.Name == "prod/admin" }) {
+		t.Fatalf("expected the demo profiles to be published, got %+v", profiles)
+	}
+
+	cfg.Demo = false
+	inventory = &core.AWSProfileInventory{}
+	if _, err := provider.Generate(context.Background(), &core.GenerateOptions{DryRun: true, AWSProfiles: inventory}); err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+	if _, ok := inventory.Profiles(cfg.ConfigPath); ok {
+		t.Fatal("expected a real dry run not to publish profiles it did not write")
+	}
+}
+
+func TestProviderGenerateDiscoversClusterRegions(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.ConfigPath = filepath.Join(t.TempDir(), "config")
+	cfg.SSO.Region = testRegion
+	cfg.SSO.StartURL = testStartURL
+	cfg.RegionVariants = []RegionVariantRule{{EKSRegions: true}}
+	cfg.ClusterRegions = map[string][]string{"prod/admin": {"ap-south-1"}}
+	cfg.DiscoverClusterRegions = func(_ context.Context, path string, profiles []core.AWSProfile, _ *core.ProviderCache) (map[string][]string, error) {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		if !strings.Contains(string(data), "[profile prod/admin]") {
+			return nil, fmt.Errorf("scan config misses this run's profile:\n%s", data)
+		}
+		if !slices.ContainsFunc(profiles, func(profile core.AWSProfile) bool { return profile.Name == "prod/admin" }) {
+			return nil, fmt.Errorf("scan profiles = %+v", profiles)
+		}
+		return map[string][]string{"prod/admin": {"eu-west-1"}}, nil
+	}
+	provider := NewProvider(cfg)
+	provider.discover = func(context.Context, *Config, core.ProgressReporter) ([]DiscoveredProfile, []string, []string, error) {
+		return []DiscoveredProfile{{AccountID: "111111111111", AccountName: "prod", RoleName: "Admin"}}, nil, nil, nil
+	}
+
+	// The config file does not exist yet, as on a first run.
+	if _, err := provider.Generate(context.Background(), &core.GenerateOptions{Force: true}); err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+	data, err := os.ReadFile(cfg.ConfigPath)
+	if err != nil {
+		t.Fatalf("read config: %v", err)
+	}
+	if !strings.Contains(string(data), "[profile prod/admin@eu-west-1]") {
+		t.Fatalf("expected a variant for the discovered cluster region:\n%s", data)
+	}
+	if strings.Contains(string(data), "ap-south-1") {
+		t.Fatalf("expected stale cluster regions to be replaced:\n%s", data)
+	}
+
+	cfg.DiscoverClusterRegions = func(context.Context, string, []core.AWSProfile, *core.ProviderCache) (map[string][]string, error) {
+		return nil, errors.New("no credentials")
+	}
+	if _, err := provider.Generate(context.Background(), &core.GenerateOptions{Force: true}); err == nil || !strings.Contains(err.Error(), "discover eks regions") {
+		t.Fatalf("expected the eks scan error, got %v", err)
+	}
+}
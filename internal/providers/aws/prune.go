@@ -7,161 +7,202 @@ import (
 	"strings"
 )
 
-type configSection struct {
-	Header string
-	Keys   []string
-	Name   string
-	Raw    string
+// mergeConfigContent merges generated config content into the config file at
+// path. Profiles tagged with markerKey and the cfgctl sso-sessions are updated
+// in place, or removed when they are no longer generated, and new sections are
+// appended. Everything else, including comments, blank lines, and key order in
+// hand-written sections, is kept byte-for-byte. A hand-written profile that
+// shares a name with a generated one wins and is left alone, and a warning
+// names it. Managed profiles named in keep are not removed even when they are
+// no longer generated.
+func mergeConfigContent(path, generated, markerKey string, sessionNames []string, keep map[string]bool) (string, []string, error) {
+	managed := func(section *iniSection) bool {
+		return section.hasKey(markerKey) || isGeneratedSession(section.name, sessionNames)
+	}
+	kept := func(section *iniSection) bool {
+		name, ok := strings.CutPrefix(section.name, profileSectionPrefix)
+		return ok && keep[name]
+	}
+	return mergeINIContent(path, generated, markerKey, managed, kept)
 }
 
-func mergeConfigContent(path, generated string, generatedNames []string, markerKey, sessionName string) (string, error) {
-	markerKey = strings.TrimSpace(markerKey)
-	if markerKey == "" {
-		return generated, nil
+// mergeCredentialsContent merges generated credentials content into the
+// credentials file at path with the same rules as mergeConfigContent, so
+// static keys and other hand-written profiles are preserved.
+func mergeCredentialsContent(path, generated, markerKey string, keep map[string]bool) (string, []string, error) {
+	managed := func(section *iniSection) bool {
+		return section.hasKey(markerKey)
+	}
+	kept := func(section *iniSection) bool {
+		return keep[section.name]
+	}
+	return mergeINIContent(path, generated, markerKey, managed, kept)
+}
+
+func mergeINIContent(path, generated, markerKey string, managed, keep func(*iniSection) bool) (string, []string, error) {
+	if strings.TrimSpace(markerKey) == "" {
+		return generated, nil, nil
 	}
 
 	existing, err := readConfigFile(path)
 	if err != nil {
-		return "", err
+		return "", nil, err
 	}
 
 	if strings.TrimSpace(existing) == "" {
-		return generated, nil
+		return generated, nil, nil
 	}
 
-	existingSections := parseConfigSections(existing)
-	generatedSections := parseConfigSections(generated)
+	file, kept := mergeINI(parseINI(existing), parseINI(generated), managed, keep)
+	warnings := make([]string, 0, len(kept))
+	for _, name := range kept {
+		warnings = append(warnings, fmt.Sprintf("[%s] in %s is hand-written and was kept instead of the generated section; add %s = true to let cfgctl manage it", name, path, markerKey))
+	}
+	return file.String(), warnings, nil
+}
 
-	generatedSet := make(map[string]bool, len(generatedNames))
-	for _, name := range generatedNames {
-		if strings.TrimSpace(name) == "" {
-			continue
-		}
-		generatedSet[name] = true
+// mergeINI applies the sections of generated to file, editing only the
+// sections for which managed returns true. Managed sections that are no longer
+// generated are removed unless keep, which may be nil, returns true. It also
+// returns the names of the generated sections that were not written because
+// an unmanaged section already has their name.
+func mergeINI(file, generated *iniFile, managed, keep func(*iniSection) bool) (*iniFile, []string) {
+	wanted := make(map[string]*iniSection, len(generated.sections))
+	for _, section := range generated.sections {
+		wanted[section.name] = section
 	}
 
-	keptExisting := make([]configSection, 0, len(existingSections))
-	for _, section := range existingSections {
-		if isGeneratedSession(section, sessionName) {
+	for _, section := range append([]*iniSection(nil), file.sections...) {
+		if !managed(section) {
 			continue
 		}
-		if section.Name == "" {
-			keptExisting = append(keptExisting, section)
+		if want, ok := wanted[section.name]; ok {
+			section.update(want.properties(), file.cr)
 			continue
 		}
-		if generatedSet[section.Name] {
+		if keep != nil && keep(section) {
 			continue
 		}
-		if section.HasKey(markerKey) {
-			continue
-		}
-		keptExisting = append(keptExisting, section)
+		file.remove(section)
 	}
 
-	keptExisting = append(keptExisting, generatedSections...)
-	return joinSections(keptExisting), nil
+	var kept []string
+	for _, section := range generated.sections {
+		existing := file.section(section.name)
+		switch {
+		case existing == nil:
+			file.appendSection(section)
+		case !managed(existing):
+			kept = append(kept, section.name)
+		}
+	}
+	return file, kept
 }
 
-func readConfigFile(path string) (string, error) {
-	if strings.TrimSpace(path) == "" {
-		return "", errors.New("config path is empty")
+// skippedAccountProfiles returns the managed profiles in the config file at
+// path that discovery could not regenerate because it skipped accounts: those
+// of a skipped account, those that name no account at all, and role chains
+// that source from one of them. Prune keeps them until their accounts can be
+// listed again, so a throttled account does not lose its profiles.
+func skippedAccountProfiles(path, markerKey string, skipped []string) (map[string]bool, error) {
+	if len(skipped) == 0 || strings.TrimSpace(markerKey) == "" {
+		return nil, nil
 	}
-
-	// #nosec G304 -- config path is user-configurable
-	data, err := os.ReadFile(path)
+	content, err := readConfigFile(path)
 	if err != nil {
-		if errors.Is(err, os.ErrNotExist) {
-			return "", nil
-		}
-		return "", fmt.Errorf("read config file: %w", err)
+		return nil, err
 	}
 
-	return string(data), nil
-}
-
-func parseConfigSections(content string) []configSection {
-	content = strings.ReplaceAll(content, "\r\n", "\n")
-	lines := strings.Split(content, "\n")
-	sections := []configSection{}
-	current := configSection{}
-	flush := func() {
-		if current.Raw == "" && current.Header == "" && len(current.Keys) == 0 {
-			return
-		}
-		sections = append(sections, current)
-		current = configSection{}
+	accounts := make(map[string]bool, len(skipped))
+	for _, id := range skipped {
+		accounts[id] = true
 	}
-
-	for _, line := range lines {
-		trimmed := strings.TrimSpace(line)
-		if strings.HasPrefix(trimmed, "[") && strings.HasSuffix(trimmed, "]") {
-			flush()
-			current.Header = strings.TrimSpace(trimmed[1 : len(trimmed)-1])
-			current.Name = normalizeProfileName(current.Header)
-			current.Raw = line + "\n"
+	kept := map[string]bool{}
+	sources := map[string]string{}
+	for _, section := range parseINI(content).sections {
+		name, ok := strings.CutPrefix(section.name, profileSectionPrefix)
+		if !ok || !section.hasKey(markerKey) {
 			continue
 		}
+		values := iniValues(section)
+		account := values["sso_account_id"]
+		if account == "" {
+			account = values[cfgctlKeyPrefix+"sso_account_id"]
+		}
+		switch {
+		case account != "":
+			kept[name] = accounts[account]
+		case values["source_profile"] != "":
+			sources[name] = values["source_profile"]
+		default:
+			kept[name] = true
+		}
+	}
 
-		if trimmed != "" && strings.Contains(trimmed, "=") && !strings.HasPrefix(trimmed, ";") && !strings.HasPrefix(trimmed, "#") {
-			parts := strings.SplitN(trimmed, "=", 2)
-			key := strings.TrimSpace(parts[0])
-			if key != "" {
-				current.Keys = append(current.Keys, key)
+	// Chains can source from other chains, so sources are followed until
+	// nothing changes.
+	for changed := true; changed; {
+		changed = false
+		for name, source := range sources {
+			if !kept[name] && kept[source] {
+				kept[name], changed = true, true
 			}
 		}
-		current.Raw += line + "\n"
 	}
-	flush()
-
-	return sections
-}
-
-func normalizeProfileName(header string) string {
-	name := strings.TrimSpace(header)
-	prefix := "profile "
-	if strings.HasPrefix(strings.ToLower(name), prefix) {
-		name = strings.TrimSpace(name[len(prefix):])
-		return name
+	for name, keep := range kept {
+		if !keep {
+			delete(kept, name)
+		}
 	}
-	return ""
+	return kept, nil
 }
 
-func (section configSection) HasKey(key string) bool {
-	key = strings.TrimSpace(key)
-	if key == "" {
-		return false
+// handWrittenProfiles returns the profiles in the config file at path that
+// carry no marker. Prune keeps them, so role chains may source from them.
+func handWrittenProfiles(path, markerKey string) (map[string]bool, error) {
+	if strings.TrimSpace(path) == "" || strings.TrimSpace(markerKey) == "" {
+		return nil, nil
 	}
-	for _, existing := range section.Keys {
-		if strings.EqualFold(existing, key) {
-			return true
+	content, err := readConfigFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	profiles := map[string]bool{}
+	for _, section := range parseINI(content).sections {
+		if section.hasKey(markerKey) {
+			continue
+		}
+		if name, ok := strings.CutPrefix(section.name, profileSectionPrefix); ok {
+			profiles[name] = true
 		}
 	}
-	return false
+	return profiles, nil
 }
 
-func joinSections(sections []configSection) string {
-	if len(sections) == 0 {
-		return ""
-	}
-	builder := strings.Builder{}
-	for _, section := range sections {
-		builder.WriteString(section.Raw)
+func readConfigFile(path string) (string, error) {
+	if strings.TrimSpace(path) == "" {
+		return "", errors.New("config path is empty")
 	}
-	content := strings.TrimRight(builder.String(), "\n")
-	if content == "" {
-		return ""
+
+	// #nosec G304 -- config path is user-configurable
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return "", nil
+		}
+		return "", fmt.Errorf("read config file: %w", err)
 	}
-	return content
+
+	return string(data), nil
 }
 
-func isGeneratedSession(section configSection, sessionName string) bool {
-	sessionName = strings.TrimSpace(sessionName)
-	if sessionName == "" {
-		return false
-	}
-	header := strings.TrimSpace(section.Header)
-	if header == "" {
-		return false
+func isGeneratedSession(name string, sessionNames []string) bool {
+	for _, sessionName := range sessionNames {
+		sessionName = strings.TrimSpace(sessionName)
+		if sessionName != "" && strings.EqualFold(name, ssoSessionSection+" "+sessionName) {
+			return true
+		}
 	}
-	return strings.EqualFold(header, fmt.Sprintf("%s %s", "sso-session", sessionName))
+	return false
 }
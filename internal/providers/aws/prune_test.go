@@ -3,6 +3,7 @@ package aws
 import (
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -42,26 +43,27 @@ sso_role_name = Admin
 sso_auto_populated = true
 `
 
-	merged, err := mergeConfigContent(configPath, generated, []string{"fresh-marked"}, "sso_auto_populated", "cfgctl")
+	merged, _, err := mergeConfigContent(configPath, generated, "sso_auto_populated", []string{"cfgctl"}, nil)
 	if err != nil {
 		t.Fatalf("merge config content: %v", err)
 	}
 
-	expected := `[profile keep-unmarked]
-sso_session = cfgctl
-sso_account_id = 111111111111
-sso_role_name = Admin
-
-[sso-session cfgctl]
+	expected := `[sso-session cfgctl]
 sso_start_url = https://example.awsapps.com/start
 sso_region = us-east-1
 sso_registration_scopes = sso:account:access
 
+[profile keep-unmarked]
+sso_session = cfgctl
+sso_account_id = 111111111111
+sso_role_name = Admin
+
 [profile fresh-marked]
 sso_session = cfgctl
 sso_account_id = 333333333333
 sso_role_name = Admin
-sso_auto_populated = true`
+sso_auto_populated = true
+`
 
 	if merged != expected {
 		t.Fatalf("merged content = %q", merged)
@@ -87,7 +89,7 @@ sso_region = us-east-1
 sso_registration_scopes = sso:account:access
 `
 
-	merged, err := mergeConfigContent(configPath, generated, []string{}, "", "cfgctl")
+	merged, _, err := mergeConfigContent(configPath, generated, "", []string{"cfgctl"}, nil)
 	if err != nil {
 		t.Fatalf("merge config content: %v", err)
 	}
@@ -96,3 +98,157 @@ sso_registration_scopes = sso:account:access
 		t.Fatalf("merged content = %q", merged)
 	}
 }
+
+func TestMergeConfigContentEditsInPlace(t *testing.T) {
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "config")
+	initial := `# Personal profiles first.
+[profile hand-tuned]
+region=eu-west-1
+output    = yaml
+s3 =
+  max_concurrent_requests = 20
+
+# Generated by cfgctl; comments here survive.
+[profile prod/admin]
+sso_session = cfgctl
+sso_account_id    = 111111111111
+sso_role_name = OldRole
+legacy_key = remove-me
+  nested = also-removed
+sso_auto_populated = true
+
+[sso-session cfgctl]
+sso_start_url = https://old.awsapps.com/start
+sso_region = us-east-1
+
+; trailing notes
+[profile last]
+region = us-west-2
+`
+	if err := os.WriteFile(configPath, []byte(initial), 0600); err != nil {
+		t.Fatalf("write existing config: %v", err)
+	}
+
+	generated := `# This file was generated by cfgctl. Do not edit manually.
+
+[sso-session cfgctl]
+sso_start_url = https://example.awsapps.com/start
+sso_region = us-east-1
+
+[profile hand-tuned]
+region = us-east-1
+sso_auto_populated = true
+
+[profile prod/admin]
+sso_session = cfgctl
+sso_account_id = 111111111111
+sso_role_name = Admin
+sso_auto_populated = true
+sso_account_name = prod`
+
+	merged, _, err := mergeConfigContent(configPath, generated, "sso_auto_populated", []string{"cfgctl"}, nil)
+	if err != nil {
+		t.Fatalf("merge config content: %v", err)
+	}
+
+	expected := `# Personal profiles first.
+[profile hand-tuned]
+region=eu-west-1
+output    = yaml
+s3 =
+  max_concurrent_requests = 20
+
+# Generated by cfgctl; comments here survive.
+[profile prod/admin]
+sso_session = cfgctl
+sso_account_id    = 111111111111
+sso_role_name = Admin
+sso_auto_populated = true
+sso_account_name = prod
+
+[sso-session cfgctl]
+sso_start_url = https://example.awsapps.com/start
+sso_region = us-east-1
+
+; trailing notes
+[profile last]
+region = us-west-2
+`
+	if merged != expected {
+		t.Fatalf("merged content =\n%s\nwant\n%s", merged, expected)
+	}
+}
+
+func TestMergeConfigContentWarnsOnHandWrittenClash(t *testing.T) {
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "config")
+	initial := `[default]
+region = eu-west-1
+output = json
+sso_auto_populated = true
+
+[profile prod/admin]
+region = us-west-2
+`
+	if err := os.WriteFile(configPath, []byte(initial), 0600); err != nil {
+		t.Fatalf("write existing config: %v", err)
+	}
+
+	generated := `[default]
+region = us-east-1
+output = json
+sso_auto_populated = true
+
+[profile prod/admin]
+sso_session = cfgctl
+sso_account_id = 111111111111
+sso_role_name = Admin
+sso_auto_populated = true
+`
+
+	merged, warnings, err := mergeConfigContent(configPath, generated, "sso_auto_populated", []string{"cfgctl"}, nil)
+	if err != nil {
+		t.Fatalf("merge config content: %v", err)
+	}
+
+	expected := `[default]
+region = us-east-1
+output = json
+sso_auto_populated = true
+
+[profile prod/admin]
+region = us-west-2
+`
+	if merged != expected {
+		t.Fatalf("merged content =\n%s\nwant\n%s", merged, expected)
+	}
+	if len(warnings) != 1 || !strings.Contains(warnings[0], "[profile prod/admin]") || !strings.Contains(warnings[0], "sso_auto_populated = true") {
+		t.Fatalf("warnings = %v, want one for the hand-written prod/admin", warnings)
+	}
+}
+
+func TestMergeCredentialsContentKeepsStaticKeys(t *testing.T) {
+	dir := t.TempDir()
+	credentialsPath := filepath.Join(dir, "credentials")
+	initial := "[static]\r\naws_access_key_id = AKIAEXAMPLE\r\naws_secret_access_key = secret\r\n\r\n" +
+		"[stale]\r\ncredential_process = granted credential-process --profile stale\r\nsso_auto_populated = true\r\n"
+	if err := os.WriteFile(credentialsPath, []byte(initial), 0600); err != nil {
+		t.Fatalf("write existing credentials: %v", err)
+	}
+
+	generated := `[prod/admin]
+credential_process = granted credential-process --profile prod/admin
+sso_auto_populated = true`
+
+	merged, _, err := mergeCredentialsContent(credentialsPath, generated, "sso_auto_populated", nil)
+	if err != nil {
+		t.Fatalf("merge credentials content: %v", err)
+	}
+
+	expected := "[static]\r\naws_access_key_id = AKIAEXAMPLE\r\naws_secret_access_key = secret\r\n\r\n" +
+		"[prod/admin]\r\ncredential_process = granted credential-process --profile prod/admin\r\nsso_auto_populated = true\r\n"
+	if merged != expected {
+		t.Fatalf("merged content = %q, want %q", merged, expected)
+	}
+}
@@ -0,0 +1,254 @@
+package aws
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"text/template"
+)
+
+const (
+	defaultRoleChainNameTemplate = "{{ .SourceProfile }}/{{ alias .AccountID }}/{{ .RoleName }}"
+	minRoleChainDuration         = 900
+	maxRoleChainDuration         = 43200
+)
+
+var (
+	errRoleChainSource           = errors.New("role chain needs exactly one of source_profile or credential_source")
+	errRoleChainCredentialSource = errors.New("credential_source must be Environment, Ec2InstanceMetadata, or EcsContainer")
+	errRoleChainDuration         = errors.New("duration_seconds must be between 900 and 43200")
+	errRoleChainDanglingSource   = errors.New("source_profile is not a discovered, chained, or hand-written profile")
+	errRoleChainCycle            = errors.New("role chains form a cycle")
+	errRoleChainCollision        = errors.New("role chain name is already a discovered profile")
+	errRoleChainRuleRole         = errors.New("role chain rule needs role_name and target_account_ids")
+	errRoleChainAccountID        = errors.New("target account id must be 12 digits")
+)
+
+var (
+	accountIDPattern   = regexp.MustCompile(`^[0-9]{12}$`)
+	credentialSources  = map[string]bool{"Environment": true, "Ec2InstanceMetadata": true, "EcsContainer": true}
+	roleSessionPattern = regexp.MustCompile(`^[\w+=,.@-]{2,64}$`)
+)
+
+// RoleChainRule generates a role chain from every discovered profile Match
+// selects into each of TargetAccountIDs, assuming RoleName there. An empty
+// Match selects every discovered profile.
+type RoleChainRule struct {
+	Match            FilterRule `yaml:"match"`
+	RoleName         string     `yaml:"role_name"`
+	TargetAccountIDs []string   `yaml:"target_account_ids"`
+
+	// NameTemplate names the generated profiles from SourceProfile,
+	// AccountID, and RoleName, with the profile template functions.
+	// SourceProfile is the source's name without its profile_prefix, which
+	// is prepended to the result as it is to every generated profile.
+	NameTemplate string `yaml:"name_template,omitempty"`
+
+	Region          string `yaml:"region,omitempty"`
+	MFASerial       string `yaml:"mfa_serial,omitempty"`
+	ExternalID      string `yaml:"external_id,omitempty"`
+	RoleSessionName string `yaml:"role_session_name,omitempty"`
+	DurationSeconds int    `yaml:"duration_seconds,omitempty"`
+}
+
+// validate checks a role chain's fields without regard to the profiles it
+// may source from.
+func (c RoleChain) validate() error {
+	hasSource := strings.TrimSpace(c.SourceProfile) != ""
+	credentialSource := strings.TrimSpace(c.CredentialSource)
+	if hasSource == (credentialSource != "") {
+		return errRoleChainSource
+	}
+	if credentialSource != "" && !credentialSources[credentialSource] {
+		return fmt.Errorf("%w: %q", errRoleChainCredentialSource, credentialSource)
+	}
+	return validateAssumeRoleOptions(c.DurationSeconds, c.RoleSessionName)
+}
+
+func validateAssumeRoleOptions(durationSeconds int, roleSessionName string) error {
+	if durationSeconds != 0 && (durationSeconds < minRoleChainDuration || durationSeconds > maxRoleChainDuration) {
+		return fmt.Errorf("%w: %d", errRoleChainDuration, durationSeconds)
+	}
+	if name := strings.TrimSpace(roleSessionName); name != "" && !roleSessionPattern.MatchString(name) {
+		return fmt.Errorf("role_session_name %q is not a valid session name", name)
+	}
+	return nil
+}
+
+// validateRoleChains checks role chains and rules before discovery; sources
+// and cycles are checked once discovered profiles are known.
+func (c *Config) validateRoleChains() error {
+	for i, chain := range c.RoleChains {
+		if err := chain.validate(); err != nil {
+			return fmt.Errorf("role_chains[%d]: %w", i, err)
+		}
+	}
+	_, err := compileRoleChainRules(c.RoleChainRules, profileTemplateFuncs(c.AccountAliases))
+	return err
+}
+
+type roleChainRule struct {
+	RoleChainRule
+	name  string
+	match compiledRule
+	tmpl  *template.Template
+}
+
+func compileRoleChainRules(rules []RoleChainRule, funcs template.FuncMap) ([]roleChainRule, error) {
+	compiled := make([]roleChainRule, 0, len(rules))
+	for i, rule := range rules {
+		name := fmt.Sprintf("role_chain_rules[%d]", i)
+		if strings.TrimSpace(rule.RoleName) == "" || len(rule.TargetAccountIDs) == 0 {
+			return nil, fmt.Errorf("%s: %w", name, errRoleChainRuleRole)
+		}
+		for _, accountID := range rule.TargetAccountIDs {
+			if !accountIDPattern.MatchString(strings.TrimSpace(accountID)) {
+				return nil, fmt.Errorf("%s: %w: %q", name, errRoleChainAccountID, accountID)
+			}
+		}
+		if err := validateAssumeRoleOptions(rule.DurationSeconds, rule.RoleSessionName); err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+
+		match, err := compileRule(name+" match", rule.Match)
+		if err != nil {
+			return nil, err
+		}
+
+		text := rule.NameTemplate
+		if strings.TrimSpace(text) == "" {
+			text = defaultRoleChainNameTemplate
+		}
+		tmpl, err := template.New("role_chain").Funcs(funcs).Option("missingkey=error").Parse(text)
+		if err != nil {
+			return nil, fmt.Errorf("%s: parse name_template: %w", name, err)
+		}
+
+		compiled = append(compiled, roleChainRule{RoleChainRule: rule, name: name, match: match, tmpl: tmpl})
+	}
+	return compiled, nil
+}
+
+// expandRoleChainRules returns the role chains generated from profiles, and a
+// warning for each rule that selected none of them.
+func expandRoleChainRules(cfg *Config, profiles map[string]generatedProfile) ([]roleChainProfile, []string, error) {
+	rules, err := compileRoleChainRules(cfg.RoleChainRules, profileTemplateFuncs(cfg.AccountAliases))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	sessions := cfg.sessionConfigs()
+	names := make([]string, 0, len(profiles))
+	for name := range profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var chains []roleChainProfile
+	var warnings []string
+	for _, rule := range rules {
+		matched := false
+		for _, sourceName := range names {
+			source := profiles[sourceName]
+			if source.VariantOf != "" {
+				continue
+			}
+			selector := DiscoveredProfile{AccountID: source.AccountID, AccountName: source.AccountName, RoleName: source.RoleName, OU: source.OU, Tags: source.Tags}
+			if _, ok := rule.match.match(selector); !ok {
+				continue
+			}
+			matched = true
+
+			session := findSession(sessions, source.SSOSession)
+			partition := arnPartition(session.SSO.Region)
+			for _, accountID := range rule.TargetAccountIDs {
+				accountID = strings.TrimSpace(accountID)
+				roleName := strings.TrimSpace(rule.RoleName)
+				name, err := executeRoleChainTemplate(rule, strings.TrimPrefix(sourceName, session.ProfilePrefix), accountID, roleName)
+				if err != nil {
+					return nil, nil, err
+				}
+				chains = append(chains, roleChainProfile{
+					DurationSeconds: rule.DurationSeconds,
+					ExternalID:      strings.TrimSpace(rule.ExternalID),
+					MFASerial:       strings.TrimSpace(rule.MFASerial),
+					Name:            session.ProfilePrefix + name,
+					Region:          strings.TrimSpace(rule.Region),
+					RoleARN:         fmt.Sprintf("arn:%s:iam::%s:role/%s", partition, accountID, roleName),
+					RoleSessionName: strings.TrimSpace(rule.RoleSessionName),
+					SourceProfile:   sourceName,
+				})
+			}
+		}
+		if !matched {
+			warnings = append(warnings, fmt.Sprintf("%s matched no discovered profiles", rule.name))
+		}
+	}
+	return chains, warnings, nil
+}
+
+func executeRoleChainTemplate(rule roleChainRule, sourceProfile, accountID, roleName string) (string, error) {
+	var builder strings.Builder
+	data := map[string]string{
+		"AccountID":     accountID,
+		"RoleName":      roleName,
+		"SourceProfile": sourceProfile,
+	}
+	if err := rule.tmpl.Execute(&builder, data); err != nil {
+		return "", fmt.Errorf("%s: execute name_template: %w", rule.name, err)
+	}
+
+	name := strings.ToLower(strings.TrimSpace(builder.String()))
+	if name == "" {
+		return "", fmt.Errorf("%s: generated role chain name is empty", rule.name)
+	}
+	return name, nil
+}
+
+// checkRoleChainSources fails when a chain sources from a profile that is
+// neither discovered, another chain, nor one of the hand-written profiles in
+// external, or when chains source from each other in a loop.
+func checkRoleChainSources(chains map[string]roleChainProfile, profiles map[string]generatedProfile, external map[string]bool) error {
+	names := make([]string, 0, len(chains))
+	for name, chain := range chains {
+		if _, ok := profiles[name]; ok {
+			return fmt.Errorf("%w: %q", errRoleChainCollision, name)
+		}
+		if chain.SourceProfile == "" {
+			continue
+		}
+		if _, ok := profiles[chain.SourceProfile]; ok || external[chain.SourceProfile] {
+			continue
+		}
+		if _, ok := chains[chain.SourceProfile]; !ok {
+			return fmt.Errorf("%w: %q sources %q", errRoleChainDanglingSource, name, chain.SourceProfile)
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	const (
+		visiting = iota + 1
+		done
+	)
+	state := make(map[string]int, len(chains))
+	for _, start := range names {
+		path := []string{}
+		for name := start; ; name = chains[name].SourceProfile {
+			if _, ok := chains[name]; !ok || state[name] == done {
+				break
+			}
+			if state[name] == visiting {
+				return fmt.Errorf("%w: %s -> %s", errRoleChainCycle, strings.Join(path, " -> "), name)
+			}
+			state[name] = visiting
+			path = append(path, name)
+		}
+		for _, name := range path {
+			state[name] = done
+		}
+	}
+	return nil
+}
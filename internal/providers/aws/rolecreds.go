@@ -0,0 +1,344 @@
+package aws
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sso"
+)
+
+// Credential process helpers written into generated profiles.
+const (
+	credentialProcessGranted = "granted"
+	credentialProcessCfgctl  = "cfgctl"
+)
+
+// credentialsExpiryMargin is how long before expiry cached role credentials
+// are fetched again, so callers never receive credentials about to lapse.
+const credentialsExpiryMargin = 5 * time.Minute
+
+// cfgctlKeyPrefix marks the sso keys recorded in credential_process profiles
+// that cfgctl serves, which the AWS SDKs ignore.
+const cfgctlKeyPrefix = "cfgctl_"
+
+// credentialsCacheDir holds cached role credentials inside the cfgctl cache.
+const credentialsCacheDir = "aws-credentials"
+
+// Credential output formats for FormatCredentials.
+const (
+	CredentialsFormatBash = "bash"
+	CredentialsFormatZsh  = "zsh"
+	CredentialsFormatFish = "fish"
+	CredentialsFormatJSON = "json"
+)
+
+var (
+	errProfileNotFound      = errors.New("profile not found in aws config")
+	errProfileNotSSO        = errors.New("profile has no sso account and role")
+	errCredentialsFormat    = errors.New("credentials format must be bash, zsh, fish, or json")
+	errCredentialProcessCmd = errors.New("credential process helper must be granted or cfgctl")
+)
+
+// RoleCredentials are short-lived credentials for an account role.
+type RoleCredentials struct {
+	AccessKeyID     string    `json:"AccessKeyId"`
+	SecretAccessKey string    `json:"SecretAccessKey"`
+	SessionToken    string    `json:"SessionToken"`
+	Expiration      time.Time `json:"Expiration"`
+}
+
+// Environ returns the credentials as AWS environment variables.
+func (c RoleCredentials) Environ() []string {
+	return []string{
+		"AWS_ACCESS_KEY_ID=" + c.AccessKeyID,
+		"AWS_SECRET_ACCESS_KEY=" + c.SecretAccessKey,
+		"AWS_SESSION_TOKEN=" + c.SessionToken,
+		"AWS_CREDENTIAL_EXPIRATION=" + c.Expiration.UTC().Format(time.RFC3339),
+	}
+}
+
+// FormatCredentials renders credentials as shell exports for bash, zsh, or
+// fish, or as the JSON document credential_process expects.
+func FormatCredentials(creds RoleCredentials, format string) (string, error) {
+	switch format {
+	case CredentialsFormatBash, CredentialsFormatZsh:
+		var builder strings.Builder
+		for _, entry := range creds.Environ() {
+			key, value, _ := strings.Cut(entry, "=")
+			fmt.Fprintf(&builder, "export %s=%s\n", key, shellQuote(value))
+		}
+		return builder.String(), nil
+	case CredentialsFormatFish:
+		var builder strings.Builder
+		for _, entry := range creds.Environ() {
+			key, value, _ := strings.Cut(entry, "=")
+			fmt.Fprintf(&builder, "set -gx %s %s;\n", key, shellQuote(value))
+		}
+		return builder.String(), nil
+	case CredentialsFormatJSON:
+		data, err := json.MarshalIndent(credentialProcessOutput{
+			Version:         1,
+			AccessKeyID:     creds.AccessKeyID,
+			SecretAccessKey: creds.SecretAccessKey,
+			SessionToken:    creds.SessionToken,
+			Expiration:      creds.Expiration.UTC().Format(time.RFC3339),
+		}, "", "  ")
+		if err != nil {
+			return "", fmt.Errorf("encode credentials: %w", err)
+		}
+		return string(data) + "\n", nil
+	default:
+		return "", fmt.Errorf("%w: %q", errCredentialsFormat, format)
+	}
+}
+
+// credentialProcessOutput is the credential_process JSON document.
+type credentialProcessOutput struct {
+	Version         int    `json:"Version"`
+	AccessKeyID     string `json:"AccessKeyId"`
+	SecretAccessKey string `json:"SecretAccessKey"`
+	SessionToken    string `json:"SessionToken"`
+	Expiration      string `json:"Expiration"`
+}
+
+// shellQuote single-quotes value for bash, zsh, and fish.
+func shellQuote(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", `'\''`) + "'"
+}
+
+// credentialProcessCommand returns the credential_process command for a
+// generated profile.
+func credentialProcessCommand(cfg *Config, profileName string) string {
+	if cfg.CredentialProcessHelper == credentialProcessCfgctl {
+		return "cfgctl aws credential-process --profile " + profileName
+	}
+	return "granted credential-process --profile " + profileName
+}
+
+// ProfileCredentials returns credentials for the SSO profile named profile in
+// the AWS config file, fetching them with GetRoleCredentials unless cacheDir
+// holds unexpired ones. A missing or expired SSO session is refreshed or
+// signed in to as generate would.
+func ProfileCredentials(ctx context.Context, cfg *Config, profile, cacheDir string) (RoleCredentials, error) {
+	return profileCredentials(ctx, cfg, profile, cacheDir, NewSSOClientFactory(cfg.EndpointURLs.SSO), time.Now().UTC())
+}
+
+func profileCredentials(ctx context.Context, cfg *Config, profile, cacheDir string, factory SSOClientFactory, now time.Time) (RoleCredentials, error) {
+	if cfg == nil {
+		return RoleCredentials{}, errors.New("aws config is nil")
+	}
+
+	target, err := resolveProfile(cfg, profile)
+	if err != nil {
+		return RoleCredentials{}, err
+	}
+	return targetCredentials(ctx, target, cacheDir, factory, now)
+}
+
+// targetCredentials returns credentials for a resolved profile.
+func targetCredentials(ctx context.Context, target profileTarget, cacheDir string, factory SSOClientFactory, now time.Time) (RoleCredentials, error) {
+	cachePath := credentialsCachePath(cacheDir, target)
+	if creds, ok := readCachedCredentials(cachePath, now); ok {
+		return creds, nil
+	}
+
+	token, err := ensureSSOToken(ctx, target.session, now)
+	if err != nil {
+		return RoleCredentials{}, err
+	}
+
+	client, err := factory(ctx, target.session.SSO.Region, token.AccessToken)
+	if err != nil {
+		return RoleCredentials{}, fmt.Errorf("create sso client: %w", err)
+	}
+
+	var output *sso.GetRoleCredentialsOutput
+	err = newThrottle().do(ctx, func() error {
+		var callErr error
+		output, callErr = client.GetRoleCredentials(ctx, &sso.GetRoleCredentialsInput{
+			AccessToken: aws.String(token.AccessToken),
+			AccountId:   aws.String(target.accountID),
+			RoleName:    aws.String(target.roleName),
+		})
+		return callErr
+	})
+	if err != nil {
+		return RoleCredentials{}, fmt.Errorf("get role credentials for %s: %w", target.name, err)
+	}
+	if output.RoleCredentials == nil {
+		return RoleCredentials{}, fmt.Errorf("get role credentials for %s: empty response", target.name)
+	}
+
+	creds := RoleCredentials{
+		AccessKeyID:     aws.ToString(output.RoleCredentials.AccessKeyId),
+		SecretAccessKey: aws.ToString(output.RoleCredentials.SecretAccessKey),
+		SessionToken:    aws.ToString(output.RoleCredentials.SessionToken),
+		Expiration:      time.UnixMilli(output.RoleCredentials.Expiration).UTC(),
+	}
+	if cachePath != "" {
+		if err := writeCachedCredentials(cachePath, creds); err != nil {
+			return RoleCredentials{}, err
+		}
+	}
+	return creds, nil
+}
+
+// ensureSSOToken returns a valid access token for the session, refreshing it
+// or signing in first when needed.
+func ensureSSOToken(ctx context.Context, cfg *Config, now time.Time) (SSOToken, error) {
+	token, err := LoadMatchingToken(cfg.TokenCachePaths, cfg.SSO.StartURL, cfg.SSO.Region, now)
+	if !errors.Is(err, errNoValidToken) {
+		return token, err
+	}
+
+	// A token that cannot be refreshed is replaced by signing in, so the
+	// refresh error itself is not interesting.
+	if err := refreshSSOToken(ctx, cfg, now); err != nil {
+		if err := runSSOLogin(ctx, cfg); err != nil {
+			return SSOToken{}, fmt.Errorf("auto sso login: %w", err)
+		}
+	}
+	return LoadMatchingToken(cfg.TokenCachePaths, cfg.SSO.StartURL, cfg.SSO.Region, now)
+}
+
+// profileTarget is the account role behind a named profile and the session
+// to sign in with.
+type profileTarget struct {
+	name      string
+	accountID string
+	roleName  string
+	region    string
+	session   *Config
+}
+
+// resolveProfile finds profile in the AWS config file. SSO profiles name
+// their account and role with sso_* keys; credential_process profiles that
+// cfgctl generates record them with cfgctl_sso_* keys instead. The session is
+// taken from the provider configuration when it is one of its sessions, and
+// from the file's [sso-session] block otherwise.
+func resolveProfile(cfg *Config, profile string) (profileTarget, error) {
+	content, err := readConfigFile(cfg.ConfigPath)
+	if err != nil {
+		return profileTarget{}, err
+	}
+	return resolveProfileIn(cfg, parseINI(content), profile)
+}
+
+// resolveProfileIn is resolveProfile for an already parsed AWS config file.
+func resolveProfileIn(cfg *Config, file *iniFile, profile string) (profileTarget, error) {
+	sectionName := profileSectionPrefix + profile
+	if profile == "default" {
+		sectionName = profile
+	}
+	section := file.section(sectionName)
+	if section == nil {
+		return profileTarget{}, fmt.Errorf("%w: %s", errProfileNotFound, profile)
+	}
+	values := iniValues(section)
+
+	lookup := func(key string) string {
+		if value := values[key]; value != "" {
+			return value
+		}
+		return values[cfgctlKeyPrefix+key]
+	}
+	target := profileTarget{
+		name:      profile,
+		accountID: lookup("sso_account_id"),
+		roleName:  lookup("sso_role_name"),
+		region:    values["region"],
+	}
+	if target.accountID == "" || target.roleName == "" {
+		return profileTarget{}, fmt.Errorf("%w: %s", errProfileNotSSO, profile)
+	}
+
+	sessionName := lookup("sso_session")
+	if sessionName != "" {
+		for _, session := range cfg.sessionConfigs() {
+			if session.SSO.SessionName == sessionName {
+				target.session = session
+				return target, nil
+			}
+		}
+	}
+
+	// Sessions the provider does not manage, and legacy profiles that set
+	// sso_start_url and sso_region themselves, are read from the file.
+	session := *cfg
+	session.SSOSessions = nil
+	ssoValues := values
+	if sessionName != "" {
+		block := file.section(ssoSessionSection + " " + sessionName)
+		if block == nil {
+			return profileTarget{}, fmt.Errorf("sso-session %q used by profile %s not found in aws config", sessionName, profile)
+		}
+		ssoValues = iniValues(block)
+		session.SSO.SessionName = sessionName
+	}
+	session.SSO.StartURL = strings.TrimRight(ssoValues["sso_start_url"], "/")
+	session.SSO.Region = ssoValues["sso_region"]
+	if session.SSO.StartURL == "" || session.SSO.Region == "" {
+		return profileTarget{}, fmt.Errorf("profile %s: %w", profile, errSSOStartURLEmpty)
+	}
+	target.session = &session
+	return target, nil
+}
+
+// iniValues returns the section's properties keyed by lowercased name.
+func iniValues(section *iniSection) map[string]string {
+	values := map[string]string{}
+	for _, property := range section.properties() {
+		values[strings.ToLower(property.key)] = property.value
+	}
+	return values
+}
+
+func credentialsCachePath(cacheDir string, target profileTarget) string {
+	if strings.TrimSpace(cacheDir) == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(strings.Join([]string{target.session.SSO.StartURL, target.accountID, target.roleName}, "\n")))
+	return filepath.Join(cacheDir, credentialsCacheDir, hex.EncodeToString(sum[:])+".json")
+}
+
+func readCachedCredentials(path string, now time.Time) (RoleCredentials, bool) {
+	if path == "" {
+		return RoleCredentials{}, false
+	}
+	// #nosec G304 -- path is derived from the configured cache directory
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return RoleCredentials{}, false
+	}
+	var creds RoleCredentials
+	if err := json.Unmarshal(data, &creds); err != nil {
+		return RoleCredentials{}, false
+	}
+	if creds.AccessKeyID == "" || !now.Add(credentialsExpiryMargin).Before(creds.Expiration) {
+		return RoleCredentials{}, false
+	}
+	return creds, true
+}
+
+func writeCachedCredentials(path string, creds RoleCredentials) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return fmt.Errorf("create credentials cache: %w", err)
+	}
+	data, err := json.Marshal(creds)
+	if err != nil {
+		return fmt.Errorf("encode credentials: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		return fmt.Errorf("write credentials cache: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,218 @@
+package aws
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sso/types"
+)
+
+const roleCredsTestConfig = `[sso-session cfgctl]
+sso_start_url = https://example.awsapps.com/start
+sso_region = us-east-1
+
+[sso-session other]
+sso_start_url = https://other.awsapps.com/start
+sso_region = eu-west-1
+
+[profile prod/admin]
+sso_session = cfgctl
+sso_account_id = 111111111111
+sso_role_name = Admin
+
+[profile served]
+credential_process = cfgctl aws credential-process --profile served
+cfgctl_sso_session = cfgctl
+cfgctl_sso_account_id = 222222222222
+cfgctl_sso_role_name = ReadOnly
+
+[profile other/readonly]
+sso_session = other
+sso_account_id = 333333333333
+sso_role_name = ReadOnly
+
+[profile legacy]
+sso_start_url = https://legacy.awsapps.com/start/
+sso_region = us-west-2
+sso_account_id = 444444444444
+sso_role_name = Admin
+
+[profile static]
+region = us-east-1
+`
+
+func roleCredsTestConfigFor(t *testing.T) *Config {
+	t.Helper()
+
+	dir := t.TempDir()
+	cfg := DefaultConfig()
+	cfg.ConfigPath = filepath.Join(dir, "config")
+	cfg.SSO.Region = testRegion
+	cfg.SSO.StartURL = testStartURL
+	cfg.TokenCachePaths = []string{filepath.Join(dir, "sso", "cache")}
+	if err := os.WriteFile(cfg.ConfigPath, []byte(roleCredsTestConfig), 0o600); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	return cfg
+}
+
+func TestResolveProfile(t *testing.T) {
+	cfg := roleCredsTestConfigFor(t)
+
+	tests := []struct {
+		profile   string
+		accountID string
+		roleName  string
+		startURL  string
+		region    string
+	}{
+		{profile: "prod/admin", accountID: "111111111111", roleName: "Admin", startURL: testStartURL, region: testRegion},
+		{profile: "served", accountID: "222222222222", roleName: "ReadOnly", startURL: testStartURL, region: testRegion},
+		{profile: "other/readonly", accountID: "333333333333", roleName: "ReadOnly", startURL: "https://other.awsapps.com/start", region: "eu-west-1"},
+		{profile: "legacy", accountID: "444444444444", roleName: "Admin", startURL: "https://legacy.awsapps.com/start", region: "us-west-2"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.profile, func(t *testing.T) {
+			target, err := resolveProfile(cfg, tt.profile)
+			if err != nil {
+				t.Fatalf("resolveProfile failed: %v", err)
+			}
+			if target.accountID != tt.accountID || target.roleName != tt.roleName {
+				t.Fatalf("target = %s/%s, want %s/%s", target.accountID, target.roleName, tt.accountID, tt.roleName)
+			}
+			if target.session.SSO.StartURL != tt.startURL || target.session.SSO.Region != tt.region {
+				t.Fatalf("session = %s %s, want %s %s", target.session.SSO.StartURL, target.session.SSO.Region, tt.startURL, tt.region)
+			}
+		})
+	}
+}
+
+func TestResolveProfileErrors(t *testing.T) {
+	cfg := roleCredsTestConfigFor(t)
+
+	if _, err := resolveProfile(cfg, "missing"); !errors.Is(err, errProfileNotFound) {
+		t.Fatalf("expected errProfileNotFound, got %v", err)
+	}
+	if _, err := resolveProfile(cfg, "static"); !errors.Is(err, errProfileNotSSO) {
+		t.Fatalf("expected errProfileNotSSO, got %v", err)
+	}
+}
+
+func TestProfileCredentialsCachesUntilExpiry(t *testing.T) {
+	cfg := roleCredsTestConfigFor(t)
+	cacheDir := t.TempDir()
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	token := SSOToken{AccessToken: "token", ExpiresAt: now.Add(time.Hour), Region: testRegion, StartURL: testStartURL}
+	if err := writeToken(filepath.Join(cfg.TokenCachePaths[0], "token.json"), token); err != nil {
+		t.Fatalf("writeToken failed: %v", err)
+	}
+
+	client := &mockSSOClient{credentials: &types.RoleCredentials{
+		AccessKeyId:     aws.String("AKIA"),
+		SecretAccessKey: aws.String("secret"),
+		SessionToken:    aws.String("session"),
+		Expiration:      now.Add(time.Hour).UnixMilli(),
+	}}
+	factory := func(_ context.Context, _, _ string) (SSOClient, error) {
+		return client, nil
+	}
+
+	creds, err := profileCredentials(context.Background(), cfg, "prod/admin", cacheDir, factory, now)
+	if err != nil {
+		t.Fatalf("profileCredentials failed: %v", err)
+	}
+	if creds.AccessKeyID != "AKIA" || !creds.Expiration.Equal(now.Add(time.Hour)) {
+		t.Fatalf("unexpected credentials: %+v", creds)
+	}
+	call := client.credentialCalls[0]
+	if aws.ToString(call.AccountId) != "111111111111" || aws.ToString(call.RoleName) != "Admin" || aws.ToString(call.AccessToken) != "token" {
+		t.Fatalf("unexpected GetRoleCredentials input: %+v", call)
+	}
+
+	if _, err := profileCredentials(context.Background(), cfg, "prod/admin", cacheDir, factory, now.Add(30*time.Minute)); err != nil {
+		t.Fatalf("profileCredentials failed: %v", err)
+	}
+	if len(client.credentialCalls) != 1 {
+		t.Fatalf("expected cached credentials, got %d calls", len(client.credentialCalls))
+	}
+
+	// Within the expiry margin the credentials are fetched again.
+	token.ExpiresAt = now.Add(2 * time.Hour)
+	if err := writeToken(filepath.Join(cfg.TokenCachePaths[0], "token.json"), token); err != nil {
+		t.Fatalf("writeToken failed: %v", err)
+	}
+	if _, err := profileCredentials(context.Background(), cfg, "prod/admin", cacheDir, factory, now.Add(58*time.Minute)); err != nil {
+		t.Fatalf("profileCredentials failed: %v", err)
+	}
+	if len(client.credentialCalls) != 2 {
+		t.Fatalf("expected credentials to be refetched, got %d calls", len(client.credentialCalls))
+	}
+}
+
+func TestFormatCredentials(t *testing.T) {
+	creds := RoleCredentials{
+		AccessKeyID:     "AKIA",
+		SecretAccessKey: "se'cret",
+		SessionToken:    "session",
+		Expiration:      time.Date(2026, 1, 1, 13, 0, 0, 0, time.UTC),
+	}
+
+	bash, err := FormatCredentials(creds, CredentialsFormatBash)
+	if err != nil {
+		t.Fatalf("FormatCredentials failed: %v", err)
+	}
+	if !strings.Contains(bash, `export AWS_SECRET_ACCESS_KEY='se'\''cret'`) {
+		t.Fatalf("bash output = %q", bash)
+	}
+
+	fish, err := FormatCredentials(creds, CredentialsFormatFish)
+	if err != nil {
+		t.Fatalf("FormatCredentials failed: %v", err)
+	}
+	if !strings.Contains(fish, "set -gx AWS_ACCESS_KEY_ID 'AKIA';") {
+		t.Fatalf("fish output = %q", fish)
+	}
+
+	jsonOutput, err := FormatCredentials(creds, CredentialsFormatJSON)
+	if err != nil {
+		t.Fatalf("FormatCredentials failed: %v", err)
+	}
+	if !strings.Contains(jsonOutput, `"Version": 1`) || !strings.Contains(jsonOutput, `"Expiration": "2026-01-01T13:00:00Z"`) {
+		t.Fatalf("json output = %q", jsonOutput)
+	}
+
+	if _, err := FormatCredentials(creds, "powershell"); !errors.Is(err, errCredentialsFormat) {
+		t.Fatalf("expected errCredentialsFormat, got %v", err)
+	}
+}
+
+func TestBuildConfigContentCfgctlCredentialProcess(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.SSO.Region = testRegion
+	cfg.SSO.StartURL = testStartURL
+	cfg.UseCredentialProcess = true
+	cfg.CredentialProcessHelper = credentialProcessCfgctl
+
+	content, _, err := BuildConfigContent(cfg, []DiscoveredProfile{{AccountID: "111111111111", AccountName: "prod", RoleName: "Admin"}})
+	if err != nil {
+		t.Fatalf("BuildConfigContent failed: %v", err)
+	}
+
+	expected := `[profile prod/admin]
+credential_process = cfgctl aws credential-process --profile prod/admin
+cfgctl_sso_session = cfgctl
+cfgctl_sso_account_id = 111111111111
+cfgctl_sso_role_name = Admin
+sso_auto_populated = true`
+	if !strings.HasSuffix(content, expected) {
+		t.Fatalf("config content = %q", content)
+	}
+}
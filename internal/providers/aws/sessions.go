@@ -0,0 +1,250 @@
+package aws
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ErrSessionsExpiring is returned by callers that fail when listed sessions
+// expire soon.
+var ErrSessionsExpiring = errors.New("aws sessions expire soon")
+
+// awsVaultCommand is the aws-vault binary ListSessions asks for its sessions.
+// Overridden in tests.
+var awsVaultCommand = "aws-vault"
+
+// Sessions lists the SSO tokens, cached role credentials, and aws-vault
+// sessions found on this machine.
+type Sessions struct {
+	Tokens      []TokenSession
+	Credentials []CredentialsSession
+	Vault       []VaultSession
+}
+
+// TokenSession is an SSO token in one of the token cache paths.
+type TokenSession struct {
+	Path        string
+	StartURL    string
+	Region      string
+	ExpiresAt   time.Time
+	Refreshable bool
+	// Configured is set when the token belongs to one of the provider's SSO
+	// sessions.
+	Configured bool
+}
+
+// CredentialsSession is a set of role credentials cfgctl cached for a
+// profile in the AWS config file.
+type CredentialsSession struct {
+	Profile    string
+	AccountID  string
+	RoleName   string
+	Expiration time.Time
+}
+
+// VaultSession is a session aws-vault holds in its keyring, as reported by
+// aws-vault list.
+type VaultSession struct {
+	Profile   string
+	Type      string
+	ExpiresAt time.Time
+}
+
+// ListSessions collects the SSO tokens in cfg.TokenCachePaths, the role
+// credentials cached under cacheDir for profiles in cfg.ConfigPath, and the
+// aws-vault sessions when aws-vault is installed. Expired entries are
+// included. Failing to ask aws-vault is reported as a warning.
+func ListSessions(ctx context.Context, cfg *Config, cacheDir string, now time.Time) (Sessions, []string, error) {
+	var sessions Sessions
+
+	tokens, err := loadTokens(cfg.TokenCachePaths)
+	if err != nil {
+		return Sessions{}, nil, err
+	}
+	configs := cfg.sessionConfigs()
+	for _, token := range tokens {
+		configured := false
+		for _, session := range configs {
+			if token.MatchesSession(session.SSO.StartURL, session.SSO.Region) {
+				configured = true
+				break
+			}
+		}
+		sessions.Tokens = append(sessions.Tokens, TokenSession{
+			Path:        token.path,
+			StartURL:    token.StartURL,
+			Region:      token.Region,
+			ExpiresAt:   token.ExpiresAt,
+			Refreshable: token.CanRefresh(now),
+			Configured:  configured,
+		})
+	}
+	sort.Slice(sessions.Tokens, func(i, j int) bool {
+		return sessions.Tokens[i].Path < sessions.Tokens[j].Path
+	})
+
+	sessions.Credentials, err = listCachedCredentials(cfg, cacheDir)
+	if err != nil {
+		return Sessions{}, nil, err
+	}
+
+	var warnings []string
+	sessions.Vault, err = listVaultSessions(ctx, now)
+	if err != nil {
+		warnings = append(warnings, fmt.Sprintf("failed to list aws-vault sessions: %v", err))
+	}
+
+	return sessions, warnings, nil
+}
+
+// ExpiringWithin returns how many listed sessions expire within window of
+// now. Tokens count, even when already expired, only if they belong to a
+// configured SSO session and cannot be refreshed. Cached role credentials
+// that already expired are not counted, since they are fetched again on use.
+func (s Sessions) ExpiringWithin(window time.Duration, now time.Time) int {
+	deadline := now.Add(window)
+	count := 0
+	for _, token := range s.Tokens {
+		if token.Configured && !token.Refreshable && !token.ExpiresAt.After(deadline) {
+			count++
+		}
+	}
+	for _, creds := range s.Credentials {
+		if creds.Expiration.After(now) && !creds.Expiration.After(deadline) {
+			count++
+		}
+	}
+	for _, session := range s.Vault {
+		if !session.ExpiresAt.After(deadline) {
+			count++
+		}
+	}
+	return count
+}
+
+// listCachedCredentials returns the cached role credentials of every SSO
+// profile in the AWS config file, including expired ones.
+func listCachedCredentials(cfg *Config, cacheDir string) ([]CredentialsSession, error) {
+	if strings.TrimSpace(cacheDir) == "" {
+		return nil, nil
+	}
+	content, err := readConfigFile(cfg.ConfigPath)
+	if err != nil {
+		return nil, err
+	}
+
+	file := parseINI(content)
+	var sessions []CredentialsSession
+	for _, section := range file.sections {
+		profile, ok := strings.CutPrefix(section.name, profileSectionPrefix)
+		if !ok && section.name != "default" {
+			continue
+		}
+		if !ok {
+			profile = section.name
+		}
+		target, err := resolveProfileIn(cfg, file, profile)
+		if err != nil {
+			continue
+		}
+
+		// #nosec G304 -- path is derived from the configured cache directory
+		data, err := os.ReadFile(credentialsCachePath(cacheDir, target))
+		if err != nil {
+			continue
+		}
+		var creds RoleCredentials
+		if err := json.Unmarshal(data, &creds); err != nil || creds.AccessKeyID == "" {
+			continue
+		}
+		sessions = append(sessions, CredentialsSession{
+			Profile:    profile,
+			AccountID:  target.accountID,
+			RoleName:   target.roleName,
+			Expiration: creds.Expiration,
+		})
+	}
+	return sessions, nil
+}
+
+// listVaultSessions runs aws-vault list and parses the sessions column of its
+// table. It returns nothing when aws-vault is not installed.
+func listVaultSessions(ctx context.Context, now time.Time) ([]VaultSession, error) {
+	if _, err := exec.LookPath(awsVaultCommand); err != nil {
+		return nil, nil
+	}
+
+	// #nosec G204 -- the command is a fixed binary name.
+	output, err := exec.CommandContext(ctx, awsVaultCommand, "list").Output()
+	if err != nil {
+		return nil, fmt.Errorf("aws-vault list: %w", err)
+	}
+	return parseVaultSessions(string(output), now), nil
+}
+
+// parseVaultSessions reads the rows below the "=======" rule of aws-vault
+// list, whose last column holds comma-separated "<type>:<remaining>" labels
+// or "-". Labels whose remaining time does not parse are skipped.
+func parseVaultSessions(output string, now time.Time) []VaultSession {
+	var sessions []VaultSession
+	inTable := false
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "=") {
+			inTable = true
+			continue
+		}
+		fields := strings.Fields(line)
+		if !inTable || len(fields) < 3 {
+			continue
+		}
+		for _, label := range strings.Split(strings.Join(fields[2:], " "), ",") {
+			sessionType, remaining, ok := strings.Cut(strings.TrimSpace(label), ":")
+			if !ok {
+				continue
+			}
+			duration, err := time.ParseDuration(remaining)
+			if err != nil {
+				continue
+			}
+			sessions = append(sessions, VaultSession{
+				Profile:   fields[0],
+				Type:      sessionType,
+				ExpiresAt: now.Add(duration),
+			})
+		}
+	}
+	return sessions
+}
+
+// FormatCountdown renders the time left before an expiry in days, hours, and
+// minutes, such as "1d2h", "3h05m", or "12m". Durations that are not positive
+// render as "expired".
+func FormatCountdown(remaining time.Duration) string {
+	if remaining <= 0 {
+		return "expired"
+	}
+	remaining = remaining.Truncate(time.Minute)
+	days := int(remaining / (24 * time.Hour))
+	hours := int(remaining % (24 * time.Hour) / time.Hour)
+	minutes := int(remaining % time.Hour / time.Minute)
+	switch {
+	case days > 0:
+		return fmt.Sprintf("%dd%dh", days, hours)
+	case hours > 0:
+		return fmt.Sprintf("%dh%02dm", hours, minutes)
+	case minutes > 0:
+		return fmt.Sprintf("%dm", minutes)
+	default:
+		return "<1m"
+	}
+}
@@ -0,0 +1,161 @@
+package aws
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestListSessions(t *testing.T) {
+	orig := awsVaultCommand
+	awsVaultCommand = "nonexistent-aws-vault-binary"
+	t.Cleanup(func() { awsVaultCommand = orig })
+
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	cfg := roleCredsTestConfigFor(t)
+	cacheDir := t.TempDir()
+
+	tokenPath := filepath.Join(cfg.TokenCachePaths[0], "token.json")
+	token := SSOToken{
+		AccessToken:  "token",
+		ExpiresAt:    now.Add(30 * time.Minute),
+		Region:       testRegion,
+		StartURL:     testStartURL,
+		ClientID:     "client-id",
+		ClientSecret: "client-secret",
+		RefreshToken: "refresh",
+	}
+	if err := writeToken(tokenPath, token); err != nil {
+		t.Fatalf("writeToken failed: %v", err)
+	}
+
+	target, err := resolveProfile(cfg, "prod/admin")
+	if err != nil {
+		t.Fatalf("resolveProfile failed: %v", err)
+	}
+	creds := RoleCredentials{AccessKeyID: "AKIA", SecretAccessKey: "secret", Expiration: now.Add(-time.Minute)}
+	if err := writeCachedCredentials(credentialsCachePath(cacheDir, target), creds); err != nil {
+		t.Fatalf("writeCachedCredentials failed: %v", err)
+	}
+
+	sessions, warnings, err := ListSessions(context.Background(), cfg, cacheDir, now)
+	if err != nil {
+		t.Fatalf("ListSessions failed: %v", err)
+	}
+	if len(warnings) != 0 {
+		t.Fatalf("warnings = %v", warnings)
+	}
+	if len(sessions.Tokens) != 1 || sessions.Tokens[0].Path != tokenPath || !sessions.Tokens[0].Refreshable {
+		t.Fatalf("tokens = %+v", sessions.Tokens)
+	}
+	if len(sessions.Credentials) != 1 || sessions.Credentials[0].Profile != "prod/admin" || sessions.Credentials[0].AccountID != "111111111111" {
+		t.Fatalf("credentials = %+v", sessions.Credentials)
+	}
+	if len(sessions.Vault) != 0 {
+		t.Fatalf("vault sessions = %+v", sessions.Vault)
+	}
+
+	// Neither the refreshable token nor the expired credentials are counted.
+	if got := sessions.ExpiringWithin(time.Hour, now); got != 0 {
+		t.Fatalf("ExpiringWithin() = %d, want 0", got)
+	}
+
+	token.RefreshToken = ""
+	if err := writeToken(tokenPath, token); err != nil {
+		t.Fatalf("writeToken failed: %v", err)
+	}
+	// A token for a start URL the provider does not manage is listed but
+	// not counted.
+	otherPath := filepath.Join(cfg.TokenCachePaths[0], "other.json")
+	other := SSOToken{AccessToken: "other", ExpiresAt: now.Add(-time.Hour), Region: testRegion, StartURL: "https://unrelated.awsapps.com/start"}
+	if err := writeToken(otherPath, other); err != nil {
+		t.Fatalf("writeToken failed: %v", err)
+	}
+	creds.Expiration = now.Add(20 * time.Minute)
+	if err := writeCachedCredentials(credentialsCachePath(cacheDir, target), creds); err != nil {
+		t.Fatalf("writeCachedCredentials failed: %v", err)
+	}
+	sessions, _, err = ListSessions(context.Background(), cfg, cacheDir, now)
+	if err != nil {
+		t.Fatalf("ListSessions failed: %v", err)
+	}
+	if len(sessions.Tokens) != 2 || sessions.Tokens[0].Path != otherPath || sessions.Tokens[0].Configured || !sessions.Tokens[1].Configured {
+		t.Fatalf("tokens = %+v", sessions.Tokens)
+	}
+	if got := sessions.ExpiringWithin(time.Hour, now); got != 2 {
+		t.Fatalf("ExpiringWithin() = %d, want 2", got)
+	}
+	if got := sessions.ExpiringWithin(25*time.Minute, now); got != 1 {
+		t.Fatalf("ExpiringWithin(25m) = %d, want 1", got)
+	}
+	if got := sessions.ExpiringWithin(10*time.Minute, now); got != 0 {
+		t.Fatalf("ExpiringWithin(10m) = %d, want 0", got)
+	}
+}
+
+func TestListSessionsAWSVault(t *testing.T) {
+	script := filepath.Join(t.TempDir(), "aws-vault")
+	output := `Profile                  Credentials              Sessions
+=======                  ===========              ========
+default                  -                        -
+prod                     prod                     sts.GetSessionToken:59m23s, sts.AssumeRole:10m0s
+`
+	if err := os.WriteFile(script, []byte("#!/bin/sh\ncat <<'EOF'\n"+output+"EOF\n"), 0o755); err != nil {
+		t.Fatalf("write mock script: %v", err)
+	}
+	orig := awsVaultCommand
+	awsVaultCommand = script
+	t.Cleanup(func() { awsVaultCommand = orig })
+
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	cfg := roleCredsTestConfigFor(t)
+
+	sessions, _, err := ListSessions(context.Background(), cfg, "", now)
+	if err != nil {
+		t.Fatalf("ListSessions failed: %v", err)
+	}
+	want := []VaultSession{
+		{Profile: "prod", Type: "sts.GetSessionToken", ExpiresAt: now.Add(59*time.Minute + 23*time.Second)},
+		{Profile: "prod", Type: "sts.AssumeRole", ExpiresAt: now.Add(10 * time.Minute)},
+	}
+	if len(sessions.Vault) != len(want) {
+		t.Fatalf("vault sessions = %+v, want %+v", sessions.Vault, want)
+	}
+	for i := range want {
+		if sessions.Vault[i] != want[i] {
+			t.Fatalf("vault session %d = %+v, want %+v", i, sessions.Vault[i], want[i])
+		}
+	}
+
+	if err := os.WriteFile(script, []byte("#!/bin/sh\nexit 1\n"), 0o755); err != nil {
+		t.Fatalf("write mock script: %v", err)
+	}
+	_, warnings, err := ListSessions(context.Background(), cfg, "", now)
+	if err != nil {
+		t.Fatalf("ListSessions failed: %v", err)
+	}
+	if len(warnings) != 1 || !strings.Contains(warnings[0], "aws-vault list") {
+		t.Fatalf("warnings = %v", warnings)
+	}
+}
+
+func TestFormatCountdown(t *testing.T) {
+	tests := []struct {
+		remaining time.Duration
+		want      string
+	}{
+		{remaining: -time.Minute, want: "expired"},
+		{remaining: 30 * time.Second, want: "<1m"},
+		{remaining: 12*time.Minute + 30*time.Second, want: "12m"},
+		{remaining: 3*time.Hour + 5*time.Minute, want: "3h05m"},
+		{remaining: 26 * time.Hour, want: "1d2h"},
+	}
+	for _, tt := range tests {
+		if got := FormatCountdown(tt.remaining); got != tt.want {
+			t.Errorf("FormatCountdown(%s) = %q, want %q", tt.remaining, got, tt.want)
+		}
+	}
+}
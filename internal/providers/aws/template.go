@@ -0,0 +1,83 @@
+package aws
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"text/template"
+
+	"gopkg.in/yaml.v3"
+)
+
+var kebabSeparators = regexp.MustCompile(`[^a-z0-9]+`)
+
+// profileTemplateFuncs returns the functions available to profile templates.
+// String functions take the value last so they can be used in pipelines, as
+// in {{ .AccountName | replace "_" "-" }}.
+func profileTemplateFuncs(aliases map[string]string) template.FuncMap {
+	return template.FuncMap{
+		"alias": func(name string) string {
+			return lookupAlias(aliases, name)
+		},
+		"kebab":        kebab,
+		"lower":        strings.ToLower,
+		"regexReplace": regexReplace,
+		"replace": func(old, replacement, s string) string {
+			return strings.ReplaceAll(s, old, replacement)
+		},
+		"trimPrefix": func(prefix, s string) string {
+			return strings.TrimPrefix(s, prefix)
+		},
+		"trimSuffix": func(suffix, s string) string {
+			return strings.TrimSuffix(s, suffix)
+		},
+		"upper": strings.ToUpper,
+	}
+}
+
+// kebab lowercases s and joins its words with hyphens.
+func kebab(s string) string {
+	return strings.Trim(kebabSeparators.ReplaceAllString(strings.ToLower(s), "-"), "-")
+}
+
+func regexReplace(pattern, replacement, s string) (string, error) {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return "", fmt.Errorf("regexReplace: %w", err)
+	}
+	return re.ReplaceAllString(s, replacement), nil
+}
+
+// lookupAlias returns the alias for an account name or ID, or name itself
+// when it has none.
+func lookupAlias(aliases map[string]string, name string) string {
+	if alias, ok := aliases[name]; ok {
+		return alias
+	}
+	return name
+}
+
+// accountAlias returns the name profile templates see for an account: its
+// alias by ID, then by name, falling back to the discovered name.
+func accountAlias(aliases map[string]string, profile DiscoveredProfile) string {
+	if alias, ok := aliases[profile.AccountID]; ok {
+		return alias
+	}
+	return lookupAlias(aliases, profile.AccountName)
+}
+
+// loadAccountAliases reads a YAML map of account names or IDs to aliases.
+func loadAccountAliases(path string) (map[string]string, error) {
+	// #nosec G304 -- alias file path is user-configurable
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read account aliases: %w", err)
+	}
+
+	aliases := map[string]string{}
+	if err := yaml.Unmarshal(data, &aliases); err != nil {
+		return nil, fmt.Errorf("parse account aliases %s: %w", path, err)
+	}
+	return aliases, nil
+}
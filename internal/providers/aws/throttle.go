@@ -0,0 +1,116 @@
+package aws
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"sync"
+	"time"
+
+	"github.com/aws/smithy-go"
+)
+
+const (
+	throttleBaseDelay   = 250 * time.Millisecond
+	throttleMaxDelay    = 20 * time.Second
+	throttleMaxAttempts = 8
+)
+
+// throttle spaces out SSO API calls once the service starts throttling. It is
+// shared by every worker of a discovery run: a throttled response makes all of
+// them wait, the delay doubles with each consecutive throttled response up to
+// throttleMaxDelay, and a successful call resets it.
+type throttle struct {
+	mu    sync.Mutex
+	delay time.Duration
+	until time.Time
+
+	now    func() time.Time
+	wait   func(ctx context.Context, d time.Duration) error
+	jitter func(d time.Duration) time.Duration
+}
+
+func newThrottle() *throttle {
+	return &throttle{now: time.Now, wait: waitContext, jitter: equalJitter}
+}
+
+// do runs call, retrying it while the service reports throttling, up to
+// throttleMaxAttempts attempts in total.
+func (t *throttle) do(ctx context.Context, call func() error) error {
+	for attempt := 1; ; attempt++ {
+		if err := t.pause(ctx); err != nil {
+			return err
+		}
+
+		err := call()
+		if !isThrottlingError(err) {
+			if err == nil {
+				t.succeeded()
+			}
+			return err
+		}
+		if attempt >= throttleMaxAttempts {
+			return err
+		}
+		t.throttled()
+	}
+}
+
+// pause waits until the current backoff has passed.
+func (t *throttle) pause(ctx context.Context) error {
+	t.mu.Lock()
+	remaining := t.until.Sub(t.now())
+	t.mu.Unlock()
+
+	if remaining <= 0 {
+		return nil
+	}
+	return t.wait(ctx, remaining)
+}
+
+func (t *throttle) throttled() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	switch {
+	case t.delay == 0:
+		t.delay = throttleBaseDelay
+	case t.delay < throttleMaxDelay:
+		t.delay = min(2*t.delay, throttleMaxDelay)
+	}
+	if until := t.now().Add(t.jitter(t.delay)); until.After(t.until) {
+		t.until = until
+	}
+}
+
+func (t *throttle) succeeded() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.delay = 0
+}
+
+// equalJitter returns a random duration between d/2 and d, so that workers
+// throttled together do not all retry at the same moment.
+func equalJitter(d time.Duration) time.Duration {
+	half := d / 2
+	if half <= 0 {
+		return d
+	}
+	// #nosec G404 -- jitter does not need a cryptographic source
+	return half + rand.N(half)
+}
+
+// isThrottlingError reports whether err is a throttling response from an AWS
+// API, such as the TooManyRequestsException returned by IAM Identity Center.
+func isThrottlingError(err error) bool {
+	var apiErr smithy.APIError
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+	switch apiErr.ErrorCode() {
+	case "TooManyRequestsException", "ThrottlingException", "Throttling":
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,100 @@
+package aws
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sso/types"
+)
+
+func newTestThrottle() (*throttle, *[]time.Duration) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	waits := []time.Duration{}
+	limiter := newThrottle()
+	limiter.now = func() time.Time { return now }
+	limiter.wait = func(_ context.Context, d time.Duration) error {
+		waits = append(waits, d)
+		now = now.Add(d)
+		return nil
+	}
+	limiter.jitter = func(d time.Duration) time.Duration { return d }
+	return limiter, &waits
+}
+
+func TestThrottleRetriesThrottledCalls(t *testing.T) {
+	limiter, waits := newTestThrottle()
+
+	calls := 0
+	err := limiter.do(context.Background(), func() error {
+		calls++
+		if calls <= 3 {
+			return &types.TooManyRequestsException{Message: aws.String("slow down")}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("do failed: %v", err)
+	}
+	if calls != 4 {
+		t.Fatalf("calls = %d, want 4", calls)
+	}
+	want := []time.Duration{throttleBaseDelay, 2 * throttleBaseDelay, 4 * throttleBaseDelay}
+	if !reflect.DeepEqual(*waits, want) {
+		t.Fatalf("waits = %v, want %v", *waits, want)
+	}
+
+	// A success resets the backoff.
+	if limiter.delay != 0 {
+		t.Fatalf("delay after success = %s, want 0", limiter.delay)
+	}
+}
+
+func TestThrottleGivesUp(t *testing.T) {
+	limiter, waits := newTestThrottle()
+
+	calls := 0
+	throttled := &types.TooManyRequestsException{Message: aws.String("slow down")}
+	err := limiter.do(context.Background(), func() error {
+		calls++
+		return throttled
+	})
+	if !errors.Is(err, throttled) {
+		t.Fatalf("expected the throttling error, got %v", err)
+	}
+	if calls != throttleMaxAttempts {
+		t.Fatalf("calls = %d, want %d", calls, throttleMaxAttempts)
+	}
+	for _, wait := range *waits {
+		if wait > throttleMaxDelay {
+			t.Fatalf("wait %s exceeds the maximum delay", wait)
+		}
+	}
+}
+
+func TestThrottleDoesNotRetryOtherErrors(t *testing.T) {
+	limiter, waits := newTestThrottle()
+
+	calls := 0
+	failure := errors.New("access denied")
+	if err := limiter.do(context.Background(), func() error {
+		calls++
+		return failure
+	}); !errors.Is(err, failure) {
+		t.Fatalf("expected the original error, got %v", err)
+	}
+	if calls != 1 || len(*waits) != 0 {
+		t.Fatalf("calls = %d, waits = %v; want one call and no waits", calls, *waits)
+	}
+}
+
+func TestEqualJitter(t *testing.T) {
+	for range 100 {
+		if d := equalJitter(time.Second); d < 500*time.Millisecond || d >= time.Second {
+			t.Fatalf("equalJitter(1s) = %s, want [500ms, 1s)", d)
+		}
+	}
+}
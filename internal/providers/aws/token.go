@@ -1,6 +1,8 @@
 package aws
 
 import (
+	"crypto/sha1" // #nosec G505 -- matches the AWS CLI token cache file names
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -14,6 +16,14 @@ import (
 
 const tokenExpiryLayout = "2006-01-02T15:04:05Z"
 
+// registrationExpiryWindow is how long before the OIDC client registration
+// expires that generate starts warning about it.
+const registrationExpiryWindow = 7 * 24 * time.Hour
+
+// tokenExpiryWindow is how long before an SSO token that cannot be refreshed
+// expires that validate and generate start warning about it.
+const tokenExpiryWindow = time.Hour
+
 var errNoValidToken = errors.New("no valid sso token found")
 
 // LoadNewestToken finds the newest valid SSO token across cache paths.
@@ -42,18 +52,42 @@ func LoadMatchingToken(cachePaths []string, startURL, region string, now time.Ti
 		valid = matching
 	}
 
-	if len(valid) == 0 {
+	return newestToken(valid)
+}
+
+// loadRefreshableToken finds the newest expired SSO token for the start URL
+// and region that can still be refreshed without signing in.
+func loadRefreshableToken(cachePaths []string, startURL, region string, now time.Time) (SSOToken, error) {
+	tokens, err := loadTokens(cachePaths)
+	if err != nil {
+		return SSOToken{}, err
+	}
+
+	refreshable := make([]SSOToken, 0, len(tokens))
+	for _, t := range tokens {
+		if t.IsExpired(now) && t.CanRefresh(now) && t.MatchesSession(startURL, region) {
+			refreshable = append(refreshable, t)
+		}
+	}
+
+	return newestToken(refreshable)
+}
+
+// newestToken returns the token that expires last, preferring the most
+// recently issued one on ties.
+func newestToken(tokens []SSOToken) (SSOToken, error) {
+	if len(tokens) == 0 {
 		return SSOToken{}, errNoValidToken
 	}
 
-	sort.Slice(valid, func(i, j int) bool {
-		if valid[i].ExpiresAt.Equal(valid[j].ExpiresAt) {
-			return valid[i].IssuedAt.After(valid[j].IssuedAt)
+	sort.Slice(tokens, func(i, j int) bool {
+		if tokens[i].ExpiresAt.Equal(tokens[j].ExpiresAt) {
+			return tokens[i].IssuedAt.After(tokens[j].IssuedAt)
 		}
-		return valid[i].ExpiresAt.After(valid[j].ExpiresAt)
+		return tokens[i].ExpiresAt.After(tokens[j].ExpiresAt)
 	})
 
-	return valid[0], nil
+	return tokens[0], nil
 }
 
 func loadTokens(cachePaths []string) ([]SSOToken, error) {
@@ -132,6 +166,7 @@ func readToken(path string) (SSOToken, error) {
 	if err != nil {
 		return SSOToken{}, fmt.Errorf("parse token fields %q: %w", path, err)
 	}
+	token.path = path
 
 	return token, nil
 }
@@ -148,12 +183,17 @@ func filterValidTokens(tokens []SSOToken, now time.Time) []SSOToken {
 	return valid
 }
 
+// tokenFile is the token cache format shared with the AWS CLI.
 type tokenFile struct {
-	AccessToken string `json:"accessToken"`
-	ExpiresAt   string `json:"expiresAt"`
-	IssuedAt    string `json:"issuedAt"`
-	Region      string `json:"region"`
-	StartURL    string `json:"startUrl"`
+	AccessToken           string `json:"accessToken"`
+	ExpiresAt             string `json:"expiresAt"`
+	IssuedAt              string `json:"issuedAt,omitempty"`
+	Region                string `json:"region"`
+	StartURL              string `json:"startUrl"`
+	ClientID              string `json:"clientId,omitempty"`
+	ClientSecret          string `json:"clientSecret,omitempty"`
+	RegistrationExpiresAt string `json:"registrationExpiresAt,omitempty"`
+	RefreshToken          string `json:"refreshToken,omitempty"`
 }
 
 func (t tokenFile) toToken() (SSOToken, error) {
@@ -172,15 +212,72 @@ func (t tokenFile) toToken() (SSOToken, error) {
 		}
 	}
 
+	var registrationExpiresAt time.Time
+	if strings.TrimSpace(t.RegistrationExpiresAt) != "" {
+		registrationExpiresAt, err = time.Parse(tokenExpiryLayout, t.RegistrationExpiresAt)
+		if err != nil {
+			return SSOToken{}, fmt.Errorf("parse registrationExpiresAt: %w", err)
+		}
+	}
+
 	return SSOToken{
-		AccessToken: t.AccessToken,
-		ExpiresAt:   expiresAt,
-		IssuedAt:    issuedAt,
-		Region:      t.Region,
-		StartURL:    t.StartURL,
+		AccessToken:           t.AccessToken,
+		ExpiresAt:             expiresAt,
+		IssuedAt:              issuedAt,
+		Region:                t.Region,
+		StartURL:              t.StartURL,
+		ClientID:              t.ClientID,
+		ClientSecret:          t.ClientSecret,
+		RegistrationExpiresAt: registrationExpiresAt,
+		RefreshToken:          t.RefreshToken,
 	}, nil
 }
 
+func newTokenFile(token SSOToken) tokenFile {
+	file := tokenFile{
+		AccessToken:  token.AccessToken,
+		ExpiresAt:    token.ExpiresAt.UTC().Format(tokenExpiryLayout),
+		Region:       token.Region,
+		StartURL:     token.StartURL,
+		ClientID:     token.ClientID,
+		ClientSecret: token.ClientSecret,
+		RefreshToken: token.RefreshToken,
+	}
+	if !token.IssuedAt.IsZero() {
+		file.IssuedAt = token.IssuedAt.UTC().Format(tokenExpiryLayout)
+	}
+	if !token.RegistrationExpiresAt.IsZero() {
+		file.RegistrationExpiresAt = token.RegistrationExpiresAt.UTC().Format(tokenExpiryLayout)
+	}
+	return file
+}
+
+// tokenCacheFile returns the path the AWS CLI uses for an sso-session's
+// token: the SHA-1 of the session name, in the first cache directory.
+func tokenCacheFile(cachePaths []string, sessionName string) (string, error) {
+	if len(cachePaths) == 0 || strings.TrimSpace(cachePaths[0]) == "" {
+		return "", errTokenCachePathsEmpty
+	}
+	// #nosec G401 -- SHA-1 names the cache file to match the AWS CLI; it is not used for security
+	sum := sha1.Sum([]byte(sessionName))
+	return filepath.Join(cachePaths[0], hex.EncodeToString(sum[:])+".json"), nil
+}
+
+// writeToken saves token to path in the AWS CLI token cache format.
+func writeToken(path string, token SSOToken) error {
+	data, err := json.MarshalIndent(newTokenFile(token), "", "  ")
+	if err != nil {
+		return fmt.Errorf("encode sso token: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return fmt.Errorf("create token cache directory: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		return fmt.Errorf("write sso token: %w", err)
+	}
+	return nil
+}
+
 // SSOToken represents an SSO access token payload.
 type SSOToken struct {
 	AccessToken string
@@ -188,6 +285,16 @@ type SSOToken struct {
 	IssuedAt    time.Time
 	Region      string
 	StartURL    string
+
+	// ClientID, ClientSecret, RegistrationExpiresAt, and RefreshToken are set
+	// for tokens issued to a registered OIDC client.
+	ClientID              string
+	ClientSecret          string
+	RegistrationExpiresAt time.Time
+	RefreshToken          string
+
+	// path is the cache file the token was read from, if any.
+	path string
 }
 
 // IsExpired reports whether the token is expired at the provided time.
@@ -195,6 +302,16 @@ func (t SSOToken) IsExpired(now time.Time) bool {
 	return !t.ExpiresAt.After(now)
 }
 
+// CanRefresh reports whether the token carries a refresh token and a client
+// registration that has not expired at the provided time. Registrations
+// without a recorded expiry are assumed to be valid.
+func (t SSOToken) CanRefresh(now time.Time) bool {
+	if t.RefreshToken == "" || t.ClientID == "" || t.ClientSecret == "" {
+		return false
+	}
+	return t.RegistrationExpiresAt.IsZero() || t.RegistrationExpiresAt.After(now)
+}
+
 // MatchesSession reports whether the token matches the provided session metadata.
 func (t SSOToken) MatchesSession(startURL, region string) bool {
 	return strings.EqualFold(strings.TrimSpace(t.StartURL), strings.TrimSpace(startURL)) &&
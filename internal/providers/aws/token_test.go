@@ -1,6 +1,7 @@
 package aws
 
 import (
+	"errors"
 	"os"
 	"path/filepath"
 	"testing"
@@ -134,3 +135,60 @@ func TestLoadTokensFromMissingPath(t *testing.T) {
 		t.Fatalf("expected no tokens for missing path")
 	}
 }
+
+func TestLoadRefreshableToken(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	startURL := "https://example.awsapps.com/start"
+	cache := t.TempDir()
+
+	tokens := map[string]SSOToken{
+		"valid": {
+			ExpiresAt:    now.Add(time.Hour),
+			ClientID:     "client-id",
+			ClientSecret: "client-secret",
+			RefreshToken: "refresh",
+		},
+		"no-refresh": {
+			ExpiresAt:    now.Add(-time.Minute),
+			ClientID:     "client-id",
+			ClientSecret: "client-secret",
+		},
+		"registration-expired": {
+			ExpiresAt:             now.Add(-time.Minute),
+			ClientID:              "client-id",
+			ClientSecret:          "client-secret",
+			RefreshToken:          "refresh",
+			RegistrationExpiresAt: now.Add(-time.Hour),
+		},
+		"refreshable": {
+			ExpiresAt:             now.Add(-time.Hour),
+			ClientID:              "client-id",
+			ClientSecret:          "client-secret",
+			RefreshToken:          "refresh",
+			RegistrationExpiresAt: now.Add(time.Hour),
+		},
+	}
+	for name, token := range tokens {
+		token.AccessToken = name
+		token.Region = tokenTestRegion
+		token.StartURL = startURL
+		if err := writeToken(filepath.Join(cache, name+".json"), token); err != nil {
+			t.Fatalf("write token %s: %v", name, err)
+		}
+	}
+
+	selected, err := loadRefreshableToken([]string{cache}, startURL, tokenTestRegion, now)
+	if err != nil {
+		t.Fatalf("loadRefreshableToken failed: %v", err)
+	}
+	if selected.AccessToken != "refreshable" {
+		t.Fatalf("selected token = %q, want refreshable", selected.AccessToken)
+	}
+	if selected.path != filepath.Join(cache, "refreshable.json") {
+		t.Fatalf("selected token path = %q", selected.path)
+	}
+
+	if _, err := loadRefreshableToken([]string{cache}, "https://other.awsapps.com/start", tokenTestRegion, now); !errors.Is(err, errNoValidToken) {
+		t.Fatalf("expected errNoValidToken for another start URL, got %v", err)
+	}
+}
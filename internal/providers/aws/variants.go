@@ -0,0 +1,158 @@
+package aws
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/jmreicha/cfgctl/internal/core"
+)
+
+// regionVariantSeparator joins a profile name and a region in the name of
+// the profile's region variant.
+const regionVariantSeparator = "@"
+
+var (
+	errRegionVariantRegions   = errors.New("region variant rule needs regions or eks_regions")
+	errRegionVariantRegion    = errors.New("region variant region is invalid")
+	errRegionVariantCollision = errors.New("region variant name is already a generated profile")
+)
+
+var regionPattern = regexp.MustCompile(`^[a-z]{2}(-[a-z]+)+-[0-9]+$`)
+
+// RegionVariantRule generates a copy of each profile Match selects for every
+// listed region, named "<profile>@<region>" with region set. An empty Match
+// selects every profile.
+type RegionVariantRule struct {
+	Match   FilterRule `yaml:"match"`
+	Regions []string   `yaml:"regions"`
+
+	// EKSRegions adds the regions where this run's EKS discovery finds
+	// clusters for the profile.
+	EKSRegions bool `yaml:"eks_regions"`
+}
+
+type regionVariantRule struct {
+	match      compiledRule
+	regions    []string
+	eksRegions bool
+}
+
+// compileRegionVariants validates rules and compiles their selectors.
+func compileRegionVariants(rules []RegionVariantRule) ([]regionVariantRule, error) {
+	compiled := make([]regionVariantRule, 0, len(rules))
+	for i, rule := range rules {
+		name := fmt.Sprintf("region_variants[%d]", i)
+		if len(rule.Regions) == 0 && !rule.EKSRegions {
+			return nil, fmt.Errorf("%s: %w", name, errRegionVariantRegions)
+		}
+		match, err := compileRule(name+" match", rule.Match)
+		if err != nil {
+			return nil, err
+		}
+
+		regions := make([]string, 0, len(rule.Regions))
+		for _, region := range rule.Regions {
+			region = strings.ToLower(strings.TrimSpace(region))
+			if !regionPattern.MatchString(region) {
+				return nil, fmt.Errorf("%s: %w: %q", name, errRegionVariantRegion, region)
+			}
+			regions = append(regions, region)
+		}
+		compiled = append(compiled, regionVariantRule{match: match, regions: regions, eksRegions: rule.EKSRegions})
+	}
+	return compiled, nil
+}
+
+// ClusterRegionsFunc scans profiles, the profiles of the AWS config file at
+// path, for EKS clusters and returns the regions of the clusters by profile.
+// cache is the aws provider's discovery cache and may be nil.
+type ClusterRegionsFunc func(ctx context.Context, path string, profiles []core.AWSProfile, cache *core.ProviderCache) (map[string][]string, error)
+
+// UsesEKSRegions reports whether any region variant rule takes regions from
+// discovered EKS clusters, which callers then supply through
+// DiscoverClusterRegions.
+func (c *Config) UsesEKSRegions() bool {
+	for _, rule := range c.RegionVariants {
+		if rule.EKSRegions {
+			return true
+		}
+	}
+	return false
+}
+
+// resolveVariantRegions returns the sorted regions every matching rule
+// gives profile, which is generated as name.
+func resolveVariantRegions(rules []regionVariantRule, clusterRegions map[string][]string, name string, profile DiscoveredProfile) []string {
+	set := make(map[string]bool)
+	for _, rule := range rules {
+		if _, ok := rule.match.match(profile); !ok {
+			continue
+		}
+		for _, region := range rule.regions {
+			set[region] = true
+		}
+		if rule.eksRegions {
+			for _, region := range clusterRegions[name] {
+				set[strings.ToLower(strings.TrimSpace(region))] = true
+			}
+		}
+	}
+
+	regions := make([]string, 0, len(set))
+	for region := range set {
+		if region != "" {
+			regions = append(regions, region)
+		}
+	}
+	sort.Strings(regions)
+	return regions
+}
+
+// addRegionVariants adds the region variants of the generated profiles to
+// profileMap and returns their names. A variant keeps its profile's
+// attributes, with region replaced.
+func addRegionVariants(cfg *Config, discovered map[string]DiscoveredProfile, profileMap map[string]generatedProfile) ([]string, error) {
+	rules, err := compileRegionVariants(cfg.RegionVariants)
+	if err != nil || len(rules) == 0 {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(discovered))
+	for name := range discovered {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var variants []string
+	for _, name := range names {
+		base := profileMap[name]
+		for _, region := range resolveVariantRegions(rules, cfg.ClusterRegions, name, discovered[name]) {
+			variant := base
+			variant.Name = name + regionVariantSeparator + region
+			variant.VariantOf = name
+			variant.Attributes = make(map[string]string, len(base.Attributes)+1)
+			for key, value := range base.Attributes {
+				variant.Attributes[key] = value
+			}
+			variant.Attributes["region"] = region
+
+			if _, exists := profileMap[variant.Name]; exists {
+				return nil, fmt.Errorf("%w: %q", errRegionVariantCollision, variant.Name)
+			}
+			profileMap[variant.Name] = variant
+			variants = append(variants, variant.Name)
+		}
+	}
+	return variants, nil
+}
+
+// writeVariantOf records the profile a region variant was copied from.
+func writeVariantOf(builder *strings.Builder, profile generatedProfile) {
+	if profile.VariantOf != "" {
+		writeKeyValue(builder, core.AWSVariantOfKey, profile.VariantOf)
+	}
+}
@@ -0,0 +1,161 @@
+package aws
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jmreicha/cfgctl/internal/core"
+)
+
+func TestBuildConfigContentRegionVariants(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.SSO.Region = testRegion
+	cfg.SSO.StartURL = testStartURL
+	cfg.ProfileAttributes = []ProfileAttributeRule{
+		{Set: map[string]string{"region": "us-east-1", "output": "json"}},
+	}
+	cfg.RegionVariants = []RegionVariantRule{
+		{Match: FilterRule{Accounts: []string{"prod"}}, Regions: []string{"EU-West-1"}},
+		{Match: FilterRule{Roles: []string{"Admin"}}, EKSRegions: true},
+	}
+	cfg.ClusterRegions = map[string][]string{
+		"prod/admin": {"us-west-2", "eu-west-1"},
+		"dev/admin":  {"ap-southeast-2"},
+	}
+
+	profiles := []DiscoveredProfile{
+		{AccountID: "111111111111", AccountName: "prod", RoleName: "Admin"},
+		{AccountID: "222222222222", AccountName: "dev", RoleName: "ReadOnly"},
+	}
+
+	content, names, _, err := BuildGeneratedConfigContent(cfg, profiles)
+	if err != nil {
+		t.Fatalf("BuildGeneratedConfigContent failed: %v", err)
+	}
+
+	wantNames := []string{"dev/readonly", "prod/admin", "prod/admin@eu-west-1", "prod/admin@us-west-2"}
+	if strings.Join(names, ",") != strings.Join(wantNames, ",") {
+		t.Fatalf("generated names = %v, want %v", names, wantNames)
+	}
+
+	variant := `[profile prod/admin@eu-west-1]
+sso_session = cfgctl
+sso_account_id = 111111111111
+sso_account_name = prod
+sso_role_name = Admin
+cfgctl_variant_of = prod/admin
+output = json
+region = eu-west-1
+sso_auto_populated = true
+`
+	if !strings.Contains(content, variant) {
+		t.Fatalf("expected variant section %q in content:\n%s", variant, content)
+	}
+
+	inventory := core.ParseAWSProfilesContent(content, cfg.MarkerKey)
+	for _, profile := range inventory {
+		if profile.Name == "prod/admin@us-west-2" && (profile.VariantOf != "prod/admin" || profile.Region != "us-west-2" || !profile.Managed) {
+			t.Fatalf("variant inventory entry = %+v", profile)
+		}
+	}
+}
+
+func TestBuildConfigContentRegionVariantCollision(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.SSO.Region = testRegion
+	cfg.SSO.StartURL = testStartURL
+	cfg.ProfileTemplate = "{{ .AccountName }}"
+	cfg.RegionVariants = []RegionVariantRule{{Match: FilterRule{Accounts: []string{"prod"}}, Regions: []string{"eu-west-1"}}}
+
+	profiles := []DiscoveredProfile{
+		{AccountID: "111111111111", AccountName: "prod", RoleName: "Admin"},
+		{AccountID: "222222222222", AccountName: "prod@eu-west-1", RoleName: "Admin"},
+	}
+	if _, _, err := BuildConfigContent(cfg, profiles); !errors.Is(err, errRegionVariantCollision) {
+		t.Fatalf("error = %v, want %v", err, errRegionVariantCollision)
+	}
+}
+
+func TestBuildConfigContentPrunesRegionVariants(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.SSO.Region = testRegion
+	cfg.SSO.StartURL = testStartURL
+	cfg.Prune = true
+	cfg.RegionVariants = []RegionVariantRule{{Regions: []string{"eu-west-1"}}}
+
+	profiles := []DiscoveredProfile{{AccountID: "111111111111", AccountName: "prod", RoleName: "Admin"}}
+	path := filepath.Join(t.TempDir(), "config")
+
+	content, _, err := buildConfigContent(cfg, path, profiles, nil, &core.Result{Metadata: map[string]interface{}{}})
+	if err != nil {
+		t.Fatalf("buildConfigContent failed: %v", err)
+	}
+	if !strings.Contains(content, "[profile prod/admin@eu-west-1]") {
+		t.Fatalf("expected variant in content:\n%s", content)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+
+	cfg.RegionVariants = nil
+	content, _, err = buildConfigContent(cfg, path, profiles, nil, &core.Result{Metadata: map[string]interface{}{}})
+	if err != nil {
+		t.Fatalf("buildConfigContent failed: %v", err)
+	}
+	if strings.Contains(content, "@eu-west-1") {
+		t.Fatalf("expected stale variant to be pruned:\n%s", content)
+	}
+	if !strings.Contains(content, "[profile prod/admin]") {
+		t.Fatalf("expected base profile to be kept:\n%s", content)
+	}
+}
+
+func TestBuildConfigContentRoleChainRulesSkipRegionVariants(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.SSO.Region = testRegion
+	cfg.SSO.StartURL = testStartURL
+	cfg.RegionVariants = []RegionVariantRule{{Regions: []string{"eu-west-1"}}}
+	cfg.RoleChainRules = []RoleChainRule{{RoleName: "Deploy", TargetAccountIDs: []string{"333333333333"}}}
+
+	profiles := []DiscoveredProfile{{AccountID: "111111111111", AccountName: "prod", RoleName: "Admin"}}
+	_, names, _, err := BuildGeneratedConfigContent(cfg, profiles)
+	if err != nil {
+		t.Fatalf("BuildGeneratedConfigContent failed: %v", err)
+	}
+	for _, name := range names {
+		if strings.HasPrefix(name, "prod/admin@eu-west-1/") {
+			t.Fatalf("role chain generated from a region variant: %v", names)
+		}
+	}
+}
+
+func TestConfigValidateRegionVariants(t *testing.T) {
+	tests := []struct {
+		name string
+		rule RegionVariantRule
+		want error
+	}{
+		{name: "no regions", rule: RegionVariantRule{}, want: errRegionVariantRegions},
+		{name: "invalid region", rule: RegionVariantRule{Regions: []string{"eu west"}}, want: errRegionVariantRegion},
+		{name: "invalid match", rule: RegionVariantRule{Match: FilterRule{Accounts: []string{"/[/"}}, Regions: []string{"eu-west-1"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := DefaultConfig()
+			cfg.SSO.Region = testRegion
+			cfg.SSO.StartURL = testStartURL
+			cfg.RegionVariants = []RegionVariantRule{tt.rule}
+
+			err := cfg.Validate()
+			if err == nil {
+				t.Fatal("expected error, got nil")
+			}
+			if tt.want != nil && !errors.Is(err, tt.want) {
+				t.Fatalf("Validate() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
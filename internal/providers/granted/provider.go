@@ -5,8 +5,10 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"log/slog"
 	"os"
 	"path/filepath"
+	"time"
 
 	"github.com/jmreicha/cfgctl/internal/core"
 )
@@ -23,16 +25,31 @@ var (
 // It handles generation, backup, restoration, and cleanup of Granted configuration files.
 type Provider struct {
 	config *Config
+	logger *slog.Logger
 }
 
 // NewProvider creates a new Granted provider instance with the given configuration.
-func NewProvider(config *Config) *Provider {
+func NewProvider(config *Config, opts ...ProviderOption) *Provider {
 	if config == nil {
 		config = DefaultConfig()
 	}
 
-	return &Provider{
-		config: config,
+	p := &Provider{config: config, logger: slog.New(slog.NewTextHandler(os.Stderr, nil))}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
+}
+
+// ProviderOption configures a Provider.
+type ProviderOption func(*Provider)
+
+// WithLogger sets the logger for the provider.
+func WithLogger(logger *slog.Logger) ProviderOption {
+	return func(p *Provider) {
+		if logger != nil {
+			p.logger = logger
+		}
 	}
 }
 
@@ -96,7 +113,9 @@ func (p *Provider) Generate(_ context.Context, opts *core.GenerateOptions) (*cor
 		return result, nil
 	}
 
+	start := time.Now()
 	configContent := p.buildConfigContent()
+	result.RecordPhase(core.TimingRender, start)
 
 	if opts.DryRun {
 		result.Warnings = append(result.Warnings, "dry-run mode: no files were actually created")
@@ -105,6 +124,8 @@ func (p *Provider) Generate(_ context.Context, opts *core.GenerateOptions) (*cor
 		return result, nil
 	}
 
+	p.logger.Debug("writing granted config", "path", configPath)
+	start = time.Now()
 	configDir := filepath.Dir(configPath)
 	if err := os.MkdirAll(configDir, 0700); err != nil {
 		return nil, fmt.Errorf("failed to create config directory: %w", err)
@@ -113,6 +134,7 @@ func (p *Provider) Generate(_ context.Context, opts *core.GenerateOptions) (*cor
 	if err := os.WriteFile(configPath, []byte(configContent), 0600); err != nil {
 		return nil, fmt.Errorf("failed to write config file: %w", err)
 	}
+	result.RecordPhase(core.TimingWrite, start)
 
 	result.FilesCreated = append(result.FilesCreated, configPath)
 
@@ -2,6 +2,8 @@ package granted
 
 import (
 	"context"
+	"io"
+	"log/slog"
 	"os"
 	"path/filepath"
 	"strings"
@@ -796,3 +798,20 @@ func TestProvider_GenerateWriteFileError(t *testing.T) {
 		t.Error("expected error for write failure")
 	}
 }
+
+func TestWithLogger(t *testing.T) {
+	t.Run("nil logger keeps default", func(t *testing.T) {
+		provider := NewProvider(nil, WithLogger(nil))
+		if provider.logger == nil {
+			t.Error("expected non-nil logger when WithLogger(nil) is used")
+		}
+	})
+
+	t.Run("non-nil logger is set", func(t *testing.T) {
+		customLogger := slog.New(slog.NewTextHandler(io.Discard, nil))
+		provider := NewProvider(nil, WithLogger(customLogger))
+		if provider.logger != customLogger {
+			t.Error("expected custom logger to be set")
+		}
+	})
+}
@@ -10,7 +10,6 @@ import (
 
 	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/credentials"
-	"github.com/aws/aws-sdk-go-v2/service/eks"
 )
 
 // awsVaultCommand is the command name for aws-vault. Overridden in tests.
@@ -83,8 +82,9 @@ func prefetchAWSVaultCredentials(ctx context.Context, profiles []string) (map[st
 
 // NewAWSVaultEKSClientFactory returns a factory that creates EKS clients
 // using pre-fetched aws-vault credentials. Credentials are looked up by
-// profile and reused across all regions.
-func NewAWSVaultEKSClientFactory(creds map[string]*credentialProcessOutput) EKSClientFactory {
+// profile and reused across all regions. A non-empty endpointURL replaces the
+// EKS endpoint.
+func NewAWSVaultEKSClientFactory(creds map[string]*credentialProcessOutput, endpointURL string) EKSClientFactory {
 	return func(ctx context.Context, profile, region string) (EKSClient, error) {
 		cred, ok := creds[profile]
 		if !ok {
@@ -105,6 +105,6 @@ func NewAWSVaultEKSClientFactory(creds map[string]*credentialProcessOutput) EKSC
 			return nil, fmt.Errorf("load aws config for profile %q region %q: %w", profile, region, err)
 		}
 
-		return eks.NewFromConfig(cfg), nil
+		return newEKSClient(cfg, endpointURL), nil
 	}
 }
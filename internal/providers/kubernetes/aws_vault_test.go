@@ -245,7 +245,7 @@ func TestNewAWSVaultEKSClientFactory(t *testing.T) {
 		},
 	}
 
-	factory := NewAWSVaultEKSClientFactory(creds)
+	factory := NewAWSVaultEKSClientFactory(creds, "")
 
 	t.Run("missing profile", func(t *testing.T) {
 		_, err := factory(context.Background(), "nonexistent", "us-east-1")
@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"github.com/jmreicha/cfgctl/internal/core"
+	awsprovider "github.com/jmreicha/cfgctl/internal/providers/aws"
 	"gopkg.in/yaml.v3"
 )
 
@@ -55,6 +56,9 @@ type Config struct {
 
 	// ManualConfigs defines manual kubeconfig entries to preserve.
 	ManualConfigs []ManualConfig `yaml:"manual_configs"`
+
+	// Fixtures replaces AWS discovery with demo fixtures when set.
+	Fixtures *core.DemoFixtures `yaml:"-"`
 }
 
 // AWSConfig represents EKS discovery settings.
@@ -81,6 +85,20 @@ type AWSConfig struct {
 
 	// Timeout is the AWS call timeout for discovery.
 	Timeout time.Duration `yaml:"timeout"`
+
+	// EndpointURLs overrides the AWS service endpoints, e.g. for LocalStack.
+	EndpointURLs EndpointURLsConfig `yaml:"endpoint_urls"`
+}
+
+// EndpointURLsConfig overrides the endpoints of the AWS services used for
+// discovery. Empty fields use the AWS_ENDPOINT_URL_<SERVICE> and
+// AWS_ENDPOINT_URL environment variables, and then the real AWS endpoint.
+type EndpointURLsConfig struct {
+	// EKS is the EKS API used to list and describe clusters.
+	EKS string `yaml:"eks"`
+
+	// EC2 is the EC2 API used to list regions when regions is "all".
+	EC2 string `yaml:"ec2"`
 }
 
 // MergeConfig defines settings for merging existing kubeconfig files.
@@ -300,6 +318,13 @@ func (c *Config) Validate() error {
 
 		c.AWS.ConfigFile = configFile
 
+		for _, endpoint := range []*string{&c.AWS.EndpointURLs.EKS, &c.AWS.EndpointURLs.EC2} {
+			*endpoint = strings.TrimSpace(*endpoint)
+			if err := awsprovider.ValidateEndpointURL(*endpoint); err != nil {
+				return err
+			}
+		}
+
 		// CredentialsFile is optional; normalize if provided.
 		if strings.TrimSpace(c.AWS.CredentialsFile) != "" {
 			credentialsFile, err := normalizePath(c.AWS.CredentialsFile, errAWSCredentialsEmpty)
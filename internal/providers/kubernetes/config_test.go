@@ -216,6 +216,26 @@ func TestConfigValidate(t *testing.T) {
 	}
 }
 
+func TestConfigValidateEndpointURLs(t *testing.T) {
+	baseDir := t.TempDir()
+	cfg := DefaultConfig()
+	cfg.ConfigPath = filepath.Join(baseDir, "kube", "config")
+	cfg.AWS.ConfigFile = filepath.Join(baseDir, "aws", "config")
+	cfg.AWS.EndpointURLs = EndpointURLsConfig{EKS: " http://localhost:4566 "}
+
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("Validate failed: %v", err)
+	}
+	if cfg.AWS.EndpointURLs.EKS != "http://localhost:4566" {
+		t.Errorf("EKS endpoint = %q, want trimmed", cfg.AWS.EndpointURLs.EKS)
+	}
+
+	cfg.AWS.EndpointURLs.EC2 = "ftp://localhost"
+	if err := cfg.Validate(); err == nil {
+		t.Fatal("expected error for non-http endpoint")
+	}
+}
+
 func TestConfigFromMapNil(t *testing.T) {
 	home := t.TempDir()
 	t.Setenv("HOME", home)
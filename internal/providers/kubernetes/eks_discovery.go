@@ -12,6 +12,7 @@ import (
 	"sort"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
@@ -22,12 +23,17 @@ import (
 	"github.com/aws/aws-sdk-go-v2/service/eks"
 	"github.com/aws/aws-sdk-go-v2/service/eks/types"
 	"github.com/aws/smithy-go"
+	"github.com/jmreicha/cfgctl/internal/core"
 	awsprovider "github.com/jmreicha/cfgctl/internal/providers/aws"
 	"golang.org/x/sync/errgroup"
 )
 
 const authModeAWSVault = "aws-vault"
 
+// timingAWSVaultPrefetch is recorded separately from discovery so slow
+// aws-vault credential fetches can be told apart from EKS API calls.
+const timingAWSVaultPrefetch = "aws-vault prefetch"
+
 var errProfilesNotFound = errors.New("no aws profiles found in credentials file")
 
 // RegionLister describes the EC2 DescribeRegions API.
@@ -49,6 +55,51 @@ type EKSClientFactory func(ctx context.Context, profile, region string) (EKSClie
 
 // DiscoverEKSClusters scans AWS profiles and regions for EKS clusters.
 func DiscoverEKSClusters(ctx context.Context, cfg *Config, factory EKSClientFactory, logger *slog.Logger) ([]DiscoveredCluster, []string, error) {
+	return discoverEKSClusters(ctx, cfg, factory, logger, nil, nil, nil, nil)
+}
+
+// ProfileClusterRegions scans profiles, the profiles of the AWS config file at
+// path, for EKS clusters with cfg's regions, roles, and credentials, and
+// returns the regions of the clusters found by profile. The aws provider uses
+// it for region variants before this provider runs. Scans are stored in
+// cache, which may be nil, so this provider can reuse them later in the run.
+func ProfileClusterRegions(ctx context.Context, cfg *Config, path string, profiles []core.AWSProfile, cache *core.ProviderCache, logger *slog.Logger) (map[string][]string, error) {
+	if cfg == nil {
+		return nil, errors.New("kubernetes config is nil")
+	}
+	scan := *cfg
+	scan.MergeOnly = false
+	scan.AWS.ConfigFile = path
+	if err := scan.Validate(); err != nil {
+		return nil, err
+	}
+
+	inventory := &core.AWSProfileInventory{}
+	inventory.Publish(path, profiles)
+	clusters, _, err := discoverEKSClusters(ctx, &scan, nil, logger, nil, nil, cache, inventory)
+	if err != nil {
+		return nil, err
+	}
+
+	sets := make(map[string]map[string]struct{})
+	for _, cluster := range clusters {
+		if sets[cluster.Profile] == nil {
+			sets[cluster.Profile] = make(map[string]struct{})
+		}
+		sets[cluster.Profile][cluster.Region] = struct{}{}
+	}
+	regions := make(map[string][]string, len(sets))
+	for profile, set := range sets {
+		regions[profile] = sortedKeys(set)
+	}
+	return regions, nil
+}
+
+// discoverEKSClusters implements DiscoverEKSClusters, recording sub-phase
+// timings on result, reporting progress, reusing cached clusters per profile
+// and region, and taking profiles from the run's AWS profile inventory when
+// they are not nil.
+func discoverEKSClusters(ctx context.Context, cfg *Config, factory EKSClientFactory, logger *slog.Logger, result *core.Result, progress core.ProgressReporter, cache *core.ProviderCache, inventory *core.AWSProfileInventory) ([]DiscoveredCluster, []string, error) {
 	if cfg == nil {
 		return nil, nil, errors.New("kubernetes config is nil")
 	}
@@ -56,16 +107,27 @@ func DiscoverEKSClusters(ctx context.Context, cfg *Config, factory EKSClientFact
 		logger = slog.New(slog.NewTextHandler(os.Stderr, nil))
 	}
 
-	profiles, err := resolveProfiles(cfg.AWS.ConfigFile, cfg.AWS.CredentialsFile)
+	var (
+		resolved []core.AWSProfile
+		err      error
+	)
+	if cfg.Fixtures != nil {
+		// Fixtures are never cached, so they cannot leak into a real run.
+		cache = nil
+		resolved, err = fixtureProfiles(cfg.Fixtures)
+	} else {
+		resolved, err = resolveProfiles(cfg.AWS.ConfigFile, cfg.AWS.CredentialsFile, inventory)
+	}
 	if err != nil {
 		return nil, nil, err
 	}
-	logger.Debug("resolved aws profiles", "count", len(profiles), "profiles", profiles)
 
 	if len(cfg.AWS.Roles) > 0 {
-		profiles = filterProfilesByRole(profiles, cfg.AWS.Roles)
-		logger.Debug("filtered profiles by role", "roles", cfg.AWS.Roles, "remaining", len(profiles))
+		resolved = core.FilterAWSProfilesByRole(resolved, cfg.AWS.Roles)
+		logger.Debug("filtered profiles by role", "roles", cfg.AWS.Roles, "remaining", len(resolved))
 	}
+	profiles := core.AWSProfileNames(resolved)
+	logger.Debug("resolved aws profiles", "count", len(profiles), "profiles", profiles)
 
 	var (
 		authMode   string
@@ -73,25 +135,41 @@ func DiscoverEKSClusters(ctx context.Context, cfg *Config, factory EKSClientFact
 	)
 	if factory == nil {
 		switch {
+		case cfg.Fixtures != nil:
+			factory = fixtureEKSClientFactory(cfg.Fixtures)
+			logger.Debug("using demo fixtures")
+		case cache.Offline():
+			// Only cached clusters are used, so no credentials are needed.
+			factory = NewEKSClientFactory(cfg.AWS.ConfigFile, cfg.AWS.EndpointURLs.EKS)
+			logger.Debug("offline, using cached clusters only")
 		case hasValidSSOToken():
-			factory = NewEKSClientFactory(cfg.AWS.ConfigFile)
+			factory = NewEKSClientFactory(cfg.AWS.ConfigFile, cfg.AWS.EndpointURLs.EKS)
 			logger.Debug("using SSO token auth")
 		case isAWSVaultAvailable():
 			logger.Debug("using aws-vault auth")
+			core.ReportProgress(progress, core.ProgressEvent{Kind: core.ProgressStep, Message: "prefetching aws-vault credentials"})
+			start := time.Now()
 			var err error
 			vaultCreds, err = prefetchAWSVaultCredentials(ctx, profiles)
 			if err != nil {
 				return nil, nil, fmt.Errorf("prefetch aws-vault credentials: %w", err)
 			}
-			factory = NewAWSVaultEKSClientFactory(vaultCreds)
+			result.RecordPhase(timingAWSVaultPrefetch, start)
+			factory = NewAWSVaultEKSClientFactory(vaultCreds, cfg.AWS.EndpointURLs.EKS)
 			authMode = authModeAWSVault
 		default:
-			factory = NewEKSClientFactory(cfg.AWS.ConfigFile)
+			factory = NewEKSClientFactory(cfg.AWS.ConfigFile, cfg.AWS.EndpointURLs.EKS)
 			logger.Debug("using default aws auth")
 		}
 	}
 
-	regions, err := resolveRegions(ctx, cfg.AWS.Regions, cfg.AWS.ConfigFile, profiles[0], vaultCreds, logger)
+	core.ReportProgress(progress, core.ProgressEvent{Kind: core.ProgressStep, Message: "resolving regions"})
+	var regions []string
+	if cfg.Fixtures != nil {
+		regions, err = resolveFixtureRegions(ctx, cfg.AWS.Regions, cfg.Fixtures)
+	} else {
+		regions, err = resolveRegions(ctx, cfg.AWS.Regions, cfg.AWS.ConfigFile, cfg.AWS.EndpointURLs.EC2, profiles[0], vaultCreds, logger, cache)
+	}
 	if err != nil {
 		return nil, nil, err
 	}
@@ -102,17 +180,26 @@ func DiscoverEKSClusters(ctx context.Context, cfg *Config, factory EKSClientFact
 		g.SetLimit(cfg.AWS.ParallelWorkers)
 	}
 
-	var (
-		clusters []DiscoveredCluster
-		warnings []string
-		mu       sync.Mutex
-	)
+	state := &discoveryState{progress: progress, cache: cache}
+	total := len(profiles) * len(regions)
+	var scanned atomic.Int64
 
 	for _, profile := range profiles {
 		for _, region := range regions {
 			profile := profile
 			g.Go(func() error {
-				return discoverClustersForProfileRegion(groupCtx, cfg, factory, profile, region, authMode, &mu, &clusters, &warnings)
+				core.ReportProgress(progress, core.ProgressEvent{
+					Kind:    core.ProgressStep,
+					Message: fmt.Sprintf("scanning profile %s region %s", profile, region),
+				})
+				err := discoverClustersForProfileRegion(groupCtx, cfg, factory, profile, region, authMode, state)
+				core.ReportProgress(progress, core.ProgressEvent{
+					Kind:    core.ProgressCount,
+					Message: "profile regions",
+					Current: int(scanned.Add(1)),
+					Total:   total,
+				})
+				return err
 			})
 		}
 	}
@@ -121,16 +208,73 @@ func DiscoverEKSClusters(ctx context.Context, cfg *Config, factory EKSClientFact
 		return nil, nil, err
 	}
 
-	sortClusters(clusters)
-	sort.Strings(warnings)
+	sortClusters(state.clusters)
+	sort.Strings(state.warnings)
 
-	return clusters, warnings, nil
+	return state.clusters, state.warnings, nil
 }
 
-func discoverClustersForProfileRegion(ctx context.Context, cfg *Config, factory EKSClientFactory, profile, region, authMode string, mu *sync.Mutex, clusters *[]DiscoveredCluster, warnings *[]string) error {
+// discoveryState collects clusters and warnings from concurrent discovery workers.
+type discoveryState struct {
+	mu       sync.Mutex
+	clusters []DiscoveredCluster
+	warnings []string
+	progress core.ProgressReporter
+	cache    *core.ProviderCache
+}
+
+// regionScan is the result of scanning one profile and region, as stored in the cache.
+type regionScan struct {
+	Clusters []DiscoveredCluster `json:"clusters"`
+	Warnings []string            `json:"warnings,omitempty"`
+}
+
+func (s *discoveryState) addCluster(cluster DiscoveredCluster) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.clusters = append(s.clusters, cluster)
+}
+
+func (s *discoveryState) addWarning(warning string) {
+	s.mu.Lock()
+	s.warnings = append(s.warnings, warning)
+	s.mu.Unlock()
+	core.ReportProgress(s.progress, core.ProgressEvent{Kind: core.ProgressWarning, Message: warning})
+}
+
+// discoverClustersForProfileRegion adds the clusters in one profile and region
+// to state, using the cached scan when one is fresh.
+func discoverClustersForProfileRegion(ctx context.Context, cfg *Config, factory EKSClientFactory, profile, region, authMode string, state *discoveryState) error {
+	key := core.CacheKey{Profile: profile, Region: region}
+	var scan regionScan
+	found, err := state.cache.Load(key, &scan)
+	if err != nil {
+		return err
+	}
+	if !found {
+		scan, err = scanProfileRegion(ctx, cfg, factory, profile, region, authMode)
+		if err != nil {
+			return err
+		}
+		// A failed cache write only costs a rediscovery next time.
+		_ = state.cache.Store(key, scan)
+	}
+
+	for _, cluster := range scan.Clusters {
+		state.addCluster(cluster)
+	}
+	for _, warning := range scan.Warnings {
+		state.addWarning(warning)
+	}
+	return nil
+}
+
+func scanProfileRegion(ctx context.Context, cfg *Config, factory EKSClientFactory, profile, region, authMode string) (regionScan, error) {
+	scan := regionScan{Clusters: []DiscoveredCluster{}}
+
 	client, err := factory(ctx, profile, region)
 	if err != nil {
-		return fmt.Errorf("create eks client for profile %q region %q: %w", profile, region, err)
+		return scan, fmt.Errorf("create eks client for profile %q region %q: %w", profile, region, err)
 	}
 
 	listCtx, cancel := context.WithTimeout(ctx, cfg.AWS.Timeout)
@@ -139,40 +283,34 @@ func discoverClustersForProfileRegion(ctx context.Context, cfg *Config, factory
 	listOutput, err := client.ListClusters(listCtx, &eks.ListClustersInput{})
 	if err != nil {
 		if isAccessDenied(err) {
-			mu.Lock()
-			*warnings = append(*warnings, fmt.Sprintf("skipping profile %q region %q: access denied for eks:ListClusters", profile, region))
-			mu.Unlock()
-			return nil
+			scan.Warnings = append(scan.Warnings, fmt.Sprintf("skipping profile %q region %q: access denied for eks:ListClusters", profile, region))
+			return scan, nil
 		}
-		return fmt.Errorf("list eks clusters for profile %q region %q: %w", profile, region, err)
+		return scan, fmt.Errorf("list eks clusters for profile %q region %q: %w", profile, region, err)
 	}
 
 	for _, name := range listOutput.Clusters {
 		cluster, err := describeCluster(ctx, client, cfg.AWS.Timeout, profile, region, name)
 		if err != nil {
 			if isAccessDenied(err) {
-				mu.Lock()
-				*warnings = append(*warnings, fmt.Sprintf("skipping cluster %q for profile %q region %q: access denied", name, profile, region))
-				mu.Unlock()
+				scan.Warnings = append(scan.Warnings, fmt.Sprintf("skipping cluster %q for profile %q region %q: access denied", name, profile, region))
 				continue
 			}
-			return err
+			return scan, err
 		}
 		cluster.AuthMode = authMode
-
-		mu.Lock()
-		*clusters = append(*clusters, cluster)
-		mu.Unlock()
+		scan.Clusters = append(scan.Clusters, cluster)
 	}
 
-	return nil
+	return scan, nil
 }
 
 // NewEKSClientFactory returns a default EKS client factory that loads
 // credentials from the AWS config file using native SSO authentication.
 // The credentials file is suppressed to avoid triggering credential_process
-// helpers (e.g. aws-vault, granted) for each profile.
-func NewEKSClientFactory(configFile string) EKSClientFactory {
+// helpers (e.g. aws-vault, granted) for each profile. A non-empty endpointURL
+// replaces the EKS endpoint.
+func NewEKSClientFactory(configFile, endpointURL string) EKSClientFactory {
 	return func(ctx context.Context, profile, region string) (EKSClient, error) {
 		loadOpts := []func(*config.LoadOptions) error{
 			config.WithRegion(region),
@@ -192,10 +330,20 @@ func NewEKSClientFactory(configFile string) EKSClientFactory {
 			return nil, fmt.Errorf("load aws config for profile %q region %q: %w", profile, region, err)
 		}
 
-		return eks.NewFromConfig(cfg), nil
+		return newEKSClient(cfg, endpointURL), nil
 	}
 }
 
+// newEKSClient creates an EKS client, replacing its endpoint with endpointURL
+// when it is not empty.
+func newEKSClient(cfg aws.Config, endpointURL string) *eks.Client {
+	return eks.NewFromConfig(cfg, func(opts *eks.Options) {
+		if endpointURL != "" {
+			opts.BaseEndpoint = aws.String(endpointURL)
+		}
+	})
+}
+
 func describeCluster(ctx context.Context, client EKSClient, timeout time.Duration, profile, region, name string) (DiscoveredCluster, error) {
 	describeCtx, cancel := context.WithTimeout(ctx, timeout)
 	defer cancel()
@@ -230,29 +378,6 @@ func describeCluster(ctx context.Context, client EKSClient, timeout time.Duratio
 	}, nil
 }
 
-// filterProfilesByRole returns only profiles whose role segment matches one of
-// the given roles. The role is extracted as the last path component of the
-// profile name (e.g. "prod/adminaccess" → "adminaccess"). Matching is
-// case-insensitive.
-func filterProfilesByRole(profiles, roles []string) []string {
-	roleSet := make(map[string]struct{}, len(roles))
-	for _, r := range roles {
-		roleSet[strings.ToLower(strings.TrimSpace(r))] = struct{}{}
-	}
-
-	var filtered []string
-	for _, profile := range profiles {
-		role := profile
-		if idx := strings.LastIndex(profile, "/"); idx >= 0 {
-			role = profile[idx+1:]
-		}
-		if _, ok := roleSet[strings.ToLower(role)]; ok {
-			filtered = append(filtered, profile)
-		}
-	}
-	return filtered
-}
-
 // isAccessDenied reports whether an error is an AWS AccessDeniedException.
 func isAccessDenied(err error) bool {
 	var apiErr smithy.APIError
@@ -286,7 +411,16 @@ func decodeClusterCA(authority *types.Certificate) ([]byte, error) {
 	return decoded, nil
 }
 
-func resolveProfiles(configFile, credentialsFile string) ([]string, error) {
+// resolveProfiles returns the profiles to scan. Profiles published by the aws
+// provider for the configured config file are preferred; otherwise the config
+// file is parsed, falling back to the credentials file.
+func resolveProfiles(configFile, credentialsFile string, inventory *core.AWSProfileInventory) ([]core.AWSProfile, error) {
+	if published, ok := inventory.Profiles(configFile); ok {
+		if profiles := namedProfiles(published); len(profiles) > 0 {
+			return profiles, nil
+		}
+	}
+
 	// Prefer config file for profile resolution (SSO-based profiles).
 	if strings.TrimSpace(configFile) != "" {
 		parsed, err := parseConfigFileProfiles(configFile)
@@ -305,7 +439,11 @@ func resolveProfiles(configFile, credentialsFile string) ([]string, error) {
 		return nil, errProfilesNotFound
 	}
 
-	return parsed, nil
+	profiles := make([]core.AWSProfile, 0, len(parsed))
+	for _, name := range parsed {
+		profiles = append(profiles, core.AWSProfile{Name: name})
+	}
+	return profiles, nil
 }
 
 func parseCredentialProfiles(credentialsFile string) (profiles []string, err error) {
@@ -353,69 +491,64 @@ func parseCredentialProfiles(credentialsFile string) (profiles []string, err err
 	return sortedKeys(profileSet), nil
 }
 
-// parseConfigFileProfiles extracts profile names from an AWS config file.
-// Only [profile xxx] sections are included; other sections like [sso-session ...]
-// and [default] are skipped.
-func parseConfigFileProfiles(configFile string) (profiles []string, err error) {
+// parseConfigFileProfiles reads the named profiles from an AWS config file,
+// sorted by name. Only [profile xxx] sections are included; other sections
+// like [sso-session ...] and [default] are skipped.
+func parseConfigFileProfiles(configFile string) ([]core.AWSProfile, error) {
 	if strings.TrimSpace(configFile) == "" {
 		return nil, errAWSConfigFileEmpty
 	}
 
-	// #nosec G304 -- config file path is user configured.
-	file, err := os.Open(configFile)
+	profiles, err := core.ParseAWSProfiles(configFile, "")
 	if err != nil {
-		return nil, fmt.Errorf("open aws config file: %w", err)
+		return nil, err
 	}
-	defer func() {
-		if closeErr := file.Close(); closeErr != nil && err == nil {
-			err = fmt.Errorf("close aws config file: %w", closeErr)
-		}
-	}()
-
-	profileSet := make(map[string]struct{})
-	scanner := bufio.NewScanner(file)
-	for scanner.Scan() {
-		line := strings.TrimSpace(scanner.Text())
-		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";") {
-			continue
-		}
-
-		if !strings.HasPrefix(line, "[") || !strings.HasSuffix(line, "]") {
-			continue
-		}
-
-		section := strings.TrimSpace(strings.TrimSuffix(strings.TrimPrefix(line, "["), "]"))
-
-		// Only include [profile xxx] sections.
-		if !strings.HasPrefix(section, "profile ") {
-			continue
-		}
+	return namedProfiles(profiles), nil
+}
 
-		name := strings.TrimSpace(strings.TrimPrefix(section, "profile "))
-		if name == "" {
-			continue
+// namedProfiles returns profiles without the default profile and region
+// variants, which reach the same clusters as the profile they copy, sorted by
+// name.
+func namedProfiles(profiles []core.AWSProfile) []core.AWSProfile {
+	named := make([]core.AWSProfile, 0, len(profiles))
+	for _, profile := range profiles {
+		if profile.Name != "default" && profile.VariantOf == "" {
+			named = append(named, profile)
 		}
-
-		profileSet[name] = struct{}{}
-	}
-
-	if err := scanner.Err(); err != nil {
-		return nil, fmt.Errorf("read aws config file: %w", err)
 	}
-
-	return sortedKeys(profileSet), nil
+	sort.Slice(named, func(i, j int) bool { return named[i].Name < named[j].Name })
+	return named
 }
 
-func resolveRegions(ctx context.Context, regions []string, configFile, profile string, vaultCreds map[string]*credentialProcessOutput, logger *slog.Logger) ([]string, error) {
+// resolveRegions expands the configured regions. The "all" keyword is resolved
+// with EC2 DescribeRegions, at ec2Endpoint when it is not empty, and the
+// result is cached per profile.
+func resolveRegions(ctx context.Context, regions []string, configFile, ec2Endpoint, profile string, vaultCreds map[string]*credentialProcessOutput, logger *slog.Logger, cache *core.ProviderCache) ([]string, error) {
 	if len(regions) == 0 {
 		return nil, errRegionsEmpty
 	}
 
 	for _, r := range regions {
-		if strings.EqualFold(strings.TrimSpace(r), "all") {
-			logger.Debug("fetching all enabled AWS regions", "profile", profile)
-			return fetchAllRegions(ctx, configFile, profile, vaultCreds)
+		if !strings.EqualFold(strings.TrimSpace(r), "all") {
+			continue
 		}
+
+		key := core.CacheKey{Profile: profile, Region: "all"}
+		var all []string
+		found, err := cache.Load(key, &all)
+		if err != nil || found {
+			return all, err
+		}
+
+		logger.Debug("fetching all enabled AWS regions", "profile", profile)
+		all, err = fetchAllRegions(ctx, configFile, ec2Endpoint, profile, vaultCreds)
+		if err != nil {
+			return nil, err
+		}
+		if err := cache.Store(key, all); err != nil {
+			logger.Warn("failed to cache regions", "error", err)
+		}
+		return all, nil
 	}
 
 	return normalizeRegions(regions)
@@ -442,7 +575,7 @@ func normalizeRegions(regions []string) ([]string, error) {
 	return sortedKeys(set), nil
 }
 
-func fetchAllRegions(ctx context.Context, configFile, profile string, vaultCreds map[string]*credentialProcessOutput) ([]string, error) {
+func fetchAllRegions(ctx context.Context, configFile, ec2Endpoint, profile string, vaultCreds map[string]*credentialProcessOutput) ([]string, error) {
 	var client RegionLister
 
 	if regionListerFactory != nil {
@@ -479,9 +612,18 @@ func fetchAllRegions(ctx context.Context, configFile, profile string, vaultCreds
 			return nil, fmt.Errorf("load aws config for region discovery: %w", err)
 		}
 
-		client = ec2.NewFromConfig(awsCfg)
+		client = ec2.NewFromConfig(awsCfg, func(opts *ec2.Options) {
+			if ec2Endpoint != "" {
+				opts.BaseEndpoint = aws.String(ec2Endpoint)
+			}
+		})
 	}
 
+	return describeRegions(ctx, client)
+}
+
+// describeRegions lists the regions enabled for the account behind client.
+func describeRegions(ctx context.Context, client RegionLister) ([]string, error) {
 	out, err := client.DescribeRegions(ctx, &ec2.DescribeRegionsInput{
 		AllRegions: aws.Bool(false),
 		Filters: []ec2types.Filter{
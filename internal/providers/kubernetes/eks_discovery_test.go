@@ -6,6 +6,8 @@ import (
 	"fmt"
 	"io"
 	"log/slog"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
 	"reflect"
@@ -14,11 +16,13 @@ import (
 	"testing"
 	"time"
 
+	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/ec2"
 	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
 	"github.com/aws/aws-sdk-go-v2/service/eks"
 	"github.com/aws/aws-sdk-go-v2/service/eks/types"
 	"github.com/aws/smithy-go"
+	"github.com/jmreicha/cfgctl/internal/core"
 )
 
 type mockEKSClient struct {
@@ -259,7 +263,7 @@ func (e *mockAPIError) ErrorFault() smithy.ErrorFault { return smithy.FaultUnkno
 func TestResolveRegionsExplicit(t *testing.T) {
 	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
 
-	regions, err := resolveRegions(context.Background(), []string{"us-west-2", "eu-west-1"}, "", "test", nil, logger)
+	regions, err := resolveRegions(context.Background(), []string{"us-west-2", "eu-west-1"}, "", "", "test", nil, logger, nil)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -288,7 +292,7 @@ func TestResolveRegionsAllKeyword(t *testing.T) {
 	}
 	defer func() { regionListerFactory = oldFactory }()
 
-	regions, err := resolveRegions(context.Background(), []string{"all"}, "", "test-profile", nil, logger)
+	regions, err := resolveRegions(context.Background(), []string{"all"}, "", "", "test-profile", nil, logger, nil)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -316,7 +320,7 @@ func TestResolveRegionsAllCaseInsensitive(t *testing.T) {
 	defer func() { regionListerFactory = oldFactory }()
 
 	for _, keyword := range []string{"ALL", "All", " all "} {
-		regions, err := resolveRegions(context.Background(), []string{keyword}, "", "p", nil, logger)
+		regions, err := resolveRegions(context.Background(), []string{keyword}, "", "", "p", nil, logger, nil)
 		if err != nil {
 			t.Fatalf("keyword %q: unexpected error: %v", keyword, err)
 		}
@@ -349,7 +353,7 @@ func TestResolveRegionsAllWithVaultCreds(t *testing.T) {
 		"myprofile": {AccessKeyID: "AKIA", SecretAccessKey: "secret", SessionToken: "tok"},
 	}
 
-	regions, err := resolveRegions(context.Background(), []string{"all"}, "", "myprofile", vaultCreds, logger)
+	regions, err := resolveRegions(context.Background(), []string{"all"}, "", "", "myprofile", vaultCreds, logger, nil)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -373,7 +377,7 @@ func TestFetchAllRegionsError(t *testing.T) {
 	}
 	defer func() { regionListerFactory = oldFactory }()
 
-	_, err := resolveRegions(context.Background(), []string{"all"}, "", "p", nil, logger)
+	_, err := resolveRegions(context.Background(), []string{"all"}, "", "", "p", nil, logger, nil)
 	if err == nil {
 		t.Fatal("expected error, got nil")
 	}
@@ -614,6 +618,39 @@ aws_access_key_id = prod
 	})
 }
 
+func TestResolveProfilesPrefersInventory(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config")
+	if err := writeFixture(configPath, "[profile from-file]\nsso_session = cfgctl\n"); err != nil {
+		t.Fatalf("writeFixture failed: %v", err)
+	}
+
+	inventory := &core.AWSProfileInventory{}
+	inventory.Publish(configPath, []core.AWSProfile{
+		{Name: "default"},
+		{Name: "prod-admin", RoleName: "AdminAccess"},
+		{Name: "prod-admin@eu-west-1", RoleName: "AdminAccess", VariantOf: "prod-admin"},
+	})
+
+	profiles, err := resolveProfiles(configPath, "", inventory)
+	if err != nil {
+		t.Fatalf("resolveProfiles failed: %v", err)
+	}
+	if got := core.AWSProfileNames(profiles); !reflect.DeepEqual(got, []string{"prod-admin"}) {
+		t.Fatalf("profiles = %v, want [prod-admin]", got)
+	}
+
+	// Profiles published for another file are ignored.
+	other := &core.AWSProfileInventory{}
+	other.Publish(configPath+".other", []core.AWSProfile{{Name: "elsewhere"}})
+	profiles, err = resolveProfiles(configPath, "", other)
+	if err != nil {
+		t.Fatalf("resolveProfiles failed: %v", err)
+	}
+	if got := core.AWSProfileNames(profiles); !reflect.DeepEqual(got, []string{"from-file"}) {
+		t.Fatalf("profiles = %v, want [from-file]", got)
+	}
+}
+
 func TestParseConfigFileProfiles(t *testing.T) {
 	t.Run("empty config file path", func(t *testing.T) {
 		_, err := parseConfigFileProfiles("")
@@ -659,7 +696,7 @@ region = us-east-1
 		}
 
 		expected := []string{"prod", "staging"}
-		if !reflect.DeepEqual(profiles, expected) {
+		if !reflect.DeepEqual(core.AWSProfileNames(profiles), expected) {
 			t.Errorf("profiles = %v, want %v", profiles, expected)
 		}
 	})
@@ -688,7 +725,7 @@ sso_session = test
 		}
 
 		expected := []string{"dev", "prod"}
-		if !reflect.DeepEqual(profiles, expected) {
+		if !reflect.DeepEqual(core.AWSProfileNames(profiles), expected) {
 			t.Errorf("profiles = %v, want %v", profiles, expected)
 		}
 	})
@@ -718,8 +755,22 @@ sso_session = test
 	})
 }
 
-func TestDiscoverClustersForProfileRegionAccessDeniedList(t *testing.T) {
+func TestDiscoverEKSClustersReportsProgress(t *testing.T) {
+	tmpDir := t.TempDir()
+	configData, err := readFixture("config_valid")
+	if err != nil {
+		t.Fatalf("readFixture failed: %v", err)
+	}
+	configPath := filepath.Join(tmpDir, "config")
+	if err := writeFixture(configPath, string(configData)); err != nil {
+		t.Fatalf("writeFixture failed: %v", err)
+	}
+
 	cfg := DefaultConfig()
+	cfg.AWS.ConfigFile = configPath
+	cfg.AWS.CredentialsFile = ""
+	cfg.AWS.Regions = []string{"us-west-2", "us-east-1"}
+	cfg.AWS.ParallelWorkers = 2
 	cfg.AWS.Timeout = 100 * time.Millisecond
 
 	factory := func(_ context.Context, _, _ string) (EKSClient, error) {
@@ -729,18 +780,66 @@ func TestDiscoverClustersForProfileRegionAccessDeniedList(t *testing.T) {
 	}
 
 	var (
-		clusters []DiscoveredCluster
-		warnings []string
-		mu       sync.Mutex
+		mu     sync.Mutex
+		events []core.ProgressEvent
 	)
+	progress := core.ProgressFunc(func(event core.ProgressEvent) {
+		mu.Lock()
+		defer mu.Unlock()
+		events = append(events, event)
+	})
+
+	_, warnings, err := discoverEKSClusters(context.Background(), cfg, factory, nil, nil, progress, nil, nil)
+	if err != nil {
+		t.Fatalf("discoverEKSClusters failed: %v", err)
+	}
+
+	var steps, counts, warningEvents, maxCurrent int
+	for _, event := range events {
+		switch event.Kind {
+		case core.ProgressStep:
+			steps++
+		case core.ProgressCount:
+			counts++
+			if event.Total != 4 {
+				t.Errorf("count total = %d, want 4", event.Total)
+			}
+			maxCurrent = max(maxCurrent, event.Current)
+		case core.ProgressWarning:
+			warningEvents++
+		}
+	}
+
+	if counts != 4 || maxCurrent != 4 {
+		t.Errorf("counts = %d, max current = %d, want 4 and 4", counts, maxCurrent)
+	}
+	if steps < 4 {
+		t.Errorf("steps = %d, want at least 4", steps)
+	}
+	if warningEvents != len(warnings) {
+		t.Errorf("warning events = %d, warnings = %d", warningEvents, len(warnings))
+	}
+}
+
+func TestDiscoverClustersForProfileRegionAccessDeniedList(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.AWS.Timeout = 100 * time.Millisecond
 
+	factory := func(_ context.Context, _, _ string) (EKSClient, error) {
+		return &mockEKSClient{
+			listErr: &mockAPIError{code: "AccessDeniedException", message: "not allowed"},
+		}, nil
+	}
+
+	state := &discoveryState{}
 	err := discoverClustersForProfileRegion(
 		context.Background(), cfg, factory, "test-profile", "us-west-2", "",
-		&mu, &clusters, &warnings,
+		state,
 	)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
+	clusters, warnings := state.clusters, state.warnings
 
 	if len(clusters) != 0 {
 		t.Errorf("expected 0 clusters, got %d", len(clusters))
@@ -764,19 +863,15 @@ func TestDiscoverClustersForProfileRegionAccessDeniedDescribe(t *testing.T) {
 		}, nil
 	}
 
-	var (
-		clusters []DiscoveredCluster
-		warnings []string
-		mu       sync.Mutex
-	)
-
+	state := &discoveryState{}
 	err := discoverClustersForProfileRegion(
 		context.Background(), cfg, factory, "test-profile", "us-west-2", "",
-		&mu, &clusters, &warnings,
+		state,
 	)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
+	clusters, warnings := state.clusters, state.warnings
 
 	if len(clusters) != 0 {
 		t.Errorf("expected 0 clusters, got %d", len(clusters))
@@ -815,19 +910,15 @@ func TestDiscoverClustersForProfileRegionSetsAuthMode(t *testing.T) {
 		}, nil
 	}
 
-	var (
-		clusters []DiscoveredCluster
-		warnings []string
-		mu       sync.Mutex
-	)
-
+	state := &discoveryState{}
 	err := discoverClustersForProfileRegion(
 		context.Background(), cfg, factory, "prod", "us-west-2", authModeAWSVault,
-		&mu, &clusters, &warnings,
+		state,
 	)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
+	clusters := state.clusters
 
 	if len(clusters) != 1 {
 		t.Fatalf("expected 1 cluster, got %d", len(clusters))
@@ -837,6 +928,52 @@ func TestDiscoverClustersForProfileRegionSetsAuthMode(t *testing.T) {
 	}
 }
 
+func TestDiscoverClustersForProfileRegionUsesCache(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.AWS.Timeout = 100 * time.Millisecond
+
+	clusterName := "cached"
+	endpoint := "https://cached.eks.amazonaws.com"
+	calls := 0
+	factory := func(_ context.Context, _, _ string) (EKSClient, error) {
+		calls++
+		return &mockEKSClient{
+			listOutput: &eks.ListClustersOutput{Clusters: []string{clusterName}},
+			outputs: map[string]*eks.DescribeClusterOutput{
+				clusterName: {Cluster: &types.Cluster{Name: &clusterName, Endpoint: &endpoint}},
+			},
+		}, nil
+	}
+
+	cache, err := core.NewCache(t.TempDir(), core.CacheDefault)
+	if err != nil {
+		t.Fatalf("NewCache failed: %v", err)
+	}
+
+	for range 2 {
+		state := &discoveryState{cache: cache.ForProvider(ProviderName, time.Hour)}
+		if err := discoverClustersForProfileRegion(context.Background(), cfg, factory, "prod", "us-west-2", "", state); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(state.clusters) != 1 || state.clusters[0].Endpoint != endpoint {
+			t.Fatalf("clusters = %+v", state.clusters)
+		}
+	}
+	if calls != 1 {
+		t.Fatalf("factory calls = %d, want 1", calls)
+	}
+
+	offline, err := core.NewCache(t.TempDir(), core.CacheOffline)
+	if err != nil {
+		t.Fatalf("NewCache failed: %v", err)
+	}
+	state := &discoveryState{cache: offline.ForProvider(ProviderName, time.Hour)}
+	err = discoverClustersForProfileRegion(context.Background(), cfg, factory, "prod", "us-west-2", "", state)
+	if !errors.Is(err, core.ErrCacheMiss) {
+		t.Fatalf("expected ErrCacheMiss offline, got %v", err)
+	}
+}
+
 func TestIsAccessDenied(t *testing.T) {
 	tests := []struct {
 		name string
@@ -891,7 +1028,7 @@ func TestFetchAllRegionsWithVaultCreds(t *testing.T) {
 		"testprofile": {AccessKeyID: "AKIA", SecretAccessKey: "secret", SessionToken: "tok"},
 	}
 
-	regions, err := fetchAllRegions(context.Background(), "/config", "testprofile", vaultCreds)
+	regions, err := fetchAllRegions(context.Background(), "/config", "", "testprofile", vaultCreds)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -910,7 +1047,7 @@ func TestFetchAllRegionsFactoryError(t *testing.T) {
 	}
 	defer func() { regionListerFactory = oldFactory }()
 
-	_, err := fetchAllRegions(context.Background(), "", "profile", nil)
+	_, err := fetchAllRegions(context.Background(), "", "", "profile", nil)
 	if err == nil {
 		t.Fatal("expected error, got nil")
 	}
@@ -933,7 +1070,7 @@ func TestFetchAllRegionsNilRegionName(t *testing.T) {
 	}
 	defer func() { regionListerFactory = oldFactory }()
 
-	regions, err := fetchAllRegions(context.Background(), "", "p", nil)
+	regions, err := fetchAllRegions(context.Background(), "", "", "p", nil)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -943,61 +1080,52 @@ func TestFetchAllRegionsNilRegionName(t *testing.T) {
 	}
 }
 
-func TestResolveRegionsEmpty(t *testing.T) {
-	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
-	_, err := resolveRegions(context.Background(), nil, "", "p", nil, logger)
-	if err == nil {
-		t.Fatal("expected error for empty regions, got nil")
+func TestFetchAllRegionsEndpointURL(t *testing.T) {
+	var action string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = r.ParseForm()
+		action = r.Form.Get("Action")
+		_, _ = w.Write([]byte(`<DescribeRegionsResponse xmlns="http://ec2.amazonaws.com/doc/2016-11-15/">
+<regionInfo><item><regionName>us-west-2</regionName></item><item><regionName>eu-west-1</regionName></item></regionInfo>
+</DescribeRegionsResponse>`))
+	}))
+	defer server.Close()
+
+	vaultCreds := map[string]*credentialProcessOutput{
+		"p": {AccessKeyID: "test", SecretAccessKey: "test"},
+	}
+
+	regions, err := fetchAllRegions(context.Background(), "", server.URL, "p", vaultCreds)
+	if err != nil {
+		t.Fatalf("fetchAllRegions failed: %v", err)
+	}
+	if action != "DescribeRegions" {
+		t.Fatalf("action = %q, want DescribeRegions", action)
+	}
+	if want := []string{"eu-west-1", "us-west-2"}; !reflect.DeepEqual(regions, want) {
+		t.Errorf("regions = %v, want %v", regions, want)
 	}
 }
 
-func TestFilterProfilesByRole(t *testing.T) {
-	profiles := []string{
-		"prod/adminaccess",
-		"prod/readonly",
-		"staging/adminaccess",
-		"staging/poweruser",
-		"simple-profile",
+func TestNewEKSClientFactoryEndpointURL(t *testing.T) {
+	configFile := filepath.Join(t.TempDir(), "config")
+	if err := writeFixture(configFile, "[profile dev]\nregion = us-west-2\n"); err != nil {
+		t.Fatalf("writeFixture failed: %v", err)
 	}
 
-	tests := []struct {
-		name     string
-		roles    []string
-		expected []string
-	}{
-		{
-			name:     "single role",
-			roles:    []string{"adminaccess"},
-			expected: []string{"prod/adminaccess", "staging/adminaccess"},
-		},
-		{
-			name:     "multiple roles",
-			roles:    []string{"adminaccess", "readonly"},
-			expected: []string{"prod/adminaccess", "prod/readonly", "staging/adminaccess"},
-		},
-		{
-			name:     "case insensitive",
-			roles:    []string{"AdminAccess"},
-			expected: []string{"prod/adminaccess", "staging/adminaccess"},
-		},
-		{
-			name:     "no slash in profile",
-			roles:    []string{"simple-profile"},
-			expected: []string{"simple-profile"},
-		},
-		{
-			name:     "no match",
-			roles:    []string{"nonexistent"},
-			expected: nil,
-		},
+	client, err := NewEKSClientFactory(configFile, "http://localhost:4566")(context.Background(), "dev", "us-west-2")
+	if err != nil {
+		t.Fatalf("NewEKSClientFactory failed: %v", err)
+	}
+	if got := aws.ToString(client.(*eks.Client).Options().BaseEndpoint); got != "http://localhost:4566" {
+		t.Errorf("BaseEndpoint = %q", got)
 	}
+}
 
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			result := filterProfilesByRole(profiles, tt.roles)
-			if !reflect.DeepEqual(result, tt.expected) {
-				t.Errorf("filterProfilesByRole() = %v, want %v", result, tt.expected)
-			}
-		})
+func TestResolveRegionsEmpty(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	_, err := resolveRegions(context.Background(), nil, "", "", "p", nil, logger, nil)
+	if err == nil {
+		t.Fatal("expected error for empty regions, got nil")
 	}
 }
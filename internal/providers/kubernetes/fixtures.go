@@ -0,0 +1,97 @@
+package kubernetes
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/aws/aws-sdk-go-v2/service/eks"
+	"github.com/aws/aws-sdk-go-v2/service/eks/types"
+	"github.com/jmreicha/cfgctl/internal/core"
+)
+
+var errFixtureClustersEmpty = errors.New("demo fixtures have no clusters")
+
+// fixtureProfiles returns the profiles named by the fixture clusters.
+func fixtureProfiles(fixtures *core.DemoFixtures) ([]core.AWSProfile, error) {
+	names := fixtures.ClusterProfiles()
+	if len(names) == 0 {
+		return nil, errFixtureClustersEmpty
+	}
+
+	profiles := make([]core.AWSProfile, 0, len(names))
+	for _, name := range names {
+		profiles = append(profiles, core.AWSProfile{Name: name})
+	}
+	return profiles, nil
+}
+
+// resolveFixtureRegions expands the configured regions like resolveRegions,
+// with "all" meaning every region a fixture cluster is in.
+func resolveFixtureRegions(ctx context.Context, regions []string, fixtures *core.DemoFixtures) ([]string, error) {
+	for _, r := range regions {
+		if strings.EqualFold(strings.TrimSpace(r), "all") {
+			return describeRegions(ctx, fixtureRegionLister{regions: fixtures.ClusterRegions()})
+		}
+	}
+	return normalizeRegions(regions)
+}
+
+// fixtureRegionLister answers DescribeRegions from demo fixtures.
+type fixtureRegionLister struct {
+	regions []string
+}
+
+func (l fixtureRegionLister) DescribeRegions(context.Context, *ec2.DescribeRegionsInput, ...func(*ec2.Options)) (*ec2.DescribeRegionsOutput, error) {
+	regions := make([]ec2types.Region, 0, len(l.regions))
+	for _, region := range l.regions {
+		regions = append(regions, ec2types.Region{RegionName: aws.String(region)})
+	}
+	return &ec2.DescribeRegionsOutput{Regions: regions}, nil
+}
+
+// fixtureEKSClient answers EKS calls for one profile and region from demo
+// fixtures.
+type fixtureEKSClient struct {
+	clusters []core.ClusterFixture
+}
+
+// fixtureEKSClientFactory returns a factory for clients that serve the
+// fixture clusters of the requested profile and region.
+func fixtureEKSClientFactory(fixtures *core.DemoFixtures) EKSClientFactory {
+	return func(_ context.Context, profile, region string) (EKSClient, error) {
+		client := &fixtureEKSClient{}
+		for _, cluster := range fixtures.Clusters {
+			if cluster.Profile == profile && cluster.Region == region {
+				client.clusters = append(client.clusters, cluster)
+			}
+		}
+		return client, nil
+	}
+}
+
+func (c *fixtureEKSClient) ListClusters(context.Context, *eks.ListClustersInput, ...func(*eks.Options)) (*eks.ListClustersOutput, error) {
+	names := make([]string, 0, len(c.clusters))
+	for _, cluster := range c.clusters {
+		names = append(names, cluster.Name)
+	}
+	return &eks.ListClustersOutput{Clusters: names}, nil
+}
+
+func (c *fixtureEKSClient) DescribeCluster(_ context.Context, params *eks.DescribeClusterInput, _ ...func(*eks.Options)) (*eks.DescribeClusterOutput, error) {
+	for _, cluster := range c.clusters {
+		if cluster.Name != aws.ToString(params.Name) {
+			continue
+		}
+		return &eks.DescribeClusterOutput{Cluster: &types.Cluster{
+			Name:                 aws.String(cluster.Name),
+			Endpoint:             aws.String(cluster.Endpoint),
+			CertificateAuthority: &types.Certificate{Data: aws.String(cluster.CertificateAuthority)},
+		}}, nil
+	}
+	return nil, fmt.Errorf("cluster %s is not in the demo fixtures", aws.ToString(params.Name))
+}
@@ -0,0 +1,67 @@
+package kubernetes
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/jmreicha/cfgctl/internal/core"
+)
+
+func TestDiscoverEKSClustersFromFixtures(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.AWS.ConfigFile = "/nonexistent/config"
+	cfg.AWS.Regions = []string{"all"}
+	cfg.AWS.Timeout = time.Second
+	cfg.Fixtures = &core.DemoFixtures{Clusters: []core.ClusterFixture{
+		{Profile: "prod/AdminAccess", Region: "us-west-2", Name: "web", Endpoint: "https://web.example.com", CertificateAuthority: "ZGVtbw=="},
+		{Profile: "prod/AdminAccess", Region: "eu-west-1", Name: "batch", Endpoint: "https://batch.example.com"},
+		{Profile: "dev/AdminAccess", Region: "us-west-2", Name: "sandbox", Endpoint: "https://sandbox.example.com"},
+	}}
+
+	clusters, warnings, err := DiscoverEKSClusters(context.Background(), cfg, nil, nil)
+	if err != nil {
+		t.Fatalf("DiscoverEKSClusters failed: %v", err)
+	}
+	if len(warnings) != 0 {
+		t.Fatalf("warnings = %v", warnings)
+	}
+
+	want := []DiscoveredCluster{
+		{Profile: "dev/AdminAccess", Region: "us-west-2", Name: "sandbox", Endpoint: "https://sandbox.example.com"},
+		{Profile: "prod/AdminAccess", Region: "eu-west-1", Name: "batch", Endpoint: "https://batch.example.com"},
+		{Profile: "prod/AdminAccess", Region: "us-west-2", Name: "web", Endpoint: "https://web.example.com", CAData: []byte("demo")},
+	}
+	if !reflect.DeepEqual(clusters, want) {
+		t.Fatalf("clusters = %+v, want %+v", clusters, want)
+	}
+}
+
+func TestDiscoverEKSClustersFromFixturesRegions(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.AWS.Regions = []string{"eu-west-1"}
+	cfg.AWS.Timeout = time.Second
+	cfg.Fixtures = &core.DemoFixtures{Clusters: []core.ClusterFixture{
+		{Profile: "prod/AdminAccess", Region: "us-west-2", Name: "web", Endpoint: "https://web.example.com"},
+		{Profile: "prod/AdminAccess", Region: "eu-west-1", Name: "batch", Endpoint: "https://batch.example.com"},
+	}}
+
+	clusters, _, err := DiscoverEKSClusters(context.Background(), cfg, nil, nil)
+	if err != nil {
+		t.Fatalf("DiscoverEKSClusters failed: %v", err)
+	}
+	if len(clusters) != 1 || clusters[0].Name != "batch" {
+		t.Fatalf("clusters = %+v, want only batch", clusters)
+	}
+}
+
+func TestDiscoverEKSClustersFromEmptyFixtures(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Fixtures = &core.DemoFixtures{}
+
+	if _, _, err := DiscoverEKSClusters(context.Background(), cfg, nil, nil); !errors.Is(err, errFixtureClustersEmpty) {
+		t.Fatalf("error = %v, want %v", err, errFixtureClustersEmpty)
+	}
+}
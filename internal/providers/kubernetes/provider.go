@@ -9,6 +9,7 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/jmreicha/cfgctl/internal/core"
 	"k8s.io/client-go/tools/clientcmd"
@@ -94,10 +95,12 @@ func (p *Provider) Generate(ctx context.Context, opts *core.GenerateOptions) (*c
 		"merge_enabled", p.config.MergeEnabled,
 	)
 
-	discovered, discoveryWarnings, err := p.discoverClusters(ctx)
+	start := time.Now()
+	discovered, discoveryWarnings, err := p.discoverClusters(ctx, result, opts)
 	if err != nil {
 		return nil, err
 	}
+	result.RecordPhase(core.TimingDiscover, start)
 	result.Warnings = append(result.Warnings, discoveryWarnings...)
 
 	for _, c := range discovered {
@@ -109,10 +112,12 @@ func (p *Provider) Generate(ctx context.Context, opts *core.GenerateOptions) (*c
 		)
 	}
 
+	start = time.Now()
 	mergeConfig, mergeFiles, err := p.buildKubeconfig(discovered)
 	if err != nil {
 		return nil, err
 	}
+	result.RecordPhase(core.TimingRender, start)
 
 	if mergeConfig == nil {
 		result.Warnings = append(result.Warnings, "no kubeconfig data generated")
@@ -156,9 +161,11 @@ func (p *Provider) Generate(ctx context.Context, opts *core.GenerateOptions) (*c
 
 	outputPath := p.config.ConfigPath
 	p.logger.Debug("writing kubeconfig", "path", outputPath)
+	start = time.Now()
 	if err := p.writeKubeconfig(outputPath, mergeConfig, opts, result); err != nil {
 		return nil, err
 	}
+	result.RecordPhase(core.TimingWrite, start)
 
 	return result, nil
 }
@@ -179,12 +186,22 @@ func (p *Provider) validateAndPrepare(opts *core.GenerateOptions) error {
 	return p.config.Validate()
 }
 
-func (p *Provider) discoverClusters(ctx context.Context) ([]DiscoveredCluster, []string, error) {
+func (p *Provider) discoverClusters(ctx context.Context, result *core.Result, opts *core.GenerateOptions) ([]DiscoveredCluster, []string, error) {
 	if p.config.MergeOnly {
 		return nil, nil, nil
 	}
 
-	return DiscoverEKSClusters(ctx, p.config, nil, p.logger)
+	var (
+		progress  core.ProgressReporter
+		cache     *core.ProviderCache
+		inventory *core.AWSProfileInventory
+	)
+	if opts != nil {
+		progress = opts.Progress
+		cache = opts.Cache
+		inventory = opts.AWSProfiles
+	}
+	return discoverEKSClusters(ctx, p.config, nil, p.logger, result, progress, cache, inventory)
 }
 
 func (p *Provider) buildKubeconfig(discovered []DiscoveredCluster) (*api.Config, []string, error) {
@@ -257,6 +274,23 @@ func (p *Provider) writeKubeconfig(outputPath string, mergeConfig *api.Config, o
 	return nil
 }
 
+// WatchPaths returns the AWS config file used for discovery and, when merging
+// is enabled, the directory of kubeconfig files to merge.
+func (p *Provider) WatchPaths() []string {
+	if p.config == nil || !p.config.Enabled {
+		return nil
+	}
+
+	var paths []string
+	if !p.config.MergeOnly {
+		paths = append(paths, p.config.AWS.ConfigFile)
+	}
+	if p.config.MergeEnabled || p.config.MergeOnly {
+		paths = append(paths, p.config.Merge.SourceDir)
+	}
+	return paths
+}
+
 // NeedsBackup reports whether a backup should be created before generation.
 func (p *Provider) NeedsBackup(opts *core.GenerateOptions) (bool, error) {
 	if p.config == nil {
@@ -713,7 +713,7 @@ func TestProvider_discoverClusters(t *testing.T) {
 		cfg.MergeOnly = true
 		provider := NewProvider(cfg)
 
-		clusters, warnings, err := provider.discoverClusters(context.Background())
+		clusters, warnings, err := provider.discoverClusters(context.Background(), nil, nil)
 		if err != nil {
 			t.Fatalf("unexpected error: %v", err)
 		}
@@ -963,3 +963,31 @@ func TestProvider_validateAndPrepare(t *testing.T) {
 		}
 	})
 }
+
+func TestProvider_WatchPaths(t *testing.T) {
+	config := DefaultConfig()
+	config.Enabled = true
+	config.AWS.ConfigFile = "/home/user/.aws/config"
+	config.Merge.SourceDir = "/home/user/.kube"
+
+	var _ core.Watchable = NewProvider(config)
+
+	if got := NewProvider(config).WatchPaths(); len(got) != 1 || got[0] != config.AWS.ConfigFile {
+		t.Fatalf("WatchPaths() = %v, want only the AWS config file", got)
+	}
+
+	config.MergeEnabled = true
+	if got := NewProvider(config).WatchPaths(); len(got) != 2 || got[1] != config.Merge.SourceDir {
+		t.Fatalf("WatchPaths() with merge = %v", got)
+	}
+
+	config.MergeOnly = true
+	if got := NewProvider(config).WatchPaths(); len(got) != 1 || got[0] != config.Merge.SourceDir {
+		t.Fatalf("WatchPaths() merge-only = %v", got)
+	}
+
+	config.Enabled = false
+	if got := NewProvider(config).WatchPaths(); len(got) != 0 {
+		t.Fatalf("WatchPaths() disabled = %v", got)
+	}
+}
@@ -32,6 +32,9 @@ type Config struct {
 
 	// Hosts contains SSH host configurations
 	Hosts []HostConfig `yaml:"hosts"`
+
+	// Fixtures replaces the shell history files with demo fixtures when set.
+	Fixtures *core.DemoFixtures `yaml:"-"`
 }
 
 // HostConfig represents configuration for a single SSH host.
@@ -31,40 +31,64 @@ var (
 	hostOnlyRegex     = regexp.MustCompile(`(?:^|\s)([a-zA-Z0-9._-]+)(?:\s|$)`)
 )
 
-// ParseHistoryFiles reads shell history files and extracts SSH commands.
-// It looks for ~/.zsh_history and ~/.bash_history by default.
-func ParseHistoryFiles() ([]Command, error) {
+// HistoryFilePaths returns the shell history files read by ParseHistoryFiles.
+func HistoryFilePaths() ([]string, error) {
 	home, err := os.UserHomeDir()
 	if err != nil {
 		return nil, fmt.Errorf("failed to get home directory: %w", err)
 	}
 
-	historyFiles := []string{
+	return []string{
 		filepath.Join(home, ".zsh_history"),
 		filepath.Join(home, ".bash_history"),
-	}
+	}, nil
+}
 
-	commands := make([]Command, 0)
-	seen := make(map[string]bool)
+// ParseHistoryFiles reads shell history files and extracts SSH commands.
+// It looks for ~/.zsh_history and ~/.bash_history by default.
+func ParseHistoryFiles() ([]Command, error) {
+	historyFiles, err := HistoryFilePaths()
+	if err != nil {
+		return nil, err
+	}
 
+	var commands []Command
 	for _, histFile := range historyFiles {
 		fileCommands, err := parseHistoryFile(histFile)
 		if err != nil {
 			// Skip files that don't exist or can't be read
 			continue
 		}
+		commands = append(commands, fileCommands...)
+	}
 
-		// Deduplicate commands based on hostname
-		for _, cmd := range fileCommands {
-			key := fmt.Sprintf("%s@%s:%d", cmd.User, cmd.Hostname, cmd.Port)
-			if !seen[key] {
-				seen[key] = true
-				commands = append(commands, cmd)
-			}
+	return dedupeCommands(commands), nil
+}
+
+// ParseHistoryLines extracts SSH commands from shell history lines, such as
+// those of demo fixtures.
+func ParseHistoryLines(lines []string) []Command {
+	var commands []Command
+	for _, line := range lines {
+		if cmd, ok := parseHistoryLine(line); ok {
+			commands = append(commands, cmd)
 		}
 	}
+	return dedupeCommands(commands)
+}
 
-	return commands, nil
+// dedupeCommands drops commands for a user, hostname, and port seen earlier.
+func dedupeCommands(commands []Command) []Command {
+	deduped := make([]Command, 0, len(commands))
+	seen := make(map[string]bool)
+	for _, cmd := range commands {
+		key := fmt.Sprintf("%s@%s:%d", cmd.User, cmd.Hostname, cmd.Port)
+		if !seen[key] {
+			seen[key] = true
+			deduped = append(deduped, cmd)
+		}
+	}
+	return deduped
 }
 
 // parseHistoryFile reads a single history file and extracts SSH commands.
@@ -83,18 +107,7 @@ func parseHistoryFile(path string) ([]Command, error) {
 	scanner := bufio.NewScanner(f)
 
 	for scanner.Scan() {
-		line := scanner.Text()
-
-		// Handle zsh extended history format: : timestamp:duration;command
-		if strings.HasPrefix(line, ":") {
-			parts := strings.SplitN(line, ";", 2)
-			if len(parts) == 2 {
-				line = parts[1]
-			}
-		}
-
-		cmd, ok := parseSSHCommand(line)
-		if ok {
+		if cmd, ok := parseHistoryLine(scanner.Text()); ok {
 			commands = append(commands, cmd)
 		}
 	}
@@ -106,6 +119,18 @@ func parseHistoryFile(path string) ([]Command, error) {
 	return commands, nil
 }
 
+// parseHistoryLine extracts an SSH command from one history line.
+func parseHistoryLine(line string) (Command, bool) {
+	// Handle zsh extended history format: : timestamp:duration;command
+	if strings.HasPrefix(line, ":") {
+		parts := strings.SplitN(line, ";", 2)
+		if len(parts) == 2 {
+			line = parts[1]
+		}
+	}
+	return parseSSHCommand(line)
+}
+
 // parseSSHCommand extracts SSH connection details from a command line.
 func parseSSHCommand(line string) (Command, bool) {
 	// Check if line contains an SSH command
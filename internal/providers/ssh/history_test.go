@@ -227,6 +227,25 @@ func TestParseHistoryFiles(t *testing.T) {
 	}
 }
 
+func TestParseHistoryLines(t *testing.T) {
+	commands := ParseHistoryLines([]string{
+		"ls -la",
+		": 1700000000:0;ssh -p 2222 deploy@bastion.example.com",
+		"ssh deploy@bastion.example.com -p 2222",
+		"ssh db.internal",
+	})
+
+	if len(commands) != 2 {
+		t.Fatalf("ParseHistoryLines() returned %d commands, want 2: %+v", len(commands), commands)
+	}
+	if commands[0].Hostname != "bastion.example.com" || commands[0].User != "deploy" || commands[0].Port != 2222 {
+		t.Errorf("first command = %+v", commands[0])
+	}
+	if commands[1].Hostname != "db.internal" || commands[1].Port != 22 {
+		t.Errorf("second command = %+v", commands[1])
+	}
+}
+
 func TestCommand_ConvertToHostConfig(t *testing.T) {
 	tests := []struct {
 		name string
@@ -5,8 +5,10 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"log/slog"
 	"os"
 	"path/filepath"
+	"time"
 
 	"github.com/jmreicha/cfgctl/internal/core"
 	"github.com/kevinburke/ssh_config"
@@ -26,6 +28,7 @@ var (
 type Provider struct {
 	// config holds provider-specific configuration
 	config *Config
+	logger *slog.Logger
 }
 
 func (c *Config) normalizedConfigPath() (string, error) {
@@ -88,13 +91,27 @@ func (c *Config) Validate() error {
 }
 
 // NewProvider creates a new SSH provider instance with the given configuration.
-func NewProvider(config *Config) *Provider {
+func NewProvider(config *Config, opts ...ProviderOption) *Provider {
 	if config == nil {
 		config = DefaultConfig()
 	}
 
-	return &Provider{
-		config: config,
+	p := &Provider{config: config, logger: slog.New(slog.NewTextHandler(os.Stderr, nil))}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
+}
+
+// ProviderOption configures a Provider.
+type ProviderOption func(*Provider)
+
+// WithLogger sets the logger for the provider.
+func WithLogger(logger *slog.Logger) ProviderOption {
+	return func(p *Provider) {
+		if logger != nil {
+			p.logger = logger
+		}
 	}
 }
 
@@ -154,11 +171,14 @@ func (p *Provider) Generate(_ context.Context, opts *core.GenerateOptions) (*cor
 		return result, nil
 	}
 
-	// Parse history files if enabled
-	if p.config.ParseHistory {
+	// Parse history files if enabled. Fixture history is always used.
+	if p.config.ParseHistory || p.config.Fixtures != nil {
+		start := time.Now()
 		if err := p.mergeHistoryHosts(result); err != nil {
 			result.Warnings = append(result.Warnings, fmt.Sprintf("failed to parse history: %v", err))
 		}
+		result.RecordPhase(core.TimingDiscover, start)
+		p.logger.Debug("merged shell history hosts", "hosts", len(p.config.Hosts))
 	}
 
 	if len(p.config.Hosts) == 0 && len(p.config.GlobalOptions) == 0 {
@@ -179,6 +199,7 @@ func (p *Provider) Generate(_ context.Context, opts *core.GenerateOptions) (*cor
 	}
 
 	// Parse existing config or create new one
+	start := time.Now()
 	var cfg *ssh_config.Config
 	if fileExists {
 		cfg, err = ParseConfig(configPath)
@@ -199,6 +220,7 @@ func (p *Provider) Generate(_ context.Context, opts *core.GenerateOptions) (*cor
 
 	// Add or update hosts from configuration
 	hostsAdded, hostsUpdated := p.upsertHosts(cfg, existingHosts, result)
+	result.RecordPhase(core.TimingRender, start)
 
 	if opts.DryRun {
 		result.Warnings = append(result.Warnings, "dry-run mode: no files were actually created")
@@ -208,6 +230,8 @@ func (p *Provider) Generate(_ context.Context, opts *core.GenerateOptions) (*cor
 	}
 
 	// Ensure config directory exists
+	p.logger.Debug("writing ssh config", "path", configPath)
+	start = time.Now()
 	if err := os.MkdirAll(p.config.ConfigPath, 0700); err != nil {
 		return nil, fmt.Errorf("failed to create config directory: %w", err)
 	}
@@ -216,6 +240,7 @@ func (p *Provider) Generate(_ context.Context, opts *core.GenerateOptions) (*cor
 	if err := WriteConfig(cfg, configPath); err != nil {
 		return nil, fmt.Errorf("failed to write config: %w", err)
 	}
+	result.RecordPhase(core.TimingWrite, start)
 
 	result.FilesCreated = append(result.FilesCreated, configPath)
 	result.Metadata["hosts_added"] = hostsAdded
@@ -286,11 +311,32 @@ func (p *Provider) Clean(_ context.Context) error {
 	return nil
 }
 
-// mergeHistoryHosts parses shell history and merges discovered hosts into the configuration.
-func (p *Provider) mergeHistoryHosts(result *core.Result) error {
-	historyCommands, err := ParseHistoryFiles()
+// WatchPaths returns the shell history files when history parsing is enabled.
+func (p *Provider) WatchPaths() []string {
+	if p.config == nil || !p.config.ParseHistory {
+		return nil
+	}
+
+	paths, err := HistoryFilePaths()
 	if err != nil {
-		return err
+		p.logger.Debug("cannot resolve history files", "error", err)
+		return nil
+	}
+	return paths
+}
+
+// mergeHistoryHosts parses shell history, or the fixture history when set,
+// and merges discovered hosts into the configuration.
+func (p *Provider) mergeHistoryHosts(result *core.Result) error {
+	var historyCommands []Command
+	if p.config.Fixtures != nil {
+		historyCommands = ParseHistoryLines(p.config.Fixtures.History)
+	} else {
+		var err error
+		historyCommands, err = ParseHistoryFiles()
+		if err != nil {
+			return err
+		}
 	}
 
 	// Convert history commands to host configs and add to config
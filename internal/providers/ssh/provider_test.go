@@ -2,7 +2,10 @@ package ssh
 
 import (
 	"context"
+	"io"
+	"log/slog"
 	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
@@ -312,6 +315,51 @@ func TestProvider_Clean(t *testing.T) {
 func TestProvider_InterfaceCompliance(_ *testing.T) {
 	// Compile-time check that Provider implements core.Provider
 	var _ core.Provider = (*Provider)(nil)
+	var _ core.Watchable = (*Provider)(nil)
+}
+
+func TestProvider_WatchPaths(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	config := DefaultConfig()
+	config.ParseHistory = false
+	if got := NewProvider(config).WatchPaths(); len(got) != 0 {
+		t.Fatalf("WatchPaths() without history parsing = %v", got)
+	}
+
+	config.ParseHistory = true
+	got := NewProvider(config).WatchPaths()
+	want := []string{home + "/.zsh_history", home + "/.bash_history"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Fatalf("WatchPaths() = %v, want %v", got, want)
+	}
+}
+
+func TestProvider_GenerateFixtureHistory(t *testing.T) {
+	// Real history files must not be read when fixtures are set.
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	if err := os.WriteFile(filepath.Join(home, ".bash_history"), []byte("ssh real.example.com\n"), 0o600); err != nil {
+		t.Fatalf("write history: %v", err)
+	}
+
+	config := &Config{
+		Enabled:    true,
+		ConfigPath: t.TempDir(),
+		Fixtures:   &core.DemoFixtures{History: []string{"ssh demo@bastion.example.com"}},
+	}
+
+	result, err := NewProvider(config).Generate(context.Background(), &core.GenerateOptions{DryRun: true})
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+	if got := result.Metadata["hosts_from_history"]; got != 1 {
+		t.Fatalf("hosts_from_history = %v, want 1", got)
+	}
+	if len(config.Hosts) != 1 || config.Hosts[0].Host != "bastion.example.com" || config.Hosts[0].User != "demo" {
+		t.Fatalf("hosts = %+v, want only the fixture host", config.Hosts)
+	}
 }
 
 // TestProvider_GenerateIntegration tests the full generation lifecycle with real filesystem.
@@ -715,3 +763,20 @@ func TestProvider_ValidateInvalidPaths(t *testing.T) {
 		})
 	}
 }
+
+func TestWithLogger(t *testing.T) {
+	t.Run("nil logger keeps default", func(t *testing.T) {
+		provider := NewProvider(nil, WithLogger(nil))
+		if provider.logger == nil {
+			t.Error("expected non-nil logger when WithLogger(nil) is used")
+		}
+	})
+
+	t.Run("non-nil logger is set", func(t *testing.T) {
+		customLogger := slog.New(slog.NewTextHandler(io.Discard, nil))
+		provider := NewProvider(nil, WithLogger(customLogger))
+		if provider.logger != customLogger {
+			t.Error("expected custom logger to be set")
+		}
+	})
+}
@@ -57,6 +57,10 @@ type Config struct {
 	// Enabled indicates whether this provider should be active.
 	Enabled bool `yaml:"enabled"`
 
+	// Fixtures replaces the AWS config file and the existing connection
+	// config with demo fixtures when set.
+	Fixtures *core.DemoFixtures `yaml:"-"`
+
 	// ProfileRegions maps profile names to per-profile region lists.
 	ProfileRegions map[string][]string `yaml:"profile_regions"`
 
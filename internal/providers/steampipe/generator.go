@@ -69,59 +69,6 @@ func resolveRegions(profile string, defaultRegions []string, profileRegions map[
 	return defaultRegions
 }
 
-// dedupeByAccount groups profiles by their account name (the part before the
-// first "/"), then selects one representative profile per account.
-//
-// Selection order:
-//  1. The first profile whose role (part after "/") matches an entry in
-//     preferredRoles (checked in preferredRoles order).
-//  2. Otherwise the first profile encountered for that account.
-//
-// The returned slice preserves the order of first occurrence per account.
-func dedupeByAccount(profiles []string, preferredRoles []string) []string {
-	type accountEntry struct {
-		profiles []string // all profiles for this account, in encounter order
-	}
-	seen := make(map[string]*accountEntry)
-	var order []string // account names in first-encounter order
-
-	for _, p := range profiles {
-		account := p
-		if idx := strings.Index(p, "/"); idx >= 0 {
-			account = p[:idx]
-		}
-		key := sanitizeProfileName(account, "")
-		if _, ok := seen[key]; !ok {
-			seen[key] = &accountEntry{}
-			order = append(order, key)
-		}
-		seen[key].profiles = append(seen[key].profiles, p)
-	}
-
-	result := make([]string, 0, len(order))
-	for _, key := range order {
-		entry := seen[key]
-		result = append(result, selectProfile(entry.profiles, preferredRoles))
-	}
-	return result
-}
-
-// selectProfile returns the best profile from a group that all share the same
-// account. It prefers profiles whose role matches an entry in preferredRoles
-// (checked in order), falling back to the first profile in the group.
-func selectProfile(profiles []string, preferredRoles []string) string {
-	for _, role := range preferredRoles {
-		for _, p := range profiles {
-			if idx := strings.Index(p, "/"); idx >= 0 {
-				if strings.EqualFold(p[idx+1:], role) {
-					return p
-				}
-			}
-		}
-	}
-	return profiles[0]
-}
-
 // connectionNameForProfile returns the connection name for a profile using
 // only the account portion (before "/"), so that all roles for the same
 // account map to a single connection name.
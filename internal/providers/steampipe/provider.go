@@ -2,13 +2,14 @@
 package steampipe
 
 import (
-	"bufio"
 	"context"
 	"errors"
 	"fmt"
+	"log/slog"
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/jmreicha/cfgctl/internal/core"
 )
@@ -21,14 +22,32 @@ var errProviderConfigNil = errors.New("steampipe provider configuration is nil")
 // Provider implements the core.Provider interface for steampipe configuration.
 type Provider struct {
 	config *Config
+	logger *slog.Logger
 }
 
 // NewProvider creates a new steampipe provider instance.
-func NewProvider(config *Config) *Provider {
+func NewProvider(config *Config, opts ...ProviderOption) *Provider {
 	if config == nil {
 		config = DefaultConfig()
 	}
-	return &Provider{config: config}
+
+	p := &Provider{config: config, logger: slog.New(slog.NewTextHandler(os.Stderr, nil))}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
+}
+
+// ProviderOption configures a Provider.
+type ProviderOption func(*Provider)
+
+// WithLogger sets the logger for the provider.
+func WithLogger(logger *slog.Logger) ProviderOption {
+	return func(p *Provider) {
+		if logger != nil {
+			p.logger = logger
+		}
+	}
 }
 
 // Name returns the unique identifier for this provider.
@@ -71,21 +90,18 @@ func (p *Provider) Generate(_ context.Context, opts *core.GenerateOptions) (*cor
 		return result, nil
 	}
 
-	// Resolve AWS config path.
-	awsConfigPath := p.config.AWSConfigPath
-	if awsConfigPath == "" {
-		home, err := os.UserHomeDir()
-		if err != nil {
-			return nil, fmt.Errorf("resolve home directory: %w", err)
-		}
-		awsConfigPath = filepath.Join(home, ".aws", "config")
+	awsConfigPath, err := p.awsConfigPath()
+	if err != nil {
+		return nil, err
 	}
 
 	// Read AWS profiles.
-	profiles, warn, err := parseAWSProfiles(awsConfigPath)
+	start := time.Now()
+	profiles, warn, err := p.awsProfiles(awsConfigPath, opts)
 	if err != nil {
 		return nil, err
 	}
+	p.logger.Debug("resolved aws profiles", "path", awsConfigPath, "count", len(profiles))
 	if warn != "" {
 		result.Warnings = append(result.Warnings, warn)
 		return result, nil
@@ -107,11 +123,14 @@ func (p *Provider) Generate(_ context.Context, opts *core.GenerateOptions) (*cor
 	}
 
 	// Deduplicate: one connection per AWS account.
-	profiles = dedupeByAccount(profiles, p.config.PreferredRoles)
+	profiles = core.DedupeAWSProfilesByAccount(profiles, p.config.PreferredRoles)
+	result.RecordPhase(core.TimingDiscover, start)
 
 	// Generate new managed blocks.
+	start = time.Now()
 	generated := make([]spcBlock, 0, len(profiles))
-	for _, profile := range profiles {
+	for _, awsProfile := range profiles {
+		profile := awsProfile.Name
 		connName := connectionNameForProfile(profile, p.config.ConnectionPrefix)
 		regions := resolveRegions(profile, p.config.Regions, p.config.ProfileRegions)
 		// Use only the account portion of the profile name (before "/") so
@@ -129,9 +148,14 @@ func (p *Provider) Generate(_ context.Context, opts *core.GenerateOptions) (*cor
 		})
 	}
 
-	// Merge with existing file if it exists.
+	// Merge with existing file if it exists. A demo run never reads it, so
+	// real connections stay out of the demo output.
 	var finalBlocks []spcBlock
-	existingContent, readErr := readFileIfExists(outputPath)
+	var existingContent string
+	var readErr error
+	if p.config.Fixtures == nil {
+		existingContent, readErr = readFileIfExists(outputPath)
+	}
 	switch {
 	case readErr != nil:
 		result.Warnings = append(result.Warnings, fmt.Sprintf("failed to parse existing config, user blocks may not be preserved: %v", readErr))
@@ -144,6 +168,7 @@ func (p *Provider) Generate(_ context.Context, opts *core.GenerateOptions) (*cor
 	}
 
 	finalContent := renderBlocks(finalBlocks)
+	result.RecordPhase(core.TimingRender, start)
 
 	result.Metadata["connections"] = len(profiles)
 
@@ -154,6 +179,8 @@ func (p *Provider) Generate(_ context.Context, opts *core.GenerateOptions) (*cor
 		return result, nil
 	}
 
+	p.logger.Debug("writing steampipe config", "path", outputPath)
+	start = time.Now()
 	if err := os.MkdirAll(filepath.Dir(outputPath), 0o700); err != nil {
 		return nil, fmt.Errorf("create config directory: %w", err)
 	}
@@ -162,11 +189,39 @@ func (p *Provider) Generate(_ context.Context, opts *core.GenerateOptions) (*cor
 	if err := os.WriteFile(outputPath, []byte(finalContent), 0o600); err != nil {
 		return nil, fmt.Errorf("write steampipe config: %w", err)
 	}
+	result.RecordPhase(core.TimingWrite, start)
 
 	result.FilesCreated = append(result.FilesCreated, outputPath)
 	return result, nil
 }
 
+// WatchPaths returns the AWS config file that connections are generated from.
+func (p *Provider) WatchPaths() []string {
+	if !p.config.Enabled {
+		return nil
+	}
+
+	path, err := p.awsConfigPath()
+	if err != nil {
+		p.logger.Debug("cannot resolve aws config path", "error", err)
+		return nil
+	}
+	return []string{path}
+}
+
+// awsConfigPath returns the configured AWS config path, defaulting to ~/.aws/config.
+func (p *Provider) awsConfigPath() (string, error) {
+	if p.config.AWSConfigPath != "" {
+		return p.config.AWSConfigPath, nil
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("resolve home directory: %w", err)
+	}
+	return filepath.Join(home, ".aws", "config"), nil
+}
+
 // Backup creates a timestamped backup of the existing config file.
 func (p *Provider) Backup(_ context.Context) (string, error) {
 	if p.config == nil {
@@ -230,89 +285,63 @@ func (p *Provider) applyGenerateOptions(opts *core.GenerateOptions) error {
 	return p.config.Validate()
 }
 
-// parseAWSProfiles reads profile names from an AWS config file.
+// awsProfiles returns the generated profiles in the AWS config file, preferring
+// the profiles the aws provider published earlier in the run over parsing the
+// file. Demo runs fall back to the fixture accounts instead of the file.
+func (p *Provider) awsProfiles(path string, opts *core.GenerateOptions) ([]core.AWSProfile, string, error) {
+	if opts != nil {
+		if published, ok := opts.AWSProfiles.Profiles(path); ok {
+			p.logger.Debug("using aws profiles published by the aws provider", "path", path)
+			return managedProfiles(published), "", nil
+		}
+	}
+	if p.config.Fixtures != nil {
+		p.logger.Debug("using aws profiles from demo fixtures")
+		return managedProfiles(p.config.Fixtures.AccountProfiles()), "", nil
+	}
+	return parseAWSProfiles(path)
+}
+
+// parseAWSProfiles reads profiles from an AWS config file.
 // Returns (profiles, warning, error). A warning is returned instead of an
-// error when the file is missing or empty so other providers can still run.
+// error when the file is missing so other providers can still run.
 //
 // Only profiles that contain `sso_auto_populated = true` are returned; this
 // restricts generation to profiles that were written by an SSO login tool
 // rather than manually maintained entries.
-func parseAWSProfiles(path string) ([]string, string, error) {
-	// #nosec G304 -- path is user-configurable
-	f, err := os.Open(filepath.Clean(path))
+func parseAWSProfiles(path string) ([]core.AWSProfile, string, error) {
+	profiles, err := core.ParseAWSProfiles(path, core.DefaultAWSMarkerKey)
 	if err != nil {
 		if errors.Is(err, os.ErrNotExist) {
 			return nil, fmt.Sprintf("AWS config not found at %s, skipping steampipe generation", path), nil
 		}
-		return nil, "", fmt.Errorf("open AWS config: %w", err)
-	}
-	defer f.Close() //nolint:errcheck
-
-	var profiles []string
-	var currentProfile string // empty when not inside a named profile section
-	var autoPopulated bool
-
-	flush := func() {
-		if currentProfile != "" && autoPopulated {
-			profiles = append(profiles, currentProfile)
-		}
-		currentProfile = ""
-		autoPopulated = false
-	}
-
-	scanner := bufio.NewScanner(f)
-	for scanner.Scan() {
-		line := strings.TrimSpace(scanner.Text())
-
-		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
-			flush()
-			header := strings.TrimSpace(line[1 : len(line)-1])
-			// AWS config uses `[profile name]` for named profiles; `[default]`
-			// is a special case with no "profile " prefix.
-			if strings.HasPrefix(strings.ToLower(header), "profile ") {
-				currentProfile = strings.TrimSpace(header[len("profile "):])
-			} else if strings.EqualFold(header, "default") {
-				currentProfile = "default"
-			}
-			// Skip sso-session and other non-profile sections.
-			continue
-		}
-
-		if currentProfile != "" {
-			if k, v, ok := splitKV(line); ok {
-				if strings.EqualFold(k, "sso_auto_populated") && strings.EqualFold(v, "true") {
-					autoPopulated = true
-				}
-			}
-		}
+		return nil, "", err
 	}
-	flush()
-
-	if err := scanner.Err(); err != nil {
-		return nil, "", fmt.Errorf("read AWS config: %w", err)
-	}
-
-	return profiles, "", nil
+	return managedProfiles(profiles), "", nil
 }
 
-// splitKV parses a "key = value" line from an AWS config file.
-func splitKV(line string) (key, value string, ok bool) {
-	idx := strings.IndexByte(line, '=')
-	if idx < 0 {
-		return "", "", false
+// managedProfiles returns the profiles that carry the generation marker,
+// except the generated [default], which has no account, and region variants,
+// which would duplicate their profile's connection.
+func managedProfiles(profiles []core.AWSProfile) []core.AWSProfile {
+	var managed []core.AWSProfile
+	for _, profile := range profiles {
+		if profile.Managed && profile.Name != "default" && profile.VariantOf == "" {
+			managed = append(managed, profile)
+		}
 	}
-	return strings.TrimSpace(line[:idx]), strings.TrimSpace(line[idx+1:]), true
+	return managed
 }
 
 // filterProfiles returns only profiles whose name appears in the allowed set.
-func filterProfiles(profiles []string, allowed []string) []string {
+func filterProfiles(profiles []core.AWSProfile, allowed []string) []core.AWSProfile {
 	set := make(map[string]bool, len(allowed))
 	for _, a := range allowed {
 		set[a] = true
 	}
-	var out []string
+	var out []core.AWSProfile
 	for _, p := range profiles {
-		if set[p] {
+		if set[p.Name] {
 			out = append(out, p)
 		}
 	}
@@ -2,6 +2,8 @@ package steampipe
 
 import (
 	"context"
+	"io"
+	"log/slog"
 	"os"
 	"path/filepath"
 	"strings"
@@ -108,8 +110,8 @@ sso_account_id = 000000000000
 	if len(profiles) != 1 {
 		t.Fatalf("want 1 profile (only sso_auto_populated=true), got %d: %v", len(profiles), profiles)
 	}
-	if profiles[0] != "sso-profile/AdminAccess" {
-		t.Errorf("unexpected profile: %s", profiles[0])
+	if profiles[0].Name != "sso-profile/AdminAccess" {
+		t.Errorf("unexpected profile: %s", profiles[0].Name)
 	}
 }
 
@@ -338,6 +340,87 @@ func TestGenerate_BasicGeneration(t *testing.T) {
 	}
 }
 
+func TestGenerate_UsesPublishedProfiles(t *testing.T) {
+	dir := t.TempDir()
+	awsCfg := filepath.Join(dir, "aws_config")
+	spcOut := filepath.Join(dir, "aws.spc")
+
+	cfg := DefaultConfig()
+	cfg.AWSConfigPath = awsCfg
+	cfg.ConfigPath = spcOut
+	cfg.PreferredRoles = []string{"readonly"}
+
+	inventory := &core.AWSProfileInventory{}
+	inventory.Publish(awsCfg, []core.AWSProfile{
+		{Name: "default", Region: "us-east-1", Managed: true},
+		{Name: "published/admin", AccountID: "111111111111", RoleName: "Admin", Managed: true},
+		{Name: "published/readonly", AccountID: "111111111111", RoleName: "ReadOnly", Managed: true},
+		{Name: "other/readonly@eu-west-1", AccountID: "222222222222", RoleName: "ReadOnly", Managed: true, VariantOf: "other/readonly"},
+		{Name: "manual", Region: "us-east-1"},
+	})
+
+	// The file does not exist, so the profiles can only come from the inventory.
+	p := NewProvider(cfg)
+	result, err := p.Generate(context.Background(), &core.GenerateOptions{AWSProfiles: inventory})
+	if err != nil {
+		t.Fatalf("Generate: %v", err)
+	}
+	if result.Metadata["connections"] != 1 {
+		t.Fatalf("connections = %v, want 1", result.Metadata["connections"])
+	}
+	if content := readFile(t, spcOut); !strings.Contains(content, "aws_published") {
+		t.Errorf("expected published connection in output, got:\n%s", content)
+	}
+}
+
+func TestGenerate_DedupesByAccount(t *testing.T) {
+	dir := t.TempDir()
+	awsCfg := filepath.Join(dir, "aws_config")
+	spcOut := filepath.Join(dir, "aws.spc")
+	// Profiles without sso_account_id still group by the account name before
+	// "/"; profiles with one group by the ID, whatever their names.
+	writeFile(t, awsCfg, `[profile deviceplatform/cloudinfra]
+sso_auto_populated = true
+
+[profile deviceplatform/lytxread]
+sso_auto_populated = true
+
+[profile aft-management/cloudinfra]
+sso_auto_populated = true
+
+[profile prod/admin]
+sso_auto_populated = true
+sso_account_id = 111111111111
+
+[profile prod-renamed/admin]
+sso_auto_populated = true
+sso_account_id = 111111111111
+`)
+
+	cfg := DefaultConfig()
+	cfg.AWSConfigPath = awsCfg
+	cfg.ConfigPath = spcOut
+
+	p := NewProvider(cfg)
+	result, err := p.Generate(context.Background(), &core.GenerateOptions{})
+	if err != nil {
+		t.Fatalf("Generate: %v", err)
+	}
+	if result.Metadata["connections"] != 3 {
+		t.Fatalf("connections = %v, want 3", result.Metadata["connections"])
+	}
+	content := readFile(t, spcOut)
+	if strings.Count(content, `connection "aws_deviceplatform"`) != 1 {
+		t.Errorf("expected one deviceplatform connection, got:\n%s", content)
+	}
+	if !strings.Contains(content, "aws_aft_management") || !strings.Contains(content, "aws_prod") {
+		t.Errorf("expected aft-management and prod connections, got:\n%s", content)
+	}
+	if strings.Contains(content, "aws_prod_renamed") {
+		t.Errorf("expected prod-renamed to share the prod account connection, got:\n%s", content)
+	}
+}
+
 func TestGenerate_MissingAWSConfig(t *testing.T) {
 	dir := t.TempDir()
 	spcOut := filepath.Join(dir, "aws.spc")
@@ -435,6 +518,34 @@ func TestGenerate_DryRun(t *testing.T) {
 	}
 }
 
+func TestGenerate_DemoFixturesReadNoRealFiles(t *testing.T) {
+	dir := t.TempDir()
+	awsCfg := filepath.Join(dir, "aws_config")
+	spcOut := filepath.Join(dir, "aws.spc")
+	writeFile(t, awsCfg, "[profile real/admin]\nsso_account_id = 999999999999\nsso_auto_populated = true\n")
+	writeFile(t, spcOut, "connection \"real_connection\" {\n  plugin = \"aws\"\n}\n")
+
+	cfg := DefaultConfig()
+	cfg.AWSConfigPath = awsCfg
+	cfg.ConfigPath = spcOut
+	cfg.Fixtures = &core.DemoFixtures{Accounts: []core.AccountFixture{
+		{ID: "111111111111", Name: "prod", Roles: []string{"ReadOnly"}},
+	}}
+
+	p := NewProvider(cfg)
+	result, err := p.Generate(context.Background(), &core.GenerateOptions{DryRun: true})
+	if err != nil {
+		t.Fatalf("Generate: %v", err)
+	}
+	content, _ := result.Metadata["config_content"].(string)
+	if !strings.Contains(content, "aws_prod") {
+		t.Errorf("expected the fixture connection, got:\n%s", content)
+	}
+	if strings.Contains(content, "real") {
+		t.Errorf("demo output leaked the real files:\n%s", content)
+	}
+}
+
 func TestGenerate_PreservesUserBlocks(t *testing.T) {
 	dir := t.TempDir()
 	awsCfg := filepath.Join(dir, "aws_config")
@@ -675,61 +786,6 @@ func TestConnectionNameForProfile(t *testing.T) {
 	}
 }
 
-func TestDedupeByAccount_SingleRole(t *testing.T) {
-	profiles := []string{"default", "bedrock-test"}
-	got := dedupeByAccount(profiles, nil)
-	if len(got) != 2 {
-		t.Fatalf("want 2, got %d: %v", len(got), got)
-	}
-}
-
-func TestDedupeByAccount_MultipleRoles(t *testing.T) {
-	profiles := []string{
-		"deviceplatform/cloudinfra",
-		"deviceplatform/lytxread",
-		"aft-management/cloudinfra",
-		"aft-management/lytxread",
-	}
-	got := dedupeByAccount(profiles, nil)
-	if len(got) != 2 {
-		t.Fatalf("want 2 accounts, got %d: %v", len(got), got)
-	}
-	// First profile for each account used by default.
-	if got[0] != "deviceplatform/cloudinfra" {
-		t.Errorf("want deviceplatform/cloudinfra, got %s", got[0])
-	}
-	if got[1] != "aft-management/cloudinfra" {
-		t.Errorf("want aft-management/cloudinfra, got %s", got[1])
-	}
-}
-
-func TestDedupeByAccount_PreferredRole(t *testing.T) {
-	profiles := []string{
-		"deviceplatform/cloudinfra",
-		"deviceplatform/lytxread",
-	}
-	got := dedupeByAccount(profiles, []string{"lytxread"})
-	if len(got) != 1 {
-		t.Fatalf("want 1, got %d: %v", len(got), got)
-	}
-	if got[0] != "deviceplatform/lytxread" {
-		t.Errorf("want deviceplatform/lytxread, got %s", got[0])
-	}
-}
-
-func TestDedupeByAccount_PreserveOrder(t *testing.T) {
-	profiles := []string{
-		"aft-management/cloudinfra",
-		"deviceplatform/cloudinfra",
-		"aft-management/lytxread",
-	}
-	got := dedupeByAccount(profiles, nil)
-	// aft-management first, then deviceplatform.
-	if len(got) != 2 || got[0] != "aft-management/cloudinfra" || got[1] != "deviceplatform/cloudinfra" {
-		t.Errorf("unexpected order: %v", got)
-	}
-}
-
 func TestMergeBlocks_GeneratedWinsOnNameCollision(t *testing.T) {
 	// Simulate an existing file where a user block has the same connection name
 	// as a to-be-generated block — the generated block should take precedence.
@@ -907,3 +963,40 @@ func TestNeedsBackup_DryRun(t *testing.T) {
 		t.Error("expected NeedsBackup=false for dry-run")
 	}
 }
+
+func TestWithLogger(t *testing.T) {
+	t.Run("nil logger keeps default", func(t *testing.T) {
+		provider := NewProvider(nil, WithLogger(nil))
+		if provider.logger == nil {
+			t.Error("expected non-nil logger when WithLogger(nil) is used")
+		}
+	})
+
+	t.Run("non-nil logger is set", func(t *testing.T) {
+		customLogger := slog.New(slog.NewTextHandler(io.Discard, nil))
+		provider := NewProvider(nil, WithLogger(customLogger))
+		if provider.logger != customLogger {
+			t.Error("expected custom logger to be set")
+		}
+	})
+}
+
+func TestProvider_WatchPaths(t *testing.T) {
+	config := DefaultConfig()
+	config.Enabled = true
+	config.AWSConfigPath = "/home/user/.aws/config"
+
+	var _ core.Watchable = NewProvider(config)
+
+	if got := NewProvider(config).WatchPaths(); len(got) != 1 || got[0] != config.AWSConfigPath {
+		t.Fatalf("WatchPaths() = %v, want [%s]", got, config.AWSConfigPath)
+	}
+
+	t.Setenv("HOME", t.TempDir())
+	config.AWSConfigPath = ""
+	home, _ := os.UserHomeDir()
+	want := filepath.Join(home, ".aws", "config")
+	if got := NewProvider(config).WatchPaths(); len(got) != 1 || got[0] != want {
+		t.Fatalf("WatchPaths() = %v, want [%s]", got, want)
+	}
+}